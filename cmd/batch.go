@@ -0,0 +1,110 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// aiBatch is the --batch flag shared by explain, suggest, and fix.
+var aiBatch bool
+
+// queryInput is what a command with --batch reads: a single query, or
+// with --batch the files to read a query from each.
+type queryInput struct {
+	query string
+	files []string
+}
+
+// readQueryInput reads the query from the arguments, -f, or stdin, or
+// with --batch resolves the arguments into files.
+func readQueryInput(args []string, inputFile string) (queryInput, error) {
+	if aiBatch {
+		files, err := batchFiles(args, inputFile)
+		return queryInput{files: files}, err
+	}
+	query, err := getInputFrom(args, inputFile, os.Stdin, isTerminal)
+	return queryInput{query: query}, err
+}
+
+// each runs fn on the query, or on each file's query with runBatch.
+func (in queryInput) each(out io.Writer, fn func(query string) error) error {
+	if in.files == nil {
+		return fn(in.query)
+	}
+	return runBatch(in.files, out, os.Stderr, fn)
+}
+
+// batchFiles expands the arguments of a --batch run into files. Globs are
+// matched here as well, for shells that pass them through quoted, and
+// directories yield the .kql files they contain.
+func batchFiles(args []string, inputFile string) ([]string, error) {
+	if inputFile != "" {
+		return nil, fmt.Errorf("--file cannot be combined with --batch; pass the files as arguments")
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("--batch needs at least one file, directory, or glob")
+	}
+
+	var expanded []string
+	for _, arg := range args {
+		if arg == "-" {
+			return nil, fmt.Errorf("stdin cannot be read with --batch")
+		}
+		if !strings.ContainsAny(arg, "*?[") {
+			expanded = append(expanded, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		expanded = append(expanded, matches...)
+	}
+	files, err := expandLintArgs(expanded, []string{".kql"})
+	if err == nil && len(files) == 0 {
+		err = fmt.Errorf("no .kql files found in %s", strings.Join(args, ", "))
+	}
+	return files, err
+}
+
+// runBatch runs fn on the query in each file, writing a "=== file ==="
+// header to out before its result. A file that can't be read or whose
+// query fails is reported on errOut and the remaining files still run;
+// the returned error counts the failures.
+func runBatch(files []string, out, errOut io.Writer, fn func(query string) error) error {
+	failed := 0
+	for i, name := range files {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "=== %s ===\n", name)
+
+		data, err := os.ReadFile(name)
+		if err == nil {
+			if query := strings.TrimSpace(string(data)); query != "" {
+				err = fn(query)
+			} else {
+				err = errors.New("empty file")
+			}
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(errOut, "%s: %v\n", name, err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d file(s) failed", failed, len(files))
+	}
+	return nil
+}
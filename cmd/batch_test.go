@@ -0,0 +1,154 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// writeQueryFiles creates the named files under a temp dir and returns it.
+func writeQueryFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBatchFiles(t *testing.T) {
+	dir := writeQueryFiles(t, map[string]string{
+		"a.kql":       "T | take 1",
+		"b.kql":       "T | take 2",
+		"notes.txt":   "not a query",
+		"sub/c.kql":   "T | take 3",
+		"other/d.csl": "T | take 4",
+	})
+
+	// Globs are expanded even when the shell didn't
+	files, err := batchFiles([]string{filepath.Join(dir, "*.kql")}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "a.kql" || filepath.Base(files[1]) != "b.kql" {
+		t.Errorf("glob: got %v", files)
+	}
+
+	// Directories yield their .kql files; plain files pass through
+	files, err = batchFiles([]string{filepath.Join(dir, "sub"), filepath.Join(dir, "other", "d.csl")}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "c.kql" || filepath.Base(files[1]) != "d.csl" {
+		t.Errorf("dir: got %v", files)
+	}
+}
+
+func TestBatchFiles_Errors(t *testing.T) {
+	dir := writeQueryFiles(t, map[string]string{"notes.txt": "x"})
+	tests := []struct {
+		name string
+		args []string
+		file string
+		want string
+	}{
+		{"no args", nil, "", "at least one file"},
+		{"with -f", []string{"a.kql"}, "a.kql", "--file cannot be combined"},
+		{"stdin", []string{"-"}, "", "stdin"},
+		{"no matches", []string{filepath.Join(dir, "*.kql")}, "", "no files match"},
+		{"empty dir", []string{dir}, "", "no .kql files"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := batchFiles(tt.args, tt.file)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRunBatch_ContinuesPastFailures(t *testing.T) {
+	dir := writeQueryFiles(t, map[string]string{
+		"a.kql":     "T | take 1",
+		"bad.kql":   "T | take 2",
+		"empty.kql": "  \n",
+		"c.kql":     "T | take 3",
+	})
+	files := []string{
+		filepath.Join(dir, "a.kql"),
+		filepath.Join(dir, "bad.kql"),
+		filepath.Join(dir, "empty.kql"),
+		filepath.Join(dir, "missing.kql"),
+		filepath.Join(dir, "c.kql"),
+	}
+
+	var out, errOut bytes.Buffer
+	var seen []string
+	err := runBatch(files, &out, &errOut, func(query string) error {
+		seen = append(seen, query)
+		if query == "T | take 2" {
+			return errors.New("provider failed")
+		}
+		out.WriteString("ok: " + query + "\n")
+		return nil
+	})
+
+	if err == nil || err.Error() != "3 of 5 file(s) failed" {
+		t.Errorf("err = %v", err)
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected the readable files to run, got %q", seen)
+	}
+
+	want := "=== " + files[0] + " ===\nok: T | take 1\n\n=== " + files[1] + " ===\n\n=== " + files[2] + " ===\n\n=== " + files[3] + " ===\n\n=== " + files[4] + " ===\nok: T | take 3\n"
+	if out.String() != want {
+		t.Errorf("out = %q, want %q", out.String(), want)
+	}
+	for _, w := range []string{"bad.kql: provider failed", "empty.kql: empty file", "missing.kql: open"} {
+		if !strings.Contains(errOut.String(), w) {
+			t.Errorf("expected stderr to contain %q, got:\n%s", w, errOut.String())
+		}
+	}
+}
+
+func TestFixQuery(t *testing.T) {
+	defer func(strict bool, retries, maxEdits int) {
+		fixStrict, fixRetries, fixMaxEdits = strict, retries, maxEdits
+	}(fixStrict, fixRetries, fixMaxEdits)
+	fixStrict, fixRetries, fixMaxEdits = true, 0, 0
+
+	noProvider := func() (ai.Provider, error) {
+		t.Error("expected no provider for a valid query")
+		return nil, errors.New("unused")
+	}
+	var out bytes.Buffer
+	if err := fixQuery("T | take 10", &out, noProvider); err != nil || out.String() != "T | take 10\n" {
+		t.Errorf("valid query: out = %q, err = %v", out.String(), err)
+	}
+
+	// A fix that still doesn't parse is rejected under --strict
+	out.Reset()
+	p := &sequenceProvider{responses: []string{brokenQuery}}
+	err := fixQuery(brokenQuery, &out, func() (ai.Provider, error) { return p, nil })
+	if !errors.Is(err, errFixRejected) {
+		t.Errorf("expected errFixRejected, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output, got %q", out.String())
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// Response cache flags, shared by the AI commands
+var (
+	aiCache    bool
+	aiNoCache  bool
+	aiCacheTTL time.Duration
+)
+
+// applyCacheFlags overrides the merged cache settings with --cache,
+// --no-cache, and --cache-ttl. --no-cache wins over --cache.
+func applyCacheFlags(cfg ai.Config) ai.Config {
+	if aiCache {
+		cfg.Cache.Enabled = true
+	}
+	if aiNoCache {
+		cfg.Cache.Enabled = false
+	}
+	if aiCacheTTL > 0 {
+		cfg.Cache.TTL = aiCacheTTL
+	}
+	return cfg
+}
@@ -0,0 +1,27 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+)
+
+// ANSI colors for terminal output.
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiGray   = "\033[90m"
+	ansiReset  = "\033[0m"
+)
+
+// useColor reports whether to color output written to f: not disabled by
+// flag or the NO_COLOR convention, and f is a terminal.
+func useColor(noColor bool, f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(f)
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/spf13/cobra"
+)
+
+// completionShells are the shells kql completion generates scripts for.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for kql and write it to stdout.
+
+Load it in the current shell, or save it where your shell loads
+completions from to enable it for every session.`,
+	Example: `  # Bash (current shell)
+  source <(kql completion bash)
+
+  # Bash (every session, Linux)
+  kql completion bash > /etc/bash_completion.d/kql
+
+  # Zsh
+  kql completion zsh > "${fpath[1]}/_kql"
+
+  # Fish
+  kql completion fish > ~/.config/fish/completions/kql.fish
+
+  # PowerShell
+  kql completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             completionShells,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeCompletion(cmd.Root(), args[0], cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// writeCompletion writes root's completion script for shell to w.
+func writeCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("unknown shell: %q (supported: bash, zsh, fish, powershell)", shell)
+	}
+}
+
+// completeValues returns a flag completion function offering a fixed set
+// of values and no file names.
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeProvider completes --provider with the supported AI providers.
+var completeProvider = completeValues(ai.Providers...)
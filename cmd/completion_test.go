@@ -0,0 +1,49 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range completionShells {
+		var buf bytes.Buffer
+		if err := writeCompletion(rootCmd, shell, &buf); err != nil {
+			t.Errorf("%s: %v", shell, err)
+			continue
+		}
+		if buf.Len() == 0 {
+			t.Errorf("%s: expected a completion script", shell)
+		}
+	}
+}
+
+func TestWriteCompletion_UnknownShell(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeCompletion(rootCmd, "tcsh", &buf)
+	if err == nil || !strings.Contains(err.Error(), "unknown shell") {
+		t.Errorf("expected an unknown shell error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestCompleteProvider(t *testing.T) {
+	values, _ := completeProvider(nil, nil, "")
+	for _, want := range []string{"ollama", "instructlab", "vertex", "azure"} {
+		found := false
+		for _, v := range values {
+			if v == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %q in %v", want, values)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/spf13/cobra"
+)
+
+// configEnv names a config file to use instead of ~/.kql/config.yaml;
+// --config takes precedence over it.
+const configEnv = "KQL_CONFIG"
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage kql configuration",
+	Long: `Commands for managing kql configuration.
+
+Settings are read from ~/.kql/config.yaml, or from the file given with
+--config or KQL_CONFIG. Secrets such as API keys can be
+kept in the OS keyring instead, and referenced from the file as
+"keyring:<name>".`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
+
+// configFilePath returns the config file named by --config or KQL_CONFIG,
+// or ~/.kql/config.yaml.
+func configFilePath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	if path := os.Getenv(configEnv); path != "" {
+		return path, nil
+	}
+	return ai.ConfigFilePath()
+}
+
+// errConfigNotFound is returned by loadConfigFile when the file named by
+// --config or KQL_CONFIG doesn't exist.
+var errConfigNotFound = errors.New("config file not found")
+
+// loadConfigFile loads the config file from configFilePath. The default
+// file is optional, but one named explicitly must exist.
+func loadConfigFile() (*ai.FileConfig, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
+	fileCfg, err := ai.LoadConfigFromPath(path)
+	if err == nil && fileCfg == nil && (configFile != "" || os.Getenv(configEnv) != "") {
+		return nil, fmt.Errorf("%w: %s", errConfigNotFound, path)
+	}
+	return fileCfg, err
+}
+
+// loadConfigFileOrWarn is loadConfigFile for commands that can run
+// without the file: a file that can't be read is only a warning, but a
+// missing --config or KQL_CONFIG file is an error.
+func loadConfigFileOrWarn() (*ai.FileConfig, error) {
+	fileCfg, err := loadConfigFile()
+	if errors.Is(err, errConfigNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		logf(logWarn, "Warning: error loading config file: %v", err)
+	}
+	return fileCfg, nil
+}
+
+// loadAIFileConfig loads the config file with the --profile (or
+// default_profile) section in place of ai. A file that can't be read is
+// only a warning, as for the other commands, but a missing explicit file
+// or an unknown profile is an error.
+func loadAIFileConfig() (*ai.FileConfig, error) {
+	fileCfg, err := loadConfigFileOrWarn()
+	if err != nil {
+		return nil, err
+	}
+	return fileCfg.WithProfile(configProfile)
+}
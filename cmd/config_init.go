@@ -0,0 +1,79 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// configTemplate is the commented config file written by config init. It
+// is kept identical to config.example.yaml at the top of the repository.
+//
+//go:embed config_template.yaml
+var configTemplate string
+
+var configInitForce bool
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a commented config file template",
+	Long: `Write ~/.kql/config.yaml with every setting, its default, and a comment
+describing it. Edit the file to taste; settings left at their defaults can
+be deleted.
+
+An existing config file is left alone unless --force is given.`,
+	Example: `  # Create ~/.kql/config.yaml
+  kql config init
+
+  # Start over from the template
+  kql config init --force`,
+	Args: cobra.NoArgs,
+	RunE: runConfigInit,
+}
+
+func init() {
+	configCmd.AddCommand(configInitCmd)
+
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "Overwrite an existing config file")
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := writeConfigTemplate(path, configInitForce); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
+
+// writeConfigTemplate writes configTemplate to path, creating its
+// directory. The file is private to the user, since it may come to hold
+// API keys.
+func writeConfigTemplate(path string, force bool) error {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(configTemplate), 0o600); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigTemplate_RoundTrips(t *testing.T) {
+	dec := yaml.NewDecoder(strings.NewReader(configTemplate))
+	dec.KnownFields(true)
+
+	var f ai.FileConfig
+	if err := dec.Decode(&f); err != nil {
+		t.Fatalf("template does not decode into FileConfig: %v", err)
+	}
+
+	// The template's values are the defaults
+	cfg := ai.MergeFileConfig(ai.DefaultConfig(), &f)
+	if cfg.Provider != ai.DefaultProvider || cfg.Temperature != ai.DefaultTemperature {
+		t.Errorf("expected the default provider and temperature, got %q and %v", cfg.Provider, cfg.Temperature)
+	}
+	if cfg.Validation.Retries != ai.DefaultValidationRetries || !cfg.Validation.Enabled {
+		t.Errorf("expected the default validation settings, got %+v", cfg.Validation)
+	}
+}
+
+func TestConfigTemplate_MatchesExample(t *testing.T) {
+	example, err := os.ReadFile(filepath.Join("..", "config.example.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(example) != configTemplate {
+		t.Error("cmd/config_template.yaml and config.example.yaml have drifted; keep them identical")
+	}
+}
+
+func TestWriteConfigTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kql", "config.yaml")
+
+	if err := writeConfigTemplate(path, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != configTemplate {
+		t.Fatalf("expected the template to be written, got %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected a private file, got %v", info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(path, []byte("ai:\n  provider: openai\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeConfigTemplate(path, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected an existing file to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "openai") {
+		t.Error("expected the existing file to be left alone")
+	}
+
+	if err := writeConfigTemplate(path, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != configTemplate {
+		t.Error("expected --force to overwrite the file")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/keyring"
+	"github.com/spf13/cobra"
+)
+
+// keyProviders are the providers that take an API key.
+var keyProviders = []string{"azure", "openai", "anthropic"}
+
+// storeKey saves a secret in the keyring. Tests replace it.
+var storeKey = keyring.Set
+
+var configSetKeyCmd = &cobra.Command{
+	Use:   "set-key <provider>",
+	Short: "Store a provider API key in the OS keyring",
+	Long: `Store an API key for azure, openai, or anthropic in the OS keyring
+(the macOS keychain, or the Secret Service via secret-tool on Linux).
+
+The key is read from stdin, or prompted for on a terminal, so it never
+appears in shell history. Reference it from ~/.kql/config.yaml as
+"keyring:<provider>":
+
+  ai:
+    azure:
+      api_key: keyring:azure`,
+	Example: `  # Prompt for the key
+  kql config set-key azure
+
+  # Read the key from another secret store
+  op read op://vault/openai/key | kql config set-key openai`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigSetKey,
+}
+
+func init() {
+	configCmd.AddCommand(configSetKeyCmd)
+}
+
+func runConfigSetKey(cmd *cobra.Command, args []string) error {
+	provider := args[0]
+
+	prompt := isTerminal(os.Stdin)
+	if prompt {
+		fmt.Fprintf(os.Stderr, "API key for %s: ", provider)
+		restore := disableEcho()
+		defer restore()
+	}
+
+	err := setKey(provider, os.Stdin)
+	if prompt {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Stored the %s API key in the keyring. Reference it in ~/.kql/config.yaml with:\n", provider)
+	fmt.Fprintf(os.Stderr, "  api_key: %s%s\n", keyring.Prefix, provider)
+	return nil
+}
+
+// setKey reads one line from in and stores it as provider's API key.
+func setKey(provider string, in io.Reader) error {
+	known := false
+	for _, p := range keyProviders {
+		if p == provider {
+			known = true
+		}
+	}
+	if !known {
+		return fmt.Errorf("unknown provider %q (providers with API keys: %s)", provider, strings.Join(keyProviders, ", "))
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("reading key: %w", err)
+	}
+	key := strings.TrimSpace(line)
+	if key == "" {
+		return fmt.Errorf("no API key provided")
+	}
+
+	return storeKey(provider, key)
+}
+
+// disableEcho turns off terminal echo while the key is typed, where stty
+// is available, and returns a function that turns it back on.
+func disableEcho() func() {
+	stty := func(arg string) error {
+		c := exec.Command("stty", arg)
+		c.Stdin = os.Stdin
+		return c.Run()
+	}
+	if err := stty("-echo"); err != nil {
+		return func() {}
+	}
+	return func() { _ = stty("echo") }
+}
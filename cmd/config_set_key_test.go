@@ -0,0 +1,45 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetKey(t *testing.T) {
+	orig := storeKey
+	defer func() { storeKey = orig }()
+
+	stored := map[string]string{}
+	storeKey = func(name, secret string) error {
+		stored[name] = secret
+		return nil
+	}
+
+	if err := setKey("azure", strings.NewReader("  az-key \n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored["azure"] != "az-key" {
+		t.Errorf("stored = %v", stored)
+	}
+
+	// A key without a trailing newline is read too
+	if err := setKey("openai", strings.NewReader("sk-key")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored["openai"] != "sk-key" {
+		t.Errorf("stored = %v", stored)
+	}
+
+	if err := setKey("anthropic", strings.NewReader("\n")); err == nil {
+		t.Error("expected an error for an empty key")
+	}
+	if err := setKey("ollama", strings.NewReader("key\n")); err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Errorf("expected unknown provider error, got %v", err)
+	}
+	if _, ok := stored["anthropic"]; ok {
+		t.Error("expected nothing stored for an empty key")
+	}
+}
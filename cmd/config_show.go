@@ -0,0 +1,148 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/keyring"
+	"github.com/cloudygreybeard/kql/pkg/link"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration",
+	Long: `Print the configuration the AI and link commands would use, as YAML in
+the layout of ~/.kql/config.yaml: the config file, overridden by the KQL_*
+environment variables and the --provider, --model, and --temperature flags,
+with defaults filled in.
+
+API keys are shown as "(set)" unless they are keyring references, and a
+password in the proxy URL is masked. The model is the selected provider's
+default unless one is set. Empty endpoints mean the provider's own
+default; variables read only by a provider, such as OPENAI_API_KEY, are
+not shown (see --provider-info on any AI command).`,
+	Example: `  # Check what a command will use
+  kql config show
+
+  # With a different provider
+  KQL_AI_PROVIDER=anthropic kql config show`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+
+	configShowCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = configShowCmd.RegisterFlagCompletionFunc("provider", completeProvider)
+	configShowCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
+	configShowCmd.Flags().Float32Var(&aiTemperature, "temperature", 0.2, "Temperature (0.0-1.0)")
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	fileCfg, err := loadAIFileConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg := aiConfigFrom(fileCfg)
+	cfg.Validation = applyValidationEnv(cfg.Validation, os.Getenv)
+
+	linkCfg := link.MergeConfig(applyLinkEnv(link.Config{}, os.Getenv), linkFileConfig(fileCfg))
+
+	return writeConfig(os.Stdout, effectiveConfig(cfg, linkCfg))
+}
+
+// effectiveConfig lays out resolved settings as a config file, with
+// secrets redacted.
+func effectiveConfig(cfg ai.Config, linkCfg link.Config) ai.FileConfig {
+	var f ai.FileConfig
+
+	a := &f.AI
+	a.Provider = cfg.Provider
+	a.Model = cfg.Model
+	a.Temperature = cfg.Temperature
+	a.SystemPrompt = cfg.SystemPrompt
+	a.Ollama.Endpoint = cfg.Ollama.Endpoint
+	a.Vertex.Project = cfg.Vertex.Project
+	a.Vertex.Location = cfg.Vertex.Location
+	a.Azure.Endpoint = cfg.Azure.Endpoint
+	a.Azure.Deployment = cfg.Azure.Deployment
+	a.Azure.APIKey = redactKey(cfg.Azure.APIKey)
+	a.InstructLab.Endpoint = cfg.InstructLab.Endpoint
+	a.OpenAI.APIKey = redactKey(cfg.OpenAI.APIKey)
+	a.OpenAI.BaseURL = cfg.OpenAI.BaseURL
+	a.Anthropic.APIKey = redactKey(cfg.Anthropic.APIKey)
+	a.Anthropic.BaseURL = cfg.Anthropic.BaseURL
+	a.Proxy = redactURL(cfg.Transport.Proxy)
+	a.CACert = cfg.Transport.CACert
+	a.Cache.Enabled = &cfg.Cache.Enabled
+	a.Cache.TTL = &cfg.Cache.TTL
+
+	v := cfg.Validation
+	fv := &a.Validation
+	fv.Enabled = &v.Enabled
+	fv.Strict = &v.Strict
+	fv.Semantic = &v.Semantic
+	fv.Retries = &v.Retries
+	fv.Feedback.Errors = &v.Feedback.Errors
+	fv.Feedback.Hints = &v.Feedback.Hints
+	fv.Feedback.Examples = &v.Feedback.Examples
+	fv.Feedback.Progressive = &v.Feedback.Progressive
+	fv.Feedback.MaxErrors = &v.Feedback.MaxErrors
+	fv.Feedback.MaxHints = &v.Feedback.MaxHints
+	fv.Feedback.MaxExamples = &v.Feedback.MaxExamples
+	fv.Feedback.MaxPromptBytes = &v.Feedback.MaxPromptBytes
+	for _, r := range v.Feedback.Rules {
+		fv.Feedback.Rules = append(fv.Feedback.Rules, ai.FeedbackRuleFile{Match: r.Match, Hint: r.Hint, Example: r.Example})
+	}
+	fv.Temperature.Adjust = &v.Temp.Adjust
+	fv.Temperature.Increment = &v.Temp.Increment
+	fv.Temperature.Max = &v.Temp.Max
+
+	f.Link = ai.LinkFileConfig{
+		Cluster:   linkCfg.Cluster,
+		Database:  linkCfg.Database,
+		Cloud:     linkCfg.Cloud,
+		BaseURL:   linkCfg.BaseURL,
+		MaxLength: linkCfg.MaxLength,
+	}
+	f.Link.Shortener.URL = linkCfg.ShortenerURL
+
+	return f
+}
+
+// redactKey hides an API key. Keyring references name no secret, so they
+// are shown as written.
+func redactKey(key string) string {
+	if key == "" || keyring.IsRef(key) {
+		return key
+	}
+	return "(set)"
+}
+
+// redactURL hides the password in a URL such as a proxy's.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+// writeConfig prints f as YAML.
+func writeConfig(w io.Writer, f ai.FileConfig) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kql.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigFlag_LoadsFileForProviders(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(configEnv, "")
+	defer func(path string) { configFile = path }(configFile)
+	configFile = writeTestConfig(t, "ai:\n  provider: instructlab\n  model: granite\n")
+
+	provider, _, err := resolveProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "instructlab" || provider.Model() != "granite" {
+		t.Errorf("expected the --config file's provider, got %s/%s", provider.Name(), provider.Model())
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(path string) { configFile = path }(configFile)
+	configFile = ""
+
+	envPath := writeTestConfig(t, "ai:\n  model: from-env\n")
+	t.Setenv(configEnv, envPath)
+
+	fileCfg, err := loadConfigFile()
+	if err != nil || fileCfg == nil || fileCfg.AI.Model != "from-env" {
+		t.Fatalf("expected KQL_CONFIG to be loaded, got %+v, %v", fileCfg, err)
+	}
+
+	// --config wins over KQL_CONFIG
+	configFile = writeTestConfig(t, "ai:\n  model: from-flag\n")
+	if fileCfg, _ := loadConfigFile(); fileCfg == nil || fileCfg.AI.Model != "from-flag" {
+		t.Errorf("expected --config to win, got %+v", fileCfg)
+	}
+}
+
+func TestLoadConfigFile_MissingExplicitPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(configEnv, "")
+	defer func(path string) { configFile = path }(configFile)
+
+	// A missing default file is fine
+	configFile = ""
+	if fileCfg, err := loadConfigFile(); err != nil || fileCfg != nil {
+		t.Errorf("expected no config and no error, got %+v, %v", fileCfg, err)
+	}
+
+	// A missing file named explicitly is not
+	configFile = filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := loadConfigFile(); err == nil {
+		t.Error("expected an error for a missing --config file")
+	}
+
+	// Commands that otherwise only warn fail too
+	if _, err := loadAIFileConfig(); !errors.Is(err, errConfigNotFound) {
+		t.Errorf("expected errConfigNotFound from loadAIFileConfig, got %v", err)
+	}
+	t.Setenv(configEnv, configFile)
+	configFile = ""
+	if _, err := loadConfigFileOrWarn(); !errors.Is(err, errConfigNotFound) {
+		t.Errorf("expected errConfigNotFound for a missing KQL_CONFIG file, got %v", err)
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(configEnv, "")
+	defer func(path, profile string) { configFile, configProfile = path, profile }(configFile, configProfile)
+	configFile = writeTestConfig(t, `
+ai:
+  provider: ollama
+profiles:
+  lab:
+    provider: instructlab
+    model: granite
+`)
+
+	configProfile = "lab"
+	provider, _, err := resolveProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "instructlab" || provider.Model() != "granite" {
+		t.Errorf("expected the lab profile, got %s/%s", provider.Name(), provider.Model())
+	}
+
+	configProfile = "cloud"
+	if _, _, err := resolveProvider(); err == nil || !strings.Contains(err.Error(), "available: lab") {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+}
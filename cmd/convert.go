@@ -0,0 +1,233 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertFrom       string
+	convertDialect    string
+	convertInputFile  string
+	convertDebug      bool
+	convertTimeout    int
+	convertNoValidate bool
+	convertStrict     bool
+	convertRetries    int
+)
+
+// convertSources are the query languages kql convert accepts with --from.
+var convertSources = []string{"sql"}
+
+var convertCmd = &cobra.Command{
+	Use:   "convert [query]",
+	Short: "Translate a SQL query into KQL",
+	Long: `Translate a query written in another language into KQL.
+
+The query can be provided as an argument, from a file (-f), or via stdin.
+Use --dialect to say which SQL dialect it is written in, so dialect-specific
+functions and syntax (TOP, LIMIT, DATEADD, ...) are read correctly.
+
+The result is validated like 'kql generate' output: a translation that
+doesn't parse is sent back with the errors and retried.
+
+Uses the same AI providers as 'kql explain'.`,
+	Example: `  # Convert a SQL query
+  kql convert --from sql "SELECT State, count(*) FROM StormEvents WHERE DamageProperty > 1000000 GROUP BY State"
+
+  # T-SQL from a file
+  kql convert --from sql --dialect tsql -f report.sql
+
+  # From stdin
+  cat query.sql | kql convert --from sql --dialect postgres
+
+  # Fail if no translation parses
+  kql convert --from sql --strict -f report.sql`,
+	RunE: runConvert,
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	// Provider selection (reuse from explain)
+	convertCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = convertCmd.RegisterFlagCompletionFunc("provider", completeProvider)
+	convertCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
+	convertCmd.Flags().Float32Var(&aiTemperature, "temperature", 0.1, "Temperature (0.0-1.0)")
+
+	// Ollama
+	convertCmd.Flags().StringVar(&ollamaEndpoint, "ollama-endpoint", "", "Ollama endpoint URL")
+
+	// Vertex AI
+	convertCmd.Flags().StringVar(&vertexProject, "vertex-project", "", "GCP project ID")
+	convertCmd.Flags().StringVar(&vertexLocation, "vertex-location", "", "GCP location")
+
+	// Azure OpenAI
+	convertCmd.Flags().StringVar(&azureEndpoint, "azure-endpoint", "", "Azure OpenAI endpoint URL")
+	convertCmd.Flags().StringVar(&azureDeployment, "azure-deployment", "", "Azure OpenAI deployment name")
+
+	// InstructLab
+	convertCmd.Flags().StringVar(&instructEndpoint, "instructlab-endpoint", "", "InstructLab endpoint URL")
+
+	// OpenAI
+	convertCmd.Flags().StringVar(&openaiAPIKey, "openai-api-key", "", "OpenAI API key (default from config or OPENAI_API_KEY)")
+
+	// Anthropic
+	convertCmd.Flags().StringVar(&anthropicAPIKey, "anthropic-api-key", "", "Anthropic API key (default from config or ANTHROPIC_API_KEY)")
+
+	// Network
+	convertCmd.Flags().StringVar(&aiProxy, "proxy", "", "Proxy URL for AI requests (default from HTTPS_PROXY/HTTP_PROXY)")
+	convertCmd.Flags().StringVar(&aiCACert, "ca-cert", "", "PEM file of extra CA certificates to trust")
+
+	// System prompt
+	convertCmd.Flags().StringVar(&aiSystemPrompt, "system-prompt", "", "System message sent ahead of every prompt (default from config)")
+	convertCmd.Flags().StringVar(&aiSystemPromptFile, "system-prompt-file", "", "Read the system message from a file")
+
+	// Response cache
+	convertCmd.Flags().BoolVar(&aiCache, "cache", false, "Reuse identical responses from the on-disk cache")
+	convertCmd.Flags().BoolVar(&aiNoCache, "no-cache", false, "Disable the response cache, overriding --cache and the config file")
+	convertCmd.Flags().DurationVar(&aiCacheTTL, "cache-ttl", 0, "How long cached responses are reused (default 24h)")
+
+	// Diagnostics
+	convertCmd.Flags().BoolVar(&aiProviderInfo, "provider-info", false, "Print the resolved provider settings and their sources, then exit")
+
+	// Command options
+	convertCmd.Flags().StringVar(&convertFrom, "from", "sql", "Source query language: sql")
+	convertCmd.Flags().StringVar(&convertDialect, "dialect", "", "Source dialect hint, e.g. tsql, postgres, mysql, sqlite, bigquery")
+	convertCmd.Flags().StringVarP(&convertInputFile, "file", "f", "", "Read query from file")
+	addInputSourceFlags(convertCmd)
+	convertCmd.Flags().StringVarP(&aiOutput, "output", "o", "", "Write the result to a file instead of stdout")
+	convertCmd.Flags().BoolVar(&convertDebug, "debug", false, "Show raw LLM responses (for troubleshooting)")
+	convertCmd.Flags().IntVar(&convertTimeout, "timeout", 60, "Timeout in seconds")
+
+	// Validation flags
+	convertCmd.Flags().BoolVar(&convertNoValidate, "no-validate", false, "Disable validation")
+	convertCmd.Flags().BoolVar(&convertStrict, "strict", false, "Fail with exit code 1 if validation fails")
+	convertCmd.Flags().IntVar(&convertRetries, "retries", 2, "Number of retry attempts on validation failure")
+}
+
+func runConvert(cmd *cobra.Command, args []string) (err error) {
+	if aiProviderInfo {
+		return runProviderInfo(cmd)
+	}
+
+	if err := checkConvertSource(convertFrom); err != nil {
+		return err
+	}
+
+	// Get query input
+	source, err := getInputFrom(args, convertInputFile, os.Stdin, isTerminal)
+	if err != nil {
+		return err
+	}
+
+	provider, cfg, err := resolveProvider()
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(aiOutput, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer func() { err = out.finish(err) }()
+
+	valCfg := cfg.Validation
+	if convertNoValidate {
+		valCfg.Enabled = false
+	}
+	if convertStrict {
+		valCfg.Strict = true
+	}
+	valCfg.Retries = convertRetries
+
+	timeout := time.Duration(convertTimeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	logf(logInfo, "Using %s provider with model %s...", provider.Name(), provider.Model())
+	if convertDialect != "" {
+		logf(logInfo, "Source dialect: %s", convertDialect)
+	}
+
+	// Debug output writer
+	var debugWriter io.Writer
+	if convertDebug {
+		debugWriter = os.Stderr
+	}
+
+	style := ai.PromptStyleFor(provider)
+	result, err := ai.GenerateWithValidation(
+		ctx,
+		provider,
+		ai.GenerateRequest{Prompt: source},
+		valCfg,
+		cfg.Temperature,
+		func(r ai.GenerateRequest) string {
+			return buildConvertPrompt(r.Prompt, convertDialect, style)
+		},
+		extractKQL,
+		logWriter(logInfo),
+		debugWriter,
+	)
+	if err != nil {
+		return aiRequestError(err, timeout)
+	}
+
+	if !result.Valid {
+		if valCfg.Strict {
+			return validationFailure(result)
+		}
+		logf(logWarn, "%s", ai.FormatValidationWarning(result))
+	}
+
+	fmt.Fprintln(out, result.Query)
+	return nil
+}
+
+// checkConvertSource rejects --from values other than convertSources.
+func checkConvertSource(from string) error {
+	for _, s := range convertSources {
+		if strings.EqualFold(from, s) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported source language: %q (supported: %s)", from, strings.Join(convertSources, ", "))
+}
+
+func buildConvertPrompt(query, dialect string, style ai.PromptStyle) string {
+	var context strings.Builder
+
+	if style == ai.PromptStyleTerse {
+		context.WriteString("Translate the SQL query into an equivalent KQL query. Output only the KQL, with no backticks or explanation.\n")
+	} else {
+		context.WriteString(`You are a Kusto Query Language (KQL) expert. Translate the user's SQL query into an equivalent KQL query.
+
+Rules:
+1. Output ONLY the raw KQL query, no explanations
+2. Do NOT wrap the query in backticks or code blocks
+3. Keep the same tables, columns, filters, grouping, and ordering
+4. Use KQL idioms: where for WHERE, summarize ... by for GROUP BY, project for the SELECT list, top or take for TOP/LIMIT
+5. Use KQL functions in place of SQL ones (e.g. count() for COUNT(*), dcount() for COUNT(DISTINCT ...))
+`)
+	}
+
+	if dialect != "" {
+		context.WriteString(fmt.Sprintf("\nSQL dialect: %s\n", dialect))
+	}
+
+	context.WriteString("\n" + style.Field("sql", "SQL", query) + "\n")
+	context.WriteString("\nTranslate to KQL:")
+
+	return context.String()
+}
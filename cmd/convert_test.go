@@ -0,0 +1,48 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestBuildConvertPrompt(t *testing.T) {
+	sql := "SELECT State, count(*) FROM StormEvents GROUP BY State"
+
+	tagged := buildConvertPrompt(sql, "tsql", ai.PromptStyleTagged)
+	if !strings.Contains(tagged, "<sql>\n"+sql+"\n</sql>") {
+		t.Errorf("expected tagged prompt to wrap the SQL, got:\n%s", tagged)
+	}
+	if !strings.Contains(tagged, "SQL dialect: tsql") {
+		t.Errorf("expected the dialect hint, got:\n%s", tagged)
+	}
+
+	plain := buildConvertPrompt(sql, "", ai.PromptStyleDefault)
+	if !strings.Contains(plain, "SQL: "+sql) {
+		t.Errorf("expected default prompt to label the SQL, got:\n%s", plain)
+	}
+	if strings.Contains(plain, "dialect") {
+		t.Error("expected no dialect line without --dialect")
+	}
+
+	terse := buildConvertPrompt(sql, "", ai.PromptStyleTerse)
+	if len(terse) >= len(plain) || !strings.Contains(terse, sql) {
+		t.Errorf("expected a shorter prompt containing the SQL, got:\n%s", terse)
+	}
+}
+
+func TestCheckConvertSource(t *testing.T) {
+	for _, from := range []string{"sql", "SQL"} {
+		if err := checkConvertSource(from); err != nil {
+			t.Errorf("%s: %v", from, err)
+		}
+	}
+	err := checkConvertSource("spl")
+	if err == nil || !strings.Contains(err.Error(), "supported: sql") {
+		t.Errorf("expected an unsupported language error, got %v", err)
+	}
+}
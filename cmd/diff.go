@@ -0,0 +1,139 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+	"github.com/spf13/cobra"
+)
+
+var diffSemantic bool
+
+// diffStdout and diffStdin are variables to allow testing
+var (
+	diffStdout io.Writer = os.Stdout
+	diffStdin  io.Reader = os.Stdin
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare two KQL queries ignoring formatting",
+	Long: `Diff compares two KQL queries by their normalized forms (see
+'kql normalize') and prints a unified diff of those, so layout and comments
+don't show up as changes.
+
+With --semantic, the structural differences are listed after the diff:
+added or removed filters, a changed summarize key, changed let bindings,
+and so on. Both queries must parse.
+
+The exit code is 0 when the queries are equivalent and 1 when they differ.
+Use '-' for either file to read it from stdin.`,
+	Example: `  # Compare two versions of a query
+  kql diff old.kql new.kql
+
+  # Also describe what changed
+  kql diff --semantic old.kql new.kql
+
+  # Compare the committed query with a working copy
+  git show HEAD:queries/q.kql | kql diff - queries/q.kql`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().BoolVar(&diffSemantic, "semantic", false, "List structural differences such as added filters or changed summarize keys")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	different, err := doDiff(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if different {
+		osExit(1)
+	}
+	return nil
+}
+
+// doDiff prints the differences between the queries in two files and
+// reports whether there are any.
+// Separated from runDiff to enable testing without os.Exit.
+func doDiff(oldName, newName string) (bool, error) {
+	if oldName == "-" && newName == "-" {
+		return false, fmt.Errorf("only one of the queries can be read from stdin")
+	}
+
+	oldQuery, err := readDiffInput(oldName)
+	if err != nil {
+		return false, err
+	}
+	newQuery, err := readDiffInput(newName)
+	if err != nil {
+		return false, err
+	}
+
+	diff := kqlfmt.UnifiedDiff(diffName(oldName), diffName(newName), canonical(oldQuery), canonical(newQuery))
+	if diff == "" {
+		return false, nil
+	}
+	fmt.Fprint(diffStdout, diff)
+
+	if diffSemantic {
+		oldShape, err := shapeOf(diffName(oldName), oldQuery)
+		if err != nil {
+			return true, fmt.Errorf("--semantic: %w", err)
+		}
+		newShape, err := shapeOf(diffName(newName), newQuery)
+		if err != nil {
+			return true, fmt.Errorf("--semantic: %w", err)
+		}
+		if changes := describeChanges(oldShape, newShape); len(changes) > 0 {
+			fmt.Fprintln(diffStdout)
+			for _, c := range changes {
+				fmt.Fprintln(diffStdout, c)
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// readDiffInput reads a query from a file, or stdin when name is "-".
+func readDiffInput(name string) (string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(diffStdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %w", diffName(name), err)
+	}
+	return string(data), nil
+}
+
+// diffName returns the name shown for a diff input.
+func diffName(name string) string {
+	if name == "-" {
+		return "stdin"
+	}
+	return name
+}
+
+// canonical returns the normalized form of query, or the query as written
+// when it cannot be normalized.
+func canonical(query string) string {
+	if normalized, err := kqlfmt.Normalize(query); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(query)
+}
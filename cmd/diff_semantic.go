@@ -0,0 +1,220 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/ast"
+)
+
+// queryShape is the structure of a query that --semantic compares: its
+// let bindings and the pipeline of its final query statement.
+type queryShape struct {
+	lets     []binding
+	source   string
+	pipeline []stage
+}
+
+// binding is a let statement.
+type binding struct {
+	name, value string
+}
+
+// stage is one operator of a pipeline, as normalized text without the
+// leading "|". kind is the operator name, such as "where" or "summarize".
+type stage struct {
+	kind, text string
+	// by is the group-by clause of a summarize
+	by string
+}
+
+// shapeOf parses query and returns its shape.
+func shapeOf(name, query string) (*queryShape, error) {
+	result := kqlparser.Parse(name, query)
+	if result.HasErrors() {
+		return nil, fmt.Errorf("%s: %v", name, result.Errors[0])
+	}
+
+	// Operator End positions are approximate, so each part of a statement
+	// runs up to the start of the next part
+	stmts := result.AST.Stmts
+	stmtEnd := func(i int) int {
+		if i+1 < len(stmts) {
+			return int(stmts[i+1].Pos()) - 1
+		}
+		return len(query)
+	}
+
+	shape := &queryShape{}
+	for i, s := range stmts {
+		switch s := s.(type) {
+		case *ast.LetStmt:
+			shape.lets = append(shape.lets, binding{
+				name:  s.Name.Name,
+				value: shapeText(query, int(s.Value.Pos())-1, stmtEnd(i)),
+			})
+		case *ast.ExprStmt:
+			shape.source, shape.pipeline = "", nil
+			pipe, ok := s.X.(*ast.PipeExpr)
+			if !ok {
+				shape.source = shapeText(query, int(s.Pos())-1, stmtEnd(i))
+				continue
+			}
+			ops := pipe.Operators
+			end := stmtEnd(i)
+			if len(ops) > 0 {
+				end = int(ops[0].Pos()) - 1
+			}
+			shape.source = shapeText(query, int(pipe.Pos())-1, end)
+			for j, op := range ops {
+				end := stmtEnd(i)
+				if j+1 < len(ops) {
+					end = int(ops[j+1].Pos()) - 1
+				}
+				st := stage{text: strings.TrimSpace(strings.TrimPrefix(shapeText(query, int(op.Pos())-1, end), "|"))}
+				st.kind, _, _ = strings.Cut(st.text, " ")
+				if sum, ok := op.(*ast.SummarizeOp); ok && len(sum.GroupBy) > 0 {
+					st.by = shapeText(query, int(sum.GroupBy[0].Pos())-1, end)
+				}
+				shape.pipeline = append(shape.pipeline, st)
+			}
+		}
+	}
+	return shape, nil
+}
+
+// shapeText returns query[start:end] normalized, without a trailing ";".
+func shapeText(query string, start, end int) string {
+	text := query[start:end]
+	if normalized, err := kqlfmt.Normalize(text); err == nil {
+		text = normalized
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), ";"))
+}
+
+// describeChanges lists the structural differences from before to after, such
+// as "added filter: x > 1". Stages are matched in order; between matched
+// stages, a removed and an added stage of the same kind are reported as a
+// change.
+func describeChanges(before, after *queryShape) []string {
+	var changes []string
+
+	// Let bindings are matched by name
+	newLets := make(map[string]string)
+	for _, b := range after.lets {
+		newLets[b.name] = b.value
+	}
+	oldLets := make(map[string]bool)
+	for _, b := range before.lets {
+		oldLets[b.name] = true
+		value, ok := newLets[b.name]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("removed let %s", b.name))
+		case value != b.value:
+			changes = append(changes, fmt.Sprintf("changed let %s: %s → %s", b.name, b.value, value))
+		}
+	}
+	for _, b := range after.lets {
+		if !oldLets[b.name] {
+			changes = append(changes, fmt.Sprintf("added let %s = %s", b.name, b.value))
+		}
+	}
+
+	if before.source != after.source {
+		changes = append(changes, fmt.Sprintf("changed source: %s → %s", before.source, after.source))
+	}
+
+	// Walk a longest common subsequence of the stages, pairing up the
+	// removals and additions between matched stages
+	x, y := before.pipeline, after.pipeline
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i].text == y[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var removed, added []stage
+	flush := func() {
+		paired := make([]bool, len(added))
+		for _, r := range removed {
+			change := fmt.Sprintf("removed %s: %s", stageLabel(r), stageArgs(r))
+			for j, a := range added {
+				if !paired[j] && a.kind == r.kind {
+					paired[j] = true
+					change = describeChange(r, a)
+					break
+				}
+			}
+			changes = append(changes, change)
+		}
+		for j, a := range added {
+			if !paired[j] {
+				changes = append(changes, fmt.Sprintf("added %s: %s", stageLabel(a), stageArgs(a)))
+			}
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i].text == y[j].text:
+			flush()
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, x[i])
+			i++
+		default:
+			added = append(added, y[j])
+			j++
+		}
+	}
+	flush()
+
+	return changes
+}
+
+// describeChange describes how a stage changed, given two stages of the
+// same kind.
+func describeChange(before, after stage) string {
+	if before.kind == "summarize" && before.by != after.by {
+		return fmt.Sprintf("changed summarize key: %s → %s", byClause(before.by), byClause(after.by))
+	}
+	return fmt.Sprintf("changed %s: %s → %s", stageLabel(before), stageArgs(before), stageArgs(after))
+}
+
+// stageLabel names a stage in a change description.
+func stageLabel(s stage) string {
+	if s.kind == "where" {
+		return "filter"
+	}
+	return s.kind
+}
+
+// stageArgs returns a stage's text after the operator name.
+func stageArgs(s stage) string {
+	return strings.TrimSpace(strings.TrimPrefix(s.text, s.kind))
+}
+
+// byClause formats a group-by clause for a change description.
+func byClause(by string) string {
+	if by == "" {
+		return "(none)"
+	}
+	return "by " + by
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeQuery(t *testing.T, name, query string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(query), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDoDiff(t *testing.T) {
+	var out bytes.Buffer
+	defer func() { diffStdout, diffStdin = os.Stdout, os.Stdin }()
+	diffStdout = &out
+
+	a := writeQuery(t, "a.kql", "T | where x > 1 // big\n| take 10")
+	b := writeQuery(t, "b.kql", "T\n|   where x>1\n| take 10\n")
+	c := writeQuery(t, "c.kql", "T | where x > 2 | take 10")
+
+	// Layout and comments don't count
+	if different, err := doDiff(a, b); err != nil || different || out.Len() != 0 {
+		t.Errorf("different=%v err=%v out=%q", different, err, out.String())
+	}
+
+	different, err := doDiff(a, c)
+	if err != nil || !different {
+		t.Fatalf("different=%v err=%v", different, err)
+	}
+	for _, want := range []string{"--- " + a, "+++ " + c, "-| where x > 1", "+| where x > 2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+
+	// Either side can come from stdin
+	out.Reset()
+	diffStdin = strings.NewReader("T | where x > 2 | take 10")
+	if different, err := doDiff(a, "-"); err != nil || !different || !strings.Contains(out.String(), "+++ stdin") {
+		t.Errorf("different=%v err=%v out=%q", different, err, out.String())
+	}
+
+	if _, err := doDiff("-", "-"); err == nil {
+		t.Error("expected an error with both queries from stdin")
+	}
+}
+
+func TestDescribeChanges(t *testing.T) {
+	tests := []struct {
+		name, before, after string
+		want                []string
+	}{
+		{
+			name:   "added filter",
+			before: "T | summarize count() by State",
+			after:  "T | where Year == 2024 | summarize count() by State",
+			want:   []string{"added filter: Year == 2024"},
+		},
+		{
+			name:   "removed and changed filter",
+			before: "T | where a == 1 | where b == 2 | take 5",
+			after:  "T | where a == 3 | take 5",
+			want:   []string{"changed filter: a == 1 → a == 3", "removed filter: b == 2"},
+		},
+		{
+			name:   "changed key with an added filter before it",
+			before: "T | summarize count() by State",
+			after:  "T | where Year == 2024 | summarize count() by State, EventType",
+			want:   []string{"changed summarize key: by State → by State, EventType", "added filter: Year == 2024"},
+		},
+		{
+			name:   "changed summarize key",
+			before: "T | summarize count() by State",
+			after:  "T | summarize count() by State, EventType",
+			want:   []string{"changed summarize key: by State → by State, EventType"},
+		},
+		{
+			name:   "let bindings and source",
+			before: "let n = 5;\nlet m = 1;\nT | take n",
+			after:  "let n = 10;\nlet k = 2;\nU | take n",
+			want:   []string{"changed let n: 5 → 10", "removed let m", "added let k = 2", "changed source: T → U"},
+		},
+		{
+			name:   "last stage runs to the end",
+			before: "T | top 3 by x desc",
+			after:  "T | top 3 by x asc",
+			want:   []string{"changed top: 3 by x desc → 3 by x asc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := shapeOf("before", tt.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := shapeOf("after", tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := describeChanges(before, after)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoDiff_Semantic(t *testing.T) {
+	var out bytes.Buffer
+	defer func() { diffStdout, diffSemantic = os.Stdout, false }()
+	diffStdout = &out
+	diffSemantic = true
+
+	a := writeQuery(t, "a.kql", "T | summarize count() by State")
+	b := writeQuery(t, "b.kql", "T | where Year == 2024 | summarize count() by State")
+	if different, err := doDiff(a, b); err != nil || !different {
+		t.Fatalf("different=%v err=%v", different, err)
+	}
+	if !strings.HasSuffix(out.String(), "\nadded filter: Year == 2024\n") {
+		t.Errorf("expected the structural change after the diff, got:\n%s", out.String())
+	}
+
+	// Structural comparison needs queries that parse
+	bad := writeQuery(t, "bad.kql", "T | where (")
+	if _, err := doDiff(a, bad); err == nil {
+		t.Error("expected an error for an unparseable query")
+	}
+}
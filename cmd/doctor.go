@@ -0,0 +1,145 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorTimeout int
+	doctorNoColor bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the AI provider is configured and reachable",
+	Long: `Doctor resolves the AI settings as the AI commands do, prints the
+selected provider, model, and endpoint with where each came from, then
+checks the provider answers without running a model:
+
+  ollama       GET /api/tags, and the model has been pulled
+  instructlab  GET /v1/models
+  openai       GET /models with the API key
+  anthropic    GET /v1/models with the API key
+  azure        GET /openai/models with the API key
+  vertex       gcloud is installed and returns an access token
+
+It exits non-zero if the provider cannot be set up or is unreachable.`,
+	Example: `  # Check the configured provider
+  kql doctor
+
+  # Check another provider or profile
+  kql doctor --provider ollama --ollama-endpoint http://gpu-box:11434
+  kql doctor --profile work`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = doctorCmd.RegisterFlagCompletionFunc("provider", completeProvider)
+	doctorCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
+	doctorCmd.Flags().StringVar(&ollamaEndpoint, "ollama-endpoint", "", "Ollama endpoint URL")
+	doctorCmd.Flags().StringVar(&vertexProject, "vertex-project", "", "GCP project ID")
+	doctorCmd.Flags().StringVar(&vertexLocation, "vertex-location", "", "GCP location")
+	doctorCmd.Flags().StringVar(&azureEndpoint, "azure-endpoint", "", "Azure OpenAI endpoint URL")
+	doctorCmd.Flags().StringVar(&azureDeployment, "azure-deployment", "", "Azure OpenAI deployment name")
+	doctorCmd.Flags().StringVar(&instructEndpoint, "instructlab-endpoint", "", "InstructLab endpoint URL")
+	doctorCmd.Flags().StringVar(&aiProxy, "proxy", "", "Proxy URL for AI requests (default from HTTPS_PROXY/HTTP_PROXY)")
+	doctorCmd.Flags().StringVar(&aiCACert, "ca-cert", "", "PEM file of extra CA certificates to trust")
+	doctorCmd.Flags().IntVar(&doctorTimeout, "timeout", 10, "Timeout in seconds for the provider check")
+	doctorCmd.Flags().BoolVar(&doctorNoColor, "no-color", false, "Disable colored results (also honors NO_COLOR)")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	fileCfg, err := loadAIFileConfig()
+	if err != nil {
+		return err
+	}
+	settings := resolveProviderInfo(buildAIConfig(), cmd.Flags().Changed, fileCfg, os.Getenv)
+
+	if path, err := configFilePath(); err == nil {
+		source := sourceDefault
+		switch {
+		case configFile != "":
+			source = sourceFlag + " --config"
+		case os.Getenv(configEnv) != "":
+			source = "env " + configEnv
+		}
+		if fileCfg == nil {
+			path += " (not found)"
+		}
+		settings = append([]resolvedSetting{{Name: "config", Value: path, Source: source}}, settings...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(doctorTimeout)*time.Second)
+	defer cancel()
+
+	d := doctorChecks{newProvider: ai.NewProvider, lookPath: exec.LookPath}
+	if !d.run(ctx, os.Stdout, aiConfigFrom(fileCfg), settings, useColor(doctorNoColor, os.Stdout)) {
+		osExit(1)
+	}
+	return nil
+}
+
+// doctorChecks holds the dependencies of the doctor checks, replaced in
+// tests.
+type doctorChecks struct {
+	newProvider func(ai.Config) (ai.Provider, error)
+	lookPath    func(string) (string, error)
+}
+
+// run prints the settings, then one ok or FAIL line per check, and
+// reports whether every check passed.
+func (d doctorChecks) run(ctx context.Context, w io.Writer, cfg ai.Config, settings []resolvedSetting, color bool) bool {
+	writeProviderInfo(w, settings)
+	fmt.Fprintln(w)
+
+	failed := false
+	report := func(name string, err error, detail string) {
+		status, c := "ok  ", ansiGreen
+		if err != nil {
+			status, c, detail, failed = "FAIL", ansiRed, err.Error(), true
+		}
+		if color {
+			status = c + status + ansiReset
+		}
+		fmt.Fprintf(w, "%s  %s: %s\n", status, name, detail)
+	}
+
+	if cfg.Provider == "vertex" {
+		path, err := d.lookPath("gcloud")
+		if err != nil {
+			err = fmt.Errorf("not found in PATH (install the Google Cloud CLI)")
+		}
+		report("gcloud", err, path)
+	}
+
+	provider, err := d.newProvider(cfg)
+	if err != nil {
+		report(cfg.Provider, err, "")
+		return false
+	}
+
+	start := time.Now()
+	err = ai.HealthOf(ctx, provider)
+	if errors.Is(err, ai.ErrHealthUnsupported) {
+		report(provider.Name(), nil, "configured (no reachability check for this provider)")
+	} else {
+		report(provider.Name(), err, fmt.Sprintf("reachable (%s)", time.Since(start).Round(time.Millisecond)))
+	}
+	return !failed
+}
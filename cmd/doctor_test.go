@@ -0,0 +1,89 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestDoctorChecks_Ollama(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"models":[{"name":"llama3.2:latest"}]}`))
+	}))
+	defer srv.Close()
+
+	d := doctorChecks{newProvider: ai.NewProvider, lookPath: exec.LookPath}
+	cfg := ai.DefaultConfig()
+	cfg.Provider = "ollama"
+	cfg.Ollama.Endpoint = srv.URL
+	settings := []resolvedSetting{{Name: "provider", Value: "ollama", Source: sourceDefault}}
+
+	var out bytes.Buffer
+	if !d.run(context.Background(), &out, cfg, settings, false) {
+		t.Errorf("expected the check to pass, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "provider:    ollama") || !strings.Contains(out.String(), "ok    ollama: reachable") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	// Unreachable
+	srv.Close()
+	out.Reset()
+	if d.run(context.Background(), &out, cfg, settings, true) {
+		t.Error("expected the check to fail for a stopped server")
+	}
+	if !strings.Contains(out.String(), ansiRed+"FAIL"+ansiReset+"  ollama: sending request to ollama") {
+		t.Errorf("expected a red FAIL line, got:\n%s", out.String())
+	}
+}
+
+func TestDoctorChecks_Vertex(t *testing.T) {
+	d := doctorChecks{
+		newProvider: func(ai.Config) (ai.Provider, error) { return &fakeProvider{name: "vertex"}, nil },
+		lookPath:    func(string) (string, error) { return "", exec.ErrNotFound },
+	}
+	cfg := ai.Config{Provider: "vertex"}
+
+	var out bytes.Buffer
+	if d.run(context.Background(), &out, cfg, nil, false) {
+		t.Error("expected the check to fail without gcloud")
+	}
+	if !strings.Contains(out.String(), "FAIL  gcloud: not found in PATH") {
+		t.Errorf("expected a gcloud failure, got:\n%s", out.String())
+	}
+
+	// Providers without a health check only need to be set up
+	d.lookPath = func(string) (string, error) { return "/usr/bin/gcloud", nil }
+	out.Reset()
+	if !d.run(context.Background(), &out, cfg, nil, false) {
+		t.Errorf("expected the check to pass, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "ok    gcloud: /usr/bin/gcloud") || !strings.Contains(out.String(), "no reachability check") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestDoctorChecks_SetupError(t *testing.T) {
+	d := doctorChecks{
+		newProvider: func(ai.Config) (ai.Provider, error) {
+			return nil, errors.New("openai: API key required")
+		},
+	}
+	var out bytes.Buffer
+	if d.run(context.Background(), &out, ai.Config{Provider: "openai"}, nil, false) {
+		t.Error("expected the check to fail")
+	}
+	if !strings.Contains(out.String(), "FAIL  openai: openai: API key required") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
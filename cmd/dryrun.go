@@ -0,0 +1,69 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// aiDryRun is the --dry-run flag of explain, generate, and suggest: print
+// the prompt instead of sending it.
+var aiDryRun bool
+
+// dryRunConfig resolves the AI settings a command would use, without
+// creating a provider, so it needs no credentials.
+func dryRunConfig() (ai.Config, error) {
+	cfg, err := loadAIConfig()
+	if err != nil {
+		return cfg, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return applySystemPromptFile(cfg)
+}
+
+// promptStyleForConfig returns the prompt style of the model cfg selects,
+// falling back to the provider's default model.
+func promptStyleForConfig(cfg ai.Config) ai.PromptStyle {
+	model := cfg.Model
+	if model == "" {
+		model = ai.DefaultModel(cfg.Provider)
+	}
+	return ai.PromptStyleForModel(model)
+}
+
+// writePrompt prints a prompt as it would be sent: the system prompt, if
+// one is set, and then the prompt itself.
+func writePrompt(out io.Writer, system, prompt string) {
+	if system != "" {
+		fmt.Fprintf(out, "--- system ---\n%s\n--- user ---\n", system)
+	}
+	fmt.Fprintln(out, prompt)
+}
+
+// runPromptDryRun writes the prompt for each query of input, built by
+// prompt for the configured model's style, to stdout or --output.
+func runPromptDryRun(input queryInput, prompt func(query string, style ai.PromptStyle) string) (err error) {
+	cfg, err := dryRunConfig()
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(aiOutput, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer func() { err = out.finish(err) }()
+
+	style := promptStyleForConfig(cfg)
+	return input.each(out, func(query string) error {
+		writePrompt(out, cfg.SystemPrompt, prompt(query, style))
+		return nil
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// dryRunOutput runs runPromptDryRun with --output set to a temp file and
+// returns what it wrote.
+func dryRunOutput(t *testing.T, input queryInput, prompt func(string, ai.PromptStyle) string) string {
+	t.Helper()
+	defer func(o string) { aiOutput = o }(aiOutput)
+	aiOutput = filepath.Join(t.TempDir(), "prompt.txt")
+
+	if err := runPromptDryRun(input, prompt); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(aiOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunPromptDryRun_NoCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	defer func(p, m string, v bool) { aiProvider, aiModel, logVerbose = p, m, v }(aiProvider, aiModel, logVerbose)
+
+	// Azure without an endpoint or key can't create a provider
+	aiProvider, aiModel, logVerbose = "azure", "", true
+	if _, _, err := resolveProvider(); err == nil {
+		t.Fatal("expected provider creation to fail without credentials")
+	}
+
+	got := dryRunOutput(t, queryInput{query: "T | summarize count( by A"}, explainPrompt)
+	if !strings.Contains(got, "T | summarize count( by A") {
+		t.Errorf("expected the query in the prompt, got:\n%s", got)
+	}
+	if !strings.Contains(got, "syntax issue") {
+		t.Errorf("expected --verbose to add the parse context, got:\n%s", got)
+	}
+}
+
+func TestRunPromptDryRun_StyleAndSystemPrompt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(p, m, s string) { aiProvider, aiModel, aiSystemPrompt = p, m, s }(aiProvider, aiModel, aiSystemPrompt)
+	aiProvider, aiModel, aiSystemPrompt = "anthropic", "", "Be brief."
+
+	got := dryRunOutput(t, queryInput{query: "count events by state"}, func(description string, style ai.PromptStyle) string {
+		return buildGeneratePrompt(description, "StormEvents", "", style)
+	})
+
+	if !strings.HasPrefix(got, "--- system ---\nBe brief.\n--- user ---\n") {
+		t.Errorf("expected the system prompt first, got:\n%s", got)
+	}
+	// The default Anthropic model is a Claude model, which gets tagged inputs
+	if !strings.Contains(got, "<description>") {
+		t.Errorf("expected the tagged prompt style, got:\n%s", got)
+	}
+}
+
+func TestRunPromptDryRun_RejectsUnknownProvider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(p string) { aiProvider = p }(aiProvider)
+	aiProvider = "ollamma"
+
+	if err := runPromptDryRun(queryInput{query: "T"}, explainPrompt); err == nil {
+		t.Error("expected an unknown provider error")
+	}
+}
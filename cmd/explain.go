@@ -5,8 +5,11 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/kql/pkg/ai"
@@ -25,11 +28,20 @@ var (
 	azureEndpoint    string
 	azureDeployment  string
 	instructEndpoint string
+	openaiAPIKey     string
+	anthropicAPIKey  string
+	aiProxy          string
+	aiCACert         string
+
+	// Custom system prompt flags
+	aiSystemPrompt     string
+	aiSystemPromptFile string
 
 	// Explain-specific flags
 	explainInputFile string
-	explainVerbose   bool
 	explainTimeout   int
+	explainRefresh   bool
+	explainFormat    string
 )
 
 var explainCmd = &cobra.Command{
@@ -44,11 +56,22 @@ Supported AI providers:
   - instructlab: Local InstructLab instance
   - vertex:      Google Vertex AI (Gemini, Claude)
   - azure:       Azure OpenAI
+  - openai:      OpenAI API (or a compatible proxy)
+  - anthropic:   Anthropic API (Claude)
 
 Configuration can be provided via:
   - Command-line flags
   - Environment variables (KQL_AI_PROVIDER, KQL_GCP_PROJECT, etc.)
-  - Config file (~/.kql/config.yaml)`,
+  - Config file (~/.kql/config.yaml)
+
+Explanations are cached by query, provider, model, and format. Use
+--refresh to bypass the cache for a single run.
+
+Use --format markdown for headed Sources/Filters/Aggregations/Output
+sections, or --format json for the same sections as a JSON object.
+
+Use --dry-run to print the prompt that would be sent, without creating a
+provider, so no credentials are needed.`,
 	Example: `  # Explain a simple query (using local Ollama)
   kql explain "StormEvents | summarize count() by State"
 
@@ -59,7 +82,20 @@ Configuration can be provided via:
   kql explain --provider vertex --model gemini-1.5-pro "T | take 10"
 
   # Use Azure OpenAI
-  kql explain --provider azure --azure-endpoint https://myorg.openai.azure.com "T | take 10"`,
+  kql explain --provider azure --azure-endpoint https://myorg.openai.azure.com "T | take 10"
+
+  # Ignore any cached explanation
+  kql explain --refresh -f query.kql
+
+  # Structured explanation for docs or scripts
+  kql explain --format markdown -f query.kql >> QUERIES.md
+  kql explain --format json -f query.kql | jq -r '.sources[]'
+
+  # Explain every query in a folder
+  kql explain --batch queries/
+
+  # See the exact prompt, including the parse context
+  kql explain --dry-run --verbose -f query.kql`,
 	RunE: runExplain,
 }
 
@@ -67,7 +103,8 @@ func init() {
 	rootCmd.AddCommand(explainCmd)
 
 	// Provider selection
-	explainCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure)")
+	explainCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = explainCmd.RegisterFlagCompletionFunc("provider", completeProvider)
 	explainCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
 	explainCmd.Flags().Float32Var(&aiTemperature, "temperature", 0.2, "Temperature (0.0-1.0)")
 
@@ -85,66 +122,186 @@ func init() {
 	// InstructLab
 	explainCmd.Flags().StringVar(&instructEndpoint, "instructlab-endpoint", "", "InstructLab endpoint URL")
 
+	// OpenAI
+	explainCmd.Flags().StringVar(&openaiAPIKey, "openai-api-key", "", "OpenAI API key (default from config or OPENAI_API_KEY)")
+
+	// Anthropic
+	explainCmd.Flags().StringVar(&anthropicAPIKey, "anthropic-api-key", "", "Anthropic API key (default from config or ANTHROPIC_API_KEY)")
+
+	// Network
+	explainCmd.Flags().StringVar(&aiProxy, "proxy", "", "Proxy URL for AI requests (default from HTTPS_PROXY/HTTP_PROXY)")
+	explainCmd.Flags().StringVar(&aiCACert, "ca-cert", "", "PEM file of extra CA certificates to trust")
+
+	// System prompt
+	explainCmd.Flags().StringVar(&aiSystemPrompt, "system-prompt", "", "System message sent ahead of every prompt (default from config)")
+	explainCmd.Flags().StringVar(&aiSystemPromptFile, "system-prompt-file", "", "Read the system message from a file")
+
+	// Response cache (explanations are always cached unless disabled)
+	explainCmd.Flags().BoolVar(&aiNoCache, "no-cache", false, "Neither read nor write the explanation cache")
+	explainCmd.Flags().DurationVar(&aiCacheTTL, "cache-ttl", 0, "How long cached explanations are reused (default 24h)")
+
+	// Diagnostics
+	explainCmd.Flags().BoolVar(&aiProviderInfo, "provider-info", false, "Print the resolved provider settings and their sources, then exit")
+
 	// Command options
 	explainCmd.Flags().StringVarP(&explainInputFile, "file", "f", "", "Read query from file")
-	explainCmd.Flags().BoolVarP(&explainVerbose, "verbose", "v", false, "Show additional context")
+	addInputSourceFlags(explainCmd)
+	explainCmd.Flags().StringVarP(&aiOutput, "output", "o", "", "Write the result to a file instead of stdout")
+	explainCmd.Flags().BoolVar(&aiBatch, "batch", false, "Treat each argument as a query file (globs and directories allowed), printing a header per file")
 	explainCmd.Flags().IntVar(&explainTimeout, "timeout", 60, "Timeout in seconds")
+	explainCmd.Flags().BoolVar(&explainRefresh, "refresh", false, "Bypass the explanation cache")
+	explainCmd.Flags().StringVar(&explainFormat, "format", "text", "Output format: text, markdown, json")
+	explainCmd.Flags().BoolVar(&aiDryRun, "dry-run", false, "Print the prompt that would be sent and exit, without contacting the model")
+	_ = explainCmd.RegisterFlagCompletionFunc("format", completeValues(explainFormats...))
 }
 
-func runExplain(cmd *cobra.Command, args []string) error {
+func runExplain(cmd *cobra.Command, args []string) (err error) {
+	if aiProviderInfo {
+		return runProviderInfo(cmd)
+	}
+	if err := checkExplainFormat(explainFormat); err != nil {
+		return err
+	}
+
 	// Get query input
-	query, err := getInputFrom(args, explainInputFile, os.Stdin, isTerminal)
+	input, err := readQueryInput(args, explainInputFile)
 	if err != nil {
 		return err
 	}
 
-	// Build AI config
-	cfg := buildAIConfig()
+	if aiDryRun {
+		return runPromptDryRun(input, explainPrompt)
+	}
 
-	// Load file config and merge
-	fileCfg, err := ai.LoadConfigFile()
+	provider, cfg, err := resolveProvider()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: error loading config file: %v\n", err)
+		return err
 	}
-	cfg = ai.MergeFileConfig(cfg, fileCfg)
 
-	// Apply defaults if still empty
-	if cfg.Provider == "" {
-		cfg.Provider = "ollama"
+	out, err := openOutput(aiOutput, os.Stderr)
+	if err != nil {
+		return err
 	}
+	defer func() { err = out.finish(err) }()
 
-	// Create provider
-	provider, err := ai.NewProvider(cfg)
-	if err != nil {
-		return fmt.Errorf("creating AI provider: %w", err)
+	// Explanations have their own cache, keyed by the normalized query,
+	// so a provider-level cache is bypassed
+	if caching, ok := provider.(*ai.CachingProvider); ok {
+		provider = caching.Unwrap()
+	}
+
+	// Show progress
+	logf(logInfo, "Using %s provider with model %s...", provider.Name(), provider.Model())
+
+	// Stream to a terminal so long explanations appear as they are written;
+	// JSON has to be complete before it can be checked
+	stream := !out.toFile() && explainFormat != "json" && isTerminal(os.Stdout)
+	timeout := time.Duration(explainTimeout) * time.Second
+
+	return input.each(out, func(query string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		hit, err := explainQuery(ctx, provider, query, cfg.Cache.TTL, stream, out)
+		if err != nil {
+			return aiRequestError(fmt.Errorf("getting explanation: %w", err), timeout)
+		}
+		if hit {
+			logf(logInfo, "Using cached explanation (--refresh to regenerate)")
+		}
+		return nil
+	})
+}
+
+// explainQuery writes an explanation of query to out, reusing a cached one
+// unless --no-cache or --refresh is set. The bool reports a cache hit.
+func explainQuery(ctx context.Context, provider ai.Provider, query string, cacheTTL time.Duration, stream bool, out io.Writer) (bool, error) {
+	prompt := explainPrompt(query, ai.PromptStyleFor(provider))
+
+	// The cache is best-effort; a nil cache always calls the provider
+	var cache *ai.ResponseCache
+	if !aiNoCache {
+		cache, _ = ai.NewDefaultCache()
+	}
+	if cache != nil {
+		cache.TTL = cacheTTL
 	}
+	key := explainCacheKey(provider, query, logVerbose, explainFormat)
 
-	// Optionally parse the query first for context
+	if explainFormat == "json" {
+		return writeJSONExplanation(ctx, cache, provider, key, prompt, out)
+	}
+	return writeExplanation(ctx, cache, provider, key, prompt, stream, out)
+}
+
+// explainPrompt builds the prompt for query from the explain flags,
+// including the parse context with --verbose.
+func explainPrompt(query string, style ai.PromptStyle) string {
 	var parseContext string
-	if explainVerbose {
+	if logVerbose {
 		parseContext = getParseContext(query)
 	}
+	return buildExplainPrompt(query, parseContext, explainFormat, style)
+}
 
-	// Build prompt
-	prompt := buildExplainPrompt(query, parseContext)
+// writeExplanation writes the explanation for prompt to out, followed by
+// a newline. With stream set and a provider that supports it, the text is
+// written as it is generated. The bool reports a cache hit.
+func writeExplanation(ctx context.Context, cache *ai.ResponseCache, provider ai.Provider, key, prompt string, stream bool, out io.Writer) (bool, error) {
+	if stream {
+		hit, err := cache.CompleteStream(ctx, provider, key, prompt, explainRefresh, out)
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintln(out)
+		return hit, nil
+	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(explainTimeout)*time.Second)
-	defer cancel()
+	explanation, hit, err := cache.Complete(ctx, provider, key, prompt, explainRefresh)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintln(out, explanation)
+	return hit, nil
+}
 
-	// Show progress
-	if explainVerbose {
-		fmt.Fprintf(os.Stderr, "Using %s provider with model %s...\n", provider.Name(), provider.Model())
+// explainCacheKey identifies an explanation by the normalized query, the
+// provider and model, and every flag that changes the prompt, including
+// a system prompt if one is set.
+func explainCacheKey(provider ai.Provider, query string, verbose bool, format string) string {
+	parts := []string{
+		"explain",
+		provider.Name(),
+		provider.Model(),
+		fmt.Sprintf("verbose=%t", verbose),
+		"format=" + format,
 	}
+	if system := ai.SystemPromptOf(provider); system != "" {
+		parts = append(parts, "system="+system)
+	}
+	return ai.CacheKey(append(parts, normalizeQueryForCache(query))...)
+}
 
-	// Get explanation
-	explanation, err := provider.Complete(ctx, prompt)
-	if err != nil {
-		return fmt.Errorf("getting explanation: %w", err)
+// normalizeQueryForCache drops trailing whitespace and blank lines so
+// cosmetic edits don't miss the cache.
+func normalizeQueryForCache(query string) string {
+	var lines []string
+	for _, line := range strings.Split(query, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" {
+			lines = append(lines, line)
+		}
 	}
+	return strings.Join(lines, "\n")
+}
 
-	fmt.Println(explanation)
-	return nil
+// aiRequestError reports a request that outlived its --timeout in terms of
+// the flag; other errors are returned unchanged.
+func aiRequestError(err error, timeout time.Duration) error {
+	if errors.Is(err, ai.ErrTimeout) {
+		return fmt.Errorf("AI request timed out after %s (increase with --timeout)", timeout)
+	}
+	return err
 }
 
 func buildAIConfig() ai.Config {
@@ -161,6 +318,11 @@ func buildAIConfig() ai.Config {
 	cfg.Azure.Endpoint = azureEndpoint
 	cfg.Azure.Deployment = azureDeployment
 	cfg.InstructLab.Endpoint = instructEndpoint
+	cfg.OpenAI.APIKey = openaiAPIKey
+	cfg.Anthropic.APIKey = anthropicAPIKey
+	cfg.Transport.Proxy = aiProxy
+	cfg.Transport.CACert = aiCACert
+	cfg.SystemPrompt = aiSystemPrompt
 
 	return cfg
 }
@@ -173,7 +335,20 @@ func getParseContext(query string) string {
 	return "Query syntax is valid."
 }
 
-func buildExplainPrompt(query, parseContext string) string {
+func buildExplainPrompt(query, parseContext, format string, style ai.PromptStyle) string {
+	instructions := explainFormatInstructions(format)
+
+	if style == ai.PromptStyleTerse {
+		prompt := "Explain this KQL query briefly: its data sources, filters, aggregations, and output."
+		if instructions != "" {
+			prompt += "\n" + instructions
+		}
+		if parseContext != "" {
+			prompt += "\n\n" + parseContext
+		}
+		return prompt + "\n\n" + style.Query(query)
+	}
+
 	prompt := `You are a Kusto Query Language (KQL) expert. Explain the following KQL query in clear, concise terms.
 
 Describe:
@@ -184,11 +359,15 @@ Describe:
 
 Keep the explanation accessible to someone familiar with SQL but new to KQL.`
 
+	if instructions != "" {
+		prompt += "\n\n" + instructions
+	}
+
 	if parseContext != "" {
 		prompt += "\n\n" + parseContext
 	}
 
-	prompt += "\n\nQuery:\n```kql\n" + query + "\n```"
+	prompt += "\n\nQuery:\n" + style.Query(query)
 
 	return prompt
 }
@@ -0,0 +1,111 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// explainFormats are the values of explain --format.
+var explainFormats = []string{"text", "markdown", "json"}
+
+// Explanation is a structured explanation, as printed by explain --format
+// json.
+type Explanation struct {
+	Sources      []string `json:"sources"`
+	Filters      []string `json:"filters"`
+	Aggregations []string `json:"aggregations"`
+	Output       string   `json:"output"`
+}
+
+// checkExplainFormat rejects --format values other than explainFormats.
+func checkExplainFormat(format string) error {
+	for _, f := range explainFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format: %s (supported: %s)", format, strings.Join(explainFormats, ", "))
+}
+
+// explainFormatInstructions tells the model how to lay out the
+// explanation; plain text needs no instructions.
+func explainFormatInstructions(format string) string {
+	switch format {
+	case "markdown":
+		return `Format the explanation as Markdown with exactly these sections, in this order:
+## Sources
+## Filters
+## Aggregations
+## Output
+Use bullet points within a section, and write "None." under a section that doesn't apply.`
+	case "json":
+		return `Reply with ONLY a JSON object, with no code fences or other text, of this form:
+{"sources": ["..."], "filters": ["..."], "aggregations": ["..."], "output": "..."}
+Each list holds one short sentence per item; use an empty list for a section that doesn't apply.`
+	}
+	return ""
+}
+
+// parseExplanation reads an Explanation from a model response, tolerating
+// code fences and text around the JSON object.
+func parseExplanation(response string) (*Explanation, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("no JSON object found")
+	}
+
+	var e Explanation
+	if err := json.Unmarshal([]byte(response[start:end+1]), &e); err != nil {
+		return nil, err
+	}
+	if len(e.Sources) == 0 && len(e.Filters) == 0 && len(e.Aggregations) == 0 && e.Output == "" {
+		return nil, errors.New("no explanation fields found")
+	}
+	return &e, nil
+}
+
+// writeJSONExplanation writes the explanation for prompt to out as an
+// indented Explanation. A response that isn't valid JSON is retried once
+// with the error; if that fails too, the raw text is written with a
+// warning on stderr. The bool reports a cache hit.
+func writeJSONExplanation(ctx context.Context, cache *ai.ResponseCache, provider ai.Provider, key, prompt string, out io.Writer) (bool, error) {
+	response, hit, err := cache.Complete(ctx, provider, key, prompt, explainRefresh)
+	if err != nil {
+		return false, err
+	}
+
+	explanation, parseErr := parseExplanation(response)
+	if parseErr != nil {
+		retryPrompt := fmt.Sprintf("%s\n\nYour previous reply was not valid JSON (%v). Reply with only the JSON object.", prompt, parseErr)
+		response, err = provider.Complete(ctx, retryPrompt)
+		if err != nil {
+			return false, err
+		}
+		hit = false
+
+		// Replace the unusable cached response with the good one
+		if explanation, parseErr = parseExplanation(response); parseErr == nil && cache != nil {
+			_ = cache.Put(key, response)
+		}
+	}
+
+	if parseErr != nil {
+		logf(logWarn, "Warning: explanation is not valid JSON (%v); printing it as text", parseErr)
+		fmt.Fprintln(out, strings.TrimSpace(response))
+		return hit, nil
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return hit, enc.Encode(explanation)
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+const explanationJSON = `{"sources": ["StormEvents"], "filters": ["Only events with property damage over 1M"], "aggregations": ["Counts events per state"], "output": "One row per state with its event count"}`
+
+func TestBuildExplainPrompt_Format(t *testing.T) {
+	query := "StormEvents | summarize count() by State"
+
+	text := buildExplainPrompt(query, "", "text", ai.PromptStyleDefault)
+	if strings.Contains(text, "## Sources") || strings.Contains(text, "JSON") {
+		t.Errorf("expected no format instructions for text, got:\n%s", text)
+	}
+
+	markdown := buildExplainPrompt(query, "", "markdown", ai.PromptStyleDefault)
+	for _, section := range []string{"## Sources", "## Filters", "## Aggregations", "## Output"} {
+		if !strings.Contains(markdown, section) {
+			t.Errorf("expected markdown prompt to ask for %q, got:\n%s", section, markdown)
+		}
+	}
+
+	for _, style := range []ai.PromptStyle{ai.PromptStyleDefault, ai.PromptStyleTerse} {
+		prompt := buildExplainPrompt(query, "", "json", style)
+		if !strings.Contains(prompt, `"aggregations"`) || !strings.Contains(prompt, query) {
+			t.Errorf("%s: expected JSON instructions and the query, got:\n%s", style, prompt)
+		}
+	}
+}
+
+func TestCheckExplainFormat(t *testing.T) {
+	for _, f := range explainFormats {
+		if err := checkExplainFormat(f); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+	if err := checkExplainFormat("yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestParseExplanation(t *testing.T) {
+	e, err := parseExplanation("```json\n" + explanationJSON + "\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Sources) != 1 || e.Sources[0] != "StormEvents" || e.Output == "" {
+		t.Errorf("got %+v", e)
+	}
+
+	for _, bad := range []string{"It counts storm events.", `{"sources": "StormEvents"}`, `{"summary": "x"}`} {
+		if _, err := parseExplanation(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestWriteJSONExplanation_RetriesInvalidJSON(t *testing.T) {
+	cache := &ai.ResponseCache{Dir: t.TempDir()}
+	p := &sequenceProvider{responses: []string{"This query counts storm events by state.", explanationJSON}}
+
+	var out bytes.Buffer
+	if _, err := writeJSONExplanation(context.Background(), cache, p, "k", "prompt", &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.prompts) != 2 || !strings.Contains(p.prompts[1], "not valid JSON") {
+		t.Errorf("expected one retry pointing out the invalid JSON, got prompts %q", p.prompts)
+	}
+
+	var e Explanation
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", out.String(), err)
+	}
+	if e.Aggregations[0] != "Counts events per state" {
+		t.Errorf("got %+v", e)
+	}
+
+	// The valid retry replaced the unusable cached response
+	if cached, _ := cache.Get("k"); cached != explanationJSON {
+		t.Errorf("cached = %q", cached)
+	}
+}
+
+func TestWriteJSONExplanation_FallsBackToText(t *testing.T) {
+	p := &sequenceProvider{responses: []string{"Counts storm events by state."}}
+
+	var out bytes.Buffer
+	if _, err := writeJSONExplanation(context.Background(), nil, p, "k", "prompt", &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.prompts) != 2 {
+		t.Errorf("expected exactly one retry, got %d prompts", len(p.prompts))
+	}
+	if out.String() != "Counts storm events by state.\n" {
+		t.Errorf("expected the raw text, got %q", out.String())
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// fakeProvider is a Provider that returns a fixed response and counts calls.
+type fakeProvider struct {
+	name, model string
+	response    string
+	calls       int
+}
+
+func (p *fakeProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	p.calls++
+	return p.response, nil
+}
+
+func (p *fakeProvider) CompleteChat(ctx context.Context, messages []ai.Message) (string, error) {
+	p.calls++
+	return p.response, nil
+}
+
+func (p *fakeProvider) Name() string  { return p.name }
+func (p *fakeProvider) Model() string { return p.model }
+
+func TestExplainCacheKey(t *testing.T) {
+	p := &fakeProvider{name: "ollama", model: "llama3.2"}
+
+	base := explainCacheKey(p, "T | take 10", false, "text")
+	if explainCacheKey(p, "T | take 10  \n\n", false, "text") != base {
+		t.Error("expected trailing whitespace and blank lines to be normalized away")
+	}
+	if explainCacheKey(p, "T | take 20", false, "text") == base {
+		t.Error("expected different queries to produce different keys")
+	}
+	if explainCacheKey(p, "T | take 10", true, "text") == base {
+		t.Error("expected prompt-changing flags to produce different keys")
+	}
+	if explainCacheKey(&fakeProvider{name: "ollama", model: "mistral"}, "T | take 10", false, "text") == base {
+		t.Error("expected different models to produce different keys")
+	}
+	if explainCacheKey(p, "T | take 10", false, "json") == base {
+		t.Error("expected different formats to produce different keys")
+	}
+	if explainCacheKey(ai.NewSystemPromptProvider(p, "Be brief."), "T | take 10", false, "text") == base {
+		t.Error("expected a system prompt to produce a different key")
+	}
+}
+
+func TestExplainCache_HitAndRefresh(t *testing.T) {
+	cache := &ai.ResponseCache{Dir: t.TempDir()}
+	p := &fakeProvider{name: "ollama", model: "llama3.2", response: "It takes 10 rows."}
+	ctx := context.Background()
+	key := explainCacheKey(p, "T | take 10", false, "text")
+	prompt := buildExplainPrompt("T | take 10", "", "text", ai.PromptStyleFor(p))
+
+	if _, hit, _ := cache.Complete(ctx, p, key, prompt, false); hit {
+		t.Error("expected first explain to miss the cache")
+	}
+	if _, hit, _ := cache.Complete(ctx, p, key, prompt, false); !hit {
+		t.Error("expected second identical explain to hit the cache")
+	}
+	if p.calls != 1 {
+		t.Errorf("expected 1 provider call, got %d", p.calls)
+	}
+
+	if _, hit, _ := cache.Complete(ctx, p, key, prompt, true); hit {
+		t.Error("expected --refresh to bypass the cache")
+	}
+	if p.calls != 2 {
+		t.Errorf("expected --refresh to call the provider, got %d calls", p.calls)
+	}
+}
+
+func TestBuildExplainPrompt_Style(t *testing.T) {
+	query := "StormEvents | take 10"
+
+	tagged := buildExplainPrompt(query, "", "text", ai.PromptStyleFor(&fakeProvider{model: "claude-opus-4-5"}))
+	if !strings.Contains(tagged, "<query>\n"+query+"\n</query>") {
+		t.Errorf("expected Claude prompt to wrap the query in tags, got:\n%s", tagged)
+	}
+	if strings.Contains(tagged, "```") {
+		t.Error("expected Claude prompt not to use code fences")
+	}
+
+	plain := buildExplainPrompt(query, "", "text", ai.PromptStyleFor(&fakeProvider{model: "gpt-4o"}))
+	if strings.Contains(plain, "<query>") {
+		t.Error("expected default prompt not to use tags")
+	}
+	if !strings.Contains(plain, "```kql\n"+query+"\n```") {
+		t.Errorf("expected default prompt to fence the query, got:\n%s", plain)
+	}
+
+	terse := buildExplainPrompt(query, "", "text", ai.PromptStyleTerse)
+	if len(terse) >= len(plain) || !strings.Contains(terse, query) {
+		t.Errorf("expected a shorter prompt containing the query, got:\n%s", terse)
+	}
+}
+
+// streamingFakeProvider streams its response in fixed-size pieces.
+type streamingFakeProvider struct {
+	fakeProvider
+	chunks []string
+}
+
+func (p *streamingFakeProvider) CompleteStream(ctx context.Context, prompt string, out io.Writer) error {
+	p.calls++
+	for _, c := range p.chunks {
+		if _, err := io.WriteString(out, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWriteExplanation_Streams(t *testing.T) {
+	cache := &ai.ResponseCache{Dir: t.TempDir()}
+	p := &streamingFakeProvider{
+		fakeProvider: fakeProvider{name: "ollama", model: "llama3.2", response: "unused"},
+		chunks:       []string{"It takes ", "10 rows."},
+	}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	hit, err := writeExplanation(ctx, cache, p, "k", "prompt", true, &out)
+	if err != nil || hit {
+		t.Fatalf("hit=%v err=%v", hit, err)
+	}
+	if out.String() != "It takes 10 rows.\n" {
+		t.Errorf("out = %q", out.String())
+	}
+
+	// The streamed response is cached whole
+	out.Reset()
+	if hit, _ := writeExplanation(ctx, cache, p, "k", "prompt", true, &out); !hit {
+		t.Error("expected the streamed explanation to be cached")
+	}
+	if out.String() != "It takes 10 rows.\n" || p.calls != 1 {
+		t.Errorf("out = %q, calls = %d", out.String(), p.calls)
+	}
+}
+
+func TestWriteExplanation_NoStreamFallback(t *testing.T) {
+	ctx := context.Background()
+
+	// A provider without streaming still works when streaming is wanted
+	var out bytes.Buffer
+	plain := &fakeProvider{name: "vertex", model: "gemini", response: "Ten rows."}
+	if _, err := writeExplanation(ctx, nil, plain, "k", "prompt", true, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Ten rows.\n" {
+		t.Errorf("out = %q", out.String())
+	}
+
+	// Without a terminal, streaming providers are not streamed
+	out.Reset()
+	streaming := &streamingFakeProvider{fakeProvider: fakeProvider{response: "Whole."}, chunks: []string{"Pie", "ces."}}
+	if _, err := writeExplanation(ctx, nil, streaming, "k", "prompt", false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Whole.\n" {
+		t.Errorf("out = %q", out.String())
+	}
+}
+
+func TestAIRequestError(t *testing.T) {
+	timedOut := fmt.Errorf("getting explanation: %w", fmt.Errorf("%w: context deadline exceeded", ai.ErrTimeout))
+	err := aiRequestError(timedOut, 30*time.Second)
+	if err == nil || err.Error() != "AI request timed out after 30s (increase with --timeout)" {
+		t.Errorf("got %v", err)
+	}
+
+	other := errors.New("ollama returned status 500")
+	if err := aiRequestError(other, 30*time.Second); err != other {
+		t.Errorf("expected other errors unchanged, got %v", err)
+	}
+	if err := aiRequestError(nil, 30*time.Second); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestApplyCacheFlags(t *testing.T) {
+	defer func(c, n bool, ttl time.Duration) { aiCache, aiNoCache, aiCacheTTL = c, n, ttl }(aiCache, aiNoCache, aiCacheTTL)
+
+	aiCache, aiNoCache, aiCacheTTL = true, false, time.Hour
+	cfg := applyCacheFlags(ai.DefaultConfig())
+	if !cfg.Cache.Enabled || cfg.Cache.TTL != time.Hour {
+		t.Errorf("--cache --cache-ttl 1h: got %+v", cfg.Cache)
+	}
+
+	// --no-cache wins over --cache and the config file
+	aiNoCache = true
+	base := ai.DefaultConfig()
+	base.Cache.Enabled = true
+	if cfg := applyCacheFlags(base); cfg.Cache.Enabled {
+		t.Error("expected --no-cache to disable the cache")
+	}
+
+	// Without flags the merged settings stand
+	aiCache, aiNoCache, aiCacheTTL = false, false, 0
+	if cfg := applyCacheFlags(base); !cfg.Cache.Enabled || cfg.Cache.TTL != ai.DefaultCacheTTL {
+		t.Errorf("expected merged settings unchanged, got %+v", cfg.Cache)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import "strings"
+
+// extractKQL attempts to extract just the KQL code from an LLM response.
+// Handles responses that include markdown code blocks or explanatory text.
+// Every command that asks a model for a query (generate, fix, convert, and
+// suggest --apply) extracts it here.
+func extractKQL(response string) string {
+	response = strings.TrimSpace(response)
+
+	// Check for markdown code blocks (triple backticks)
+	if strings.Contains(response, "```") {
+		// Try to find kql/kusto code block first
+		for _, lang := range []string{"```kql", "```kusto", "```"} {
+			if idx := strings.Index(response, lang); idx != -1 {
+				start := idx + len(lang)
+				// Find the closing ```
+				if end := strings.Index(response[start:], "```"); end != -1 {
+					extracted := strings.TrimSpace(response[start : start+end])
+					if extracted != "" {
+						return stripInlineBackticks(extracted)
+					}
+				}
+			}
+		}
+	}
+
+	// Strip inline backticks (e.g., `query here`)
+	response = stripInlineBackticks(response)
+
+	// If no code blocks, try to find lines that look like KQL
+	// (start with a table name or common operators)
+	lines := strings.Split(response, "\n")
+	var kqlLines []string
+	var nesting kqlNesting
+	inQuery := false
+	afterBlank := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Skip empty lines at the start
+		if !inQuery && trimmed == "" {
+			continue
+		}
+
+		// Detect start of KQL (table name or let statement)
+		if !inQuery {
+			if looksLikeKQLStart(trimmed) {
+				inQuery = true
+			}
+		}
+
+		if inQuery {
+			// Stop at explanatory text, but only between statements:
+			// inside brackets, strings, or comments it is part of the query
+			if nesting.balanced() && !strings.HasPrefix(trimmed, "//") &&
+				(looksLikeExplanation(trimmed) || afterBlank && looksLikeProse(trimmed)) {
+				break
+			}
+			kqlLines = append(kqlLines, line)
+			nesting.scan(line)
+			afterBlank = trimmed == ""
+		}
+	}
+
+	if len(kqlLines) > 0 {
+		return strings.TrimSpace(strings.Join(kqlLines, "\n"))
+	}
+
+	// Fallback: return as-is
+	return response
+}
+
+func looksLikeKQLStart(line string) bool {
+	lower := strings.ToLower(line)
+
+	// Common KQL starting patterns
+	starters := []string{
+		"let ", "//", "/*",
+	}
+
+	for _, s := range starters {
+		if strings.HasPrefix(lower, s) {
+			return true
+		}
+	}
+
+	// Starts with what looks like a table name (alphanumeric, no spaces before pipe)
+	if len(line) > 0 {
+		// Table names typically start with a letter
+		if (line[0] >= 'A' && line[0] <= 'Z') || (line[0] >= 'a' && line[0] <= 'z') {
+			return true
+		}
+	}
+
+	return false
+}
+
+func looksLikeExplanation(line string) bool {
+	lower := strings.ToLower(line)
+
+	// Common explanation starters
+	explanations := []string{
+		"this query", "the query", "this will", "explanation:",
+		"note:", "here's", "here is", "the above",
+	}
+
+	for _, e := range explanations {
+		if strings.HasPrefix(lower, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// looksLikeProse reports whether a line reads as a sentence rather than
+// KQL: several words ending in a period or colon, with no pipe.
+func looksLikeProse(line string) bool {
+	if !strings.HasSuffix(line, ".") && !strings.HasSuffix(line, ":") {
+		return false
+	}
+	return !strings.Contains(line, "|") && len(strings.Fields(line)) >= 4
+}
+
+// kqlNesting tracks, line by line, whether the text so far leaves a
+// bracket, string literal, or block comment open.
+type kqlNesting struct {
+	depth   int
+	quote   byte
+	comment bool
+}
+
+// balanced reports whether nothing is left open.
+func (n *kqlNesting) balanced() bool {
+	return n.depth <= 0 && n.quote == 0 && !n.comment
+}
+
+// scan advances the state over one line. Line comments end with the line;
+// string literals don't span lines in KQL, but are carried over anyway so
+// a stray quote keeps the rest of the query together.
+func (n *kqlNesting) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case n.comment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				n.comment = false
+				i++
+			}
+		case n.quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == n.quote {
+				n.quote = 0
+			}
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			n.comment = true
+			i++
+		case c == '"' || c == '\'':
+			n.quote = c
+		case c == '(' || c == '[' || c == '{':
+			n.depth++
+		case c == ')' || c == ']' || c == '}':
+			n.depth--
+		}
+	}
+}
+
+// stripInlineBackticks removes inline backticks from a string.
+// Handles cases like `query here` or queries starting/ending with backticks.
+func stripInlineBackticks(s string) string {
+	s = strings.TrimSpace(s)
+
+	// Remove surrounding single backticks
+	if len(s) >= 2 && s[0] == '`' && s[len(s)-1] == '`' {
+		s = s[1 : len(s)-1]
+	}
+
+	// Also handle case where only leading or trailing backtick
+	s = strings.TrimPrefix(s, "`")
+	s = strings.TrimSuffix(s, "`")
+
+	return strings.TrimSpace(s)
+}
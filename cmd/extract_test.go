@@ -0,0 +1,109 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractKQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "fenced block",
+			response: "Here you go:\n```kql\nStormEvents | take 10\n```\nThis query returns ten rows.",
+			want:     "StormEvents | take 10",
+		},
+		{
+			name:     "plain query",
+			response: "StormEvents\n| count",
+			want:     "StormEvents\n| count",
+		},
+		{
+			name: "leading lets with blank lines",
+			response: "let start = ago(7d);\n\nlet states = dynamic([\"TEXAS\", \"OHIO\"]);\n\n" +
+				"StormEvents\n| where StartTime > start and State in (states)\n| count",
+			want: "let start = ago(7d);\n\nlet states = dynamic([\"TEXAS\", \"OHIO\"]);\n\n" +
+				"StormEvents\n| where StartTime > start and State in (states)\n| count",
+		},
+		{
+			name: "let with a multi-line function body",
+			response: "let f = (n: int) {\n\n    StormEvents\n\n    | take n\n};\nf(5)\n\n" +
+				"This query defines a helper function.",
+			want: "let f = (n: int) {\n\n    StormEvents\n\n    | take n\n};\nf(5)",
+		},
+		{
+			name: "inline comments",
+			response: "// Storms per state\nStormEvents\n// Note: only recent storms\n" +
+				"| where StartTime > ago(30d) // the last month\n| summarize count() by State",
+			want: "// Storms per state\nStormEvents\n// Note: only recent storms\n" +
+				"| where StartTime > ago(30d) // the last month\n| summarize count() by State",
+		},
+		{
+			name:     "block comment spanning prose",
+			response: "/*\nThis query counts storms.\n*/\nStormEvents | count",
+			want:     "/*\nThis query counts storms.\n*/\nStormEvents | count",
+		},
+		{
+			name:     "explanation keyword inside brackets",
+			response: "StormEvents\n| where State in (\n    \"TEXAS\",\n    \"Note: ignored\"\n)\n| count",
+			want:     "StormEvents\n| where State in (\n    \"TEXAS\",\n    \"Note: ignored\"\n)\n| count",
+		},
+		{
+			name:     "trailing explanation",
+			response: "StormEvents\n| count\nThis query counts the rows.",
+			want:     "StormEvents\n| count",
+		},
+		{
+			name:     "trailing prose after a blank line",
+			response: "StormEvents\n| summarize count() by State\n\nIt groups the storms by state and counts each group.",
+			want:     "StormEvents\n| summarize count() by State",
+		},
+		{
+			name:     "inline backticks",
+			response: "`StormEvents | take 5`",
+			want:     "StormEvents | take 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractKQL(tt.response); got != tt.want {
+				t.Errorf("extractKQL() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractKQL_GenerateAndFixAgree runs the same model output through
+// generate and fix, which must extract the same query.
+func TestExtractKQL_GenerateAndFixAgree(t *testing.T) {
+	responses := []string{
+		"```kql\nStormEvents | take 10\n```",
+		"Here is the corrected query:\n\n```\nStormEvents\n| count\n```\nThe pipe was missing.",
+		"`StormEvents | summarize count() by State`",
+		"let recent = ago(1d);\nStormEvents\n| where StartTime > recent\n\nThis query keeps the last day.",
+	}
+
+	for _, response := range responses {
+		generated := generateForGolden(t, response)
+
+		p := &fakeProvider{name: "fake", model: "fake", response: response}
+		fixed, err := runFixLoop(context.Background(), p, "StormEvents |", nil, 1, 0, nil)
+		if err != nil {
+			t.Fatalf("fix failed: %v", err)
+		}
+
+		if fixed.Query != generated {
+			t.Errorf("for response %q:\ngenerate extracted %q\nfix extracted      %q", response, generated, fixed.Query)
+		}
+		if fixed.Query != extractKQL(response) {
+			t.Errorf("for response %q: fix extracted %q, want %q", response, fixed.Query, extractKQL(response))
+		}
+	}
+}
@@ -5,25 +5,28 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
 	"github.com/cloudygreybeard/kqlparser"
 	"github.com/spf13/cobra"
 )
 
 var (
 	fixInputFile string
-	fixVerbose   bool
 	fixTimeout   int
 	fixDryRun    bool
 
 	// Validation flags for fix
-	fixRetries int
-	fixStrict  bool
+	fixRetries  int
+	fixStrict   bool
+	fixMaxEdits int
 )
 
 var fixCmd = &cobra.Command{
@@ -36,6 +39,10 @@ The query can be provided as an argument, from a file (-f), or via stdin.
 
 Use --dry-run to see the suggested fix without outputting it.
 Use --verbose to see the original errors and AI reasoning.
+Use --raw to print the model's response as received instead of the query
+extracted from it; --strict and --max-edits still judge the extracted
+query. --raw and --dry-run are independent: together, the analysis shows
+the raw response as the suggested fix.
 
 Uses the same AI providers as 'kql explain'.`,
 	Example: `  # Fix a query with syntax errors
@@ -48,7 +55,13 @@ Uses the same AI providers as 'kql explain'.`,
   kql fix --dry-run "T | summarize count( by State"
 
   # Verbose mode (show errors and reasoning)
-  kql fix -v "T | where x >"`,
+  kql fix -v "T | where x >"
+
+  # Reject fixes that change more than 5 tokens of the original
+  kql fix --max-edits 5 --strict "T | summarize count( by State"
+
+  # Fix several files, continuing past failures
+  kql fix --batch broken1.kql broken2.kql`,
 	RunE: runFix,
 }
 
@@ -56,7 +69,8 @@ func init() {
 	rootCmd.AddCommand(fixCmd)
 
 	// Provider selection (reuse from explain)
-	fixCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure)")
+	fixCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = fixCmd.RegisterFlagCompletionFunc("provider", completeProvider)
 	fixCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
 	fixCmd.Flags().Float32Var(&aiTemperature, "temperature", 0.1, "Temperature (0.0-1.0)")
 
@@ -74,120 +88,126 @@ func init() {
 	// InstructLab
 	fixCmd.Flags().StringVar(&instructEndpoint, "instructlab-endpoint", "", "InstructLab endpoint URL")
 
+	// OpenAI
+	fixCmd.Flags().StringVar(&openaiAPIKey, "openai-api-key", "", "OpenAI API key (default from config or OPENAI_API_KEY)")
+
+	// Anthropic
+	fixCmd.Flags().StringVar(&anthropicAPIKey, "anthropic-api-key", "", "Anthropic API key (default from config or ANTHROPIC_API_KEY)")
+
+	// Network
+	fixCmd.Flags().StringVar(&aiProxy, "proxy", "", "Proxy URL for AI requests (default from HTTPS_PROXY/HTTP_PROXY)")
+	fixCmd.Flags().StringVar(&aiCACert, "ca-cert", "", "PEM file of extra CA certificates to trust")
+
+	// System prompt
+	fixCmd.Flags().StringVar(&aiSystemPrompt, "system-prompt", "", "System message sent ahead of every prompt (default from config)")
+	fixCmd.Flags().StringVar(&aiSystemPromptFile, "system-prompt-file", "", "Read the system message from a file")
+
+	// Response cache
+	fixCmd.Flags().BoolVar(&aiCache, "cache", false, "Reuse identical responses from the on-disk cache")
+	fixCmd.Flags().BoolVar(&aiNoCache, "no-cache", false, "Disable the response cache, overriding --cache and the config file")
+	fixCmd.Flags().DurationVar(&aiCacheTTL, "cache-ttl", 0, "How long cached responses are reused (default 24h)")
+
+	// Diagnostics
+	fixCmd.Flags().BoolVar(&aiProviderInfo, "provider-info", false, "Print the resolved provider settings and their sources, then exit")
+
 	// Command options
 	fixCmd.Flags().StringVarP(&fixInputFile, "file", "f", "", "Read query from file")
-	fixCmd.Flags().BoolVarP(&fixVerbose, "verbose", "v", false, "Show errors and reasoning")
+	addInputSourceFlags(fixCmd)
+	fixCmd.Flags().StringVarP(&aiOutput, "output", "o", "", "Write the result to a file instead of stdout")
+	fixCmd.Flags().BoolVar(&aiBatch, "batch", false, "Treat each argument as a query file (globs and directories allowed), printing a header per file")
 	fixCmd.Flags().IntVar(&fixTimeout, "timeout", 60, "Timeout in seconds")
 	fixCmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "Show analysis without outputting fixed query")
+	fixCmd.Flags().BoolVar(&aiRaw, "raw", false, "Print the model's response as received, still checking the query extracted from it")
 
 	// Retry and validation options
 	fixCmd.Flags().IntVar(&fixRetries, "retries", 2, "Number of retries if fix still has errors")
 	fixCmd.Flags().BoolVar(&fixStrict, "strict", false, "Fail with exit code 1 if fix still has errors")
+	fixCmd.Flags().IntVar(&fixMaxEdits, "max-edits", 0, "Retry fixes that change more than this many tokens of the original (0 = no limit)")
 }
 
-func runFix(cmd *cobra.Command, args []string) error {
+func runFix(cmd *cobra.Command, args []string) (err error) {
+	if aiProviderInfo {
+		return runProviderInfo(cmd)
+	}
+	if fixDryRun && aiOutput != "" {
+		return fmt.Errorf("--output cannot be combined with --dry-run")
+	}
+
 	// Get query input
-	query, err := getInputFrom(args, fixInputFile, os.Stdin, isTerminal)
+	input, err := readQueryInput(args, fixInputFile)
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutput(aiOutput, os.Stderr)
 	if err != nil {
 		return err
 	}
+	defer func() { err = out.finish(err) }()
+
+	// The provider is only needed once a query has errors
+	var provider ai.Provider
+	getProvider := func() (ai.Provider, error) {
+		if provider != nil {
+			return provider, nil
+		}
+		p, _, err := resolveProvider()
+		if err != nil {
+			return nil, err
+		}
+		logf(logInfo, "Using %s provider with model %s...", p.Name(), p.Model())
+		provider = p
+		return p, nil
+	}
 
+	return input.each(out, func(query string) error {
+		return fixQuery(query, out, getProvider)
+	})
+}
+
+// errFixRejected is returned by fixQuery when --strict rejects the fix;
+// the reasons have already been written to stderr.
+var errFixRejected = errors.New("no acceptable fix")
+
+// fixQuery writes query to out, fixed if it has syntax errors, or with
+// --dry-run writes the analysis to stderr instead.
+func fixQuery(query string, out io.Writer, getProvider func() (ai.Provider, error)) error {
 	// Parse the query to find errors
 	result := kqlparser.Parse("input", query)
 
 	if len(result.Errors) == 0 {
-		if fixVerbose {
-			fmt.Fprintln(os.Stderr, "No syntax errors found in query.")
-		}
+		logf(logInfo, "No syntax errors found in query.")
 		// Output the original query if no errors
-		fmt.Println(query)
+		fmt.Fprintln(out, query)
 		return nil
 	}
 
-	if fixVerbose {
-		fmt.Fprintln(os.Stderr, "Found errors:")
+	if logEnabled(logInfo) {
+		logf(logInfo, "Found errors:")
 		for _, e := range result.Errors {
-			fmt.Fprintf(os.Stderr, "  - %v\n", e)
+			logf(logInfo, "  - %v", e)
 		}
-		fmt.Fprintln(os.Stderr)
-	}
-
-	// Build AI config
-	cfg := buildAIConfig()
-
-	// Load file config and merge
-	fileCfg, err := ai.LoadConfigFile()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: error loading config file: %v\n", err)
-	}
-	cfg = ai.MergeFileConfig(cfg, fileCfg)
-
-	// Apply defaults if still empty
-	if cfg.Provider == "" {
-		cfg.Provider = "ollama"
+		logf(logInfo, "")
 	}
 
-	// Create provider
-	provider, err := ai.NewProvider(cfg)
+	provider, err := getProvider()
 	if err != nil {
-		return fmt.Errorf("creating AI provider: %w", err)
+		return err
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(fixTimeout)*time.Second)
+	timeout := time.Duration(fixTimeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// Show progress
-	if fixVerbose {
-		fmt.Fprintf(os.Stderr, "Using %s provider with model %s...\n", provider.Name(), provider.Model())
-	}
-
-	// Retry loop for fixing
 	maxAttempts := fixRetries + 1
-	var fixedQuery string
-	var fixErrors []error
-	currentQuery := query
-	currentErrors := result.Errors
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if fixVerbose {
-			fmt.Fprintf(os.Stderr, "Attempt %d/%d: requesting fix...\n", attempt, maxAttempts)
-		}
-
-		// Build prompt with current errors
-		errorContext := buildErrorContext(currentQuery, currentErrors)
-		prompt := buildFixPrompt(currentQuery, errorContext)
-
-		// Get fix suggestion
-		response, err := provider.Complete(ctx, prompt)
-		if err != nil {
-			return fmt.Errorf("getting fix suggestion (attempt %d): %w", attempt, err)
-		}
-
-		// Extract the fixed query
-		fixedQuery = extractFixedQuery(response)
-
-		// Validate the fix
-		fixResult := kqlparser.Parse("fixed", fixedQuery)
-		if len(fixResult.Errors) == 0 {
-			if fixVerbose {
-				fmt.Fprintln(os.Stderr, "  ✓ Fix is syntactically valid")
-			}
-			fixErrors = nil
-			break
-		}
-
-		fixErrors = fixResult.Errors
-		if fixVerbose {
-			fmt.Fprintf(os.Stderr, "  ✗ Fix still has %d error(s)\n", len(fixErrors))
-			for _, e := range fixErrors {
-				fmt.Fprintf(os.Stderr, "    - %v\n", e)
-			}
-		}
-
-		// For next attempt, use the AI's fix as the starting point
-		currentQuery = fixedQuery
-		currentErrors = fixErrors
+	outcome, err := runFixLoop(ctx, provider, query, result.Errors, maxAttempts, fixMaxEdits, logWriter(logInfo))
+	if err != nil {
+		return aiRequestError(err, timeout)
+	}
+	fixedQuery, fixErrors := outcome.Query, outcome.Errors
+	if aiRaw {
+		fixedQuery = outcome.Response
 	}
 
 	if fixDryRun {
@@ -198,6 +218,9 @@ func runFix(cmd *cobra.Command, args []string) error {
 		fmt.Fprintln(os.Stderr, fixedQuery)
 		fmt.Fprintln(os.Stderr)
 
+		if outcome.TooManyEdits {
+			fmt.Fprintf(os.Stderr, "⚠ Suggested fix changes %d tokens (--max-edits %d)\n", outcome.Edits, fixMaxEdits)
+		}
 		if len(fixErrors) == 0 {
 			fmt.Fprintln(os.Stderr, "✓ Suggested fix is syntactically valid")
 		} else {
@@ -216,16 +239,113 @@ func runFix(cmd *cobra.Command, args []string) error {
 			for _, e := range fixErrors {
 				fmt.Fprintf(os.Stderr, "  - %v\n", e)
 			}
-			os.Exit(1)
+			return errFixRejected
+		}
+		logf(logWarn, "⚠ Warning: fix still has syntax errors (after %d attempt(s))", maxAttempts)
+	}
+	if outcome.TooManyEdits {
+		if fixStrict {
+			fmt.Fprintf(os.Stderr, "Error: every fix changed more than %d tokens of the original (last: %d) after %d attempt(s)\n", fixMaxEdits, outcome.Edits, maxAttempts)
+			return errFixRejected
 		}
-		fmt.Fprintf(os.Stderr, "⚠ Warning: fix still has syntax errors (after %d attempt(s))\n", maxAttempts)
+		logf(logWarn, "⚠ Warning: fix changes %d tokens of the original, more than --max-edits %d", outcome.Edits, fixMaxEdits)
 	}
 
 	// Output the fixed query
-	fmt.Println(fixedQuery)
+	fmt.Fprintln(out, fixedQuery)
 	return nil
 }
 
+// fixOutcome is the result of the fix retry loop.
+type fixOutcome struct {
+	Query    string
+	Errors   []error
+	Attempts int
+
+	// Response is the model output Query was extracted from
+	Response string
+
+	// Edits is the token edit distance between the original and Query
+	Edits int
+
+	// TooManyEdits is set when Edits exceeds the --max-edits limit
+	TooManyEdits bool
+}
+
+// runFixLoop asks the provider for a fix until one parses and stays within
+// maxEdits tokens of the original query (0 = no limit). A fix that still
+// has errors is the starting point for the next attempt; a fix that
+// changes too much is discarded and the original is retried with a request
+// for a smaller change.
+func runFixLoop(ctx context.Context, provider ai.Provider, query string, parseErrors []error, maxAttempts, maxEdits int, verbose io.Writer) (fixOutcome, error) {
+	var outcome fixOutcome
+	currentQuery := query
+	currentErrors := parseErrors
+	var editNote string
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		outcome.Attempts = attempt
+		if verbose != nil {
+			fmt.Fprintf(verbose, "Attempt %d/%d: requesting fix...\n", attempt, maxAttempts)
+		}
+
+		// Build prompt with current errors
+		errorContext := buildErrorContext(currentQuery, currentErrors) + editNote
+		prompt := buildFixPrompt(currentQuery, errorContext)
+
+		// Get fix suggestion
+		response, err := provider.Complete(ctx, prompt)
+		if err != nil {
+			return outcome, fmt.Errorf("getting fix suggestion (attempt %d): %w", attempt, err)
+		}
+
+		// Extract the fixed query
+		fixedQuery := extractKQL(response)
+		outcome.Query = fixedQuery
+		outcome.Response = response
+		outcome.Edits = kqlfmt.TokenEditDistance(query, fixedQuery)
+		outcome.TooManyEdits = maxEdits > 0 && outcome.Edits > maxEdits
+
+		if outcome.TooManyEdits {
+			if verbose != nil {
+				fmt.Fprintf(verbose, "  ✗ Fix changes %d tokens (max %d)\n", outcome.Edits, maxEdits)
+			}
+			outcome.Errors = kqlparser.Parse("fixed", fixedQuery).Errors
+
+			// Start over from the original, asking for less
+			currentQuery = query
+			currentErrors = parseErrors
+			editNote = fmt.Sprintf("\nA previous fix changed %d tokens of the query; at most %d may change. Fix only the errors listed above and leave everything else as written.\n", outcome.Edits, maxEdits)
+			continue
+		}
+		editNote = ""
+
+		// Validate the fix
+		fixResult := kqlparser.Parse("fixed", fixedQuery)
+		if len(fixResult.Errors) == 0 {
+			if verbose != nil {
+				fmt.Fprintln(verbose, "  ✓ Fix is syntactically valid")
+			}
+			outcome.Errors = nil
+			return outcome, nil
+		}
+
+		outcome.Errors = fixResult.Errors
+		if verbose != nil {
+			fmt.Fprintf(verbose, "  ✗ Fix still has %d error(s)\n", len(outcome.Errors))
+			for _, e := range outcome.Errors {
+				fmt.Fprintf(verbose, "    - %v\n", e)
+			}
+		}
+
+		// For next attempt, use the AI's fix as the starting point
+		currentQuery = fixedQuery
+		currentErrors = outcome.Errors
+	}
+
+	return outcome, nil
+}
+
 func buildErrorContext(query string, errors []error) string {
 	var sb strings.Builder
 
@@ -253,59 +373,3 @@ Original query with errors:
 
 Output the corrected query:`, errorContext, "```kql\n"+query+"\n```")
 }
-
-// extractFixedQuery extracts the fixed query from the LLM response.
-func extractFixedQuery(response string) string {
-	response = strings.TrimSpace(response)
-
-	// Check for markdown code blocks
-	if strings.Contains(response, "```") {
-		// Try to find kql/kusto code block first
-		for _, lang := range []string{"```kql", "```kusto", "```"} {
-			if idx := strings.Index(response, lang); idx != -1 {
-				start := idx + len(lang)
-				// Find the closing ```
-				if end := strings.Index(response[start:], "```"); end != -1 {
-					extracted := strings.TrimSpace(response[start : start+end])
-					if extracted != "" {
-						return extracted
-					}
-				}
-			}
-		}
-	}
-
-	// Try to extract lines that look like KQL
-	lines := strings.Split(response, "\n")
-	var kqlLines []string
-	inQuery := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip empty lines at the start
-		if !inQuery && trimmed == "" {
-			continue
-		}
-
-		// Detect start of KQL
-		if !inQuery && looksLikeKQLStart(trimmed) {
-			inQuery = true
-		}
-
-		if inQuery {
-			// Stop at explanatory text
-			if looksLikeExplanation(trimmed) {
-				break
-			}
-			kqlLines = append(kqlLines, line)
-		}
-	}
-
-	if len(kqlLines) > 0 {
-		return strings.TrimSpace(strings.Join(kqlLines, "\n"))
-	}
-
-	// Fallback: return as-is
-	return response
-}
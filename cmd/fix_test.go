@@ -0,0 +1,112 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kqlparser"
+)
+
+// sequenceProvider returns its responses in order, repeating the last one,
+// and records the prompts it was sent.
+type sequenceProvider struct {
+	responses []string
+	prompts   []string
+}
+
+func (p *sequenceProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	i := min(len(p.prompts), len(p.responses)) - 1
+	return p.responses[i], nil
+}
+
+func (p *sequenceProvider) CompleteChat(ctx context.Context, messages []ai.Message) (string, error) {
+	return p.Complete(ctx, messages[len(messages)-1].Content)
+}
+
+func (p *sequenceProvider) Name() string  { return "sequence" }
+func (p *sequenceProvider) Model() string { return "sequence" }
+
+const (
+	brokenQuery  = "StormEvents | summarize count( by State"
+	minimalFix   = "StormEvents | summarize count() by State"
+	sweepingFix  = "StormEvents | where StartTime > ago(7d) | summarize Events=count() by State, EventType | top 10 by Events"
+	fixMaxTokens = 3
+)
+
+func parseErrors(t *testing.T, query string) []error {
+	t.Helper()
+	errs := kqlparser.Parse("input", query).Errors
+	if len(errs) == 0 {
+		t.Fatalf("expected %q to have syntax errors", query)
+	}
+	return errs
+}
+
+func TestRunFixLoop_MinimalFix(t *testing.T) {
+	p := &sequenceProvider{responses: []string{minimalFix}}
+
+	outcome, err := runFixLoop(context.Background(), p, brokenQuery, parseErrors(t, brokenQuery), 3, fixMaxTokens, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if outcome.Query != minimalFix || outcome.TooManyEdits || len(outcome.Errors) != 0 {
+		t.Errorf("expected minimal fix to be accepted, got %+v", outcome)
+	}
+	if outcome.Edits != 1 || outcome.Attempts != 1 {
+		t.Errorf("expected 1 edit in 1 attempt, got %d edits in %d attempts", outcome.Edits, outcome.Attempts)
+	}
+}
+
+func TestRunFixLoop_SweepingFixRetried(t *testing.T) {
+	p := &sequenceProvider{responses: []string{sweepingFix, minimalFix}}
+
+	outcome, err := runFixLoop(context.Background(), p, brokenQuery, parseErrors(t, brokenQuery), 3, fixMaxTokens, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if outcome.Query != minimalFix || outcome.TooManyEdits || outcome.Attempts != 2 {
+		t.Errorf("expected the sweeping fix to be retried, got %+v", outcome)
+	}
+
+	// The retry starts from the original query and asks for a smaller change
+	retry := p.prompts[1]
+	if !strings.Contains(retry, brokenQuery) {
+		t.Error("expected retry prompt to contain the original query")
+	}
+	if !strings.Contains(retry, "at most 3 may change") {
+		t.Errorf("expected retry prompt to ask for a smaller change, got:\n%s", retry)
+	}
+}
+
+func TestRunFixLoop_SweepingFixExhaustsAttempts(t *testing.T) {
+	p := &sequenceProvider{responses: []string{sweepingFix}}
+
+	outcome, err := runFixLoop(context.Background(), p, brokenQuery, parseErrors(t, brokenQuery), 2, fixMaxTokens, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !outcome.TooManyEdits || outcome.Edits <= fixMaxTokens {
+		t.Errorf("expected the fix to be flagged as too large, got %+v", outcome)
+	}
+	if len(p.prompts) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(p.prompts))
+	}
+}
+
+func TestRunFixLoop_NoLimit(t *testing.T) {
+	p := &sequenceProvider{responses: []string{sweepingFix}}
+
+	outcome, err := runFixLoop(context.Background(), p, brokenQuery, parseErrors(t, brokenQuery), 3, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if outcome.Query != sweepingFix || outcome.TooManyEdits || outcome.Attempts != 1 {
+		t.Errorf("expected --max-edits 0 to accept any fix, got %+v", outcome)
+	}
+}
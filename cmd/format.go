@@ -0,0 +1,166 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	formatWrite bool
+	formatCheck bool
+)
+
+// formatStdout and formatStdin are variables to allow testing
+var (
+	formatStdout io.Writer = os.Stdout
+	formatStdin  io.Reader = os.Stdin
+)
+
+var formatCmd = &cobra.Command{
+	Use:   "format [file...]",
+	Short: "Format KQL queries",
+	Long: `Format prints KQL queries laid out for reading: each pipe stage on its own
+line, indented by nesting, and normalized spacing around operators.
+
+Unlike normalize, format keeps comments and blank lines between statements.
+String literals are never changed, and formatting formatted output changes
+nothing. A query that doesn't parse is not reflowed; only its whitespace is
+tidied.
+
+With --check, nothing is written: the files whose formatting would change
+are listed, and the exit code is 1 if there are any. Stdin is checked the
+same way.
+
+If no files are provided, reads from stdin.
+Use '-' as a filename to explicitly read from stdin.`,
+	Example: `  # Print a formatted query
+  kql format query.kql
+
+  # Rewrite files in place
+  kql format -w queries/*.kql
+
+  # Fail in CI when a file isn't formatted
+  kql format --check queries/*.kql
+
+  # From stdin
+  pbpaste | kql format`,
+	RunE: runFormat,
+}
+
+func init() {
+	rootCmd.AddCommand(formatCmd)
+
+	formatCmd.Flags().BoolVarP(&formatWrite, "write", "w", false, "Write the result to each file instead of stdout")
+	formatCmd.Flags().BoolVar(&formatCheck, "check", false, "List files that aren't formatted and exit 1 if any; write nothing")
+}
+
+func runFormat(cmd *cobra.Command, args []string) error {
+	unformatted, err := doFormat(args)
+	if err != nil {
+		return err
+	}
+	if unformatted {
+		osExit(1)
+	}
+	return nil
+}
+
+// doFormat formats each file in args, or stdin when there are none, and
+// reports whether --check found any that aren't formatted.
+// Separated from runFormat to enable testing without os.Exit.
+func doFormat(args []string) (bool, error) {
+	if formatWrite && formatCheck {
+		return false, fmt.Errorf("cannot use --write with --check")
+	}
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+
+	mode := formatPrint
+	switch {
+	case formatWrite:
+		mode = formatRewrite
+	case formatCheck:
+		mode = formatList
+	}
+
+	unformatted := false
+	for _, name := range args {
+		changed, err := formatFile(name, mode)
+		if err != nil {
+			return false, err
+		}
+		unformatted = unformatted || (changed && mode == formatList)
+	}
+	return unformatted, nil
+}
+
+// formatMode is what formatFile does with its result.
+type formatMode int
+
+const (
+	// formatPrint prints the formatted query
+	formatPrint formatMode = iota
+	// formatRewrite writes the formatted query back to the file (--write)
+	formatRewrite
+	// formatList prints the name of a file whose formatting would change
+	// (--check)
+	formatList
+)
+
+// formatFile formats a file, or stdin when name is "-", and reports
+// whether formatting changed it.
+func formatFile(name string, mode formatMode) (bool, error) {
+	var data []byte
+	var perm os.FileMode
+	var err error
+
+	if name == "-" {
+		if mode == formatRewrite {
+			return false, fmt.Errorf("cannot use --write with stdin")
+		}
+		data, err = io.ReadAll(formatStdin)
+	} else {
+		var info os.FileInfo
+		if info, err = os.Stat(name); err == nil {
+			perm = info.Mode().Perm()
+			data, err = os.ReadFile(name)
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot read %s: %w", name, err)
+	}
+
+	formatted, err := kqlfmt.Format(string(data))
+	if err != nil {
+		return false, fmt.Errorf("format %s failed: %w", name, err)
+	}
+	formatted += "\n"
+	changed := formatted != string(data)
+
+	switch mode {
+	case formatPrint:
+		_, err = fmt.Fprint(formatStdout, formatted)
+	case formatList:
+		if changed {
+			if name == "-" {
+				name = "stdin"
+			}
+			_, err = fmt.Fprintln(formatStdout, name)
+		}
+	case formatRewrite:
+		if changed {
+			if err = os.WriteFile(name, []byte(formatted), perm); err != nil {
+				err = fmt.Errorf("writing formatted %s: %w", name, err)
+			}
+		}
+	}
+	return changed, err
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatFile_Stdout(t *testing.T) {
+	var out bytes.Buffer
+	defer func() { formatStdout, formatStdin = os.Stdout, os.Stdin }()
+	formatStdout = &out
+	formatStdin = strings.NewReader("let n=5;T|take n // rows\n")
+
+	if _, err := formatFile("-", formatPrint); err != nil {
+		t.Fatal(err)
+	}
+	if want := "let n = 5;\nT\n| take n // rows\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	if _, err := formatFile("-", formatRewrite); err == nil {
+		t.Error("expected --write with stdin to fail")
+	}
+}
+
+func TestFormatFile_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q.kql")
+	if err := os.WriteFile(path, []byte("T|where x>1|take 10"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := formatFile(path, formatRewrite); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "T\n| where x > 1\n| take 10\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Errorf("mode changed to %v", info.Mode().Perm())
+	}
+}
+
+func TestDoFormat_Check(t *testing.T) {
+	var out bytes.Buffer
+	defer func() { formatStdout, formatStdin, formatCheck = os.Stdout, os.Stdin, false }()
+	formatStdout = &out
+	formatCheck = true
+
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.kql")
+	messy := filepath.Join(dir, "messy.kql")
+	os.WriteFile(clean, []byte("T\n| take 10\n"), 0o644)
+	os.WriteFile(messy, []byte("T|take 10"), 0o644)
+
+	unformatted, err := doFormat([]string{clean, messy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unformatted || out.String() != messy+"\n" {
+		t.Errorf("unformatted=%v, listed %q", unformatted, out.String())
+	}
+	if data, _ := os.ReadFile(messy); string(data) != "T|take 10" {
+		t.Errorf("--check modified the file: %q", data)
+	}
+
+	// Already-formatted input passes
+	out.Reset()
+	if unformatted, err := doFormat([]string{clean}); err != nil || unformatted || out.Len() != 0 {
+		t.Errorf("clean file: unformatted=%v err=%v out=%q", unformatted, err, out.String())
+	}
+
+	// Stdin is checked too
+	formatStdin = strings.NewReader("T|take 10")
+	if unformatted, _ := doFormat(nil); !unformatted || out.String() != "stdin\n" {
+		t.Errorf("stdin: unformatted=%v, listed %q", unformatted, out.String())
+	}
+
+	formatWrite = true
+	defer func() { formatWrite = false }()
+	if _, err := doFormat([]string{clean}); err == nil {
+		t.Error("expected --write with --check to fail")
+	}
+}
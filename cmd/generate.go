@@ -5,27 +5,37 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kusto"
 	"github.com/spf13/cobra"
 )
 
 var (
 	generateInputFile string
-	generateVerbose   bool
 	generateDebug     bool
 	generateTimeout   int
 	generateTable     string
 	generateSchema    string
 
+	// Schema sources
+	generateSchemaFile        string
+	generateSchemaFromCluster bool
+	generateCluster           string
+	generateDatabase          string
+
 	// Validation flags
 	generateNoValidate         bool
 	generateStrict             bool
+	generateSemantic           bool
 	generateRetries            int
+	generateRetryBudget        int
 	generateNoFeedback         bool
 	generateNoFeedbackErrors   bool
 	generateNoFeedbackHints    bool
@@ -35,6 +45,21 @@ var (
 	generateTempIncrement      float32
 	generateTempMax            float32
 	generatePreset             string
+	generateListPresets        bool
+
+	// Post-processing flags
+	generateAppendRender string
+
+	// Experimentation flags
+	generateTempSweep string
+	generateFormat    string
+	generateCount     int
+
+	// Offline skeleton
+	generateOffline bool
+
+	// Golden check flags
+	generateAssertGolden string
 )
 
 var generateCmd = &cobra.Command{
@@ -46,6 +71,19 @@ The description can be provided as an argument, from a file (-f), or via stdin.
 
 Optionally provide table name and schema for more accurate generation.
 
+Use --dry-run to print the first prompt that would be sent, without
+creating a provider; --schema-from-cluster still reads the schema.
+
+Use --raw to print the model's response as received, for when extraction
+gets it wrong. Validation and --strict still apply to the query extracted
+from it. --raw only changes what is printed, so it has no effect with
+--dry-run, which stops before the model is asked.
+
+Use --offline to skip the provider, for example when it is unreachable:
+generate then validates and prints a skeleton for --table, a project of
+the --schema columns followed by a take. It takes no description, and no
+--schema-from-cluster, since that contacts the cluster.
+
 Uses the same AI providers as 'kql explain'.`,
 	Example: `  # Simple generation
   kql generate "count events by state"
@@ -57,11 +95,39 @@ Uses the same AI providers as 'kql explain'.`,
   kql generate --table StormEvents --schema "State, StartTime, DamageProperty" \
       "find events in Texas with damage over 1 million"
 
+  # Schema from a file, or from the cluster itself
+  kql generate --table StormEvents --schema-file stormevents.schema "top 10 states by damage"
+  kql generate --table StormEvents --schema-from-cluster -c help -d Samples "top 10 states by damage"
+
   # From file
   echo "get hourly event counts for the last week" | kql generate --table Events
 
+  # Save the query, keeping validation warnings on stderr
+  kql generate -o query.kql "count events by state"
+
   # Use specific provider
-  kql generate --provider vertex --model gemini-1.5-pro "summarize by category"`,
+  kql generate --provider vertex --model gemini-1.5-pro "summarize by category"
+
+  # Append a render operator chosen from the query shape
+  kql generate --table Events --append-render auto "hourly event counts for the last day"
+
+  # Scaffold a query without the AI provider
+  kql generate --offline --table StormEvents --schema "State, StartTime, DamageProperty"
+
+  # Brainstorm: up to three distinct candidate queries
+  kql generate --count 3 "count events by state"
+
+  # Compare output across temperatures (one sample each, no retries)
+  kql generate --temperature-sweep 0.0,0.3,0.6 "count events by state"
+
+  # Fail if the result differs from a curated query (ignoring formatting)
+  kql generate --table StormEvents --assert-parses-as expected.kql "count events by state"
+
+  # See everything the model said, not just the query
+  kql generate --raw "count events by state"
+
+  # See the exact prompt, with the schema
+  kql generate --dry-run --table StormEvents --schema-file storm.schema "count events by state"`,
 	RunE: runGenerate,
 }
 
@@ -69,7 +135,8 @@ func init() {
 	rootCmd.AddCommand(generateCmd)
 
 	// Provider selection (reuse from explain)
-	generateCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure)")
+	generateCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = generateCmd.RegisterFlagCompletionFunc("provider", completeProvider)
 	generateCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
 	generateCmd.Flags().Float32Var(&aiTemperature, "temperature", 0.2, "Temperature (0.0-1.0)")
 
@@ -87,20 +154,50 @@ func init() {
 	// InstructLab
 	generateCmd.Flags().StringVar(&instructEndpoint, "instructlab-endpoint", "", "InstructLab endpoint URL")
 
+	// OpenAI
+	generateCmd.Flags().StringVar(&openaiAPIKey, "openai-api-key", "", "OpenAI API key (default from config or OPENAI_API_KEY)")
+
+	// Anthropic
+	generateCmd.Flags().StringVar(&anthropicAPIKey, "anthropic-api-key", "", "Anthropic API key (default from config or ANTHROPIC_API_KEY)")
+
+	// Network
+	generateCmd.Flags().StringVar(&aiProxy, "proxy", "", "Proxy URL for AI requests (default from HTTPS_PROXY/HTTP_PROXY)")
+	generateCmd.Flags().StringVar(&aiCACert, "ca-cert", "", "PEM file of extra CA certificates to trust")
+
+	// System prompt
+	generateCmd.Flags().StringVar(&aiSystemPrompt, "system-prompt", "", "System message sent ahead of every prompt (default from config)")
+	generateCmd.Flags().StringVar(&aiSystemPromptFile, "system-prompt-file", "", "Read the system message from a file")
+
+	// Response cache
+	generateCmd.Flags().BoolVar(&aiCache, "cache", false, "Reuse identical responses from the on-disk cache")
+	generateCmd.Flags().BoolVar(&aiNoCache, "no-cache", false, "Disable the response cache, overriding --cache and the config file")
+	generateCmd.Flags().DurationVar(&aiCacheTTL, "cache-ttl", 0, "How long cached responses are reused (default 24h)")
+
+	// Diagnostics
+	generateCmd.Flags().BoolVar(&aiProviderInfo, "provider-info", false, "Print the resolved provider settings and their sources, then exit")
+
 	// Command options
 	generateCmd.Flags().StringVarP(&generateInputFile, "file", "f", "", "Read description from file")
-	generateCmd.Flags().BoolVarP(&generateVerbose, "verbose", "v", false, "Show additional context")
+	addInputSourceFlags(generateCmd)
+	generateCmd.Flags().StringVarP(&aiOutput, "output", "o", "", "Write the result to a file instead of stdout")
 	generateCmd.Flags().BoolVar(&generateDebug, "debug", false, "Show raw LLM responses (for troubleshooting)")
+	generateCmd.Flags().BoolVar(&aiRaw, "raw", false, "Print the model's response as received, still validating the query extracted from it")
 	generateCmd.Flags().IntVar(&generateTimeout, "timeout", 60, "Timeout in seconds")
 
 	// Context options
 	generateCmd.Flags().StringVarP(&generateTable, "table", "t", "", "Target table name")
 	generateCmd.Flags().StringVarP(&generateSchema, "schema", "s", "", "Table schema (comma-separated columns)")
+	generateCmd.Flags().StringVar(&generateSchemaFile, "schema-file", "", "Read the table schema from a file of comma- or newline-separated columns")
+	generateCmd.Flags().BoolVar(&generateSchemaFromCluster, "schema-from-cluster", false, "Fetch the --table schema from the cluster (needs Azure CLI login or KQL_KUSTO_TOKEN)")
+	generateCmd.Flags().StringVarP(&generateCluster, "cluster", "c", "", "Cluster for --schema-from-cluster (default from KQL_LINK_CLUSTER or link.cluster)")
+	generateCmd.Flags().StringVarP(&generateDatabase, "database", "d", "", "Database for --schema-from-cluster (default from KQL_LINK_DATABASE or link.database)")
 
 	// Validation flags
 	generateCmd.Flags().BoolVar(&generateNoValidate, "no-validate", false, "Disable validation")
 	generateCmd.Flags().BoolVar(&generateStrict, "strict", false, "Fail with exit code 1 if validation fails")
+	generateCmd.Flags().BoolVar(&generateSemantic, "semantic", false, "Also check columns against --table and --schema, retrying on unknown names")
 	generateCmd.Flags().IntVar(&generateRetries, "retries", 2, "Number of retry attempts on validation failure")
+	generateCmd.Flags().IntVar(&generateRetryBudget, "retry-budget-seconds", 0, "Keep retrying until valid or this many seconds have passed (overrides --retries)")
 
 	// Feedback control flags
 	generateCmd.Flags().BoolVar(&generateNoFeedback, "no-feedback", false, "Disable all feedback strategies")
@@ -115,55 +212,186 @@ func init() {
 	generateCmd.Flags().Float32Var(&generateTempMax, "retry-temp-max", 0, "Max temperature on retry")
 
 	// Presets
-	generateCmd.Flags().StringVar(&generatePreset, "preset", "", "Preset: minimal, balanced, thorough, strict")
+	generateCmd.Flags().StringVar(&generatePreset, "preset", "", "Validation preset: "+strings.Join(presetNames(), ", ")+" (see --list-presets)")
+	_ = generateCmd.RegisterFlagCompletionFunc("preset", completeValues(presetNames()...))
+	generateCmd.Flags().BoolVar(&generateListPresets, "list-presets", false, "Print the validation presets and their settings, then exit")
+
+	// Post-processing
+	generateCmd.Flags().StringVar(&generateAppendRender, "append-render", "", "Append a render operator: auto, table, timechart, barchart, ...")
+
+	// Experimentation
+	generateCmd.Flags().StringVar(&generateTempSweep, "temperature-sweep", "", "Generate once per comma-separated temperature (e.g. 0.0,0.3,0.6) and compare")
+	generateCmd.Flags().StringVar(&generateFormat, "format", "text", "Output format for --temperature-sweep: text, json")
+	generateCmd.Flags().IntVar(&generateCount, "count", 1, "Generate this many candidate queries, raising the temperature slightly for each")
+	generateCmd.Flags().BoolVar(&generateOffline, "offline", false, "Skip the AI provider and emit a validated skeleton query for --table (and --schema columns)")
+	generateCmd.Flags().BoolVar(&aiDryRun, "dry-run", false, "Print the prompt that would be sent and exit, without contacting the model")
+
+	// Golden checks
+	generateCmd.Flags().StringVar(&generateAssertGolden, "assert-parses-as", "", "Exit 1 with a diff unless the normalized result matches the query in this file")
 }
 
-func runGenerate(cmd *cobra.Command, args []string) error {
-	// Get description input
-	description, err := getInputFrom(args, generateInputFile, os.Stdin, isTerminal)
+func runGenerate(cmd *cobra.Command, args []string) (err error) {
+	if aiProviderInfo {
+		return runProviderInfo(cmd)
+	}
+	if generateListPresets {
+		return writePresets(os.Stdout, ai.DefaultValidationConfig())
+	}
+
+	if err := validateRenderChoice(generateAppendRender); err != nil {
+		return err
+	}
+
+	if generateFormat != "text" && generateFormat != "json" {
+		return fmt.Errorf("unknown format: %s", generateFormat)
+	}
+
+	var sweepTemps []float32
+	if generateTempSweep != "" {
+		temps, err := parseTemperatureList(generateTempSweep)
+		if err != nil {
+			return err
+		}
+		sweepTemps = temps
+		if aiRaw {
+			return fmt.Errorf("--raw cannot be combined with --temperature-sweep")
+		}
+	}
+
+	if generateCount < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+	if generateCount > 1 {
+		switch {
+		case sweepTemps != nil:
+			return fmt.Errorf("--count cannot be combined with --temperature-sweep")
+		case aiRaw:
+			return fmt.Errorf("--raw cannot be combined with --count")
+		case generateAssertGolden != "":
+			return fmt.Errorf("--assert-parses-as cannot be combined with --count")
+		}
+	}
+
+	if generateOffline {
+		switch {
+		case generateTable == "":
+			return fmt.Errorf("--offline requires --table")
+		case sweepTemps != nil:
+			return fmt.Errorf("--offline cannot be combined with --temperature-sweep")
+		case generateCount > 1:
+			return fmt.Errorf("--offline cannot be combined with --count")
+		case aiDryRun:
+			return fmt.Errorf("--offline cannot be combined with --dry-run")
+		case generateSchemaFromCluster:
+			return fmt.Errorf("--offline cannot be combined with --schema-from-cluster, which contacts the cluster; use --schema or --schema-file")
+		case len(args) > 0 || generateInputFile != "" || inputPaste || inputEditor:
+			return fmt.Errorf("--offline takes no description: the skeleton is built from --table and --schema only")
+		}
+	}
+
+	var golden string
+	if generateAssertGolden != "" {
+		if sweepTemps != nil {
+			return fmt.Errorf("--assert-parses-as cannot be combined with --temperature-sweep")
+		}
+		g, err := readGolden(generateAssertGolden)
+		if err != nil {
+			return err
+		}
+		golden = g
+	}
+
+	// Get description input; the offline skeleton doesn't need one
+	var description string
+	if !generateOffline {
+		if description, err = getInputFrom(args, generateInputFile, os.Stdin, isTerminal); err != nil {
+			return err
+		}
+	}
+
+	// Resolve the schema before the provider, so a bad schema source
+	// fails without an AI request. The config file only matters for the
+	// cluster and database of --schema-from-cluster.
+	var fileCfg *ai.FileConfig
+	if generateSchemaFromCluster {
+		if fileCfg, err = loadConfigFileOrWarn(); err != nil {
+			return err
+		}
+	}
+	schemaCtx, cancelSchema := context.WithTimeout(context.Background(), time.Duration(generateTimeout)*time.Second)
+	generateSchema, err = resolveGenerateSchema(schemaCtx, fileCfg, kusto.NewClient)
+	cancelSchema()
 	if err != nil {
 		return err
 	}
 
-	// Build AI config
-	cfg := buildAIConfig()
+	if aiDryRun {
+		return runPromptDryRun(queryInput{query: description}, func(description string, style ai.PromptStyle) string {
+			return buildGeneratePrompt(description, generateTable, generateSchema, style)
+		})
+	}
 
-	// Load file config and merge
-	fileCfg, err := ai.LoadConfigFile()
+	var provider ai.Provider
+	var cfg ai.Config
+	if generateOffline {
+		provider = &offlineProvider{query: offlineSkeleton(generateTable, generateSchema)}
+		cfg, err = loadAIConfig()
+	} else {
+		provider, cfg, err = resolveProvider()
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: error loading config file: %v\n", err)
+		return err
 	}
-	cfg = ai.MergeFileConfig(cfg, fileCfg)
 
-	// Apply defaults if still empty
-	if cfg.Provider == "" {
-		cfg.Provider = "ollama"
+	out, err := openOutput(aiOutput, os.Stderr)
+	if err != nil {
+		return err
 	}
+	defer func() { err = out.finish(err) }()
 
 	// Apply validation config from flags and environment
-	valCfg := buildValidationConfig(cfg.Validation)
-
-	// Create provider
-	provider, err := ai.NewProvider(cfg)
+	valCfg, err := buildValidationConfig(cfg.Validation, cmd.Flags().Changed("retries"))
 	if err != nil {
-		return fmt.Errorf("creating AI provider: %w", err)
+		return err
+	}
+	valCfg.RecordHistory = aiRaw
+	if generateOffline {
+		// The skeleton is the same on every attempt
+		valCfg.Retries, valCfg.RetryBudget = 0, 0
+	}
+
+	if sweepTemps != nil {
+		// Retries would blur the effect of temperature, so they are off
+		// unless explicitly requested
+		if !cmd.Flags().Changed("retries") {
+			valCfg.Retries = 0
+		}
+		return runGenerateSweep(cfg, valCfg, sweepTemps, description, out)
+	}
+	if generateCount > 1 {
+		return runGenerateCandidates(cfg, valCfg, generateCount, description, out)
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(generateTimeout)*time.Second)
+	// Create context with timeout; a retry budget extends it, since the
+	// last attempt may start just before the budget runs out
+	timeout := time.Duration(generateTimeout)*time.Second + valCfg.RetryBudget
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Show progress
-	if generateVerbose {
-		fmt.Fprintf(os.Stderr, "Using %s provider with model %s...\n", provider.Name(), provider.Model())
-		if generateTable != "" {
-			fmt.Fprintf(os.Stderr, "Target table: %s\n", generateTable)
-		}
-		if valCfg.Enabled {
-			fmt.Fprintf(os.Stderr, "Validation: enabled (retries=%d, strict=%v)\n", valCfg.Retries, valCfg.Strict)
-		} else {
-			fmt.Fprintf(os.Stderr, "Validation: disabled\n")
-		}
+	logf(logInfo, "Using %s provider with model %s...", provider.Name(), provider.Model())
+	if generateTable != "" {
+		logf(logInfo, "Target table: %s", generateTable)
+	}
+	if generateSchema != "" {
+		logf(logInfo, "Schema: %s", generateSchema)
+	}
+	if valCfg.Enabled && valCfg.RetryBudget > 0 {
+		logf(logInfo, "Validation: enabled (retry budget=%s, strict=%v)", valCfg.RetryBudget, valCfg.Strict)
+	} else if valCfg.Enabled {
+		logf(logInfo, "Validation: enabled (retries=%d, strict=%v)", valCfg.Retries, valCfg.Strict)
+	} else {
+		logf(logInfo, "Validation: disabled")
 	}
 
 	// Build request
@@ -173,16 +401,14 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		Schema: generateSchema,
 	}
 
-	// Verbose and debug output writers
-	var verboseWriter, debugWriter *os.File
-	if generateVerbose {
-		verboseWriter = os.Stderr
-	}
+	// Debug output writer
+	var debugWriter io.Writer
 	if generateDebug {
 		debugWriter = os.Stderr
 	}
 
 	// Generate with validation
+	style := ai.PromptStyleFor(provider)
 	result, err := ai.GenerateWithValidation(
 		ctx,
 		provider,
@@ -190,47 +416,104 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		valCfg,
 		cfg.Temperature,
 		func(r ai.GenerateRequest) string {
-			return buildGeneratePrompt(r.Prompt, r.Table, r.Schema)
+			return buildGeneratePrompt(r.Prompt, r.Table, r.Schema, style)
 		},
 		extractKQL,
-		verboseWriter,
+		logWriter(logInfo),
 		debugWriter,
 	)
 	if err != nil {
-		return err
+		return aiRequestError(err, timeout)
 	}
 
 	// Handle result based on validation outcome
 	if !result.Valid {
 		if valCfg.Strict {
-			fmt.Fprint(os.Stderr, ai.FormatValidationError(result))
-			os.Exit(1)
+			return validationFailure(result)
+		}
+		logf(logWarn, "%s", ai.FormatValidationWarning(result))
+	}
+
+	// Append a render operator to valid queries if requested
+	if generateAppendRender != "" && result.Valid && !aiRaw {
+		rendered, err := appendRender(result.Query, generateAppendRender)
+		if err != nil {
+			logf(logWarn, "Warning: %v", err)
 		}
-		fmt.Fprint(os.Stderr, ai.FormatValidationWarning(result))
+		result.Query = rendered
 	}
 
-	fmt.Println(result.Query)
+	if aiRaw {
+		fmt.Fprintln(out, rawResponse(result))
+	} else {
+		fmt.Fprintln(out, result.Query)
+	}
+
+	if generateAssertGolden != "" {
+		if diff := checkGolden(result.Query, golden); diff != "" {
+			return goldenMismatchError(generateAssertGolden, diff)
+		}
+	}
 	return nil
 }
 
+// validationFailure is the error for a result --strict rejects.
+func validationFailure(result *ai.GenerateResult) error {
+	msg := strings.TrimPrefix(ai.FormatValidationError(result), "Error: ")
+	return errors.New(strings.TrimSuffix(msg, "\n"))
+}
+
+// runGenerateSweep implements --temperature-sweep.
+func runGenerateSweep(cfg ai.Config, valCfg ai.ValidationConfig, temps []float32, description string, out io.Writer) error {
+	results := generateAtTemperatures(cfg, valCfg, temps, description)
+	return writeSweepResults(out, results, generateFormat)
+}
+
+// generateAtTemperatures generates one query per temperature, for
+// --temperature-sweep and --count.
+func generateAtTemperatures(cfg ai.Config, valCfg ai.ValidationConfig, temps []float32, description string) []sweepResult {
+	perTemp := time.Duration(generateTimeout)*time.Second + valCfg.RetryBudget
+	ctx, cancel := context.WithTimeout(context.Background(), perTemp*time.Duration(len(temps)))
+	defer cancel()
+
+	// Every provider in the sweep has the same model, so the prompt style
+	// from the most recent one applies to the generation that follows
+	var style ai.PromptStyle
+	newProvider := func(temp float32) (ai.Provider, error) {
+		c := cfg
+		c.Temperature = temp
+		p, err := ai.NewProvider(c)
+		if err == nil {
+			style = ai.PromptStyleFor(p)
+		}
+		return p, err
+	}
+	req := ai.GenerateRequest{
+		Prompt: description,
+		Table:  generateTable,
+		Schema: generateSchema,
+	}
+
+	return runTemperatureSweep(ctx, temps, newProvider, req, valCfg,
+		func(r ai.GenerateRequest) string {
+			return buildGeneratePrompt(r.Prompt, r.Table, r.Schema, style)
+		},
+		extractKQL,
+	)
+}
+
 // buildValidationConfig builds validation config from flags, environment, and defaults.
-func buildValidationConfig(base ai.ValidationConfig) ai.ValidationConfig {
+// retriesSet reports whether --retries was given, since its default would
+// otherwise replace the retries of the config file or preset.
+func buildValidationConfig(base ai.ValidationConfig, retriesSet bool) (ai.ValidationConfig, error) {
 	cfg := base
 
 	// Apply preset first
-	switch generatePreset {
-	case "minimal":
-		cfg.Retries = 0
-		cfg.Feedback.Hints = false
-		cfg.Feedback.Examples = false
-	case "balanced":
-		// Use defaults
-	case "thorough":
-		cfg.Retries = 5
-		cfg.Feedback.Progressive = true
-	case "strict":
-		cfg.Strict = true
-		cfg.Retries = 3
+	if generatePreset != "" {
+		var err error
+		if cfg, err = ai.ApplyPreset(generatePreset, cfg); err != nil {
+			return cfg, err
+		}
 	}
 
 	// Override with explicit flags
@@ -240,8 +523,17 @@ func buildValidationConfig(base ai.ValidationConfig) ai.ValidationConfig {
 	if generateStrict {
 		cfg.Strict = true
 	}
-	// Always apply retries flag (default is 2, which is also the config default)
-	cfg.Retries = generateRetries
+	if generateSemantic {
+		cfg.Semantic = true
+	}
+	cfg.Table = generateTable
+	cfg.Schema = generateSchema
+	if retriesSet {
+		cfg.Retries = generateRetries
+	}
+	if generateRetryBudget > 0 {
+		cfg.RetryBudget = time.Duration(generateRetryBudget) * time.Second
+	}
 
 	// Feedback flags
 	if generateNoFeedback {
@@ -276,21 +568,67 @@ func buildValidationConfig(base ai.ValidationConfig) ai.ValidationConfig {
 	}
 
 	// Environment variable overrides
-	if env := os.Getenv("KQL_VALIDATE"); env == "false" || env == "0" {
+	return applyValidationEnv(cfg, os.Getenv), nil
+}
+
+// presetNames returns the names of the validation presets.
+func presetNames() []string {
+	var names []string
+	for _, p := range ai.Presets() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// writePresets prints each preset with the settings it gives when applied
+// to base.
+func writePresets(w io.Writer, base ai.ValidationConfig) error {
+	for _, p := range ai.Presets() {
+		cfg, err := ai.ApplyPreset(p.Name, base)
+		if err != nil {
+			return err
+		}
+		var feedback []string
+		for _, f := range []struct {
+			name string
+			on   bool
+		}{
+			{"errors", cfg.Feedback.Errors},
+			{"hints", cfg.Feedback.Hints},
+			{"examples", cfg.Feedback.Examples},
+			{"progressive", cfg.Feedback.Progressive},
+		} {
+			if f.on {
+				feedback = append(feedback, f.name)
+			}
+		}
+		if len(feedback) == 0 {
+			feedback = []string{"none"}
+		}
+		fmt.Fprintf(w, "%-10s %s\n", p.Name, p.Description)
+		fmt.Fprintf(w, "%-10s retries=%d strict=%t feedback=%s\n", "", cfg.Retries, cfg.Strict, strings.Join(feedback, ","))
+	}
+	return nil
+}
+
+// applyValidationEnv applies KQL_VALIDATE and KQL_VALIDATE_STRICT.
+func applyValidationEnv(cfg ai.ValidationConfig, getenv func(string) string) ai.ValidationConfig {
+	if env := getenv("KQL_VALIDATE"); env == "false" || env == "0" {
 		cfg.Enabled = false
 	}
-	if env := os.Getenv("KQL_VALIDATE_STRICT"); env == "true" || env == "1" {
+	if env := getenv("KQL_VALIDATE_STRICT"); env == "true" || env == "1" {
 		cfg.Strict = true
 	}
-	// Add more env var handling as needed...
-
 	return cfg
 }
 
-func buildGeneratePrompt(description, table, schema string) string {
+func buildGeneratePrompt(description, table, schema string, style ai.PromptStyle) string {
 	var context strings.Builder
 
-	context.WriteString(`You are a Kusto Query Language (KQL) expert. Generate a KQL query based on the user's natural language description.
+	if style == ai.PromptStyleTerse {
+		context.WriteString("Write a KQL query for the description. Output only the query, with no backticks or explanation.\n")
+	} else {
+		context.WriteString(`You are a Kusto Query Language (KQL) expert. Generate a KQL query based on the user's natural language description.
 
 Rules:
 1. Output ONLY the raw KQL query, no explanations
@@ -299,6 +637,7 @@ Rules:
 4. Include comments only if the query is complex
 5. Prefer efficient query patterns
 `)
+	}
 
 	if table != "" {
 		context.WriteString(fmt.Sprintf("\nTarget table: %s\n", table))
@@ -308,131 +647,8 @@ Rules:
 		context.WriteString(fmt.Sprintf("Available columns: %s\n", schema))
 	}
 
-	context.WriteString(fmt.Sprintf("\nDescription: %s\n", description))
+	context.WriteString("\n" + style.Field("description", "Description", description) + "\n")
 	context.WriteString("\nGenerate the KQL query:")
 
 	return context.String()
 }
-
-// extractKQL attempts to extract just the KQL code from an LLM response.
-// Handles responses that include markdown code blocks or explanatory text.
-func extractKQL(response string) string {
-	response = strings.TrimSpace(response)
-
-	// Check for markdown code blocks (triple backticks)
-	if strings.Contains(response, "```") {
-		// Try to find kql/kusto code block first
-		for _, lang := range []string{"```kql", "```kusto", "```"} {
-			if idx := strings.Index(response, lang); idx != -1 {
-				start := idx + len(lang)
-				// Find the closing ```
-				if end := strings.Index(response[start:], "```"); end != -1 {
-					extracted := strings.TrimSpace(response[start : start+end])
-					if extracted != "" {
-						return stripInlineBackticks(extracted)
-					}
-				}
-			}
-		}
-	}
-
-	// Strip inline backticks (e.g., `query here`)
-	response = stripInlineBackticks(response)
-
-	// If no code blocks, try to find lines that look like KQL
-	// (start with a table name or common operators)
-	lines := strings.Split(response, "\n")
-	var kqlLines []string
-	inQuery := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		// Skip empty lines at the start
-		if !inQuery && trimmed == "" {
-			continue
-		}
-
-		// Detect start of KQL (table name or let statement)
-		if !inQuery {
-			if looksLikeKQLStart(trimmed) {
-				inQuery = true
-			}
-		}
-
-		if inQuery {
-			// Stop at explanatory text
-			if looksLikeExplanation(trimmed) {
-				break
-			}
-			kqlLines = append(kqlLines, line)
-		}
-	}
-
-	if len(kqlLines) > 0 {
-		return strings.TrimSpace(strings.Join(kqlLines, "\n"))
-	}
-
-	// Fallback: return as-is
-	return response
-}
-
-func looksLikeKQLStart(line string) bool {
-	lower := strings.ToLower(line)
-
-	// Common KQL starting patterns
-	starters := []string{
-		"let ", "//", "/*",
-	}
-
-	for _, s := range starters {
-		if strings.HasPrefix(lower, s) {
-			return true
-		}
-	}
-
-	// Starts with what looks like a table name (alphanumeric, no spaces before pipe)
-	if len(line) > 0 {
-		// Table names typically start with a letter
-		if (line[0] >= 'A' && line[0] <= 'Z') || (line[0] >= 'a' && line[0] <= 'z') {
-			return true
-		}
-	}
-
-	return false
-}
-
-func looksLikeExplanation(line string) bool {
-	lower := strings.ToLower(line)
-
-	// Common explanation starters
-	explanations := []string{
-		"this query", "the query", "this will", "explanation:",
-		"note:", "here's", "here is", "the above",
-	}
-
-	for _, e := range explanations {
-		if strings.HasPrefix(lower, e) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// stripInlineBackticks removes inline backticks from a string.
-// Handles cases like `query here` or queries starting/ending with backticks.
-func stripInlineBackticks(s string) string {
-	s = strings.TrimSpace(s)
-
-	// Remove surrounding single backticks
-	if len(s) >= 2 && s[0] == '`' && s[len(s)-1] == '`' {
-		s = s[1 : len(s)-1]
-	}
-
-	// Also handle case where only leading or trailing backtick
-	s = strings.TrimPrefix(s, "`")
-	s = strings.TrimSuffix(s, "`")
-
-	return strings.TrimSpace(s)
-}
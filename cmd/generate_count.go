@@ -0,0 +1,92 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// candidateTemperatures returns the temperature of each of n candidates
+// for --count: base for the first, then raised by the retry increment per
+// candidate, up to the retry maximum.
+func candidateTemperatures(n int, base float32, adjust ai.TempAdjustConfig) []float32 {
+	temps := make([]float32, n)
+	for i := range temps {
+		temp := base
+		if i > 0 {
+			temp = base + float32(i)*adjust.Increment
+			if temp > adjust.Max {
+				temp = adjust.Max
+			}
+		}
+		temps[i] = temp
+	}
+	return temps
+}
+
+// selectCandidates drops failed generations, invalid queries when strict,
+// and repeats of an earlier query, keeping the order.
+func selectCandidates(results []sweepResult, strict bool) []sweepResult {
+	var selected []sweepResult
+	seen := make(map[string]bool)
+	for i, r := range results {
+		switch {
+		case r.Error != "":
+			logf(logWarn, "Warning: candidate %d: %s", i+1, r.Error)
+		case strict && !r.Valid:
+			logf(logInfo, "Dropping invalid candidate %d", i+1)
+		case seen[r.Query]:
+			logf(logInfo, "Dropping candidate %d, a repeat of an earlier one", i+1)
+		default:
+			seen[r.Query] = true
+			selected = append(selected, r)
+		}
+	}
+	return selected
+}
+
+// writeCandidates prints each candidate under a "// --- candidate K ---"
+// comment, with the validation errors of invalid ones.
+func writeCandidates(w io.Writer, candidates []sweepResult) {
+	for i, c := range candidates {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "// --- candidate %d ---\n", i+1)
+		for _, e := range c.Errors {
+			fmt.Fprintf(w, "// invalid: line %d, col %d: %s\n", e.Line, e.Column, e.Message)
+		}
+		fmt.Fprintln(w, c.Query)
+	}
+}
+
+// runGenerateCandidates implements --count.
+func runGenerateCandidates(cfg ai.Config, valCfg ai.ValidationConfig, n int, description string, out io.Writer) error {
+	temps := candidateTemperatures(n, cfg.Temperature, valCfg.Temp)
+	results := generateAtTemperatures(cfg, valCfg, temps, description)
+
+	candidates := selectCandidates(results, valCfg.Strict)
+	if len(candidates) == 0 {
+		return fmt.Errorf("no valid candidates out of %d", n)
+	}
+
+	if generateAppendRender != "" {
+		for i, c := range candidates {
+			if !c.Valid {
+				continue
+			}
+			rendered, err := appendRender(c.Query, generateAppendRender)
+			if err != nil {
+				logf(logWarn, "Warning: candidate %d: %v", i+1, err)
+			}
+			candidates[i].Query = rendered
+		}
+	}
+
+	writeCandidates(out, candidates)
+	return nil
+}
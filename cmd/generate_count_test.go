@@ -0,0 +1,63 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestCandidateTemperatures(t *testing.T) {
+	temps := candidateTemperatures(4, 0.2, ai.TempAdjustConfig{Increment: 0.25, Max: 0.6})
+	want := []float32{0.2, 0.45, 0.6, 0.6}
+	if len(temps) != len(want) {
+		t.Fatalf("expected %d temperatures, got %v", len(want), temps)
+	}
+	for i := range want {
+		if temps[i] != want[i] {
+			t.Errorf("candidate %d: expected %.2f, got %.2f", i+1, want[i], temps[i])
+		}
+	}
+
+	// The first candidate keeps the requested temperature, even above max
+	if temps := candidateTemperatures(2, 0.9, ai.TempAdjustConfig{Increment: 0.1, Max: 0.8}); temps[0] != 0.9 || temps[1] != 0.8 {
+		t.Errorf("unexpected temperatures: %v", temps)
+	}
+}
+
+func TestSelectCandidates(t *testing.T) {
+	results := []sweepResult{
+		{Query: "T | take 10", Valid: true},
+		{Error: "unavailable"},
+		{Query: "T | where ((", Errors: []sweepResultError{{Line: 1, Column: 12, Message: "unexpected EOF"}}},
+		{Query: "T | take 10", Valid: true},
+		{Query: "T | count", Valid: true},
+	}
+
+	lenient := selectCandidates(results, false)
+	if len(lenient) != 3 || lenient[0].Query != "T | take 10" || lenient[1].Valid || lenient[2].Query != "T | count" {
+		t.Errorf("unexpected candidates: %+v", lenient)
+	}
+
+	strict := selectCandidates(results, true)
+	if len(strict) != 2 || strict[0].Query != "T | take 10" || strict[1].Query != "T | count" {
+		t.Errorf("expected only distinct valid candidates, got %+v", strict)
+	}
+}
+
+func TestWriteCandidates(t *testing.T) {
+	var buf bytes.Buffer
+	writeCandidates(&buf, []sweepResult{
+		{Query: "T | take 10", Valid: true},
+		{Query: "T | where ((", Errors: []sweepResultError{{Line: 1, Column: 12, Message: "unexpected EOF"}}},
+	})
+
+	want := "// --- candidate 1 ---\nT | take 10\n\n" +
+		"// --- candidate 2 ---\n// invalid: line 1, col 12: unexpected EOF\nT | where ((\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
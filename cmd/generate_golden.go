@@ -0,0 +1,43 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+)
+
+// readGolden reads and normalizes the expected query for --assert-parses-as.
+// The golden file must itself be a valid query.
+func readGolden(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading golden query: %w", err)
+	}
+	golden, err := kqlfmt.Normalize(string(data))
+	if err != nil {
+		return "", fmt.Errorf("golden query %s: %w", path, err)
+	}
+	return golden, nil
+}
+
+// checkGolden compares a generated query with a normalized golden query.
+// It returns a diff from golden to generated, or "" on a match. LLM output
+// varies in layout and comments, so both sides are compared normalized; a
+// generated query that cannot be normalized is compared as written.
+func checkGolden(query, golden string) string {
+	normalized, err := kqlfmt.Normalize(query)
+	if err != nil {
+		normalized = strings.TrimSpace(query)
+	}
+	return kqlfmt.Diff(golden, normalized)
+}
+
+// goldenMismatchError is the --assert-parses-as failure, with the diff.
+func goldenMismatchError(path, diff string) error {
+	return fmt.Errorf("generated query does not match %s (- expected, + generated)\n%s", path, strings.TrimSuffix(diff, "\n"))
+}
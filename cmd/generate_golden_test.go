@@ -0,0 +1,88 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// generateForGolden runs generation against a fake provider the way
+// generate does, returning the extracted query.
+func generateForGolden(t *testing.T, response string) string {
+	t.Helper()
+	p := &fakeProvider{name: "fake", model: "fake", response: response}
+	result, err := ai.GenerateWithValidation(context.Background(), p,
+		ai.GenerateRequest{Prompt: "count events by state", Table: "StormEvents"},
+		ai.DefaultValidationConfig(), 0.2,
+		func(r ai.GenerateRequest) string {
+			return buildGeneratePrompt(r.Prompt, r.Table, r.Schema, ai.PromptStyleDefault)
+		},
+		extractKQL, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	return result.Query
+}
+
+func writeGolden(t *testing.T, query string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "expected.kql")
+	if err := os.WriteFile(path, []byte(query), 0644); err != nil {
+		t.Fatalf("failed to write golden file: %v", err)
+	}
+	return path
+}
+
+func TestCheckGolden_Match(t *testing.T) {
+	golden, err := readGolden(writeGolden(t, "// expected\nStormEvents\n| summarize count() by State\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Same query, different layout, wrapped in a code fence by the model
+	query := generateForGolden(t, "```kql\nStormEvents | summarize count()   by State\n```")
+	if diff := checkGolden(query, golden); diff != "" {
+		t.Errorf("expected match, got diff:\n%s", diff)
+	}
+}
+
+func TestCheckGolden_Mismatch(t *testing.T) {
+	path := writeGolden(t, "StormEvents\n| summarize count() by State")
+	golden, err := readGolden(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	query := generateForGolden(t, "StormEvents | summarize count() by EventType")
+	diff := checkGolden(query, golden)
+	if diff == "" {
+		t.Fatal("expected a diff")
+	}
+	for _, want := range []string{"  StormEvents", "- | summarize count() by State", "+ | summarize count() by EventType"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+
+	msg := goldenMismatchError(path, diff).Error()
+	if !strings.Contains(msg, "does not match "+path) {
+		t.Errorf("expected mismatch message to name the golden file, got %q", msg)
+	}
+}
+
+func TestReadGolden_Errors(t *testing.T) {
+	if _, err := readGolden(filepath.Join(t.TempDir(), "missing.kql")); err == nil {
+		t.Error("expected error for missing golden file")
+	}
+	if _, err := readGolden(writeGolden(t, "StormEvents | where State == 'TX")); err == nil {
+		t.Error("expected error for unparseable golden query")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kusto"
+)
+
+// offlineSkeleton returns a minimal query over table for generate
+// --offline: a project of the schema's columns, if there are any, then a
+// take. Types in the schema ("Name:type") are dropped.
+func offlineSkeleton(table, schema string) string {
+	var columns []string
+	for _, field := range strings.Split(schema, ",") {
+		name, _, _ := strings.Cut(field, ":")
+		if name = strings.TrimSpace(name); name != "" {
+			columns = append(columns, kusto.QuoteName(name))
+		}
+	}
+
+	lines := []string{kusto.QuoteName(table)}
+	if len(columns) > 0 {
+		lines = append(lines, "| project "+strings.Join(columns, ", "))
+	}
+	lines = append(lines, "| take 10")
+	return strings.Join(lines, "\n")
+}
+
+// offlineProvider answers every prompt with a fixed query, so generate
+// --offline goes through the same validation as a model's answer.
+type offlineProvider struct {
+	query string
+}
+
+func (p *offlineProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.query, nil
+}
+
+func (p *offlineProvider) CompleteChat(ctx context.Context, messages []ai.Message) (string, error) {
+	return p.query, nil
+}
+
+func (p *offlineProvider) Name() string  { return "offline" }
+func (p *offlineProvider) Model() string { return "skeleton" }
@@ -0,0 +1,69 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestOfflineSkeleton(t *testing.T) {
+	tests := []struct {
+		table, schema, want string
+	}{
+		{"StormEvents", "", "StormEvents\n| take 10"},
+		{"StormEvents", "State, StartTime:datetime , ,DamageProperty:long", "StormEvents\n| project State, StartTime, DamageProperty\n| take 10"},
+		{"My Table", "Event Name:string", "['My Table']\n| project ['Event Name']\n| take 10"},
+	}
+	for _, tt := range tests {
+		if got := offlineSkeleton(tt.table, tt.schema); got != tt.want {
+			t.Errorf("offlineSkeleton(%q, %q) = %q, want %q", tt.table, tt.schema, got, tt.want)
+		}
+	}
+}
+
+func TestRunGenerate_OfflineRejects(t *testing.T) {
+	defer func(o, s bool, table, file string) {
+		generateOffline, generateSchemaFromCluster, generateTable, generateInputFile = o, s, table, file
+	}(generateOffline, generateSchemaFromCluster, generateTable, generateInputFile)
+
+	generateOffline, generateTable = true, "StormEvents"
+
+	generateSchemaFromCluster = true
+	if err := runGenerate(generateCmd, nil); err == nil || !strings.Contains(err.Error(), "--schema-from-cluster") {
+		t.Errorf("expected --schema-from-cluster to be rejected, got %v", err)
+	}
+	generateSchemaFromCluster = false
+
+	if err := runGenerate(generateCmd, []string{"count events by state"}); err == nil || !strings.Contains(err.Error(), "no description") {
+		t.Errorf("expected a description argument to be rejected, got %v", err)
+	}
+
+	generateInputFile = "description.txt"
+	if err := runGenerate(generateCmd, nil); err == nil || !strings.Contains(err.Error(), "no description") {
+		t.Errorf("expected -f to be rejected, got %v", err)
+	}
+}
+
+func TestOfflineProvider_Validates(t *testing.T) {
+	// kqlparser doesn't accept ['quoted'] names, so these are plain
+	table, schema := "StormEvents", "State:string, EventType, StartTime:datetime"
+	provider := &offlineProvider{query: offlineSkeleton(table, schema)}
+
+	valCfg := ai.DefaultValidationConfig()
+	valCfg.Retries = 0
+	valCfg.Semantic, valCfg.Table, valCfg.Schema = true, table, schema
+
+	result, err := ai.GenerateWithValidation(context.Background(), provider, ai.GenerateRequest{Table: table, Schema: schema}, valCfg, 0,
+		func(ai.GenerateRequest) string { return "" }, extractKQL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Query != provider.query {
+		t.Errorf("expected the skeleton to pass validation, got %+v", result)
+	}
+}
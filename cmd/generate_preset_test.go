@@ -0,0 +1,70 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestBuildValidationConfig_Retries(t *testing.T) {
+	oldPreset, oldRetries := generatePreset, generateRetries
+	defer func() { generatePreset, generateRetries = oldPreset, oldRetries }()
+	generatePreset, generateRetries = "", 2
+
+	// Without --retries, the config file value stands
+	base := ai.DefaultValidationConfig()
+	base.Retries = 4
+	cfg, _ := buildValidationConfig(base, false)
+	if cfg.Retries != 4 {
+		t.Errorf("expected the config's 4 retries, got %d", cfg.Retries)
+	}
+
+	generatePreset = "thorough"
+	cfg, _ = buildValidationConfig(ai.DefaultValidationConfig(), false)
+	if cfg.Retries != 5 {
+		t.Errorf("expected the preset's 5 retries, got %d", cfg.Retries)
+	}
+
+	// An explicit --retries wins over both
+	generateRetries = 1
+	cfg, _ = buildValidationConfig(base, true)
+	if cfg.Retries != 1 {
+		t.Errorf("expected --retries to override the preset, got %d", cfg.Retries)
+	}
+}
+
+func TestBuildValidationConfig_UnknownPreset(t *testing.T) {
+	oldPreset := generatePreset
+	defer func() { generatePreset = oldPreset }()
+
+	generatePreset = "fastest"
+	if _, err := buildValidationConfig(ai.DefaultValidationConfig(), false); err == nil || !strings.Contains(err.Error(), `unknown preset "fastest"`) {
+		t.Errorf("expected an unknown preset error, got %v", err)
+	}
+}
+
+func TestWritePresets(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePresets(&buf, ai.DefaultValidationConfig()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"minimal    No retries",
+		"retries=0 strict=false feedback=errors,progressive\n",
+		"retries=5 strict=false feedback=errors,hints,examples,progressive\n",
+		"retries=3 strict=true feedback=errors,hints,examples,progressive\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "minimal") > strings.Index(out, "strict ") {
+		t.Errorf("expected presets in order, got:\n%s", out)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/ast"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+// renderChartTypes lists the chart types accepted by --append-render
+// (in addition to "auto").
+var renderChartTypes = []string{
+	"table", "timechart", "barchart", "columnchart", "linechart",
+	"piechart", "areachart", "scatterchart", "card",
+}
+
+// validateRenderChoice checks an --append-render value.
+func validateRenderChoice(choice string) error {
+	if choice == "" || choice == "auto" {
+		return nil
+	}
+	for _, t := range renderChartTypes {
+		if choice == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --append-render value %q (supported: auto, %s)",
+		choice, strings.Join(renderChartTypes, ", "))
+}
+
+// appendRender appends a render operator to a validated query.
+//
+// With choice "auto" the chart type is picked from the query shape (see
+// chooseRenderType). Queries that already render are returned unchanged.
+// The result is re-validated; if appending the operator breaks the query,
+// the original query is returned along with an error.
+func appendRender(query, choice string) (string, error) {
+	result := kqlparser.Parse("generated.kql", query)
+	if len(result.Errors) > 0 {
+		return query, fmt.Errorf("query has syntax errors, not appending render")
+	}
+	if hasRender(result.AST) {
+		return query, nil
+	}
+
+	chart := choice
+	if chart == "auto" {
+		chart = chooseRenderType(result.AST)
+	}
+
+	rendered := strings.TrimRight(query, " \t\n;") + "\n| render " + chart
+	if check := kqlparser.Parse("generated.kql", rendered); len(check.Errors) > 0 {
+		return query, fmt.Errorf("appending render %s produced invalid query: %v", chart, check.Errors[0])
+	}
+
+	return rendered, nil
+}
+
+// chooseRenderType picks a chart type from the parse tree:
+//   - timechart when the final summarize groups by bin() over a timespan
+//   - barchart when the final summarize groups by one or more categories
+//   - table otherwise
+func chooseRenderType(script *ast.Script) string {
+	var last *ast.SummarizeOp
+	ast.Inspect(script, func(n ast.Node) bool {
+		if s, ok := n.(*ast.SummarizeOp); ok {
+			last = s
+		}
+		return true
+	})
+
+	if last == nil || len(last.GroupBy) == 0 {
+		return "table"
+	}
+
+	for _, g := range last.GroupBy {
+		if isTimeBin(g.Expr) {
+			return "timechart"
+		}
+	}
+
+	return "barchart"
+}
+
+// isTimeBin reports whether expr is bin(x, <timespan>) or bin_at(x, <timespan>, ...).
+func isTimeBin(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) < 2 {
+		return false
+	}
+	fn, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	name := strings.ToLower(fn.Name)
+	if name != "bin" && name != "bin_at" {
+		return false
+	}
+	lit, ok := call.Args[1].(*ast.BasicLit)
+	return ok && lit.Kind == token.TIMESPAN
+}
+
+// hasRender reports whether the script already contains a render operator.
+func hasRender(script *ast.Script) bool {
+	found := false
+	ast.Inspect(script, func(n ast.Node) bool {
+		if _, ok := n.(*ast.RenderOp); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
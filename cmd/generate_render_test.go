@@ -0,0 +1,92 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kqlparser"
+)
+
+func TestChooseRenderType(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"no summarize", "T | where x > 10 | take 10", "table"},
+		{"summarize without by", "T | summarize count()", "table"},
+		{"time bucketed", "T | summarize count() by bin(Timestamp, 1h)", "timechart"},
+		{"time bucketed with category", "T | summarize count() by State, bin(StartTime, 1d)", "timechart"},
+		{"bin_at", "T | summarize count() by bin_at(Timestamp, 1h, datetime(2024-01-01))", "timechart"},
+		{"numeric bin", "T | summarize count() by bin(Size, 100)", "barchart"},
+		{"category counts", "T | summarize count() by State", "barchart"},
+		{"last summarize wins", "T | summarize count() by State, bin(StartTime, 1h) | summarize avg(count_) by State", "barchart"},
+		{"let statement", "let t = ago(1d); T | where Timestamp > t | summarize count() by bin(Timestamp, 5m)", "timechart"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := kqlparser.Parse("test.kql", tt.query)
+			if len(result.Errors) > 0 {
+				t.Fatalf("unexpected parse errors: %v", result.Errors)
+			}
+			if got := chooseRenderType(result.AST); got != tt.want {
+				t.Errorf("chooseRenderType(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendRender(t *testing.T) {
+	got, err := appendRender("T | summarize count() by bin(Timestamp, 1h)", "auto")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(got, "| render timechart") {
+		t.Errorf("expected timechart render, got %q", got)
+	}
+
+	got, err = appendRender("T | summarize count() by State", "piechart")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(got, "| render piechart") {
+		t.Errorf("expected explicit piechart render, got %q", got)
+	}
+}
+
+func TestAppendRender_ExistingRender(t *testing.T) {
+	query := "T | summarize count() by State | render barchart"
+	got, err := appendRender(query, "auto")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != query {
+		t.Errorf("expected query unchanged, got %q", got)
+	}
+}
+
+func TestAppendRender_InvalidQuery(t *testing.T) {
+	query := "T | where (("
+	got, err := appendRender(query, "auto")
+	if err == nil {
+		t.Error("expected error for invalid query")
+	}
+	if got != query {
+		t.Errorf("expected original query, got %q", got)
+	}
+}
+
+func TestValidateRenderChoice(t *testing.T) {
+	for _, choice := range []string{"", "auto", "table", "timechart", "barchart"} {
+		if err := validateRenderChoice(choice); err != nil {
+			t.Errorf("validateRenderChoice(%q) unexpected error: %v", choice, err)
+		}
+	}
+	if err := validateRenderChoice("hologram"); err == nil {
+		t.Error("expected error for unknown chart type")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kusto"
+	"github.com/cloudygreybeard/kql/pkg/link"
+)
+
+// resolveGenerateSchema returns the schema for the prompt from --schema,
+// --schema-file, or --schema-from-cluster, whichever was given. Only the
+// cluster lookup needs the network; newClient creates its client.
+func resolveGenerateSchema(ctx context.Context, fileCfg *ai.FileConfig, newClient func(cluster string) *kusto.Client) (string, error) {
+	given := 0
+	for _, set := range []bool{generateSchema != "", generateSchemaFile != "", generateSchemaFromCluster} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		return "", fmt.Errorf("use only one of --schema, --schema-file, and --schema-from-cluster")
+	}
+
+	switch {
+	case generateSchemaFile != "":
+		data, err := os.ReadFile(generateSchemaFile)
+		if err != nil {
+			return "", fmt.Errorf("reading schema file: %w", err)
+		}
+		schema := parseSchemaList(string(data))
+		if schema == "" {
+			return "", fmt.Errorf("schema file %s lists no columns", generateSchemaFile)
+		}
+		return schema, nil
+
+	case generateSchemaFromCluster:
+		if generateTable == "" {
+			return "", fmt.Errorf("--schema-from-cluster requires --table")
+		}
+		cfg, err := resolveLinkConfig(link.Config{Cluster: generateCluster, Database: generateDatabase}, fileCfg, os.Getenv)
+		if err != nil {
+			return "", err
+		}
+		schema, err := newClient(cfg.Cluster).TableSchema(ctx, cfg.Database, generateTable)
+		if err != nil {
+			return "", err
+		}
+		return parseSchemaList(schema), nil
+	}
+
+	return generateSchema, nil
+}
+
+// parseSchemaList joins a comma- or newline-separated column list into the
+// form --schema takes. Blank entries and // comment lines are skipped.
+func parseSchemaList(text string) string {
+	var columns []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+		for _, col := range strings.Split(line, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				columns = append(columns, col)
+			}
+		}
+	}
+	return strings.Join(columns, ", ")
+}
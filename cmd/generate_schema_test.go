@@ -0,0 +1,126 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kusto"
+)
+
+// setSchemaFlags sets the schema flags for one test.
+func setSchemaFlags(t *testing.T, table, schema, file string, fromCluster bool, cluster, database string) {
+	t.Helper()
+	table0, schema0, file0 := generateTable, generateSchema, generateSchemaFile
+	fromCluster0, cluster0, database0 := generateSchemaFromCluster, generateCluster, generateDatabase
+	t.Cleanup(func() {
+		generateTable, generateSchema, generateSchemaFile = table0, schema0, file0
+		generateSchemaFromCluster, generateCluster, generateDatabase = fromCluster0, cluster0, database0
+	})
+	generateTable, generateSchema, generateSchemaFile = table, schema, file
+	generateSchemaFromCluster = fromCluster
+	generateCluster, generateDatabase = cluster, database
+}
+
+func noClient(t *testing.T) func(string) *kusto.Client {
+	return func(string) *kusto.Client {
+		t.Error("expected no cluster request")
+		return nil
+	}
+}
+
+func TestParseSchemaList(t *testing.T) {
+	text := "// StormEvents columns\nState:string, StartTime:datetime\nDamageProperty\n\n,EventType,\n"
+	if got := parseSchemaList(text); got != "State:string, StartTime:datetime, DamageProperty, EventType" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestResolveGenerateSchema_Flag(t *testing.T) {
+	setSchemaFlags(t, "T", "A, B", "", false, "", "")
+	schema, err := resolveGenerateSchema(context.Background(), nil, noClient(t))
+	if err != nil || schema != "A, B" {
+		t.Errorf("schema = %q, err = %v", schema, err)
+	}
+}
+
+func TestResolveGenerateSchema_File(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "storm.schema")
+	if err := os.WriteFile(name, []byte("State\nStartTime\nDamageProperty\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setSchemaFlags(t, "StormEvents", "", name, false, "", "")
+
+	schema, err := resolveGenerateSchema(context.Background(), nil, noClient(t))
+	if err != nil || schema != "State, StartTime, DamageProperty" {
+		t.Errorf("schema = %q, err = %v", schema, err)
+	}
+
+	if err := os.WriteFile(name, []byte("\n// nothing here\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveGenerateSchema(context.Background(), nil, noClient(t)); err == nil || !strings.Contains(err.Error(), "lists no columns") {
+		t.Errorf("expected an empty file error, got %v", err)
+	}
+}
+
+func TestResolveGenerateSchema_Cluster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Tables":[{"Columns":[{"ColumnName":"Schema","DataType":"String"}],"Rows":[["State:string,StartTime:datetime"]]}]}`))
+	}))
+	defer server.Close()
+
+	// The database comes from the config file
+	fileCfg := &ai.FileConfig{}
+	fileCfg.Link.Database = "Samples"
+	setSchemaFlags(t, "StormEvents", "", "", true, "help", "")
+
+	var cluster string
+	newClient := func(c string) *kusto.Client {
+		cluster = c
+		return &kusto.Client{Endpoint: server.URL, TokenSource: func(string) (string, error) { return "tok", nil }}
+	}
+	schema, err := resolveGenerateSchema(context.Background(), fileCfg, newClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema != "State:string, StartTime:datetime" || cluster != "help" {
+		t.Errorf("schema = %q from cluster %q", schema, cluster)
+	}
+}
+
+func TestResolveGenerateSchema_Errors(t *testing.T) {
+	t.Setenv("KQL_LINK_CLUSTER", "")
+	t.Setenv("KQL_LINK_DATABASE", "")
+	tests := []struct {
+		name        string
+		table       string
+		schema      string
+		file        string
+		fromCluster bool
+		cluster     string
+		want        string
+	}{
+		{"two sources", "T", "A", "cols.txt", false, "", "only one of"},
+		{"no table", "", "", "", true, "help", "requires --table"},
+		{"no database", "T", "", "", true, "help", "database is required"},
+		{"missing file", "T", "", filepath.Join(t.TempDir(), "missing"), false, "", "reading schema file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSchemaFlags(t, tt.table, tt.schema, tt.file, tt.fromCluster, tt.cluster, "")
+			_, err := resolveGenerateSchema(context.Background(), nil, noClient(t))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
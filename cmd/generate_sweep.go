@@ -0,0 +1,125 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// sweepResult is the outcome of one generation in a temperature sweep.
+type sweepResult struct {
+	Temperature float32            `json:"temperature"`
+	Query       string             `json:"query"`
+	Valid       bool               `json:"valid"`
+	Attempts    int                `json:"attempts"`
+	Errors      []sweepResultError `json:"errors,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// sweepResultError is a validation error in JSON form.
+type sweepResultError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// parseTemperatureList parses a comma-separated list such as "0,0.3,0.6".
+func parseTemperatureList(s string) ([]float32, error) {
+	var temps []float32
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid temperature %q in --temperature-sweep", field)
+		}
+		if v < 0 || v > ai.MaxTemperature {
+			return nil, fmt.Errorf("temperature %s out of range (0.0-%.1f)", field, ai.MaxTemperature)
+		}
+		temps = append(temps, float32(v))
+	}
+	if len(temps) == 0 {
+		return nil, fmt.Errorf("--temperature-sweep requires at least one temperature")
+	}
+	return temps, nil
+}
+
+// runTemperatureSweep generates one query per temperature. Providers fix
+// their temperature at construction, so newProvider is called once per
+// temperature. A provider error is recorded in that result and the sweep
+// continues.
+func runTemperatureSweep(
+	ctx context.Context,
+	temps []float32,
+	newProvider func(temp float32) (ai.Provider, error),
+	req ai.GenerateRequest,
+	valCfg ai.ValidationConfig,
+	buildPrompt func(ai.GenerateRequest) string,
+	extract func(string) string,
+) []sweepResult {
+	results := make([]sweepResult, 0, len(temps))
+	for _, temp := range temps {
+		r := sweepResult{Temperature: temp}
+
+		provider, err := newProvider(temp)
+		if err != nil {
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+
+		result, err := ai.GenerateWithValidation(ctx, provider, req, valCfg, temp, buildPrompt, extract, nil, nil)
+		if err != nil {
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+
+		r.Query = result.Query
+		r.Valid = result.Valid
+		r.Attempts = result.Attempts
+		for _, e := range result.Errors {
+			r.Errors = append(r.Errors, sweepResultError{Line: e.Line, Column: e.Column, Message: e.Message})
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// writeSweepResults prints sweep results as a labeled list or JSON.
+func writeSweepResults(w io.Writer, results []sweepResult, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "text":
+		for i, r := range results {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			if r.Error != "" {
+				fmt.Fprintf(w, "// temperature=%.2f error: %s\n", r.Temperature, r.Error)
+				continue
+			}
+			fmt.Fprintf(w, "// temperature=%.2f valid=%t attempts=%d\n", r.Temperature, r.Valid, r.Attempts)
+			for _, e := range r.Errors {
+				fmt.Fprintf(w, "// line %d, col %d: %s\n", e.Line, e.Column, e.Message)
+			}
+			fmt.Fprintln(w, r.Query)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
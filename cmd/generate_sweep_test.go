@@ -0,0 +1,139 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// tempProvider returns a response that depends on its temperature.
+type tempProvider struct {
+	temp     float32
+	response func(temp float32) string
+}
+
+func (p *tempProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.response(p.temp), nil
+}
+
+func (p *tempProvider) CompleteChat(ctx context.Context, messages []ai.Message) (string, error) {
+	return p.response(p.temp), nil
+}
+
+func (p *tempProvider) Name() string  { return "fake" }
+func (p *tempProvider) Model() string { return "fake-model" }
+
+func TestParseTemperatureList(t *testing.T) {
+	temps, err := parseTemperatureList("0.0, 0.3,0.6,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(temps) != 3 || temps[0] != 0 || temps[1] != 0.3 || temps[2] != 0.6 {
+		t.Errorf("unexpected temperatures: %v", temps)
+	}
+
+	for _, bad := range []string{"", ",", "hot", "-0.1", "1.5", "2.5"} {
+		if _, err := parseTemperatureList(bad); err == nil {
+			t.Errorf("parseTemperatureList(%q) expected error", bad)
+		}
+	}
+}
+
+func TestRunTemperatureSweep(t *testing.T) {
+	var created []float32
+	newProvider := func(temp float32) (ai.Provider, error) {
+		created = append(created, temp)
+		return &tempProvider{temp: temp, response: func(temp float32) string {
+			if temp > 0.5 {
+				return "T | where ((" // higher temperature, broken output
+			}
+			return "T | take 10"
+		}}, nil
+	}
+
+	valCfg := ai.DefaultValidationConfig()
+	valCfg.Retries = 0
+
+	results := runTemperatureSweep(context.Background(), []float32{0.0, 0.8}, newProvider,
+		ai.GenerateRequest{Prompt: "ten rows"}, valCfg,
+		func(r ai.GenerateRequest) string { return r.Prompt },
+		extractKQL,
+	)
+
+	if len(created) != 2 || created[0] != 0.0 || created[1] != 0.8 {
+		t.Errorf("expected one provider per temperature, got %v", created)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !results[0].Valid || results[0].Query != "T | take 10" || results[0].Attempts != 1 {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[1].Valid || len(results[1].Errors) == 0 || results[1].Attempts != 1 {
+		t.Errorf("unexpected second result: %+v", results[1])
+	}
+}
+
+func TestRunTemperatureSweep_ProviderError(t *testing.T) {
+	newProvider := func(temp float32) (ai.Provider, error) {
+		if temp == 0.3 {
+			return nil, errors.New("unavailable")
+		}
+		return &tempProvider{temp: temp, response: func(float32) string { return "T | count" }}, nil
+	}
+
+	results := runTemperatureSweep(context.Background(), []float32{0.3, 0.6}, newProvider,
+		ai.GenerateRequest{}, ai.DefaultValidationConfig(),
+		func(ai.GenerateRequest) string { return "" }, extractKQL)
+
+	if results[0].Error != "unavailable" {
+		t.Errorf("expected provider error to be recorded, got %+v", results[0])
+	}
+	if !results[1].Valid {
+		t.Errorf("expected sweep to continue after an error, got %+v", results[1])
+	}
+}
+
+func TestWriteSweepResults(t *testing.T) {
+	results := []sweepResult{
+		{Temperature: 0, Query: "T | take 10", Valid: true, Attempts: 1},
+		{Temperature: 0.6, Query: "T | where ((", Attempts: 1, Errors: []sweepResultError{{Line: 1, Column: 12, Message: "unexpected EOF"}}},
+	}
+
+	var text bytes.Buffer
+	if err := writeSweepResults(&text, results, "text"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"// temperature=0.00 valid=true attempts=1\nT | take 10\n",
+		"// temperature=0.60 valid=false attempts=1\n// line 1, col 12: unexpected EOF\nT | where ((\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("expected text output to contain %q, got:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeSweepResults(&out, results, "json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded []sweepResult
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].Temperature != 0.6 || decoded[1].Valid {
+		t.Errorf("unexpected decoded results: %+v", decoded)
+	}
+
+	if err := writeSweepResults(&out, results, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
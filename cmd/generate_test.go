@@ -0,0 +1,33 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestBuildGeneratePrompt_Style(t *testing.T) {
+	description := "count events by state"
+
+	tagged := buildGeneratePrompt(description, "StormEvents", "", ai.PromptStyleTagged)
+	if !strings.Contains(tagged, "<description>\n"+description+"\n</description>") {
+		t.Errorf("expected tagged prompt to wrap the description, got:\n%s", tagged)
+	}
+
+	plain := buildGeneratePrompt(description, "StormEvents", "", ai.PromptStyleDefault)
+	if strings.Contains(plain, "<description>") {
+		t.Error("expected default prompt not to use tags")
+	}
+	if !strings.Contains(plain, "Description: "+description) {
+		t.Errorf("expected default prompt to label the description, got:\n%s", plain)
+	}
+
+	terse := buildGeneratePrompt(description, "StormEvents", "", ai.PromptStyleTerse)
+	if len(terse) >= len(plain) || !strings.Contains(terse, "Target table: StormEvents") {
+		t.Errorf("expected a shorter prompt with the table, got:\n%s", terse)
+	}
+}
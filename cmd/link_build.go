@@ -15,20 +15,43 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strings"
+	"os/exec"
+	"runtime"
+	"time"
 
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/inputsource"
 	"github.com/cloudygreybeard/kql/pkg/link"
+	"github.com/cloudygreybeard/kql/pkg/qr"
+	"github.com/cloudygreybeard/kqlparser"
 	"github.com/spf13/cobra"
 )
 
 var (
-	buildCluster  string
-	buildDatabase string
-	buildBaseURL  string
-	buildFile     string
+	buildCluster    string
+	buildClusterURI string
+	buildDatabase   string
+	buildBaseURL    string
+	buildFile       string
+	buildPrintSize  bool
+	buildValidate   bool
+	buildFix        bool
+	buildFromLink   string
+	buildOpen       bool
+	buildClipboard  bool
+	buildQR         bool
+	buildQRFile     string
+)
+
+// Limits for --fix, matching the kql fix defaults.
+const (
+	linkFixRetries = 2
+	linkFixTimeout = 60 * time.Second
 )
 
 var linkBuildCmd = &cobra.Command{
@@ -40,13 +63,50 @@ that opens directly in Azure Data Explorer.
 The query can be provided via:
   - Positional argument (for short queries)
   - File (-f/--file flag)
-  - Standard input (pipe or redirect)`,
+  - Clipboard (--paste) or an editor (--editor)
+  - Standard input (pipe or redirect)
+
+The cluster and database not given as flags are read from the
+KQL_LINK_CLUSTER and KQL_LINK_DATABASE environment variables, then, like
+the other settings (cloud, base_url, max_length), from the link section of
+~/.kql/config.yaml. With --from-link, the cluster and database of an
+existing deep link take precedence over the environment and config file,
+but not over -c/-d.
+
+--validate refuses to build a link for a query with syntax errors. --fix
+instead repairs the query with the AI fix flow (as 'kql fix' does) and
+links the fixed query, noting the changes on stderr. --fix calls a model,
+so it is never done unless requested.
+
+--cluster-uri takes the cluster as a URI or connection string, such as
+https://help.kusto.windows.net, in place of -c. Public cloud hosts
+(*.kusto.windows.net) become their short name; other hosts, such as Fabric's
+*.kusto.fabric.microsoft.com, are used whole.
+
+--open also opens the link with the system's default handler for URLs,
+usually a browser. The URL is still printed, so piping works; a link is
+only opened when --open is given, even if stdout is a terminal. If no
+opener is available, a warning is printed and the command still succeeds.
+--clipboard copies the link to the clipboard the same way, using pbcopy,
+clip, wl-copy, xclip, or xsel.
+
+--qr prints the link as a QR code, for a phone to scan, in place of the
+URL. --qr-file writes the code to a PNG file instead, and the URL is still
+printed. A QR code holds at most 2953 bytes; longer links need
+'kql link shorten'.`,
 	Example: `  # From stdin
   echo 'StormEvents | take 10' | kql link build -c help -d Samples
 
+  # Using the cluster and database from config or environment
+  export KQL_LINK_CLUSTER=help KQL_LINK_DATABASE=Samples
+  echo 'StormEvents | take 10' | kql link build
+
   # From file
   kql link build -c mycluster.westeurope -d mydb -f query.kql
 
+  # With the cluster given as its URI
+  kql link build --cluster-uri https://help.kusto.windows.net -d Samples -f query.kql
+
   # As argument (for short queries)
   kql link build -c help -d Samples "print 'hello'"
 
@@ -56,84 +116,354 @@ The query can be provided via:
   | where StartTime > ago(7d)
   | summarize count() by State
   | top 10 by count_
-  EOF`,
+  EOF
+
+  # Show compression statistics (on stderr)
+  kql link build -c help -d Samples --print-size -f query.kql
+
+  # Link a new query to the same cluster and database as an existing link
+  kql link build --from-link "$OLD_LINK" -f query.kql
+
+  # Open the link in the browser as well
+  kql link build -c help -d Samples --open -f query.kql
+
+  # Copy the link to paste into chat
+  kql link build -c help -d Samples --clipboard -f query.kql
+
+  # Show a QR code to scan with a phone
+  kql link build -c help -d Samples --qr "StormEvents | take 10"
+
+  # Repair syntax errors with AI before linking
+  echo "StormEvents | summarize count( by State" | kql link build -c help -d Samples --fix`,
 	RunE: runLinkBuild,
 }
 
 func init() {
 	linkCmd.AddCommand(linkBuildCmd)
 
-	linkBuildCmd.Flags().StringVarP(&buildCluster, "cluster", "c", "", "Kusto cluster name (required)")
-	linkBuildCmd.Flags().StringVarP(&buildDatabase, "database", "d", "", "Database name (required)")
-	linkBuildCmd.Flags().StringVarP(&buildBaseURL, "base-url", "b", link.DefaultBaseURL, "Base URL for deep links")
+	linkBuildCmd.Flags().StringVarP(&buildCluster, "cluster", "c", "", "Kusto cluster name (default from KQL_LINK_CLUSTER or config)")
+	linkBuildCmd.Flags().StringVar(&buildClusterURI, "cluster-uri", "", "Kusto cluster URI or connection string, e.g. https://help.kusto.windows.net (instead of -c)")
+	linkBuildCmd.Flags().StringVarP(&buildDatabase, "database", "d", "", "Database name (default from KQL_LINK_DATABASE or config)")
+	linkBuildCmd.Flags().StringVarP(&buildBaseURL, "base-url", "b", "", "Base URL for deep links (default "+link.DefaultBaseURL+")")
 	linkBuildCmd.Flags().StringVarP(&buildFile, "file", "f", "", "Read query from file")
+	addInputSourceFlags(linkBuildCmd)
+	linkBuildCmd.Flags().BoolVar(&buildPrintSize, "print-size", false, "Print size and compression statistics to stderr")
+	linkBuildCmd.Flags().StringVar(&buildFromLink, "from-link", "", "Take the cluster and database from an existing deep link")
+	linkBuildCmd.Flags().BoolVar(&buildValidate, "validate", false, "Fail if the query has syntax errors")
+	linkBuildCmd.Flags().BoolVar(&buildFix, "fix", false, "Repair syntax errors with AI before building the link")
+	linkBuildCmd.Flags().BoolVar(&buildOpen, "open", false, "Also open the link in the default browser")
+	linkBuildCmd.Flags().BoolVar(&buildClipboard, "clipboard", false, "Also copy the link to the clipboard")
+	linkBuildCmd.Flags().BoolVar(&buildQR, "qr", false, "Print the link as a QR code instead of the URL")
+	linkBuildCmd.Flags().StringVar(&buildQRFile, "qr-file", "", "Also write the link as a QR code to this PNG file")
 
-	_ = linkBuildCmd.MarkFlagRequired("cluster")
-	_ = linkBuildCmd.MarkFlagRequired("database")
+	// Provider selection for --fix (reuse from explain)
+	linkBuildCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider for --fix (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = linkBuildCmd.RegisterFlagCompletionFunc("provider", completeProvider)
+	linkBuildCmd.Flags().StringVar(&aiModel, "model", "", "Model name for --fix")
 }
 
 func runLinkBuild(cmd *cobra.Command, args []string) error {
+	fileCfg, err := loadConfigFileOrWarn()
+	if err != nil {
+		return err
+	}
+	flagCfg := link.Config{Cluster: buildCluster, Database: buildDatabase, BaseURL: buildBaseURL}
+	if buildClusterURI != "" {
+		if buildCluster != "" {
+			return fmt.Errorf("--cluster-uri cannot be combined with --cluster")
+		}
+		if flagCfg.Cluster, err = link.ClusterFromURI(buildClusterURI); err != nil {
+			return err
+		}
+	}
+	if buildFromLink != "" {
+		flagCfg, err = applyTemplateLink(flagCfg, buildFromLink)
+		if err != nil {
+			return err
+		}
+	}
+	cfg, baseURL, err := resolveLinkTarget(flagCfg, fileCfg)
+	if err != nil {
+		return err
+	}
+
 	query, err := getInput(args, buildFile)
 	if err != nil {
 		return err
 	}
 
-	result, err := link.Build(query, buildCluster, buildDatabase, buildBaseURL)
+	if buildValidate || buildFix {
+		var newProvider func() (ai.Provider, error)
+		if buildFix {
+			newProvider = func() (ai.Provider, error) {
+				return ai.NewProvider(aiConfigFrom(fileCfg))
+			}
+		}
+		query, err = validateLinkQuery(query, newProvider, os.Stderr)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, stats, err := link.BuildWithStats(query, cfg.Cluster, cfg.Database, baseURL)
 	if err != nil {
 		return fmt.Errorf("build failed: %w", err)
 	}
 
-	fmt.Println(result)
-	return nil
-}
+	if cfg.MaxLength > 0 && stats.URLLength > cfg.MaxLength {
+		logf(logWarn, "Warning: link is %d chars, longer than max_length %d", stats.URLLength, cfg.MaxLength)
+	}
 
-// getInput reads input from positional args, file, or stdin (in that priority order).
-func getInput(args []string, filePath string) (string, error) {
-	return getInputFrom(args, filePath, os.Stdin, isTerminal)
-}
+	if buildPrintSize {
+		printBuildStats(os.Stderr, stats)
+	}
 
-// isTerminal checks if the given file is a terminal
-func isTerminal(f *os.File) bool {
-	stat, _ := f.Stat()
-	return (stat.Mode() & os.ModeCharDevice) != 0
+	if buildQR || buildQRFile != "" {
+		if err := writeLinkQR(os.Stdout, result, buildQR, buildQRFile); err != nil {
+			return err
+		}
+	}
+	if !buildQR {
+		fmt.Println(result)
+	}
+
+	if buildOpen {
+		if err := openURL(result); err != nil {
+			logf(logWarn, "Warning: could not open the link: %v", err)
+		}
+	}
+	if buildClipboard {
+		if err := copyToClipboard(result); err != nil {
+			logf(logWarn, "Warning: could not copy the link: %v", err)
+		} else {
+			logf(logNotice, "Copied the link to the clipboard")
+		}
+	}
+	return nil
 }
 
-// getInputFrom is the testable version of getInput
-func getInputFrom(args []string, filePath string, stdin io.Reader, isTerminalFunc func(*os.File) bool) (string, error) {
-	// Priority 1: positional argument
-	if len(args) > 0 {
-		return strings.TrimSpace(strings.Join(args, " ")), nil
+// qrScale is the pixels per module of --qr-file images.
+const qrScale = 8
+
+// writeLinkQR encodes url as a QR code, printing it to w with term and
+// writing it as a PNG to file if set.
+func writeLinkQR(w io.Writer, url string, term bool, file string) error {
+	// The lowest error correction fits the longest links, and a code on a
+	// screen or in a file is rarely damaged
+	code, err := qr.Encode([]byte(url), qr.L)
+	if errors.Is(err, qr.ErrTooLong) {
+		return fmt.Errorf("link is %d chars, too long for a QR code (at most %d); shorten it with 'kql link shorten'", len(url), qr.Capacity(40, qr.L))
+	}
+	if err != nil {
+		return err
 	}
 
-	// Priority 2: file
-	if filePath != "" {
-		data, err := os.ReadFile(filePath)
+	if file != "" {
+		// Written beside the file and renamed over it, so a failed write
+		// leaves no truncated PNG
+		out, err := openOutput(file, os.Stderr)
 		if err != nil {
-			return "", fmt.Errorf("reading file: %w", err)
+			return err
 		}
-		result := strings.TrimSpace(string(data))
-		if result == "" {
-			return "", fmt.Errorf("file is empty: %s", filePath)
+		err = code.WritePNG(out, qrScale)
+		if err != nil {
+			err = fmt.Errorf("writing %s: %w", file, err)
 		}
-		return result, nil
+		if err := out.finish(err); err != nil {
+			return err
+		}
+	}
+	if term {
+		fmt.Fprint(w, code)
+	}
+	return nil
+}
+
+// openURL opens url with the system's default handler, without waiting
+// for it to exit.
+var openURL = func(url string) error {
+	name, args := browserCommand(runtime.GOOS, url)
+	return exec.Command(name, args...).Start()
+}
+
+// copyToClipboard copies text to the system clipboard.
+var copyToClipboard = inputsource.WriteClipboard
+
+// browserCommand returns the command that opens url on goos.
+func browserCommand(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	default:
+		return "xdg-open", []string{url}
 	}
+}
 
-	// Priority 3: stdin (only if not a terminal)
-	if f, ok := stdin.(*os.File); ok {
-		if isTerminalFunc(f) {
-			return "", fmt.Errorf("no input provided (use -f <file>, stdin, or pass query as argument)")
-		}
+// linkFileConfig returns the link section of the config file, reading the
+// earlier default_cluster and default_database keys when cluster and
+// database are unset.
+func linkFileConfig(fileCfg *ai.FileConfig) link.Config {
+	if fileCfg == nil {
+		return link.Config{}
 	}
+	l := fileCfg.Link
+	cfg := link.Config{
+		Cluster:      l.Cluster,
+		Database:     l.Database,
+		Cloud:        l.Cloud,
+		BaseURL:      l.BaseURL,
+		MaxLength:    l.MaxLength,
+		ShortenerURL: l.Shortener.URL,
+	}
+	if cfg.Cluster == "" {
+		cfg.Cluster = l.DefaultCluster
+	}
+	if cfg.Database == "" {
+		cfg.Database = l.DefaultDatabase
+	}
+	return cfg
+}
+
+// applyLinkEnv fills the cluster and database not given as flags from
+// KQL_LINK_CLUSTER and KQL_LINK_DATABASE. Like applyAIEnv, it applies
+// ahead of the config file.
+func applyLinkEnv(cfg link.Config, getenv func(string) string) link.Config {
+	if cfg.Cluster == "" {
+		cfg.Cluster = getenv("KQL_LINK_CLUSTER")
+	}
+	if cfg.Database == "" {
+		cfg.Database = getenv("KQL_LINK_DATABASE")
+	}
+	return cfg
+}
 
-	data, err := io.ReadAll(stdin)
+// resolveLinkConfig layers flags over KQL_LINK_CLUSTER and
+// KQL_LINK_DATABASE, then over the config file.
+func resolveLinkConfig(flagCfg link.Config, fileCfg *ai.FileConfig, getenv func(string) string) (link.Config, error) {
+	cfg := link.MergeConfig(applyLinkEnv(flagCfg, getenv), linkFileConfig(fileCfg))
+
+	if cfg.Cluster == "" {
+		return cfg, fmt.Errorf("cluster is required: use --cluster, KQL_LINK_CLUSTER, or link.cluster in the config file")
+	}
+	if cfg.Database == "" {
+		return cfg, fmt.Errorf("database is required: use --database, KQL_LINK_DATABASE, or link.database in the config file")
+	}
+	return cfg, nil
+}
+
+// resolveLinkTarget resolves the link settings as resolveLinkConfig does
+// with the process environment, along with the base URL they select.
+func resolveLinkTarget(flagCfg link.Config, fileCfg *ai.FileConfig) (link.Config, string, error) {
+	cfg, err := resolveLinkConfig(flagCfg, fileCfg, os.Getenv)
 	if err != nil {
-		return "", fmt.Errorf("reading stdin: %w", err)
+		return cfg, "", err
 	}
+	baseURL, err := cfg.ResolveBaseURL()
+	return cfg, baseURL, err
+}
 
-	result := strings.TrimSpace(string(data))
-	if result == "" {
-		return "", fmt.Errorf("empty input from stdin")
+// applyTemplateLink fills the cluster and database not given as flags from
+// an existing deep link.
+func applyTemplateLink(flagCfg link.Config, templateURL string) (link.Config, error) {
+	_, cluster, database, err := link.ExtractAll(templateURL)
+	if err != nil {
+		return flagCfg, fmt.Errorf("reading --from-link: %w", err)
+	}
+	if flagCfg.Cluster == "" {
+		flagCfg.Cluster = cluster
 	}
+	if flagCfg.Database == "" {
+		flagCfg.Database = database
+	}
+	return flagCfg, nil
+}
 
-	return result, nil
+// validateLinkQuery checks that query parses. If it doesn't and
+// newProvider is set (--fix), the query is repaired with the fix loop and
+// a note of the changes is written to notes.
+func validateLinkQuery(query string, newProvider func() (ai.Provider, error), notes io.Writer) (string, error) {
+	parsed := kqlparser.Parse("input", query)
+	if len(parsed.Errors) == 0 {
+		return query, nil
+	}
+	if newProvider == nil {
+		return "", fmt.Errorf("query has %d syntax error(s), first: %v (use --fix to repair)", len(parsed.Errors), parsed.Errors[0])
+	}
+
+	provider, err := newProvider()
+	if err != nil {
+		return "", fmt.Errorf("creating AI provider: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkFixTimeout)
+	defer cancel()
+
+	outcome, err := runFixLoop(ctx, provider, query, parsed.Errors, linkFixRetries+1, 0, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(outcome.Errors) > 0 {
+		return "", fmt.Errorf("fix still has %d syntax error(s) after %d attempt(s), first: %v", len(outcome.Errors), outcome.Attempts, outcome.Errors[0])
+	}
+
+	fmt.Fprintf(notes, "Fixed %d syntax error(s) before building the link:\n", len(parsed.Errors))
+	for _, e := range parsed.Errors {
+		fmt.Fprintf(notes, "  - %v\n", e)
+	}
+	writeQueryDiff(notes, query, outcome.Query, "fixed", false)
+	return outcome.Query, nil
+}
+
+// printBuildStats writes deep link size statistics.
+func printBuildStats(w io.Writer, stats link.Stats) {
+	fmt.Fprintf(w, "Query:       %d bytes\n", stats.QueryBytes)
+	fmt.Fprintf(w, "Gzipped:     %d bytes\n", stats.GzipBytes)
+	fmt.Fprintf(w, "Base64:      %d bytes\n", stats.Base64Bytes)
+	fmt.Fprintf(w, "URL length:  %d chars\n", stats.URLLength)
+	fmt.Fprintf(w, "Compression: %.2fx\n", stats.CompressionRatio())
+}
+
+// inputPaste and inputEditor are set by --paste and --editor, which every
+// command reading its input through getInput accepts.
+var (
+	inputPaste  bool
+	inputEditor bool
+)
+
+// readClipboard and editInput read --paste and --editor input.
+var (
+	readClipboard = inputsource.ReadClipboard
+	editInput     = inputsource.Edit
+)
+
+// addInputSourceFlags adds --paste and --editor to cmd. The clipboard flag
+// is --paste because link build's --clipboard copies its output there.
+func addInputSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&inputPaste, "paste", false, "Read input from the clipboard")
+	cmd.Flags().BoolVar(&inputEditor, "editor", false, "Compose input in $VISUAL or $EDITOR")
+}
+
+// getInput reads input from positional args, file, clipboard, editor, or
+// stdin (in that priority order).
+func getInput(args []string, filePath string) (string, error) {
+	return getInputFrom(args, filePath, os.Stdin, isTerminal)
+}
+
+// isTerminal checks if the given file is a terminal
+func isTerminal(f *os.File) bool {
+	return inputsource.IsTerminal(f)
+}
+
+// getInputFrom is the testable version of getInput
+func getInputFrom(args []string, filePath string, stdin io.Reader, isTerminalFunc func(*os.File) bool) (string, error) {
+	if inputPaste && inputEditor {
+		return "", fmt.Errorf("use only one of --paste and --editor")
+	}
+	input, _, err := inputsource.Read(
+		inputsource.Spec{Args: args, File: filePath, Clipboard: inputPaste, Editor: inputEditor},
+		inputsource.Env{Stdin: stdin, IsTerminal: isTerminalFunc, ReadClipboard: readClipboard, Edit: editInput},
+	)
+	return input, err
 }
@@ -15,13 +15,21 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
 	"github.com/cloudygreybeard/kql/pkg/link"
 	"github.com/spf13/cobra"
 )
 
-var extractFile string
+var (
+	extractFile   string
+	extractPretty bool
+	extractJSON   bool
+)
 
 var linkExtractCmd = &cobra.Command{
 	Use:   "extract [URL]",
@@ -31,7 +39,14 @@ var linkExtractCmd = &cobra.Command{
 The URL can be provided via:
   - Positional argument
   - File (-f/--file flag)
-  - Standard input (pipe or redirect)`,
+  - Clipboard (--paste) or an editor (--editor)
+  - Standard input (pipe or redirect)
+
+By default the query is printed exactly as it was encoded. Use --pretty to
+reformat it with each pipe stage on its own line, keeping comments.
+
+Use --json to print the query with the link's cluster and database as a
+single-line JSON object.`,
 	Example: `  # As argument
   kql link extract "https://dataexplorer.azure.com/clusters/help/databases/Samples?query=..."
 
@@ -39,7 +54,13 @@ The URL can be provided via:
   echo 'https://dataexplorer.azure.com/...' | kql link extract
 
   # From file
-  kql link extract -f url.txt`,
+  kql link extract -f url.txt
+
+  # Reformat a query that was shared on one line
+  kql link extract --pretty "https://dataexplorer.azure.com/..."
+
+  # Query, cluster, and database for scripts
+  kql link extract --json -f url.txt | jq -r .cluster`,
 	RunE: runLinkExtract,
 }
 
@@ -47,6 +68,9 @@ func init() {
 	linkCmd.AddCommand(linkExtractCmd)
 
 	linkExtractCmd.Flags().StringVarP(&extractFile, "file", "f", "", "Read URL from file")
+	addInputSourceFlags(linkExtractCmd)
+	linkExtractCmd.Flags().BoolVar(&extractPretty, "pretty", false, "Reformat the extracted query for readability")
+	linkExtractCmd.Flags().BoolVar(&extractJSON, "json", false, "Print the query, cluster, and database as a JSON object")
 }
 
 func runLinkExtract(cmd *cobra.Command, args []string) error {
@@ -55,11 +79,52 @@ func runLinkExtract(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if extractJSON {
+		query, cluster, database, err := link.ExtractAll(input)
+		if err != nil {
+			return fmt.Errorf("extract failed: %w", err)
+		}
+		if extractPretty {
+			query = prettyQuery(query, os.Stderr)
+		}
+		return writeExtractJSON(os.Stdout, extractResult{Query: query, Cluster: cluster, Database: database})
+	}
+
 	query, err := link.Extract(input)
 	if err != nil {
 		return fmt.Errorf("extract failed: %w", err)
 	}
 
+	if extractPretty {
+		query = prettyQuery(query, os.Stderr)
+	}
 	fmt.Println(query)
 	return nil
 }
+
+// extractResult is the --json output of link extract.
+type extractResult struct {
+	Query    string `json:"query"`
+	Cluster  string `json:"cluster"`
+	Database string `json:"database"`
+}
+
+// writeExtractJSON writes r as one line of JSON. HTML escaping is off so
+// comparison operators in the query stay readable.
+func writeExtractJSON(w io.Writer, r extractResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(r)
+}
+
+// prettyQuery reformats an extracted query, keeping its comments and
+// literals. A query that cannot be formatted is returned unchanged, with a
+// warning written to w.
+func prettyQuery(query string, w io.Writer) string {
+	formatted, err := kqlfmt.Format(query)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not format query, printing as extracted: %v\n", err)
+		return query
+	}
+	return formatted
+}
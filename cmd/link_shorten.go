@@ -0,0 +1,107 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/link"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shortenCluster  string
+	shortenDatabase string
+	shortenBaseURL  string
+	shortenFile     string
+	shortenURL      string
+	shortenTimeout  int
+)
+
+var linkShortenCmd = &cobra.Command{
+	Use:   "shorten [QUERY]",
+	Short: "Build a deep link and shorten it",
+	Long: `Build a deep link from a KQL query, as 'kql link build' does, then shorten
+it with a URL shortener service.
+
+The shortener is any endpoint that accepts a POST of {"url": "<link>"} and
+responds with {"short": "<short link>"}. Set it with --shortener-url or
+link.shortener.url in ~/.kql/config.yaml. Without a shortener, the full link
+is printed.`,
+	Example: `  # Shorten with an explicit shortener
+  echo 'StormEvents | take 10' | kql link shorten -c help -d Samples --shortener-url https://short.example.com/api
+
+  # Using the shortener, cluster, and database from config
+  kql link shorten -f query.kql`,
+	RunE: runLinkShorten,
+}
+
+func init() {
+	linkCmd.AddCommand(linkShortenCmd)
+
+	linkShortenCmd.Flags().StringVarP(&shortenCluster, "cluster", "c", "", "Kusto cluster name (default from KQL_LINK_CLUSTER or config)")
+	linkShortenCmd.Flags().StringVarP(&shortenDatabase, "database", "d", "", "Database name (default from KQL_LINK_DATABASE or config)")
+	linkShortenCmd.Flags().StringVarP(&shortenBaseURL, "base-url", "b", "", "Base URL for deep links (default "+link.DefaultBaseURL+")")
+	linkShortenCmd.Flags().StringVarP(&shortenFile, "file", "f", "", "Read query from file")
+	addInputSourceFlags(linkShortenCmd)
+	linkShortenCmd.Flags().StringVar(&shortenURL, "shortener-url", "", "URL shortener endpoint (default from config)")
+	linkShortenCmd.Flags().IntVar(&shortenTimeout, "timeout", 10, "Shortener timeout in seconds")
+}
+
+func runLinkShorten(cmd *cobra.Command, args []string) error {
+	fileCfg, err := loadConfigFileOrWarn()
+	if err != nil {
+		return err
+	}
+	flagCfg := link.Config{
+		Cluster:      shortenCluster,
+		Database:     shortenDatabase,
+		BaseURL:      shortenBaseURL,
+		ShortenerURL: shortenURL,
+	}
+	cfg, err := resolveLinkConfig(flagCfg, fileCfg, os.Getenv)
+	if err != nil {
+		return err
+	}
+	baseURL, err := cfg.ResolveBaseURL()
+	if err != nil {
+		return err
+	}
+
+	query, err := getInput(args, shortenFile)
+	if err != nil {
+		return err
+	}
+
+	full, err := link.Build(query, cfg.Cluster, cfg.Database, baseURL)
+	if err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(shortenTimeout)*time.Second)
+	defer cancel()
+
+	return shortenLink(ctx, cfg.ShortenerURL, full, os.Stdout, os.Stderr)
+}
+
+// shortenLink prints the short form of full, or full itself with a note
+// when no shortener is configured.
+func shortenLink(ctx context.Context, endpoint, full string, out, notes io.Writer) error {
+	if endpoint == "" {
+		fmt.Fprintln(notes, "Note: no shortener configured (--shortener-url or link.shortener.url); printing the full link")
+		fmt.Fprintln(out, full)
+		return nil
+	}
+
+	short, err := link.NewShortener(endpoint).Shorten(ctx, full)
+	if err != nil {
+		return fmt.Errorf("shorten failed: %w", err)
+	}
+	fmt.Fprintln(out, short)
+	return nil
+}
@@ -15,10 +15,23 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/link"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 func TestGetInput_FromArgs(t *testing.T) {
@@ -110,6 +123,44 @@ func TestGetInputFrom_StdinReadError(t *testing.T) {
 	}
 }
 
+func TestGetInputFrom_PasteAndEditor(t *testing.T) {
+	defer func(p, e bool) { inputPaste, inputEditor = p, e }(inputPaste, inputEditor)
+	defer func(r, e func() (string, error)) { readClipboard, editInput = r, e }(readClipboard, editInput)
+	readClipboard = func() (string, error) { return "  pasted | take 1\n", nil }
+	editInput = func() (string, error) { return "edited | take 1\n", nil }
+	noTerminal := func(*os.File) bool { return true }
+
+	inputPaste, inputEditor = true, false
+	if got, err := getInputFrom(nil, "", os.Stdin, noTerminal); err != nil || got != "pasted | take 1" {
+		t.Errorf("--paste: got %q, %v", got, err)
+	}
+
+	inputPaste, inputEditor = false, true
+	if got, err := getInputFrom(nil, "", os.Stdin, noTerminal); err != nil || got != "edited | take 1" {
+		t.Errorf("--editor: got %q, %v", got, err)
+	}
+
+	// Arguments still come first
+	if got, err := getInputFrom([]string{"T"}, "", os.Stdin, noTerminal); err != nil || got != "T" {
+		t.Errorf("expected the argument over --editor, got %q, %v", got, err)
+	}
+
+	inputPaste, inputEditor = true, true
+	if _, err := getInputFrom(nil, "", os.Stdin, noTerminal); err == nil || !strings.Contains(err.Error(), "only one of") {
+		t.Errorf("expected an error for both flags, got %v", err)
+	}
+}
+
+func TestInputSourceFlags(t *testing.T) {
+	for _, c := range []*cobra.Command{convertCmd, explainCmd, fixCmd, generateCmd, linkBuildCmd, linkExtractCmd, linkShortenCmd, normalizeCmd, suggestCmd} {
+		for _, name := range []string{"file", "paste", "editor"} {
+			if c.Flags().Lookup(name) == nil {
+				t.Errorf("%s: missing --%s", c.Name(), name)
+			}
+		}
+	}
+}
+
 // errorReader is a reader that always returns an error
 type errorReader struct{}
 
@@ -209,3 +260,538 @@ func TestRunLinkBuild_EmptyCluster(t *testing.T) {
 		t.Error("expected error for empty cluster")
 	}
 }
+
+func TestRunLinkBuild_ClusterURI(t *testing.T) {
+	origCluster, origClusterURI, origDatabase, origOpen, origOpenURL := buildCluster, buildClusterURI, buildDatabase, buildOpen, openURL
+	defer func() {
+		buildCluster, buildClusterURI, buildDatabase, buildOpen, openURL = origCluster, origClusterURI, origDatabase, origOpen, origOpenURL
+	}()
+
+	var opened []string
+	openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	buildOpen = true
+	buildCluster = ""
+	buildClusterURI = "https://help.kusto.windows.net"
+	buildDatabase = "Samples"
+
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opened) != 1 || !strings.Contains(opened[0], "/clusters/help/databases/Samples") {
+		t.Errorf("expected a link to the help cluster, got %v", opened)
+	}
+
+	buildCluster = "help"
+	err := runLinkBuild(nil, []string{"StormEvents | take 10"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("expected an error for --cluster-uri with --cluster, got %v", err)
+	}
+}
+
+func TestPrintBuildStats(t *testing.T) {
+	stats := link.Stats{
+		QueryBytes:  300,
+		GzipBytes:   100,
+		Base64Bytes: 136,
+		URLLength:   220,
+	}
+
+	var buf bytes.Buffer
+	printBuildStats(&buf, stats)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Query:       300 bytes",
+		"Gzipped:     100 bytes",
+		"Base64:      136 bytes",
+		"URL length:  220 chars",
+		"Compression: 3.00x",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunLinkBuild_PrintSize(t *testing.T) {
+	origCluster, origDatabase, origPrintSize := buildCluster, buildDatabase, buildPrintSize
+	defer func() {
+		buildCluster, buildDatabase, buildPrintSize = origCluster, origDatabase, origPrintSize
+	}()
+
+	buildCluster = "help"
+	buildDatabase = "Samples"
+	buildPrintSize = true
+
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPrettyQuery(t *testing.T) {
+	var warn bytes.Buffer
+	got := prettyQuery("StormEvents | where State == 'TX'   | take 10", &warn)
+	want := "StormEvents\n| where State == 'TX'\n| take 10"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if warn.Len() != 0 {
+		t.Errorf("expected no warning, got %q", warn.String())
+	}
+}
+
+func TestPrettyQuery_KeepsComments(t *testing.T) {
+	var warn bytes.Buffer
+	got := prettyQuery("// Texas only\nStormEvents | where State == 'TX'  // state code\n| take 10", &warn)
+	for _, want := range []string{"// Texas only\n", "// state code", "| take 10"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
+
+func TestPrettyQuery_Unparsable(t *testing.T) {
+	var warn bytes.Buffer
+	query := "StormEvents | where State == 'TX"
+	if got := prettyQuery(query, &warn); got != query {
+		t.Errorf("expected the query as extracted, got %q", got)
+	}
+}
+
+func TestRunLinkExtract_Pretty(t *testing.T) {
+	origPretty := extractPretty
+	defer func() { extractPretty = origPretty }()
+
+	query := "StormEvents | where State == 'TX' | take 10"
+	url, err := link.Build(query, "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	// Plain extract keeps the exact text; --pretty reflows it
+	extracted, err := link.Extract(url)
+	if err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+	if extracted != query {
+		t.Errorf("expected plain extract to preserve %q, got %q", query, extracted)
+	}
+
+	extractPretty = true
+	if err := runLinkExtract(nil, []string{url}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWriteExtractJSON(t *testing.T) {
+	query := "StormEvents\n| where State == \"TX\" and DamageProperty > 0\n| take 10"
+	url, err := link.Build(query, "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	q, cluster, database, err := link.ExtractAll(url)
+	if err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeExtractJSON(&out, extractResult{Query: q, Cluster: cluster, Database: database}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if lines := strings.Count(out.String(), "\n"); lines != 1 {
+		t.Errorf("expected a single line of JSON, got %d lines: %q", lines, out.String())
+	}
+	if !strings.Contains(out.String(), "DamageProperty > 0") {
+		t.Errorf("expected comparison operators unescaped, got %q", out.String())
+	}
+
+	var got extractResult
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	want := extractResult{Query: query, Cluster: "help", Database: "Samples"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRunLinkExtract_JSONNeedsTarget(t *testing.T) {
+	origJSON := extractJSON
+	defer func() { extractJSON = origJSON }()
+
+	url, err := link.Build("print 1", "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	_, encoded, _ := strings.Cut(url, "?")
+
+	extractJSON = true
+	if err := runLinkExtract(nil, []string{"https://dataexplorer.azure.com/?" + encoded}); err == nil {
+		t.Error("expected error for a link without cluster and database")
+	}
+}
+
+func TestApplyTemplateLink(t *testing.T) {
+	template, err := link.Build("StormEvents | take 10", "oldcluster.westeurope", "Old DB", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	cfg, err := applyTemplateLink(link.Config{}, template)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Cluster != "oldcluster.westeurope" || cfg.Database != "Old DB" {
+		t.Errorf("expected target from template, got %+v", cfg)
+	}
+
+	// Explicit flags win over the template
+	cfg, err = applyTemplateLink(link.Config{Cluster: "newcluster"}, template)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Cluster != "newcluster" || cfg.Database != "Old DB" {
+		t.Errorf("expected -c to override the template, got %+v", cfg)
+	}
+
+	// The template takes precedence over the config file
+	fileCfg := &ai.FileConfig{}
+	fileCfg.Link.Cluster = "configcluster"
+	fileCfg.Link.Database = "configdb"
+	resolved, err := resolveLinkConfig(cfg, fileCfg, func(string) string { return "" })
+	if err != nil || resolved.Cluster != "newcluster" || resolved.Database != "Old DB" {
+		t.Errorf("expected template values to survive config merge, got %+v, %v", resolved, err)
+	}
+}
+
+func TestApplyTemplateLink_Invalid(t *testing.T) {
+	_, err := applyTemplateLink(link.Config{}, "https://dataexplorer.azure.com/clusters/help")
+	if err == nil || !strings.Contains(err.Error(), "--from-link") {
+		t.Errorf("expected descriptive --from-link error, got %v", err)
+	}
+}
+
+func TestResolveLinkConfig(t *testing.T) {
+	fileCfg := &ai.FileConfig{}
+	fileCfg.Link.Cluster = "help"
+	fileCfg.Link.Database = "Samples"
+
+	env := map[string]string{
+		"KQL_LINK_CLUSTER":  "envcluster",
+		"KQL_LINK_DATABASE": "envdb",
+	}
+	getenv := func(k string) string { return env[k] }
+	noenv := func(string) string { return "" }
+
+	tests := []struct {
+		name         string
+		flags        link.Config
+		fileCfg      *ai.FileConfig
+		getenv       func(string) string
+		wantCluster  string
+		wantDatabase string
+	}{
+		{"config defaults", link.Config{}, fileCfg, noenv, "help", "Samples"},
+		{"flags override config", link.Config{Cluster: "mycluster.westeurope", Database: "mydb"}, fileCfg, getenv, "mycluster.westeurope", "mydb"},
+		{"flag overrides one setting", link.Config{Database: "mydb"}, fileCfg, noenv, "help", "mydb"},
+		{"env before config", link.Config{}, fileCfg, getenv, "envcluster", "envdb"},
+		{"env without config", link.Config{}, nil, getenv, "envcluster", "envdb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := resolveLinkConfig(tt.flags, tt.fileCfg, tt.getenv)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Cluster != tt.wantCluster || cfg.Database != tt.wantDatabase {
+				t.Errorf("got %s/%s, want %s/%s", cfg.Cluster, cfg.Database, tt.wantCluster, tt.wantDatabase)
+			}
+		})
+	}
+}
+
+func TestResolveLinkConfig_LegacyKeys(t *testing.T) {
+	var fileCfg ai.FileConfig
+	data := "link:\n  default_cluster: help\n  default_database: Samples\n"
+	if err := yaml.Unmarshal([]byte(data), &fileCfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	noenv := func(string) string { return "" }
+
+	cfg, err := resolveLinkConfig(link.Config{}, &fileCfg, noenv)
+	if err != nil || cfg.Cluster != "help" || cfg.Database != "Samples" {
+		t.Errorf("expected default_cluster and default_database to be read, got %+v, %v", cfg, err)
+	}
+
+	fileCfg.Link.Cluster = "newcluster"
+	cfg, err = resolveLinkConfig(link.Config{}, &fileCfg, noenv)
+	if err != nil || cfg.Cluster != "newcluster" || cfg.Database != "Samples" {
+		t.Errorf("expected cluster to take precedence over default_cluster, got %+v, %v", cfg, err)
+	}
+}
+
+func TestResolveLinkConfig_Missing(t *testing.T) {
+	noenv := func(string) string { return "" }
+
+	if _, err := resolveLinkConfig(link.Config{Database: "Samples"}, nil, noenv); err == nil || !strings.Contains(err.Error(), "cluster is required") {
+		t.Errorf("expected missing cluster error, got %v", err)
+	}
+	if _, err := resolveLinkConfig(link.Config{Cluster: "help"}, nil, noenv); err == nil || !strings.Contains(err.Error(), "database is required") {
+		t.Errorf("expected missing database error, got %v", err)
+	}
+}
+
+func TestValidateLinkQuery_Valid(t *testing.T) {
+	newProvider := func() (ai.Provider, error) {
+		t.Fatal("expected no provider for a valid query")
+		return nil, nil
+	}
+	got, err := validateLinkQuery("StormEvents | take 10", newProvider, io.Discard)
+	if err != nil || got != "StormEvents | take 10" {
+		t.Errorf("expected query unchanged, got %q, %v", got, err)
+	}
+}
+
+func TestValidateLinkQuery_ValidateOnly(t *testing.T) {
+	_, err := validateLinkQuery("StormEvents | summarize count( by State", nil, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "--fix") {
+		t.Errorf("expected syntax error suggesting --fix, got %v", err)
+	}
+}
+
+func TestValidateLinkQuery_Fix(t *testing.T) {
+	broken := "StormEvents\n| summarize count( by State"
+	p := &fakeProvider{name: "fake", model: "fake", response: "```kql\nStormEvents\n| summarize count() by State\n```"}
+	newProvider := func() (ai.Provider, error) { return p, nil }
+
+	var notes bytes.Buffer
+	fixed, err := validateLinkQuery(broken, newProvider, &notes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fixed != "StormEvents\n| summarize count() by State" {
+		t.Errorf("unexpected fixed query: %q", fixed)
+	}
+	for _, want := range []string{"Fixed 1 syntax error(s)", "- | summarize count( by State", "+ | summarize count() by State"} {
+		if !strings.Contains(notes.String(), want) {
+			t.Errorf("expected note to contain %q, got:\n%s", want, notes.String())
+		}
+	}
+
+	// The link is built from the fixed query
+	url, err := link.Build(fixed, "help", "Samples", link.DefaultBaseURL)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	decoded, err := link.Extract(url)
+	if err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+	if decoded != fixed {
+		t.Errorf("expected link to carry the fixed query, got %q", decoded)
+	}
+}
+
+func TestValidateLinkQuery_FixFails(t *testing.T) {
+	p := &fakeProvider{name: "fake", model: "fake", response: "StormEvents | summarize count( by State"}
+	_, err := validateLinkQuery("StormEvents | summarize count( by State", func() (ai.Provider, error) { return p, nil }, io.Discard)
+	if err == nil {
+		t.Fatal("expected error when the fix still has syntax errors")
+	}
+	if p.calls != linkFixRetries+1 {
+		t.Errorf("expected %d attempts, got %d", linkFixRetries+1, p.calls)
+	}
+}
+
+func TestShortenLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"short":"https://s.example.com/abc"}`))
+	}))
+	defer server.Close()
+
+	full, err := link.Build("StormEvents | take 10", "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	var out, notes bytes.Buffer
+	if err := shortenLink(context.Background(), server.URL, full, &out, &notes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "https://s.example.com/abc" {
+		t.Errorf("expected short link, got %q", out.String())
+	}
+}
+
+func TestShortenLink_NoShortener(t *testing.T) {
+	var out, notes bytes.Buffer
+	if err := shortenLink(context.Background(), "", "https://example.com/full", &out, &notes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "https://example.com/full" {
+		t.Errorf("expected the full link, got %q", out.String())
+	}
+	if !strings.Contains(notes.String(), "no shortener configured") {
+		t.Errorf("expected a note, got %q", notes.String())
+	}
+}
+
+func TestShortenLink_Non2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := shortenLink(context.Background(), server.URL, "https://example.com/full", &out, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Errorf("expected status error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %q", out.String())
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	url := "https://dataexplorer.azure.com/clusters/help/databases/Samples?query=x"
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"linux", "xdg-open " + url},
+		{"freebsd", "xdg-open " + url},
+		{"darwin", "open " + url},
+		{"windows", "rundll32 url.dll,FileProtocolHandler " + url},
+	}
+	for _, tt := range tests {
+		name, args := browserCommand(tt.goos, url)
+		if got := strings.Join(append([]string{name}, args...), " "); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestRunLinkBuild_Open(t *testing.T) {
+	origCluster, origDatabase, origOpen, origOpenURL := buildCluster, buildDatabase, buildOpen, openURL
+	defer func() {
+		buildCluster, buildDatabase, buildOpen, openURL = origCluster, origDatabase, origOpen, origOpenURL
+	}()
+	defer func(w io.Writer) { logOutput = w }(logOutput)
+
+	buildCluster = "help"
+	buildDatabase = "Samples"
+
+	var opened []string
+	openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	buildOpen = false
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opened) != 0 {
+		t.Errorf("expected nothing opened without --open, got %v", opened)
+	}
+
+	buildOpen = true
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(opened) != 1 || !strings.Contains(opened[0], "/clusters/help/databases/Samples") {
+		t.Errorf("expected the link to be opened, got %v", opened)
+	}
+
+	// A missing opener is only a warning
+	var log bytes.Buffer
+	logOutput = &log
+	openURL = func(string) error { return exec.ErrNotFound }
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Fatalf("expected a missing opener not to fail the command, got %v", err)
+	}
+	if !strings.Contains(log.String(), "could not open the link") {
+		t.Errorf("expected a warning, got %q", log.String())
+	}
+}
+
+func TestRunLinkBuild_Clipboard(t *testing.T) {
+	origCluster, origDatabase, origClipboard, origCopy := buildCluster, buildDatabase, buildClipboard, copyToClipboard
+	defer func() {
+		buildCluster, buildDatabase, buildClipboard, copyToClipboard = origCluster, origDatabase, origClipboard, origCopy
+	}()
+	defer func(w io.Writer) { logOutput = w }(logOutput)
+
+	buildCluster = "help"
+	buildDatabase = "Samples"
+	buildClipboard = true
+
+	var log bytes.Buffer
+	logOutput = &log
+	var copied string
+	copyToClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(copied, "/clusters/help/databases/Samples") || !strings.Contains(log.String(), "Copied") {
+		t.Errorf("expected the link to be copied and confirmed, got %q and %q", copied, log.String())
+	}
+
+	// No clipboard is only a warning
+	log.Reset()
+	copyToClipboard = func(string) error { return errors.New("no clipboard command found") }
+	if err := runLinkBuild(nil, []string{"StormEvents | take 10"}); err != nil {
+		t.Fatalf("expected a missing clipboard not to fail the command, got %v", err)
+	}
+	if !strings.Contains(log.String(), "could not copy the link") {
+		t.Errorf("expected a warning, got %q", log.String())
+	}
+}
+
+func TestWriteLinkQR(t *testing.T) {
+	url := "https://dataexplorer.azure.com/clusters/help/databases/Samples?query=H4sIAAAAAAAAAwsuyS%2FKdS1LzSsp5lIAAC6GmtEQAAAA"
+	file := filepath.Join(t.TempDir(), "link.png")
+
+	var out bytes.Buffer
+	if err := writeLinkQR(&out, url, true, file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "█") || strings.Contains(out.String(), url) {
+		t.Errorf("expected only a QR code on stdout, got %q", out.String())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Errorf("expected a PNG file, got %v", err)
+	}
+
+	out.Reset()
+	if err := writeLinkQR(&out, url, false, file); err != nil || out.Len() != 0 {
+		t.Errorf("expected nothing printed for --qr-file alone, got %q, %v", out.String(), err)
+	}
+
+	// A directory in the way fails the final rename; nothing is left behind
+	dir := t.TempDir()
+	blocked := filepath.Join(dir, "link.png")
+	if err := os.MkdirAll(filepath.Join(blocked, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeLinkQR(&out, url, false, blocked); err == nil {
+		t.Error("expected an error writing over a directory")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary file left behind, got %v", entries)
+	}
+
+	long := url + strings.Repeat("A", 3000)
+	err = writeLinkQR(&out, long, true, "")
+	if err == nil || !strings.Contains(err.Error(), "kql link shorten") {
+		t.Errorf("expected a too-long error pointing at link shorten, got %v", err)
+	}
+}
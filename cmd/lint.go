@@ -20,10 +20,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
-	"strconv"
+	"runtime"
 	"strings"
+	"time"
 
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kqlhints"
 	"github.com/cloudygreybeard/kqlparser"
 	"github.com/spf13/cobra"
 )
@@ -35,7 +39,11 @@ var lintCmd = &cobra.Command{
 performs semantic analysis including type checking and name resolution.
 
 If no files are provided, reads from stdin.
-Use '-' as a filename to explicitly read from stdin.`,
+Use '-' as a filename to explicitly read from stdin.
+
+Directories are walked for .kql files (or the extensions given by --ext),
+skipping hidden directories and paths excluded by .kqlignore files
+(gitignore syntax).`,
 	Example: `  # Lint from stdin
   echo "T | where x > 10" | kql lint
 
@@ -48,37 +56,145 @@ Use '-' as a filename to explicitly read from stdin.`,
   # Lint multiple files
   kql lint queries/*.kql
 
+  # Lint a directory tree (honors .kqlignore)
+  kql lint queries/
+
+  # Include .csl files when walking directories
+  kql lint --ext .kql,.csl queries/
+
   # JSON output for CI
-  kql lint --format json --strict query.kql`,
+  kql lint --format json --strict query.kql
+
+  # Annotations in GitHub Actions
+  kql lint --format github queries/
+
+  # Lint KQL fences in Markdown docs
+  kql lint --input-format markdown docs/runbook.md
+
+  # Explain each error in plain language
+  kql lint --explain-errors query.kql
+
+  # Severity overrides from a config file (default .kqllint.yaml)
+  kql lint --lint-config ci/kqllint.yaml --strict queries/
+
+  # Keep stdout clean in a pipeline
+  kql lint --diagnostics-to stderr query.kql
+
+  # Fail on warnings as well as errors
+  kql lint --fail-on warning queries/
+
+  # Allow at most 10 warnings
+  kql lint --max-warnings 10 queries/
+
+  # Apply safe fixes in place, then lint
+  kql lint --fix queries/
+
+  # Fix a query from stdin (fixed query on stdout, diagnostics on stderr)
+  pbpaste | kql lint --fix
+
+  # Stable paths for golden files, whatever the working directory
+  kql lint --format json --relative-to=$PWD /abs/path/queries/
+
+  # Label a piped query with the name it came from
+  pbpaste | kql lint --stdin-name cell-3.kql
+
+  # Lint the files listed in a manifest
+  git diff --name-only main -- '*.kql' | kql lint --files-from -
+
+  # Lint a large tree on 4 workers
+  kql lint --jobs 4 queries/
+
+  # Find queries that are slow to parse
+  kql lint --statistics queries/`,
 	RunE: runLint,
 }
 
 var (
-	lintStrict bool
-	lintQuiet  bool
-	lintFormat string
+	lintStrict      bool
+	lintFormat      string
+	lintInputFormat string
+	lintExplain     bool
+	lintDiagTo      string
+	lintStatistics  bool
+	lintFailOn      string
+	lintNoColor     bool
+	lintExt         string
+	lintConfigPath  string
+	lintMaxWarnings int
+	lintWarnErrors  bool
+	lintFix         bool
+	lintJobs        int
+	lintRelativeTo  string
+	lintFilesFrom   string
+	lintStdinName   string
 )
 
+// lintFixStdin is set when --fix prints a fixed query from stdin, which
+// takes stdout from diagnostics.
+var lintFixStdin bool
+
 func init() {
 	rootCmd.AddCommand(lintCmd)
 
 	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Enable semantic analysis (type checking, name resolution)")
-	lintCmd.Flags().BoolVar(&lintQuiet, "quiet", false, "Only output errors (no success messages)")
-	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format: text, json")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format: text, json, github")
+	_ = lintCmd.RegisterFlagCompletionFunc("format", completeValues("text", "json", "github"))
+	lintCmd.Flags().StringVar(&lintInputFormat, "input-format", "kql", "Input format: kql, markdown")
+	lintCmd.Flags().BoolVar(&lintExplain, "explain-errors", false, "Add a plain-language explanation to each diagnostic")
+	lintCmd.Flags().BoolVar(&lintExplain, "explain", false, "Alias of --explain-errors; the JSON field is still explanation, not hint")
+	lintCmd.Flags().StringVar(&lintDiagTo, "diagnostics-to", "stdout", "Stream for diagnostics and status messages: stdout, stderr")
+	lintCmd.Flags().StringVar(&lintConfigPath, "lint-config", "", "Severity overrides file (default .kqllint.yaml if present)")
+	lintCmd.Flags().StringVar(&lintExt, "ext", ".kql", "Comma-separated file extensions to lint in directories")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Lowest severity that fails the run: error, warning, info, hint")
+	lintCmd.Flags().IntVar(&lintMaxWarnings, "max-warnings", -1, "Fail if there are more warnings than this (-1 = unlimited)")
+	lintCmd.Flags().BoolVar(&lintWarnErrors, "warnings-as-errors", false, "Fail on warnings (same as --fail-on warning)")
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Apply safe fixes, rewriting files in place (stdin: print the fixed query)")
+	lintCmd.Flags().IntVarP(&lintJobs, "jobs", "j", runtime.NumCPU(), "Number of files to lint in parallel")
+	lintCmd.Flags().StringVar(&lintFilesFrom, "files-from", "", "Also lint the paths listed in `FILE`, one per line ('-' for stdin)")
+	lintCmd.Flags().StringVar(&lintStdinName, "stdin-name", "stdin", "File name to report for a query read from stdin")
+	lintCmd.Flags().StringVar(&lintRelativeTo, "relative-to", "", "Report file paths relative to `DIR` (--relative-to alone: current directory)")
+	lintCmd.Flags().Lookup("relative-to").NoOptDefVal = "."
+	lintCmd.Flags().BoolVar(&lintNoColor, "no-color", false, "Disable colored severities in text output (also honors NO_COLOR)")
+	lintCmd.Flags().BoolVar(&lintStatistics, "statistics", false, "Print timing statistics to stderr after linting")
 }
 
 // LintDiagnostic represents a single diagnostic message.
 type LintDiagnostic struct {
-	File     string `json:"file"`
-	Line     int    `json:"line"`
-	Column   int    `json:"column"`
-	Severity string `json:"severity"`
-	Message  string `json:"message"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Message  string   `json:"message"`
+
+	// Explanation is a plain-language description (--explain-errors)
+	Explanation string `json:"explanation,omitempty"`
 }
 
 // osExit is a variable to allow testing
 var osExit = os.Exit
 
+// lintStdout and lintStderr are variables to allow testing
+var (
+	lintStdout io.Writer = os.Stdout
+	lintStderr io.Writer = os.Stderr
+)
+
+// diagnosticsWriter returns the stream selected by --diagnostics-to.
+func diagnosticsWriter() (io.Writer, error) {
+	switch lintDiagTo {
+	case "stdout", "":
+		if lintFixStdin {
+			return lintStderr, nil
+		}
+		return lintStdout, nil
+	case "stderr":
+		return lintStderr, nil
+	default:
+		return nil, fmt.Errorf("unknown diagnostics stream: %s (supported: stdout, stderr)", lintDiagTo)
+	}
+}
+
 func runLint(cmd *cobra.Command, args []string) error {
 	hasErrors, err := doLint(args, os.Stdin)
 	if err != nil {
@@ -90,53 +206,203 @@ func runLint(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// doLint performs the actual linting and returns whether errors were found.
+// doLint performs the actual linting and returns whether any diagnostic
+// reached the --fail-on severity.
 // Separated from runLint to enable testing without os.Exit.
 func doLint(args []string, stdin io.Reader) (bool, error) {
+	if err := validateInputFormat(lintInputFormat); err != nil {
+		return false, err
+	}
+	if _, err := diagnosticsWriter(); err != nil {
+		return false, err
+	}
+	if lintJobs < 1 {
+		return false, fmt.Errorf("--jobs must be at least 1, got %d", lintJobs)
+	}
+	if lintStdinName == "" {
+		return false, fmt.Errorf("--stdin-name cannot be empty")
+	}
+	if lintFix && lintInputFormat == inputFormatMarkdown {
+		return false, fmt.Errorf("--fix is not supported with --input-format %s", inputFormatMarkdown)
+	}
+	failOn, err := parseSeverity(lintFailOn)
+	if err != nil {
+		return false, err
+	}
+	if lintWarnErrors && !SeverityWarning.AtLeast(failOn) {
+		failOn = SeverityWarning
+	}
+	lintCfg, err := loadLintConfig()
+	if err != nil {
+		return false, fmt.Errorf("loading lint config: %w", err)
+	}
+
+	// With no paths at all, the query is read from stdin
+	queryFromStdin := len(args) == 0 && lintFilesFrom == ""
+	if lintFilesFrom != "" {
+		if lintFilesFrom == "-" && readsStdin(args) {
+			return false, fmt.Errorf("cannot read both --files-from and a query from stdin")
+		}
+		listed, err := readLintManifest(lintFilesFrom, stdin)
+		if err != nil {
+			return false, err
+		}
+		args = append(append([]string{}, args...), listed...)
+	}
+
+	if lintStatistics {
+		lintTimings = newLintStats()
+		defer func() { lintTimings = nil }()
+	}
+	if lintFix && (queryFromStdin || readsStdin(args)) {
+		lintFixStdin = true
+		defer func() { lintFixStdin = false }()
+	}
+	runStart := time.Now()
+
 	var allDiagnostics []LintDiagnostic
 
-	if len(args) == 0 {
+	if queryFromStdin {
 		// Read from stdin
-		diags, err := lintReader("stdin", stdin)
+		r := lintTimedFile("-", stdin)
+		if r.err != nil {
+			return false, r.err
+		}
+		allDiagnostics = append(allDiagnostics, r.diags...)
+	} else {
+		exts, err := parseExtensions(lintExt)
+		if err != nil {
+			return false, err
+		}
+		files, err := expandLintArgs(args, exts)
+		if err != nil {
+			return false, err
+		}
+		diags, err := lintFiles(files, stdin, lintJobs)
 		if err != nil {
 			return false, err
 		}
 		allDiagnostics = append(allDiagnostics, diags...)
-	} else {
-		for _, filename := range args {
-			var diags []LintDiagnostic
-			var err error
-
-			if filename == "-" {
-				diags, err = lintReader("stdin", stdin)
-			} else {
-				diags, err = lintFile(filename)
-			}
-
-			if err != nil {
-				return false, err
-			}
-			allDiagnostics = append(allDiagnostics, diags...)
+	}
+
+	if lintRelativeTo != "" {
+		if err := relativizePaths(allDiagnostics, lintRelativeTo); err != nil {
+			return false, err
 		}
 	}
 
-	// Check if any errors
+	allDiagnostics = lintCfg.Apply(allDiagnostics)
+
+	if lintExplain {
+		explainDiagnostics(allDiagnostics)
+	}
+
+	// Check if any diagnostic fails the run
 	hasErrors := false
+	warnings := 0
 	for _, d := range allDiagnostics {
-		if d.Severity == "error" {
+		if d.Severity.AtLeast(failOn) {
 			hasErrors = true
-			break
 		}
+		if d.Severity == SeverityWarning {
+			warnings++
+		}
+	}
+	warningsExceeded := lintMaxWarnings >= 0 && warnings > lintMaxWarnings
+	if warningsExceeded {
+		hasErrors = true
 	}
 
 	// Output results
 	if err := outputDiagnostics(allDiagnostics, hasErrors); err != nil {
 		return false, err
 	}
+	if warningsExceeded && logEnabled(logWarn) {
+		fmt.Fprintf(lintStderr, "%d warning(s) exceeded limit of %d\n", warnings, lintMaxWarnings)
+	}
+
+	if lintTimings != nil {
+		lintTimings.Total = time.Since(runStart)
+		if err := outputStatistics(lintStderr, lintTimings, lintFormat); err != nil {
+			return false, err
+		}
+	}
 
 	return hasErrors, nil
 }
 
+// relativizePaths rewrites diagnostic file paths relative to dir. Paths
+// that cannot be made relative, such as stdin or another volume, are kept.
+func relativizePaths(diagnostics []LintDiagnostic, dir string) error {
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving --relative-to %s: %w", dir, err)
+	}
+	for i, d := range diagnostics {
+		if d.File == lintStdinName || d.File == "" {
+			continue
+		}
+		abs, err := filepath.Abs(d.File)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(base, abs); err == nil {
+			diagnostics[i].File = filepath.ToSlash(rel)
+		}
+	}
+	return nil
+}
+
+// readLintManifest reads the paths listed in a --files-from manifest, or
+// stdin for "-". Blank lines and lines starting with # are skipped.
+func readLintManifest(name string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open manifest %s: %w", name, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading manifest %s: %w", name, err)
+	}
+	return paths, nil
+}
+
+// readsStdin reports whether args name stdin as "-".
+func readsStdin(args []string) bool {
+	for _, a := range args {
+		if a == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// lintInput lints a file, or stdin when filename is "-", applying --fix
+// first when it is set.
+func lintInput(filename string, stdin io.Reader) ([]LintDiagnostic, error) {
+	if lintFix {
+		return fixAndLint(filename, stdin)
+	}
+	if filename == "-" {
+		return lintReader(lintStdinName, stdin)
+	}
+	return lintFile(filename)
+}
+
 func lintFile(filename string) ([]LintDiagnostic, error) {
 	f, err := os.Open(filename)
 	if err != nil {
@@ -148,23 +414,38 @@ func lintFile(filename string) ([]LintDiagnostic, error) {
 }
 
 func lintReader(filename string, r io.Reader) ([]LintDiagnostic, error) {
-	// Read all content
-	var content strings.Builder
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		content.WriteString(scanner.Text())
-		content.WriteString("\n")
-	}
-	if err := scanner.Err(); err != nil {
+	data, err := io.ReadAll(r)
+	if err != nil {
 		return nil, fmt.Errorf("error reading %s: %w", filename, err)
 	}
+	content := normalizeNewlines(string(data))
 
-	return lintQuery(filename, content.String())
+	if lintInputFormat == inputFormatMarkdown {
+		return lintMarkdown(filename, content)
+	}
+	return lintQuery(filename, content)
+}
+
+// normalizeNewlines turns CRLF and lone CR line endings into LF. The parser
+// only breaks lines on LF, so this keeps reported lines and columns where
+// an editor shows them. Nothing else changes, so a missing final newline
+// stays missing and errors at the end of the input point at its last line.
+func normalizeNewlines(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
 }
 
 func lintQuery(filename, query string) ([]LintDiagnostic, error) {
 	var diagnostics []LintDiagnostic
 
+	start := time.Now()
+	parsed := kqlparser.Parse(filename, query)
+	lintTimings.addParse(filename, time.Since(start))
+
+	// Analysis time includes the re-parse done by ParseAndAnalyze
+	start = time.Now()
 	if lintStrict {
 		// Full semantic analysis
 		result := kqlparser.ParseAndAnalyze(filename, query, nil)
@@ -173,7 +454,7 @@ func lintQuery(filename, query string) ([]LintDiagnostic, error) {
 				File:     filename,
 				Line:     diag.Pos.Line,
 				Column:   diag.Pos.Column,
-				Severity: "error",
+				Severity: SeverityError,
 				Message:  diag.Message,
 			})
 		}
@@ -182,80 +463,216 @@ func lintQuery(filename, query string) ([]LintDiagnostic, error) {
 				File:     filename,
 				Line:     diag.Pos.Line,
 				Column:   diag.Pos.Column,
-				Severity: "warning",
+				Severity: SeverityWarning,
 				Message:  diag.Message,
 			})
 		}
 	} else {
 		// Syntax-only parsing
-		result := kqlparser.Parse(filename, query)
-		for _, err := range result.Errors {
+		for _, err := range parsed.Errors {
 			diag := parseErrorToDiagnostic(filename, err)
 			diagnostics = append(diagnostics, diag)
 		}
 	}
 
+	// Local rules need a clean parse tree
+	if !parsed.HasErrors() {
+		diagnostics = append(diagnostics, analyzeFilters(filename, query, parsed)...)
+	}
+	lintTimings.addAnalyze(filename, time.Since(start))
+
 	return diagnostics, nil
 }
 
 func outputDiagnostics(diagnostics []LintDiagnostic, hasErrors bool) error {
+	w, err := diagnosticsWriter()
+	if err != nil {
+		return err
+	}
+
 	switch lintFormat {
 	case "json":
-		return outputJSON(diagnostics)
+		return outputJSON(w, diagnostics)
+	case "github":
+		return outputGitHub(w, diagnostics)
 	case "text":
-		return outputText(diagnostics, hasErrors)
+		color := false
+		if f, ok := w.(*os.File); ok {
+			color = useColor(lintNoColor, f)
+		}
+		return outputText(w, diagnostics, hasErrors, color)
 	default:
 		return fmt.Errorf("unknown format: %s", lintFormat)
 	}
 }
 
-func outputJSON(diagnostics []LintDiagnostic) error {
+func outputJSON(w io.Writer, diagnostics []LintDiagnostic) error {
 	for _, d := range diagnostics {
 		data, err := json.Marshal(d)
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		fmt.Fprintln(w, string(data))
+	}
+	return nil
+}
+
+// githubCommands maps severities to GitHub Actions workflow commands.
+var githubCommands = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "notice",
+	SeverityHint:    "notice",
+}
+
+// outputGitHub writes diagnostics as GitHub Actions workflow commands, which
+// show as annotations on pull requests.
+func outputGitHub(w io.Writer, diagnostics []LintDiagnostic) error {
+	for _, d := range diagnostics {
+		command, ok := githubCommands[d.Severity]
+		if !ok {
+			command = "notice"
+		}
+
+		props := fmt.Sprintf("file=%s,line=%d,col=%d",
+			escapeGitHubProperty(d.File), d.Line, d.Column)
+		if d.Code != "" {
+			props += ",title=" + escapeGitHubProperty(d.Code)
+		}
+
+		message := d.Message
+		if d.Explanation != "" {
+			message += "\n" + d.Explanation
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", command, props, escapeGitHubData(message))
 	}
 	return nil
 }
 
-func outputText(diagnostics []LintDiagnostic, hasErrors bool) error {
+// escapeGitHubData escapes a workflow command message.
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+func outputText(w io.Writer, diagnostics []LintDiagnostic, hasErrors, color bool) error {
 	for _, d := range diagnostics {
-		fmt.Printf("%s:%d:%d: %s: %s\n", d.File, d.Line, d.Column, d.Severity, d.Message)
+		severity := colorSeverity(d.Severity, color)
+		if d.Code != "" {
+			fmt.Fprintf(w, "%s:%d:%d: %s: %s [%s]\n", d.File, d.Line, d.Column, severity, d.Message, d.Code)
+		} else {
+			fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", d.File, d.Line, d.Column, severity, d.Message)
+		}
+		if d.Explanation != "" {
+			fmt.Fprintf(w, "    %s\n", d.Explanation)
+		}
 	}
 
-	if !lintQuiet && len(diagnostics) == 0 {
-		fmt.Println("No issues found.")
+	if !logQuiet {
+		if len(diagnostics) == 0 {
+			fmt.Fprintln(w, "No issues found.")
+		} else {
+			fmt.Fprintln(w, diagnosticSummary(diagnostics))
+		}
 	}
 
 	return nil
 }
 
-// parseErrorToDiagnostic extracts position info from a parse error.
-// Parser errors are formatted as "file:line:col: message"
-var errPosRegex = regexp.MustCompile(`^([^:]+):(\d+):(\d+): (.+)$`)
+// diagnosticSummary counts diagnostics by severity and the files they are
+// in, e.g. "5 errors, 2 warnings across 3 files". Errors and warnings are
+// always counted; info and hint only when present.
+func diagnosticSummary(diagnostics []LintDiagnostic) string {
+	counts := make(map[Severity]int)
+	files := make(map[string]bool)
+	for _, d := range diagnostics {
+		counts[d.Severity]++
+		files[d.File] = true
+	}
 
-func parseErrorToDiagnostic(filename string, err error) LintDiagnostic {
-	errStr := err.Error()
-	matches := errPosRegex.FindStringSubmatch(errStr)
-	if matches != nil {
-		line, _ := strconv.Atoi(matches[2])
-		col, _ := strconv.Atoi(matches[3])
-		return LintDiagnostic{
-			File:     filename,
-			Line:     line,
-			Column:   col,
-			Severity: "error",
-			Message:  matches[4],
+	parts := []string{
+		plural(counts[SeverityError], "error", "errors"),
+		plural(counts[SeverityWarning], "warning", "warnings"),
+	}
+	if n := counts[SeverityInfo]; n > 0 {
+		parts = append(parts, plural(n, "info", "info"))
+	}
+	if n := counts[SeverityHint]; n > 0 {
+		parts = append(parts, plural(n, "hint", "hints"))
+	}
+
+	return fmt.Sprintf("%s across %s", strings.Join(parts, ", "), plural(len(files), "file", "files"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// lintExplanations explain kqlparser's own messages, such as "expected ),
+// got EOF", which the shared knowledge base's quoted forms don't match.
+// They are checked first, so "got STRING" isn't taken for a string error.
+var lintExplanations = []struct {
+	message     *regexp.Regexp
+	explanation string
+}{
+	{
+		regexp.MustCompile(`^expected [()], got |^unexpected token [()]$`),
+		"A parenthesis is missing or unmatched. Every '(' needs a closing ')', as in count() or bin(Timestamp, 1h).",
+	},
+	{
+		regexp.MustCompile(`^unexpected token \|$`),
+		"A '|' appears where an expression was expected. Each '|' follows a complete table expression and is followed by an operator, as in T | where x > 1 | take 10.",
+	},
+	{
+		regexp.MustCompile(`^expected ,, got |^unexpected token ,$`),
+		"A comma is missing or out of place. Function arguments and list items are separated by single commas, as in bin(Timestamp, 1h).",
+	},
+}
+
+// explainMessage returns a plain-language explanation of a parser
+// message, or "" if none is known.
+func explainMessage(msg string) string {
+	for _, e := range lintExplanations {
+		if e.message.MatchString(msg) {
+			return e.explanation
+		}
+	}
+	return kqlhints.Explain(msg)
+}
+
+// explainDiagnostics fills in Explanation for parser diagnostics with a
+// known message. Diagnostics from local rules already describe themselves.
+func explainDiagnostics(diagnostics []LintDiagnostic) {
+	for i := range diagnostics {
+		if diagnostics[i].Code == "" {
+			diagnostics[i].Explanation = explainMessage(diagnostics[i].Message)
 		}
 	}
-	// Fallback if parsing fails
+}
+
+// parseErrorToDiagnostic extracts position info from a parse error.
+func parseErrorToDiagnostic(filename string, err error) LintDiagnostic {
+	line, col, msg, ok := ai.PositionFromError(err)
+	if !ok {
+		// Fallback if there is no position
+		line, col = 1, 1
+	}
 	return LintDiagnostic{
 		File:     filename,
-		Line:     1,
-		Column:   1,
-		Severity: "error",
-		Message:  errStr,
+		Line:     line,
+		Column:   col,
+		Severity: SeverityError,
+		Message:  msg,
 	}
 }
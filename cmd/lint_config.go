@@ -0,0 +1,131 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlhints"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultLintConfig is read from the working directory when --lint-config is
+// not given.
+const defaultLintConfig = ".kqllint.yaml"
+
+// severityOff suppresses a diagnostic in a lint config.
+const severityOff = "off"
+
+// LintConfig adjusts the severity of diagnostics, loaded from .kqllint.yaml:
+//
+//	rules:
+//	  consecutive-where: off
+//	messages:
+//	  - match: "could not resolve"
+//	    severity: warning
+//
+// A diagnostic takes the severity of the first message override it
+// matches, otherwise the one set for its rule code.
+type LintConfig struct {
+	// Rules maps rule codes to a severity or "off"
+	Rules map[string]string `yaml:"rules"`
+
+	// Messages override diagnostics by message, in order
+	Messages []LintMessageOverride `yaml:"messages"`
+}
+
+// LintMessageOverride sets the severity of diagnostics whose message
+// matches. Match is a case-insensitive substring, or a regular expression
+// wrapped in slashes.
+type LintMessageOverride struct {
+	Match    string `yaml:"match"`
+	Severity string `yaml:"severity"`
+}
+
+// LoadLintConfig reads and validates a lint config file.
+func LoadLintConfig(path string) (*LintConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg LintConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	for code, sev := range cfg.Rules {
+		if err := checkConfigSeverity(sev); err != nil {
+			return nil, fmt.Errorf("%s: rule %s: %w", path, code, err)
+		}
+	}
+	for i, o := range cfg.Messages {
+		if o.Match == "" {
+			return nil, fmt.Errorf("%s: messages[%d]: match is required", path, i)
+		}
+		if err := checkConfigSeverity(o.Severity); err != nil {
+			return nil, fmt.Errorf("%s: messages[%d]: %w", path, i, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func checkConfigSeverity(sev string) error {
+	if sev == severityOff {
+		return nil
+	}
+	_, err := parseSeverity(sev)
+	return err
+}
+
+// loadLintConfig loads --lint-config, or .kqllint.yaml if it exists.
+func loadLintConfig() (*LintConfig, error) {
+	if lintConfigPath != "" {
+		return LoadLintConfig(lintConfigPath)
+	}
+	cfg, err := LoadLintConfig(defaultLintConfig)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return cfg, err
+}
+
+// severityFor returns the configured severity for d, if any.
+func (c *LintConfig) severityFor(d LintDiagnostic) (string, bool) {
+	for _, o := range c.Messages {
+		if (kqlhints.Rule{Match: o.Match}).Matches(d.Message) {
+			return o.Severity, true
+		}
+	}
+	if d.Code != "" {
+		sev, ok := c.Rules[d.Code]
+		return sev, ok
+	}
+	return "", false
+}
+
+// Apply returns diagnostics with configured severities, dropping those
+// turned off. A nil config returns diagnostics unchanged.
+func (c *LintConfig) Apply(diagnostics []LintDiagnostic) []LintDiagnostic {
+	if c == nil {
+		return diagnostics
+	}
+
+	kept := diagnostics[:0]
+	for _, d := range diagnostics {
+		if sev, ok := c.severityFor(d); ok {
+			if sev == severityOff {
+				continue
+			}
+			// Validated at load time
+			d.Severity, _ = parseSeverity(sev)
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
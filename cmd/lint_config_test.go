@@ -0,0 +1,133 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLintConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".kqllint.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadLintConfig(t *testing.T) {
+	cfg, err := LoadLintConfig(writeLintConfig(t, `
+rules:
+  consecutive-where: off
+  duplicate-filter: error
+messages:
+  - match: "/could not resolve .*/"
+    severity: warning
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Rules["consecutive-where"] != "off" || cfg.Rules["duplicate-filter"] != "error" {
+		t.Errorf("unexpected rules: %v", cfg.Rules)
+	}
+	if len(cfg.Messages) != 1 || cfg.Messages[0].Severity != "warning" {
+		t.Errorf("unexpected messages: %+v", cfg.Messages)
+	}
+}
+
+func TestLoadLintConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name, content, wantErr string
+	}{
+		{"bad rule severity", "rules:\n  duplicate-filter: fatal\n", "rule duplicate-filter"},
+		{"bad message severity", "messages:\n  - match: x\n    severity: loud\n", "messages[0]"},
+		{"missing match", "messages:\n  - severity: off\n", "match is required"},
+		{"invalid yaml", "rules: [\n", "parsing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadLintConfig(writeLintConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := LoadLintConfig(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestLintConfig_Apply(t *testing.T) {
+	cfg := &LintConfig{
+		Rules: map[string]string{
+			"consecutive-where": "off",
+			"duplicate-filter":  "error",
+		},
+		Messages: []LintMessageOverride{
+			{Match: "unresolved column", Severity: "warning"},
+			{Match: "/^noise/", Severity: "off"},
+		},
+	}
+	diagnostics := []LintDiagnostic{
+		{Severity: SeverityInfo, Code: "consecutive-where", Message: "combine"},
+		{Severity: SeverityWarning, Code: "duplicate-filter", Message: "duplicate"},
+		{Severity: SeverityError, Message: "Unresolved column 'Foo'"},
+		{Severity: SeverityError, Message: "noise from the analyzer"},
+		{Severity: SeverityError, Message: "expected ), got IDENT"},
+	}
+
+	got := cfg.Apply(diagnostics)
+	want := []Severity{SeverityError, SeverityWarning, SeverityError}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %d: %+v", len(want), len(got), got)
+	}
+	for i, d := range got {
+		if d.Severity != want[i] {
+			t.Errorf("diagnostic %d (%s): got %s, want %s", i, d.Message, d.Severity, want[i])
+		}
+	}
+
+	var none *LintConfig
+	if got := none.Apply(diagnostics[:1]); len(got) != 1 {
+		t.Error("expected a nil config to leave diagnostics unchanged")
+	}
+}
+
+func TestDoLint_ConfigSuppressesErrors(t *testing.T) {
+	origPath, origStdout := lintConfigPath, lintStdout
+	defer func() { lintConfigPath, lintStdout = origPath, origStdout }()
+	lintStdout = io.Discard
+
+	query := "T | where x > 1 | summarize count( by y"
+	hasErrors, err := doLint(nil, strings.NewReader(query))
+	if err != nil || !hasErrors {
+		t.Fatalf("expected errors without a config, got %t, %v", hasErrors, err)
+	}
+
+	lintConfigPath = writeLintConfig(t, "messages:\n  - match: \"\"\n    severity: off\n")
+	if _, err := doLint(nil, strings.NewReader(query)); err == nil {
+		t.Error("expected invalid config to be reported")
+	}
+
+	lintConfigPath = writeLintConfig(t, "messages:\n  - match: /.*/\n    severity: off\n")
+	hasErrors, err = doLint(nil, strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasErrors {
+		t.Error("expected suppressed errors not to fail the run")
+	}
+
+	lintConfigPath = filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := doLint(nil, strings.NewReader(query)); err == nil {
+		t.Error("expected error for a missing --lint-config file")
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/lexer"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+// Fixer rewrites src to correct some of diags, the parse errors in src. It
+// returns src unchanged and no descriptions when it has nothing to fix.
+type Fixer func(src string, diags []LintDiagnostic) (fixed string, applied []string)
+
+// lintFixers are the fixers applied by --fix, in order. Each must only
+// make changes that cannot alter the meaning of a query that parses.
+var lintFixers = []Fixer{
+	fixSmartQuotes,
+	fixWhereAssign,
+}
+
+// applyFixes runs each fixer in turn, keeping a fixer's change only if it
+// leaves fewer parse errors than before.
+func applyFixes(src string, fixers []Fixer) (string, []string) {
+	var applied []string
+	diags := parseDiagnostics(src)
+
+	for _, fix := range fixers {
+		if len(diags) == 0 {
+			break
+		}
+		fixed, descriptions := fix(src, diags)
+		if len(descriptions) == 0 || fixed == src {
+			continue
+		}
+		fixedDiags := parseDiagnostics(fixed)
+		if len(fixedDiags) >= len(diags) {
+			continue
+		}
+		src, diags = fixed, fixedDiags
+		applied = append(applied, descriptions...)
+	}
+
+	return src, applied
+}
+
+// parseDiagnostics returns the syntax errors in src.
+func parseDiagnostics(src string) []LintDiagnostic {
+	var diags []LintDiagnostic
+	for _, err := range kqlparser.Parse("", src).Errors {
+		diags = append(diags, parseErrorToDiagnostic("", err))
+	}
+	return diags
+}
+
+// textEdit replaces src[offset:offset+length] with text.
+type textEdit struct {
+	offset, length int
+	text           string
+}
+
+// applyEdits applies non-overlapping edits to src.
+func applyEdits(src string, edits []textEdit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+	for _, e := range edits {
+		src = src[:e.offset] + e.text + src[e.offset+e.length:]
+	}
+	return src
+}
+
+// smartQuotes maps typographic quotes, often pasted from documents and
+// chat, to the quotes KQL accepts.
+var smartQuotes = map[string]string{
+	"“": `"`,
+	"”": `"`,
+	"‘": "'",
+	"’": "'",
+}
+
+// fixSmartQuotes replaces typographic quotes outside string literals.
+// Inside a string they are content and are left alone.
+func fixSmartQuotes(src string, diags []LintDiagnostic) (string, []string) {
+	var edits []textEdit
+	var applied []string
+
+	l := lexer.New("", src)
+	for t := l.Scan(); t.Type != token.EOF; t = l.Scan() {
+		if t.Type != token.ILLEGAL {
+			continue
+		}
+		for smart, plain := range smartQuotes {
+			if strings.HasPrefix(t.Lit, smart) {
+				p := l.File().Position(t.Pos)
+				edits = append(edits, textEdit{offset: p.Offset, length: len(smart), text: plain})
+				applied = append(applied, fmt.Sprintf("%d:%d: replaced %s with %s", p.Line, p.Column, smart, plain))
+				break
+			}
+		}
+	}
+
+	return applyEdits(src, edits), applied
+}
+
+// fixWhereAssign replaces = with == in where clauses, where assignment is
+// never valid. Only an = the parser reported is changed, so the = of an
+// extend or let inside a subquery of the where is left alone.
+func fixWhereAssign(src string, diags []LintDiagnostic) (string, []string) {
+	var edits []textEdit
+	var applied []string
+
+	reported := make(map[[2]int]bool)
+	for _, d := range diags {
+		reported[[2]int{d.Line, d.Column}] = true
+	}
+
+	// scopes holds the operator open at each nesting depth, innermost
+	// last; a pipe or where ends the ones at its depth or deeper
+	type scope struct {
+		depth   int
+		isWhere bool
+	}
+	var scopes []scope
+	depth := 0
+	enter := func(isWhere bool) {
+		for len(scopes) > 0 && scopes[len(scopes)-1].depth >= depth {
+			scopes = scopes[:len(scopes)-1]
+		}
+		scopes = append(scopes, scope{depth, isWhere})
+	}
+
+	l := lexer.New("", src)
+	for t := l.Scan(); t.Type != token.EOF; t = l.Scan() {
+		switch t.Type {
+		case token.WHERE:
+			enter(true)
+		case token.PIPE:
+			enter(false)
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+			for len(scopes) > 0 && scopes[len(scopes)-1].depth > depth {
+				scopes = scopes[:len(scopes)-1]
+			}
+		case token.ASSIGN:
+			p := l.File().Position(t.Pos)
+			if len(scopes) > 0 && scopes[len(scopes)-1].isWhere && reported[[2]int{p.Line, p.Column}] {
+				edits = append(edits, textEdit{offset: p.Offset, length: len("="), text: "=="})
+				applied = append(applied, fmt.Sprintf("%d:%d: replaced = with == in where", p.Line, p.Column))
+			}
+		}
+	}
+
+	return applyEdits(src, edits), applied
+}
+
+// lintFixNotesMu keeps notes from concurrent lint workers on whole lines.
+var lintFixNotesMu sync.Mutex
+
+// fixAndLint applies --fix to a file, or to stdin when filename is "-",
+// then lints the result. Fixed files are rewritten in place; fixed stdin
+// is printed to stdout. Applied fixes are reported on stderr.
+func fixAndLint(filename string, stdin io.Reader) ([]LintDiagnostic, error) {
+	name := filename
+	var data []byte
+	var mode os.FileMode
+	var err error
+
+	if filename == "-" {
+		name = lintStdinName
+		data, err = io.ReadAll(stdin)
+	} else {
+		var info os.FileInfo
+		if info, err = os.Stat(filename); err == nil {
+			mode = info.Mode().Perm()
+			data, err = os.ReadFile(filename)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", name, err)
+	}
+
+	fixed, applied := applyFixes(string(data), lintFixers)
+	lintFixNotesMu.Lock()
+	for _, a := range applied {
+		fmt.Fprintf(lintStderr, "%s:%s\n", name, a)
+	}
+	lintFixNotesMu.Unlock()
+
+	if filename == "-" {
+		fmt.Fprint(lintStdout, fixed)
+		if !strings.HasSuffix(fixed, "\n") {
+			fmt.Fprintln(lintStdout)
+		}
+	} else if len(applied) > 0 {
+		if err := os.WriteFile(filename, []byte(fixed), mode); err != nil {
+			return nil, fmt.Errorf("writing fixed %s: %w", filename, err)
+		}
+	}
+
+	return lintQuery(name, normalizeNewlines(fixed))
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixWhereAssign(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+		applied         int
+	}{
+		{"where", "T | where State = 'TX'", "T | where State == 'TX'", 1},
+		{"two conditions", "T | where a = 1 and b = 2", "T | where a == 1 and b == 2", 2},
+		{"extend untouched", "T | extend x = 1 | where y = 2", "T | extend x = 1 | where y == 2", 1},
+		{"pipe in parens stays in where", "T | where a in ((U | project a)) and b = 1", "T | where a in ((U | project a)) and b == 1", 1},
+		{"no where", "T | extend x = 1", "T | extend x = 1", 0},
+		{"subquery extend untouched", "T | where x = 1 and y in ((U | extend a = 1 | project a))", "T | where x == 1 and y in ((U | extend a = 1 | project a))", 1},
+		{"where after subquery", "T | where y in ((U | where a = 1 | project a)) and x = 2", "T | where y in ((U | where a == 1 | project a)) and x == 2", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied := fixWhereAssign(tt.src, parseDiagnostics(tt.src))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if len(applied) != tt.applied {
+				t.Errorf("applied %d fixes, want %d: %v", len(applied), tt.applied, applied)
+			}
+		})
+	}
+}
+
+func TestFixWhereAssign_OnlyReported(t *testing.T) {
+	src := "T | where State = 'TX'"
+	if got, applied := fixWhereAssign(src, nil); got != src || len(applied) != 0 {
+		t.Errorf("expected no change without a reported error, got %q %v", got, applied)
+	}
+}
+
+func TestFixSmartQuotes(t *testing.T) {
+	got, applied := fixSmartQuotes("T | where State == “TX”", nil)
+	if want := `T | where State == "TX"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(applied) != 2 {
+		t.Errorf("applied = %v, want 2 fixes", applied)
+	}
+
+	// Quotes inside a string literal are content
+	src := `T | where Name == "it’s"`
+	if got, applied := fixSmartQuotes(src, nil); got != src || len(applied) != 0 {
+		t.Errorf("changed string content: %q %v", got, applied)
+	}
+}
+
+func TestApplyFixes_KeepsOnlyImprovements(t *testing.T) {
+	worse := func(src string, _ []LintDiagnostic) (string, []string) {
+		return src + " | | |", []string{"made it worse"}
+	}
+	src := "T | where State = 'TX'"
+	got, applied := applyFixes(src, []Fixer{worse, fixWhereAssign})
+	if got != "T | where State == 'TX'" {
+		t.Errorf("got %q", got)
+	}
+	if len(applied) != 1 || strings.Contains(applied[0], "worse") {
+		t.Errorf("applied = %v", applied)
+	}
+}
+
+func TestApplyFixes_ValidQueryUnchanged(t *testing.T) {
+	src := "T | extend x = 1 | where x == 1"
+	if got, applied := applyFixes(src, lintFixers); got != src || len(applied) != 0 {
+		t.Errorf("got %q %v, want unchanged", got, applied)
+	}
+}
+
+func TestDoLint_FixFile(t *testing.T) {
+	origStdout, origStderr := lintStdout, lintStderr
+	var stderr bytes.Buffer
+	lintStdout, lintStderr = &bytes.Buffer{}, &stderr
+	lintFix = true
+	defer func() {
+		lintFix = false
+		lintStdout, lintStderr = origStdout, origStderr
+	}()
+
+	path := filepath.Join(t.TempDir(), "q.kql")
+	if err := os.WriteFile(path, []byte("T | where State = 'TX'\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	hasErrors, err := doLint([]string{path}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasErrors {
+		t.Error("expected no errors after fixing")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "T | where State == 'TX'\n" {
+		t.Errorf("file = %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if !strings.Contains(stderr.String(), path+":1:17: replaced = with ==") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestDoLint_FixStdin(t *testing.T) {
+	origStdout, origStderr := lintStdout, lintStderr
+	var stdout, stderr bytes.Buffer
+	lintStdout, lintStderr = &stdout, &stderr
+	lintFix = true
+	defer func() {
+		lintFix = false
+		lintStdout, lintStderr = origStdout, origStderr
+	}()
+
+	hasErrors, err := doLint(nil, strings.NewReader("T | where State = “TX”\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasErrors {
+		t.Error("expected no errors after fixing")
+	}
+	if stdout.String() != "T | where State == \"TX\"\n" {
+		t.Errorf("stdout = %q, want only the fixed query", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "No issues found") {
+		t.Errorf("diagnostics should go to stderr: %q", stderr.String())
+	}
+}
+
+func TestDoLint_FixUnfixableStillFails(t *testing.T) {
+	origStdout, origStderr := lintStdout, lintStderr
+	var stderr bytes.Buffer
+	lintStdout, lintStderr = &bytes.Buffer{}, &stderr
+	lintFix = true
+	defer func() {
+		lintFix = false
+		lintStdout, lintStderr = origStdout, origStderr
+	}()
+
+	hasErrors, err := doLint(nil, strings.NewReader("T | where State = 'TX' | where (x > 1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasErrors {
+		t.Error("expected remaining errors to fail the run")
+	}
+	if !strings.Contains(stderr.String(), "error") {
+		t.Errorf("remaining error not reported: %q", stderr.String())
+	}
+}
+
+func TestDoLint_FixMarkdownUnsupported(t *testing.T) {
+	lintFix = true
+	lintInputFormat = inputFormatMarkdown
+	defer func() {
+		lintFix = false
+		lintInputFormat = "kql"
+	}()
+
+	if _, err := doLint(nil, strings.NewReader("")); err == nil {
+		t.Error("expected an error for --fix with markdown input")
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// kqlIgnoreFile is the name of the gitignore-style exclusion file honored
+// when linting directories.
+const kqlIgnoreFile = ".kqlignore"
+
+// ignoreRule is a single compiled .kqlignore pattern.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher applies .kqlignore rules using gitignore semantics:
+// the last matching rule wins, "!" re-includes, a trailing "/" matches
+// only directories, and patterns containing "/" are anchored to the
+// directory of the .kqlignore file that declared them.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadFile reads the .kqlignore in dir (if any). base is dir's path
+// relative to the walk root, in slash form ("" for the root itself).
+func (m *ignoreMatcher) loadFile(dir, base string) error {
+	f, err := os.Open(filepath.Join(dir, kqlIgnoreFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.add(scanner.Text(), base)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Join(dir, kqlIgnoreFile), err)
+	}
+	return nil
+}
+
+// add compiles a single pattern line declared in directory base.
+func (m *ignoreMatcher) add(line, base string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// "\#" and "\!" escape a literal leading character
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A slash anywhere but the end anchors the pattern to base
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if base != "" {
+		expr.WriteString(regexp.QuoteMeta(base) + "/")
+	}
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	expr.WriteString(globToRegexp(line))
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return
+	}
+	rule.re = re
+	m.rules = append(m.rules, rule)
+}
+
+// ignored reports whether rel (slash-separated, relative to the walk root)
+// is excluded.
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp converts a gitignore glob to a regular expression body.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				class := glob[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += end + 1
+			} else {
+				sb.WriteString(`\[`)
+			}
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// expandLintArgs replaces directory arguments with the files they contain
+// that have one of exts. Files and "-" are passed through unchanged.
+func expandLintArgs(args, exts []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if arg == "-" {
+			files = append(files, arg)
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			// Let lintFile report missing files
+			files = append(files, arg)
+			continue
+		}
+		found, err := walkKQLDir(arg, exts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// walkKQLDir returns the files under root with one of exts (such as
+// ".kql") in lexical order, skipping hidden directories and anything
+// excluded by .kqlignore files at the root or in nested directories.
+func walkKQLDir(root string, exts []string) ([]string, error) {
+	var matcher ignoreMatcher
+	var files []string
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel == "." {
+				return matcher.loadFile(p, "")
+			}
+			if strings.HasPrefix(d.Name(), ".") || matcher.ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			return matcher.loadFile(p, rel)
+		}
+
+		if !hasExt(rel, exts) || matcher.ignored(rel, false) {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", root, err)
+	}
+
+	return files, nil
+}
+
+// parseExtensions splits a comma-separated --ext value, adding a leading
+// dot where missing.
+func parseExtensions(value string) ([]string, error) {
+	var exts []string
+	for _, ext := range strings.Split(value, ",") {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	if len(exts) == 0 {
+		return nil, fmt.Errorf("no file extensions given (e.g. --ext .kql)")
+	}
+	return exts, nil
+}
+
+// hasExt reports whether name ends in one of exts. Matching is on the
+// suffix, so multi-part extensions like ".kql.md" work.
+func hasExt(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	var m ignoreMatcher
+	for _, line := range []string{
+		"# generated queries",
+		"",
+		"*.gen.kql",
+		"vendor/",
+		"/scratch.kql",
+		"reports/**/draft-*.kql",
+		"legacy",
+		"!legacy/keep.kql",
+		`\#literal.kql`,
+	} {
+		m.add(line, "")
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"a.gen.kql", false, true},
+		{"nested/deep/b.gen.kql", false, true},
+		{"a.kql", false, false},
+		{"vendor", true, true},
+		{"lib/vendor", true, true},
+		{"vendor", false, false}, // dir-only pattern
+		{"scratch.kql", false, true},
+		{"sub/scratch.kql", false, false}, // anchored to root
+		{"reports/draft-1.kql", false, true},
+		{"reports/2024/q1/draft-2.kql", false, true},
+		{"reports/final.kql", false, false},
+		{"legacy/old.kql", false, false}, // "legacy" matches the dir, not files under it
+		{"legacy", true, true},
+		{"legacy/keep.kql", false, false},
+		{"#literal.kql", false, true},
+	}
+
+	for _, tt := range tests {
+		if got := m.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcher_NestedBase(t *testing.T) {
+	var m ignoreMatcher
+	m.add("tmp.kql", "team")
+	m.add("/only-here.kql", "team")
+
+	if !m.ignored("team/tmp.kql", false) {
+		t.Error("expected team/tmp.kql to be ignored")
+	}
+	if !m.ignored("team/sub/tmp.kql", false) {
+		t.Error("expected team/sub/tmp.kql to be ignored")
+	}
+	if m.ignored("tmp.kql", false) {
+		t.Error("nested rule should not apply outside its directory")
+	}
+	if !m.ignored("team/only-here.kql", false) || m.ignored("team/sub/only-here.kql", false) {
+		t.Error("anchored nested rule should only match directly in its directory")
+	}
+}
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+}
+
+func TestWalkKQLDir_KqlIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".kqlignore":               "generated/\n*.draft.kql\n",
+		"a.kql":                    "T | take 1",
+		"b.draft.kql":              "T | where ((",
+		"notes.txt":                "not kql",
+		"generated/c.kql":          "T | where ((",
+		"team/d.kql":               "T | count",
+		"team/.kqlignore":          "scratch.kql\n",
+		"team/scratch.kql":         "T | where ((",
+		"other/scratch.kql":        "T | take 2",
+		".hidden/e.kql":            "T | where ((",
+		"third_party/vendored.kql": "T | take 3",
+	})
+
+	files, err := walkKQLDir(root, []string{".kql"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rel []string
+	for _, f := range files {
+		r, _ := filepath.Rel(root, f)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+
+	want := []string{"a.kql", "other/scratch.kql", "team/d.kql", "third_party/vendored.kql"}
+	if !reflect.DeepEqual(rel, want) {
+		t.Errorf("unexpected files:\n  got:  %q\n  want: %q", rel, want)
+	}
+}
+
+func TestDoLint_DirectoryHonorsKqlIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".kqlignore":      "broken/\n",
+		"ok.kql":          "T | take 1",
+		"broken/bad.kql":  "T | where ((",
+		"broken/bad2.kql": "T | summarize count(",
+	})
+
+	lintStrict = false
+	lintFormat = "text"
+	hasErrors, err := doLint([]string{root}, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasErrors {
+		t.Error("expected ignored broken files to be skipped")
+	}
+}
+
+func TestWalkKQLDir_Extensions(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.kql":        "T | take 1",
+		"b.csl":        "T | take 2",
+		"nested/c.csl": "T | take 3",
+		"d.txt":        "not kql",
+		".csl":         "no name",
+	})
+
+	exts, err := parseExtensions("kql, .csl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, err := walkKQLDir(root, exts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rel []string
+	for _, f := range files {
+		r, _ := filepath.Rel(root, f)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	want := []string{"a.kql", "b.csl", "nested/c.csl"}
+	if !reflect.DeepEqual(rel, want) {
+		t.Errorf("unexpected files:\n  got:  %q\n  want: %q", rel, want)
+	}
+
+	if _, err := parseExtensions(" , "); err == nil {
+		t.Error("expected error for an empty --ext")
+	}
+}
+
+func TestDoLint_DirectoryExt(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"ok.kql":      "T | take 1",
+		"sub/bad.csl": "T | where ((",
+	})
+
+	origExt := lintExt
+	defer func() { lintExt = origExt }()
+	lintStrict = false
+	lintFormat = "text"
+
+	// .csl files are skipped by default
+	lintExt = ".kql"
+	hasErrors, err := doLint([]string{root}, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasErrors {
+		t.Error("expected .csl files to be skipped with the default --ext")
+	}
+
+	// ...and linted when selected, failing the run
+	lintExt = ".kql,.csl"
+	hasErrors, err = doLint([]string{root}, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasErrors {
+		t.Error("expected errors from sub/bad.csl with --ext .kql,.csl")
+	}
+}
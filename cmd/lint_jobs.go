@@ -0,0 +1,85 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lintFileResult is the outcome of linting one file.
+type lintFileResult struct {
+	diags []LintDiagnostic
+	err   error
+}
+
+// lintFiles lints files with up to jobs workers and returns their
+// diagnostics sorted by file, then line and column. "-" entries read stdin
+// on the calling goroutine, since stdin can only be read once. When files
+// fail, the error for the earliest one in files is returned. jobs must be
+// at least 1.
+func lintFiles(files []string, stdin io.Reader, jobs int) ([]LintDiagnostic, error) {
+	results := make([]lintFileResult, len(files))
+	work := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				results[idx] = lintTimedFile(files[idx], nil)
+			}
+		}()
+	}
+
+	for idx, filename := range files {
+		if filename == "-" {
+			results[idx] = lintTimedFile(filename, stdin)
+			continue
+		}
+		work <- idx
+	}
+	close(work)
+	wg.Wait()
+
+	var diagnostics []LintDiagnostic
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+		diagnostics = append(diagnostics, r.diags...)
+	}
+	sortDiagnostics(diagnostics)
+	return diagnostics, nil
+}
+
+// lintTimedFile lints one file, or stdin for "-", recording its time.
+func lintTimedFile(filename string, stdin io.Reader) lintFileResult {
+	start := time.Now()
+	diags, err := lintInput(filename, stdin)
+	if filename == "-" {
+		lintTimings.addFile(lintStdinName, time.Since(start))
+	} else {
+		lintTimings.addFile(filename, time.Since(start))
+	}
+	return lintFileResult{diags: diags, err: err}
+}
+
+// sortDiagnostics orders diagnostics by file, line, and column, keeping
+// the reported order of diagnostics at the same position.
+func sortDiagnostics(diagnostics []LintDiagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeLintCorpus writes n queries, a mix of valid, invalid, and
+// rule-triggering, and returns their paths.
+func writeLintCorpus(t *testing.T, n int) []string {
+	t.Helper()
+	queries := []string{
+		"T | take 10\n",
+		"T | where (x > 1\n",
+		"T | where a > 1 | where b > 2\n",
+		"T\n| where x > 1\n| where x > 1\n",
+		"T | summarize count( by y\n",
+	}
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("q%03d.kql", i))
+		if err := os.WriteFile(path, []byte(queries[i%len(queries)]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+func TestLintFiles_ParallelMatchesSerial(t *testing.T) {
+	files := writeLintCorpus(t, 60)
+
+	for _, strict := range []bool{false, true} {
+		lintStrict = strict
+		serial, err := lintFiles(files, nil, 1)
+		if err != nil {
+			t.Fatalf("serial: %v", err)
+		}
+		parallel, err := lintFiles(files, nil, 8)
+		lintStrict = false
+		if err != nil {
+			t.Fatalf("parallel: %v", err)
+		}
+		if len(serial) == 0 {
+			t.Fatal("expected diagnostics from the corpus")
+		}
+		if !reflect.DeepEqual(serial, parallel) {
+			t.Errorf("strict=%v: parallel results differ from serial", strict)
+		}
+	}
+}
+
+func TestLintFiles_SortedByFileAndLine(t *testing.T) {
+	files := writeLintCorpus(t, 10)
+	// Reverse the input order; output order must not depend on it
+	for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+		files[i], files[j] = files[j], files[i]
+	}
+
+	diags, err := lintFiles(files, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(diags); i++ {
+		a, b := diags[i-1], diags[i]
+		if a.File > b.File || (a.File == b.File && a.Line > b.Line) {
+			t.Fatalf("diagnostics out of order at %d: %s:%d before %s:%d", i, a.File, a.Line, b.File, b.Line)
+		}
+	}
+}
+
+func TestLintFiles_FirstErrorWins(t *testing.T) {
+	files := writeLintCorpus(t, 5)
+	dir := filepath.Dir(files[0])
+	files = append([]string{filepath.Join(dir, "missing-a.kql")}, files...)
+	files = append(files, filepath.Join(dir, "missing-b.kql"))
+
+	_, err := lintFiles(files, nil, 4)
+	if err == nil || !strings.Contains(err.Error(), "missing-a.kql") {
+		t.Errorf("expected error for the first missing file, got %v", err)
+	}
+}
+
+func TestLintFiles_StdinDash(t *testing.T) {
+	files := writeLintCorpus(t, 3)
+	files = append(files, "-")
+
+	diags, err := lintFiles(files, strings.NewReader("T | where (x\n"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, d := range diags {
+		if d.File == "stdin" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected diagnostics for stdin")
+	}
+}
+
+func TestDoLint_InvalidJobs(t *testing.T) {
+	orig := lintJobs
+	lintJobs = 0
+	defer func() { lintJobs = orig }()
+
+	if _, err := doLint([]string{"x.kql"}, nil); err == nil {
+		t.Error("expected an error for --jobs 0")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Input formats accepted by lint --input-format.
+const (
+	inputFormatKQL      = "kql"
+	inputFormatMarkdown = "markdown"
+)
+
+// validateInputFormat checks a lint --input-format value.
+func validateInputFormat(format string) error {
+	switch format {
+	case inputFormatKQL, inputFormatMarkdown:
+		return nil
+	case "yaml-field", "json-field":
+		return fmt.Errorf("input format %q is not yet supported (supported: kql, markdown)", format)
+	default:
+		return fmt.Errorf("unknown input format: %s (supported: kql, markdown)", format)
+	}
+}
+
+// kqlSnippet is a KQL fragment embedded in a host file.
+type kqlSnippet struct {
+	// Text is the snippet source, with host-file indentation preserved
+	Text string
+
+	// LineOffset is the number of host-file lines before the snippet's first line
+	LineOffset int
+}
+
+// extractMarkdownKQL returns the contents of all ```kql and ```kusto fenced
+// code blocks (backtick or tilde fences) in a Markdown document.
+// An unterminated fence runs to the end of the document.
+func extractMarkdownKQL(content string) []kqlSnippet {
+	lines := strings.Split(content, "\n")
+
+	var snippets []kqlSnippet
+	var body []string
+	var fence string
+	inKQL := false
+	start := 0
+
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		if fence == "" {
+			if indent > 3 {
+				continue
+			}
+			marker := fenceMarker(trimmed)
+			if marker == "" {
+				continue
+			}
+			fence = marker
+			info := strings.Fields(strings.TrimSpace(trimmed[len(marker):]))
+			inKQL = len(info) > 0 && isKQLInfoString(info[0])
+			start = i + 1
+			body = nil
+			continue
+		}
+
+		// Closing fence: same character, at least as long, nothing after it
+		if indent <= 3 && strings.HasPrefix(trimmed, fence) &&
+			strings.Trim(strings.TrimSpace(trimmed), fence[:1]) == "" {
+			if inKQL {
+				snippets = append(snippets, kqlSnippet{
+					Text:       strings.Join(body, "\n") + "\n",
+					LineOffset: start,
+				})
+			}
+			fence = ""
+			continue
+		}
+
+		body = append(body, line)
+	}
+
+	if fence != "" && inKQL && len(body) > 0 {
+		snippets = append(snippets, kqlSnippet{
+			Text:       strings.Join(body, "\n") + "\n",
+			LineOffset: start,
+		})
+	}
+
+	return snippets
+}
+
+// fenceMarker returns the opening fence (``` or ~~~, possibly longer) at
+// the start of line, or "" if the line does not open a fence.
+func fenceMarker(line string) string {
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(line) && line[n] == c {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+func isKQLInfoString(info string) bool {
+	switch strings.ToLower(info) {
+	case "kql", "kusto":
+		return true
+	}
+	return false
+}
+
+// lintMarkdown lints each KQL fence in a Markdown document, mapping
+// diagnostic lines back to the host file.
+func lintMarkdown(filename, content string) ([]LintDiagnostic, error) {
+	var diagnostics []LintDiagnostic
+	for _, snippet := range extractMarkdownKQL(content) {
+		diags, err := lintQuery(filename, snippet.Text)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range diags {
+			d.Line += snippet.LineOffset
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+)
+
+const testMarkdown = "# Runbook\n" +
+	"\n" +
+	"Count events:\n" +
+	"\n" +
+	"```kql\n" +
+	"StormEvents\n" +
+	"| summarize count() by State\n" +
+	"```\n" +
+	"\n" +
+	"Not KQL:\n" +
+	"\n" +
+	"```bash\n" +
+	"kql lint query.kql\n" +
+	"```\n" +
+	"\n" +
+	"~~~~ Kusto title=\"broken\"\n" +
+	"T | where ((\n" +
+	"~~~~\n"
+
+func TestExtractMarkdownKQL(t *testing.T) {
+	snippets := extractMarkdownKQL(testMarkdown)
+	if len(snippets) != 2 {
+		t.Fatalf("expected 2 snippets, got %d", len(snippets))
+	}
+
+	if snippets[0].Text != "StormEvents\n| summarize count() by State\n" {
+		t.Errorf("unexpected first snippet: %q", snippets[0].Text)
+	}
+	if snippets[0].LineOffset != 5 {
+		t.Errorf("expected first snippet offset 5, got %d", snippets[0].LineOffset)
+	}
+
+	if snippets[1].Text != "T | where ((\n" {
+		t.Errorf("unexpected second snippet: %q", snippets[1].Text)
+	}
+	if snippets[1].LineOffset != 16 {
+		t.Errorf("expected second snippet offset 16, got %d", snippets[1].LineOffset)
+	}
+}
+
+func TestExtractMarkdownKQL_NoFences(t *testing.T) {
+	if snippets := extractMarkdownKQL("# Title\n\nJust text.\n"); len(snippets) != 0 {
+		t.Errorf("expected no snippets, got %d", len(snippets))
+	}
+}
+
+func TestExtractMarkdownKQL_NestedFenceMarkers(t *testing.T) {
+	// A shorter fence inside a longer one does not close it
+	md := "````kql\nT | take 1\n```\n````\n"
+	snippets := extractMarkdownKQL(md)
+	if len(snippets) != 1 {
+		t.Fatalf("expected 1 snippet, got %d", len(snippets))
+	}
+	if snippets[0].Text != "T | take 1\n```\n" {
+		t.Errorf("unexpected snippet: %q", snippets[0].Text)
+	}
+}
+
+func TestExtractMarkdownKQL_Unterminated(t *testing.T) {
+	snippets := extractMarkdownKQL("intro\n```kql\nT | take 1\n")
+	if len(snippets) != 1 {
+		t.Fatalf("expected 1 snippet, got %d", len(snippets))
+	}
+	if snippets[0].LineOffset != 2 {
+		t.Errorf("expected offset 2, got %d", snippets[0].LineOffset)
+	}
+}
+
+func TestLintMarkdown_MapsLines(t *testing.T) {
+	lintStrict = false
+	diagnostics, err := lintMarkdown("runbook.md", testMarkdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diagnostics) == 0 {
+		t.Fatal("expected diagnostics for broken fence")
+	}
+	for _, d := range diagnostics {
+		if d.File != "runbook.md" {
+			t.Errorf("expected file 'runbook.md', got %q", d.File)
+		}
+		// The broken query is on line 17 of the host file
+		if d.Line < 17 {
+			t.Errorf("expected diagnostic on host line >= 17, got %d", d.Line)
+		}
+	}
+}
+
+func TestValidateInputFormat(t *testing.T) {
+	for _, f := range []string{"kql", "markdown"} {
+		if err := validateInputFormat(f); err != nil {
+			t.Errorf("validateInputFormat(%q) unexpected error: %v", f, err)
+		}
+	}
+	for _, f := range []string{"yaml-field", "json-field", "xml"} {
+		if err := validateInputFormat(f); err == nil {
+			t.Errorf("validateInputFormat(%q) expected error", f)
+		}
+	}
+}
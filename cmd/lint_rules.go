@@ -0,0 +1,140 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/ast"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+// Local lint rule codes.
+const (
+	ruleConsecutiveWhere = "consecutive-where"
+	ruleDuplicateFilter  = "duplicate-filter"
+)
+
+// filterRun is a sequence of where operators with nothing between them.
+type filterRun []*ast.WhereOp
+
+// analyzeFilters reports consecutive where operators that could be combined
+// with "and" and filter predicates repeated within such a run. An
+// intervening operator (extend, summarize, ...) ends a run, since it can
+// change the rows or redefine the columns a later filter refers to.
+func analyzeFilters(filename, query string, result *kqlparser.ParseResult) []LintDiagnostic {
+	if result.AST == nil {
+		return nil
+	}
+
+	var diagnostics []LintDiagnostic
+	diag := func(pos token.Pos, severity Severity, code, msg string) {
+		p := result.File.Position(pos)
+		diagnostics = append(diagnostics, LintDiagnostic{
+			File:     filename,
+			Line:     p.Line,
+			Column:   p.Column,
+			Severity: severity,
+			Code:     code,
+			Message:  msg,
+		})
+	}
+
+	ast.Inspect(result.AST, func(n ast.Node) bool {
+		pipe, ok := n.(*ast.PipeExpr)
+		if !ok {
+			return true
+		}
+
+		for _, run := range filterRuns(pipe) {
+			if len(run) > 1 {
+				for _, w := range run[1:] {
+					diag(w.Where, SeverityInfo, ruleConsecutiveWhere,
+						"consecutive where operators can be combined with 'and'")
+				}
+			}
+
+			seen := make(map[string]bool)
+			for _, w := range run {
+				for _, pred := range conjuncts(w.Predicate) {
+					key := predicateKey(query, pred)
+					if key == "" {
+						continue
+					}
+					if seen[key] {
+						diag(pred.Pos(), SeverityWarning, ruleDuplicateFilter,
+							fmt.Sprintf("duplicate filter %q has no effect and can be removed", key))
+						continue
+					}
+					seen[key] = true
+				}
+			}
+		}
+		return true
+	})
+
+	return diagnostics
+}
+
+// filterRuns splits a pipeline's operators into runs of adjacent wheres.
+func filterRuns(pipe *ast.PipeExpr) []filterRun {
+	var runs []filterRun
+	var current filterRun
+	for _, op := range pipe.Operators {
+		if w, ok := op.(*ast.WhereOp); ok && w.Predicate != nil {
+			current = append(current, w)
+			continue
+		}
+		if len(current) > 0 {
+			runs = append(runs, current)
+		}
+		current = nil
+	}
+	if len(current) > 0 {
+		runs = append(runs, current)
+	}
+	return runs
+}
+
+// conjuncts flattens a chain of "and" into its operands. Parenthesized
+// groups are kept whole.
+func conjuncts(e ast.Expr) []ast.Expr {
+	if b, ok := e.(*ast.BinaryExpr); ok && b.Op == token.AND {
+		return append(conjuncts(b.X), conjuncts(b.Y)...)
+	}
+	return []ast.Expr{e}
+}
+
+// incomplete reports whether e is missing an operand, as in "x >" at the
+// end of a query, which the parser accepts without an error.
+func incomplete(e ast.Expr) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if b, ok := n.(*ast.BinaryExpr); ok && (b.X == nil || b.Y == nil) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// predicateKey returns the source text of e in normalized form, so that
+// predicates differing only in whitespace or comments compare equal.
+func predicateKey(query string, e ast.Expr) string {
+	if incomplete(e) {
+		return ""
+	}
+	start, end := int(e.Pos())-1, int(e.End())-1
+	if start < 0 || end > len(query) || start > end {
+		return ""
+	}
+	text := query[start:end]
+	if normalized, err := kqlfmt.Normalize(text); err == nil {
+		return normalized
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/cloudygreybeard/kqlparser"
+)
+
+func filterDiagnostics(t *testing.T, query string) []LintDiagnostic {
+	t.Helper()
+	result := kqlparser.Parse("q.kql", query)
+	if result.HasErrors() {
+		t.Fatalf("unexpected parse errors: %v", result.Errors)
+	}
+	return analyzeFilters("q.kql", query, result)
+}
+
+func TestAnalyzeFilters_ConsecutiveWhere(t *testing.T) {
+	diags := filterDiagnostics(t, "T\n| where A > 0\n| where B > 0\n| where C > 0")
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d: %+v", len(diags), diags)
+	}
+	for i, d := range diags {
+		if d.Code != ruleConsecutiveWhere || d.Severity != "info" {
+			t.Errorf("unexpected diagnostic: %+v", d)
+		}
+		if d.Line != i+3 || d.Column != 3 {
+			t.Errorf("expected diagnostic at %d:3, got %d:%d", i+3, d.Line, d.Column)
+		}
+	}
+}
+
+func TestAnalyzeFilters_DuplicatePredicate(t *testing.T) {
+	diags := filterDiagnostics(t, "T | where A > 0 and B == 'x' | where A>0")
+
+	var dups []LintDiagnostic
+	for _, d := range diags {
+		if d.Code == ruleDuplicateFilter {
+			dups = append(dups, d)
+		}
+	}
+	if len(dups) != 1 {
+		t.Fatalf("expected 1 duplicate filter, got %d: %+v", len(dups), diags)
+	}
+	if dups[0].Severity != "warning" || dups[0].Column != 38 {
+		t.Errorf("unexpected duplicate diagnostic: %+v", dups[0])
+	}
+}
+
+func TestAnalyzeFilters_DuplicateWithinSingleWhere(t *testing.T) {
+	diags := filterDiagnostics(t, "T | where A > 0 and A > 0")
+	if len(diags) != 1 || diags[0].Code != ruleDuplicateFilter {
+		t.Errorf("expected one duplicate filter, got %+v", diags)
+	}
+}
+
+func TestAnalyzeFilters_InterveningOperator(t *testing.T) {
+	// extend may redefine A, so neither rule applies across it
+	diags := filterDiagnostics(t, "T | where A > 0 | extend A = A - 1 | where A > 0")
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics across an intervening operator, got %+v", diags)
+	}
+}
+
+func TestAnalyzeFilters_Clean(t *testing.T) {
+	diags := filterDiagnostics(t, "T | where A > 0 and B > 0 | summarize count() by C")
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", diags)
+	}
+}
+
+func TestLintQuery_IncludesFilterRules(t *testing.T) {
+	lintStrict = false
+	diags, err := lintQuery("q.kql", "T | where A > 0 | where B > 0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != ruleConsecutiveWhere {
+		t.Errorf("expected consecutive-where diagnostic, got %+v", diags)
+	}
+
+	// Rules are skipped when the query doesn't parse
+	diags, _ = lintQuery("q.kql", "T | where A > 0 | where ((")
+	for _, d := range diags {
+		if d.Code != "" {
+			t.Errorf("expected only syntax errors, got %+v", d)
+		}
+	}
+}
+
+func TestAnalyzeFilters_MissingOperand(t *testing.T) {
+	// The parser accepts a trailing comparison without its right operand
+	diags := filterDiagnostics(t, "T | where A > 0 | where x >")
+	if len(diags) != 1 || diags[0].Code != ruleConsecutiveWhere {
+		t.Errorf("unexpected diagnostics: %+v", diags)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is the level of a lint diagnostic.
+type Severity string
+
+const (
+	// SeverityError is a problem that makes the query invalid.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is likely a mistake, though the query is valid.
+	SeverityWarning Severity = "warning"
+
+	// SeverityInfo is advice, such as a simplification.
+	SeverityInfo Severity = "info"
+
+	// SeverityHint is a minor suggestion, such as a style preference.
+	SeverityHint Severity = "hint"
+)
+
+// severities lists the levels from most to least severe.
+var severities = []Severity{SeverityError, SeverityWarning, SeverityInfo, SeverityHint}
+
+// rank orders severities; a lower rank is more severe. Unknown severities
+// rank below hint.
+func (s Severity) rank() int {
+	for i, sev := range severities {
+		if s == sev {
+			return i
+		}
+	}
+	return len(severities)
+}
+
+// AtLeast reports whether s is as severe as threshold or more.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() <= threshold.rank()
+}
+
+// parseSeverity validates a severity name, such as a --fail-on value.
+func parseSeverity(name string) (Severity, error) {
+	s := Severity(strings.ToLower(name))
+	if s.rank() == len(severities) {
+		names := make([]string, len(severities))
+		for i, sev := range severities {
+			names[i] = string(sev)
+		}
+		return "", fmt.Errorf("unknown severity: %s (supported: %s)", name, strings.Join(names, ", "))
+	}
+	return s, nil
+}
+
+// severityColors are the ANSI colors for severities in text output.
+var severityColors = map[Severity]string{
+	SeverityError:   ansiRed,
+	SeverityWarning: ansiYellow,
+	SeverityInfo:    ansiBlue,
+	SeverityHint:    ansiGray,
+}
+
+// colorSeverity returns the severity label, colored when color is set.
+func colorSeverity(s Severity, color bool) string {
+	if c, ok := severityColors[s]; ok && color {
+		return c + string(s) + ansiReset
+	}
+	return string(s)
+}
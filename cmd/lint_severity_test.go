@@ -0,0 +1,142 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSeverity_AtLeast(t *testing.T) {
+	tests := []struct {
+		s, threshold Severity
+		want         bool
+	}{
+		{SeverityError, SeverityError, true},
+		{SeverityWarning, SeverityError, false},
+		{SeverityWarning, SeverityWarning, true},
+		{SeverityError, SeverityHint, true},
+		{SeverityInfo, SeverityWarning, false},
+		{SeverityHint, SeverityInfo, false},
+		{SeverityHint, SeverityHint, true},
+	}
+	for _, tt := range tests {
+		if got := tt.s.AtLeast(tt.threshold); got != tt.want {
+			t.Errorf("%s.AtLeast(%s) = %t, want %t", tt.s, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if s, err := parseSeverity("Info"); err != nil || s != SeverityInfo {
+		t.Errorf("expected info, got %q, %v", s, err)
+	}
+	if _, err := parseSeverity("fatal"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
+
+func TestColorSeverity(t *testing.T) {
+	if got := colorSeverity(SeverityHint, false); got != "hint" {
+		t.Errorf("expected plain label without color, got %q", got)
+	}
+	if got := colorSeverity(SeverityWarning, true); got != ansiYellow+"warning"+ansiReset {
+		t.Errorf("expected colored label, got %q", got)
+	}
+}
+
+// TestDoLint_FailOn lints a query that only produces an info diagnostic
+// (consecutive where) and checks it is printed but fails only when
+// --fail-on includes info.
+func TestDoLint_FailOn(t *testing.T) {
+	origStdout, origFailOn := lintStdout, lintFailOn
+	defer func() { lintStdout, lintFailOn = origStdout, origFailOn }()
+
+	const query = "T | where A > 0 | where B > 0"
+
+	tests := []struct {
+		failOn   string
+		wantFail bool
+	}{
+		{"error", false},
+		{"warning", false},
+		{"info", true},
+		{"hint", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.failOn, func(t *testing.T) {
+			var out bytes.Buffer
+			lintStdout, lintFailOn = &out, tt.failOn
+
+			failed, err := doLint(nil, strings.NewReader(query))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if failed != tt.wantFail {
+				t.Errorf("expected fail=%t with --fail-on %s, got %t", tt.wantFail, tt.failOn, failed)
+			}
+			if !strings.Contains(out.String(), "info: consecutive where") {
+				t.Errorf("expected the info diagnostic to be printed, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestDoLint_UnknownFailOn(t *testing.T) {
+	origFailOn := lintFailOn
+	defer func() { lintFailOn = origFailOn }()
+
+	lintFailOn = "fatal"
+	if _, err := doLint(nil, strings.NewReader("T | take 10")); err == nil {
+		t.Error("expected error for unknown --fail-on severity")
+	}
+}
+
+func TestDoLint_MaxWarnings(t *testing.T) {
+	origStdout, origStderr := lintStdout, lintStderr
+	origMax, origWarnErrors, origQuiet := lintMaxWarnings, lintWarnErrors, logQuiet
+	defer func() {
+		lintStdout, lintStderr = origStdout, origStderr
+		lintMaxWarnings, lintWarnErrors, logQuiet = origMax, origWarnErrors, origQuiet
+	}()
+
+	// Two duplicate-filter warnings
+	const query = "T | where A > 0 and A > 0 | where B > 0 and B > 0"
+
+	tests := []struct {
+		name        string
+		max         int
+		warnErrors  bool
+		quiet       bool
+		wantFail    bool
+		wantSummary bool
+	}{
+		{"unlimited", -1, false, false, false, false},
+		{"under limit", 2, false, false, false, false},
+		{"over limit", 1, false, false, true, true},
+		{"over limit quiet", 0, false, true, true, false},
+		{"warnings as errors", -1, true, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			lintStdout, lintStderr = &bytes.Buffer{}, &stderr
+			lintMaxWarnings, lintWarnErrors, logQuiet = tt.max, tt.warnErrors, tt.quiet
+
+			failed, err := doLint(nil, strings.NewReader(query))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if failed != tt.wantFail {
+				t.Errorf("expected fail=%t, got %t", tt.wantFail, failed)
+			}
+			summary := fmt.Sprintf("2 warning(s) exceeded limit of %d", tt.max)
+			if got := strings.Contains(stderr.String(), summary); got != tt.wantSummary {
+				t.Errorf("expected summary=%t, got stderr %q", tt.wantSummary, stderr.String())
+			}
+		})
+	}
+}
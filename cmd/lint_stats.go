@@ -0,0 +1,169 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// lintSlowestFiles is how many files the statistics report lists by time.
+const lintSlowestFiles = 5
+
+// lintFileStats holds the timings for one linted file.
+type lintFileStats struct {
+	File    string
+	Total   time.Duration
+	Parse   time.Duration
+	Analyze time.Duration
+}
+
+// lintStats collects timings for --statistics. A nil *lintStats ignores
+// all recording, so instrumented code needs no checks. Recording is safe
+// from concurrent lint workers.
+type lintStats struct {
+	Total time.Duration
+
+	mu    sync.Mutex
+	files []*lintFileStats
+	index map[string]*lintFileStats
+}
+
+// lintTimings is the collector for the current run; nil unless
+// --statistics is set.
+var lintTimings *lintStats
+
+func newLintStats() *lintStats {
+	return &lintStats{index: make(map[string]*lintFileStats)}
+}
+
+func (s *lintStats) file(name string) *lintFileStats {
+	f, ok := s.index[name]
+	if !ok {
+		f = &lintFileStats{File: name}
+		s.index[name] = f
+		s.files = append(s.files, f)
+	}
+	return f
+}
+
+// addFile records the total time spent on a file, including reading it.
+func (s *lintStats) addFile(name string, d time.Duration) {
+	if s != nil {
+		s.mu.Lock()
+		s.file(name).Total += d
+		s.mu.Unlock()
+	}
+}
+
+// addParse records time spent parsing a query in a file.
+func (s *lintStats) addParse(name string, d time.Duration) {
+	if s != nil {
+		s.mu.Lock()
+		s.file(name).Parse += d
+		s.mu.Unlock()
+	}
+}
+
+// addAnalyze records time spent on semantic analysis and lint rules.
+func (s *lintStats) addAnalyze(name string, d time.Duration) {
+	if s != nil {
+		s.mu.Lock()
+		s.file(name).Analyze += d
+		s.mu.Unlock()
+	}
+}
+
+// lintStatsReport is the JSON form of the statistics report. Durations
+// are in milliseconds.
+type lintStatsReport struct {
+	Files     int                   `json:"files"`
+	TotalMS   float64               `json:"total_ms"`
+	ParseMS   float64               `json:"parse_ms"`
+	AnalyzeMS float64               `json:"analyze_ms"`
+	Slowest   []lintFileStatsReport `json:"slowest"`
+	PerFile   []lintFileStatsReport `json:"per_file"`
+}
+
+type lintFileStatsReport struct {
+	File      string  `json:"file"`
+	TotalMS   float64 `json:"total_ms"`
+	ParseMS   float64 `json:"parse_ms"`
+	AnalyzeMS float64 `json:"analyze_ms"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (f *lintFileStats) report() lintFileStatsReport {
+	return lintFileStatsReport{
+		File:      f.File,
+		TotalMS:   milliseconds(f.Total),
+		ParseMS:   milliseconds(f.Parse),
+		AnalyzeMS: milliseconds(f.Analyze),
+	}
+}
+
+// slowest returns up to n files ordered by total time, slowest first.
+func (s *lintStats) slowest(n int) []*lintFileStats {
+	files := make([]*lintFileStats, len(s.files))
+	copy(files, s.files)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Total > files[j].Total
+	})
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files
+}
+
+func (s *lintStats) report() lintStatsReport {
+	r := lintStatsReport{
+		Files:   len(s.files),
+		TotalMS: milliseconds(s.Total),
+		Slowest: []lintFileStatsReport{},
+		PerFile: []lintFileStatsReport{},
+	}
+	for _, f := range s.files {
+		r.ParseMS += milliseconds(f.Parse)
+		r.AnalyzeMS += milliseconds(f.Analyze)
+		r.PerFile = append(r.PerFile, f.report())
+	}
+	for _, f := range s.slowest(lintSlowestFiles) {
+		r.Slowest = append(r.Slowest, f.report())
+	}
+	return r
+}
+
+// outputStatistics writes the statistics report in the given format.
+func outputStatistics(w io.Writer, s *lintStats, format string) error {
+	r := s.report()
+
+	if format == "json" {
+		data, err := json.Marshal(map[string]lintStatsReport{"statistics": r})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	fmt.Fprintln(w, "Statistics:")
+	fmt.Fprintf(w, "  Files:   %d\n", r.Files)
+	fmt.Fprintf(w, "  Total:   %.2fms\n", r.TotalMS)
+	fmt.Fprintf(w, "  Parse:   %.2fms\n", r.ParseMS)
+	fmt.Fprintf(w, "  Analyze: %.2fms\n", r.AnalyzeMS)
+	if len(r.Slowest) > 0 {
+		fmt.Fprintln(w, "  Slowest files:")
+		for _, f := range r.Slowest {
+			fmt.Fprintf(w, "    %8.2fms  %s (parse %.2fms, analyze %.2fms)\n", f.TotalMS, f.File, f.ParseMS, f.AnalyzeMS)
+		}
+	}
+	return nil
+}
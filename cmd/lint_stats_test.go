@@ -0,0 +1,115 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lintWithStatistics lints a directory of n files with --statistics and
+// returns what was written to stderr.
+func lintWithStatistics(t *testing.T, format string, n int) string {
+	t.Helper()
+	origStdout, origStderr := lintStdout, lintStderr
+	origFormat, origStats := lintFormat, lintStatistics
+	defer func() {
+		lintStdout, lintStderr = origStdout, origStderr
+		lintFormat, lintStatistics = origFormat, origStats
+	}()
+
+	dir := t.TempDir()
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, string(rune('a'+i))+".kql")
+		if err := os.WriteFile(path, []byte("T | where x > 1 | take 10"), 0644); err != nil {
+			t.Fatalf("failed to create temp file: %v", err)
+		}
+	}
+
+	var stderr bytes.Buffer
+	lintStdout, lintStderr = io.Discard, &stderr
+	lintFormat, lintStatistics = format, true
+
+	if _, err := doLint([]string{dir}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lintTimings != nil {
+		t.Error("expected the collector to be cleared after the run")
+	}
+	return stderr.String()
+}
+
+func TestDoLint_StatisticsText(t *testing.T) {
+	out := lintWithStatistics(t, "text", 3)
+
+	for _, want := range []string{"Statistics:", "Files:   3", "Parse:", "Analyze:", "Slowest files:", "a.kql"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected statistics to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDoLint_StatisticsJSON(t *testing.T) {
+	out := lintWithStatistics(t, "json", 7)
+
+	var got struct {
+		Statistics lintStatsReport `json:"statistics"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("expected a JSON statistics object, got %q: %v", out, err)
+	}
+	s := got.Statistics
+	if s.Files != 7 || len(s.PerFile) != 7 {
+		t.Errorf("expected 7 files, got files=%d per_file=%d", s.Files, len(s.PerFile))
+	}
+	if len(s.Slowest) != lintSlowestFiles {
+		t.Errorf("expected %d slowest files, got %d", lintSlowestFiles, len(s.Slowest))
+	}
+}
+
+func TestDoLint_NoStatisticsByDefault(t *testing.T) {
+	origStderr := lintStderr
+	defer func() { lintStderr = origStderr }()
+
+	var stderr bytes.Buffer
+	lintStderr = &stderr
+	logQuiet = true
+	defer func() { logQuiet = false }()
+
+	if _, err := doLint(nil, strings.NewReader("T | take 10")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no statistics, got %q", stderr.String())
+	}
+}
+
+func TestLintStats_Slowest(t *testing.T) {
+	s := newLintStats()
+	s.addFile("fast.kql", time.Millisecond)
+	s.addFile("slow.kql", time.Second)
+	s.addParse("slow.kql", 600*time.Millisecond)
+	s.addAnalyze("slow.kql", 300*time.Millisecond)
+	s.addFile("medium.kql", 10*time.Millisecond)
+
+	slowest := s.slowest(2)
+	if len(slowest) != 2 || slowest[0].File != "slow.kql" || slowest[1].File != "medium.kql" {
+		t.Errorf("unexpected order: %+v", slowest)
+	}
+
+	r := s.report()
+	if r.Files != 3 || r.ParseMS != 600 || r.AnalyzeMS != 300 {
+		t.Errorf("unexpected report: %+v", r)
+	}
+
+	// A nil collector ignores recording
+	var none *lintStats
+	none.addFile("x.kql", time.Second)
+}
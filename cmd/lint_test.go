@@ -15,6 +15,9 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -104,6 +107,65 @@ func TestLintReader(t *testing.T) {
 	}
 }
 
+func TestLintReader_LineEndings(t *testing.T) {
+	lintStrict = false
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}{
+		// The error is at the end of the input, just after the x
+		{"LF", "T\n| where (x", 2, 11},
+		{"CRLF", "T\r\n| where (x", 2, 11},
+		{"CRLF with final newline", "T\r\n| where (x\r\n", 3, 1},
+		{"lone CR", "T\r| where (x", 2, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := lintReader("query.kql", strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(diagnostics) == 0 {
+				t.Fatal("expected a syntax error")
+			}
+			if d := diagnostics[0]; d.Line != tt.line || d.Column != tt.column {
+				t.Errorf("expected %d:%d, got %d:%d (%s)", tt.line, tt.column, d.Line, d.Column, d.Message)
+			}
+		})
+	}
+}
+
+func TestLintReader_LongLine(t *testing.T) {
+	lintStrict = false
+	// Longer than a bufio.Scanner line
+	query := "print x = \"" + strings.Repeat("a", 100_000) + "\""
+	diagnostics, err := lintReader("query.kql", strings.NewReader(query))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"a\nb":             "a\nb",
+		"a\r\nb\r\n":       "a\nb\n",
+		"a\rb":             "a\nb",
+		"a\r\r\nb":         "a\n\nb",
+		"no final newline": "no final newline",
+	}
+	for in, want := range tests {
+		if got := normalizeNewlines(in); got != want {
+			t.Errorf("normalizeNewlines(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 func TestParseErrorToDiagnostic_WithPosition(t *testing.T) {
 	err := mockError{msg: "test.kql:5:10: unexpected token"}
 	diag := parseErrorToDiagnostic("test.kql", err)
@@ -137,7 +199,7 @@ func TestOutputJSON(t *testing.T) {
 	}
 
 	// Just ensure no error - actual output goes to stdout
-	err := outputJSON(diagnostics)
+	err := outputJSON(io.Discard, diagnostics)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -148,27 +210,27 @@ func TestOutputText(t *testing.T) {
 		{File: "test.kql", Line: 1, Column: 5, Severity: "error", Message: "test error"},
 	}
 
-	err := outputText(diagnostics, true)
+	err := outputText(io.Discard, diagnostics, true, false)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
 
 func TestOutputText_NoIssues(t *testing.T) {
-	lintQuiet = false
-	defer func() { lintQuiet = false }()
+	logQuiet = false
+	defer func() { logQuiet = false }()
 
-	err := outputText(nil, false)
+	err := outputText(io.Discard, nil, false, false)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
 
 func TestOutputText_Quiet(t *testing.T) {
-	lintQuiet = true
-	defer func() { lintQuiet = false }()
+	logQuiet = true
+	defer func() { logQuiet = false }()
 
-	err := outputText(nil, false)
+	err := outputText(io.Discard, nil, false, false)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -266,10 +328,10 @@ func TestLintFile_WithSyntaxError(t *testing.T) {
 
 func TestDoLint_FromStdin(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	stdin := strings.NewReader("T | take 10\n")
@@ -284,10 +346,10 @@ func TestDoLint_FromStdin(t *testing.T) {
 
 func TestDoLint_FromStdinWithDash(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	stdin := strings.NewReader("T | take 10\n")
@@ -302,10 +364,10 @@ func TestDoLint_FromStdinWithDash(t *testing.T) {
 
 func TestDoLint_FromFile(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	tmpDir := t.TempDir()
@@ -325,10 +387,10 @@ func TestDoLint_FromFile(t *testing.T) {
 
 func TestDoLint_WithErrors(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	tmpDir := t.TempDir()
@@ -348,10 +410,10 @@ func TestDoLint_WithErrors(t *testing.T) {
 
 func TestDoLint_FileNotFound(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	_, err := doLint([]string{"/nonexistent/file.kql"}, nil)
@@ -362,10 +424,10 @@ func TestDoLint_FileNotFound(t *testing.T) {
 
 func TestDoLint_MultipleFiles(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	tmpDir := t.TempDir()
@@ -388,7 +450,7 @@ func TestDoLint_MultipleFiles(t *testing.T) {
 }
 
 func TestOutputJSON_Empty(t *testing.T) {
-	err := outputJSON(nil)
+	err := outputJSON(io.Discard, nil)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -438,9 +500,9 @@ func TestRunLint_Success(t *testing.T) {
 
 	// Reset flags
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	lintFormat = "text"
-	defer func() { lintQuiet = false }()
+	defer func() { logQuiet = false }()
 
 	// Create temp file with valid query
 	tmpDir := t.TempDir()
@@ -473,9 +535,9 @@ func TestRunLint_WithErrors(t *testing.T) {
 
 	// Reset flags
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	lintFormat = "text"
-	defer func() { lintQuiet = false }()
+	defer func() { logQuiet = false }()
 
 	// Create temp file with syntax error
 	tmpDir := t.TempDir()
@@ -499,7 +561,7 @@ func TestRunLint_WithErrors(t *testing.T) {
 func TestRunLint_DoLintError(t *testing.T) {
 	// Reset flags with invalid format to trigger error
 	lintStrict = false
-	lintQuiet = false
+	logQuiet = false
 	lintFormat = "invalid"
 	defer func() { lintFormat = "text" }()
 
@@ -542,7 +604,7 @@ func TestOutputJSON_WithMultipleDiagnostics(t *testing.T) {
 		{File: "a.kql", Line: 1, Column: 1, Severity: "error", Message: "first"},
 		{File: "b.kql", Line: 2, Column: 3, Severity: "warning", Message: "second"},
 	}
-	err := outputJSON(diagnostics)
+	err := outputJSON(io.Discard, diagnostics)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -552,7 +614,7 @@ func TestOutputText_WithWarnings(t *testing.T) {
 	diagnostics := []LintDiagnostic{
 		{File: "test.kql", Line: 1, Column: 1, Severity: "warning", Message: "this is a warning"},
 	}
-	err := outputText(diagnostics, false)
+	err := outputText(io.Discard, diagnostics, false, false)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -576,10 +638,10 @@ func TestDoLint_OutputDiagnosticsError(t *testing.T) {
 
 func TestDoLint_StdinReadError(t *testing.T) {
 	lintStrict = false
-	lintQuiet = true
+	logQuiet = true
 	defer func() {
 		lintStrict = false
-		lintQuiet = false
+		logQuiet = false
 	}()
 
 	stdin := errorReader{}
@@ -623,3 +685,401 @@ func TestLintQuery_StrictModeWithWarningsPath(t *testing.T) {
 	// Just verify we exercised the code path
 	t.Logf("Got %d diagnostics", len(diagnostics))
 }
+
+func TestExplainDiagnostics(t *testing.T) {
+	lintStrict = false
+	diags, err := lintQuery("q.kql", "T | extend s = 'abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diags) == 0 {
+		t.Fatal("expected a syntax error")
+	}
+
+	explainDiagnostics(diags)
+	if diags[0].Explanation == "" {
+		t.Errorf("expected explanation for %q", diags[0].Message)
+	}
+
+	data, err := json.Marshal(diags[0])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"explanation":`) {
+		t.Errorf("expected explanation field in JSON, got %s", data)
+	}
+}
+
+func TestExplainDiagnostics_ParserMessages(t *testing.T) {
+	lintStrict = false
+	tests := []struct {
+		name, query, want string
+	}{
+		{"unclosed paren", "T | where (A > 0", "parenthesis"},
+		{"unclosed call", "T | summarize count( by A", "parenthesis"},
+		{"stray pipe", "T | where A > | take 1", "'|'"},
+		{"doubled comma", "T | project A, , B", "comma"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, err := lintQuery("q.kql", tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(diags) == 0 {
+				t.Fatal("expected a syntax error")
+			}
+			explainDiagnostics(diags)
+			if !strings.Contains(diags[0].Explanation, tt.want) {
+				t.Errorf("%q: expected an explanation mentioning %s, got %q", diags[0].Message, tt.want, diags[0].Explanation)
+			}
+		})
+	}
+}
+
+func TestExplainDiagnostics_SkipsRuleDiagnostics(t *testing.T) {
+	diags := []LintDiagnostic{{Severity: "info", Code: ruleConsecutiveWhere, Message: "consecutive where operators can be combined with 'and'"}}
+	explainDiagnostics(diags)
+	if diags[0].Explanation != "" {
+		t.Errorf("expected no explanation for rule diagnostic, got %q", diags[0].Explanation)
+	}
+
+	data, _ := json.Marshal(diags[0])
+	if strings.Contains(string(data), "explanation") {
+		t.Errorf("expected explanation to be omitted from JSON, got %s", data)
+	}
+}
+
+func TestOutputDiagnostics_Stream(t *testing.T) {
+	origStdout, origStderr := lintStdout, lintStderr
+	origFormat, origTo, origQuiet := lintFormat, lintDiagTo, logQuiet
+	defer func() {
+		lintStdout, lintStderr = origStdout, origStderr
+		lintFormat, lintDiagTo, logQuiet = origFormat, origTo, origQuiet
+	}()
+	logQuiet = false
+
+	diagnostics := []LintDiagnostic{
+		{File: "a.kql", Line: 1, Column: 1, Severity: "error", Message: "test"},
+	}
+
+	tests := []struct {
+		format, to  string
+		diagnostics []LintDiagnostic
+		want        string
+		wantStderr  bool
+	}{
+		{"text", "stdout", diagnostics, "a.kql:1:1: error: test", false},
+		{"text", "stderr", diagnostics, "a.kql:1:1: error: test", true},
+		{"text", "stdout", nil, "No issues found.", false},
+		{"text", "stderr", nil, "No issues found.", true},
+		{"json", "stdout", diagnostics, `"message":"test"`, false},
+		{"json", "stderr", diagnostics, `"message":"test"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+"-"+tt.to, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			lintStdout, lintStderr = &stdout, &stderr
+			lintFormat, lintDiagTo = tt.format, tt.to
+
+			if err := outputDiagnostics(tt.diagnostics, tt.diagnostics != nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, other := &stdout, &stderr
+			if tt.wantStderr {
+				got, other = &stderr, &stdout
+			}
+			if !strings.Contains(got.String(), tt.want) {
+				t.Errorf("expected %q on the selected stream, got %q", tt.want, got.String())
+			}
+			if other.Len() != 0 {
+				t.Errorf("expected nothing on the other stream, got %q", other.String())
+			}
+		})
+	}
+}
+
+func TestDoLint_UnknownDiagnosticsStream(t *testing.T) {
+	origTo := lintDiagTo
+	defer func() { lintDiagTo = origTo }()
+
+	lintDiagTo = "stdlog"
+	if _, err := doLint(nil, strings.NewReader("T | take 10")); err == nil {
+		t.Error("expected error for unknown diagnostics stream")
+	}
+}
+
+func TestOutputGitHub(t *testing.T) {
+	diagnostics := []LintDiagnostic{
+		{File: "q/a.kql", Line: 3, Column: 7, Severity: SeverityError, Message: "expected ), got IDENT"},
+		{File: "b,c.kql", Line: 1, Column: 1, Severity: SeverityWarning, Code: "duplicate-filter", Message: "100% duplicate\nsecond line"},
+		{File: "d.kql", Line: 2, Column: 5, Severity: SeverityInfo, Code: "consecutive-where", Message: "combine"},
+	}
+
+	var buf bytes.Buffer
+	if err := outputGitHub(&buf, diagnostics); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"::error file=q/a.kql,line=3,col=7::expected ), got IDENT",
+		"::warning file=b%2Cc.kql,line=1,col=1,title=duplicate-filter::100%25 duplicate%0Asecond line",
+		"::notice file=d.kql,line=2,col=5,title=consecutive-where::combine",
+	}
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(want), len(got), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d:\n  got:  %s\n  want: %s", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestOutputDiagnostics_GitHub(t *testing.T) {
+	origStdout, origFormat := lintStdout, lintFormat
+	defer func() { lintStdout, lintFormat = origStdout, origFormat }()
+
+	var buf bytes.Buffer
+	lintStdout, lintFormat = &buf, "github"
+	if err := outputDiagnostics(nil, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output without diagnostics, got %q", buf.String())
+	}
+}
+
+func TestOutputText_Summary(t *testing.T) {
+	origQuiet := logQuiet
+	defer func() { logQuiet = origQuiet }()
+	logQuiet = false
+
+	diagnostics := []LintDiagnostic{
+		{File: "a.kql", Line: 1, Column: 1, Severity: SeverityError, Message: "one"},
+		{File: "a.kql", Line: 2, Column: 1, Severity: SeverityError, Message: "two"},
+		{File: "a.kql", Line: 3, Column: 1, Severity: SeverityWarning, Message: "three"},
+		{File: "b.kql", Line: 1, Column: 1, Severity: SeverityInfo, Message: "four"},
+	}
+
+	var buf bytes.Buffer
+	if err := outputText(&buf, diagnostics, true, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got, want := lines[len(lines)-1], "2 errors, 1 warning, 1 info across 2 files"; got != want {
+		t.Errorf("summary:\n  got:  %q\n  want: %q", got, want)
+	}
+
+	logQuiet = true
+	buf.Reset()
+	if err := outputText(&buf, diagnostics, true, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "across") {
+		t.Errorf("expected no summary with --quiet, got %q", buf.String())
+	}
+}
+
+func TestDiagnosticSummary(t *testing.T) {
+	tests := []struct {
+		diagnostics []LintDiagnostic
+		want        string
+	}{
+		{
+			[]LintDiagnostic{{File: "a.kql", Severity: SeverityError}},
+			"1 error, 0 warnings across 1 file",
+		},
+		{
+			[]LintDiagnostic{
+				{File: "a.kql", Severity: SeverityWarning},
+				{File: "b.kql", Severity: SeverityWarning},
+				{File: "c.kql", Severity: SeverityHint},
+				{File: "c.kql", Severity: SeverityHint},
+			},
+			"0 errors, 2 warnings, 2 hints across 3 files",
+		},
+	}
+	for _, tt := range tests {
+		if got := diagnosticSummary(tt.diagnostics); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRelativizePaths(t *testing.T) {
+	base := t.TempDir()
+	diags := []LintDiagnostic{
+		{File: filepath.Join(base, "queries", "a.kql")},
+		{File: filepath.Join(filepath.Dir(base), "other.kql")},
+		{File: "stdin"},
+	}
+	if err := relativizePaths(diags, base); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"queries/a.kql", "../other.kql", "stdin"}
+	for i, d := range diags {
+		if d.File != want[i] {
+			t.Errorf("diags[%d].File = %q, want %q", i, d.File, want[i])
+		}
+	}
+}
+
+func TestDoLint_RelativeTo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "bad.kql")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("T | where (x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	origStdout := lintStdout
+	lintStdout = &stdout
+	lintFormat = "json"
+	lintRelativeTo = dir
+	defer func() {
+		lintStdout = origStdout
+		lintFormat = "text"
+		lintRelativeTo = ""
+	}()
+
+	if _, err := doLint([]string{path}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"file":"sub/bad.kql"`) {
+		t.Errorf("expected a relative path, got %s", stdout.String())
+	}
+	if strings.Contains(stdout.String(), dir) {
+		t.Errorf("absolute path leaked into output: %s", stdout.String())
+	}
+}
+
+func TestDoLint_StdinName(t *testing.T) {
+	var stdout bytes.Buffer
+	origStdout := lintStdout
+	lintStdout = &stdout
+	defer func(name, format, relativeTo string) {
+		lintStdout = origStdout
+		lintStdinName, lintFormat, lintRelativeTo = name, format, relativeTo
+	}(lintStdinName, lintFormat, lintRelativeTo)
+
+	lintStdinName = "cell-3.kql"
+	lintFormat = "text"
+	if _, err := doLint(nil, strings.NewReader("T | where (x\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "cell-3.kql:2:1:") || strings.Contains(stdout.String(), "stdin") {
+		t.Errorf("expected diagnostics labeled cell-3.kql, got %s", stdout.String())
+	}
+
+	// The name is kept as given, not made relative
+	stdout.Reset()
+	lintFormat = "json"
+	lintRelativeTo = t.TempDir()
+	if _, err := doLint([]string{"-"}, strings.NewReader("T | where (x\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"file":"cell-3.kql"`) {
+		t.Errorf("expected JSON diagnostics for cell-3.kql, got %s", stdout.String())
+	}
+
+	lintStdinName = ""
+	if _, err := doLint(nil, strings.NewReader("T | take 10")); err == nil {
+		t.Error("expected an error for an empty --stdin-name")
+	}
+}
+
+func TestReadLintManifest(t *testing.T) {
+	manifest := "# changed files\n\na.kql\r\n  queries/b.kql  \n#c.kql\n"
+	got, err := readLintManifest("-", strings.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.kql", "queries/b.kql"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := readLintManifest(filepath.Join(t.TempDir(), "missing.txt"), nil); err == nil {
+		t.Error("expected an error for a missing manifest")
+	}
+}
+
+func TestDoLint_FilesFrom(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.kql")
+	bad := filepath.Join(dir, "bad.kql")
+	unlisted := filepath.Join(dir, "unlisted.kql")
+	for path, query := range map[string]string{good: "T | take 1\n", bad: "T | where (x\n", unlisted: "T | where (y\n"} {
+		if err := os.WriteFile(path, []byte(query), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest := filepath.Join(dir, "changed.txt")
+	if err := os.WriteFile(manifest, []byte("# changed\n"+good+"\n\n"+bad+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	origStdout := lintStdout
+	lintStdout = &stdout
+	lintFilesFrom = manifest
+	defer func() {
+		lintStdout = origStdout
+		lintFilesFrom = ""
+	}()
+
+	hasErrors, err := doLint(nil, strings.NewReader("ignored ((\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasErrors {
+		t.Error("expected errors from bad.kql")
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "bad.kql") || strings.Contains(out, "unlisted.kql") || strings.Contains(out, "stdin") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	// An empty manifest lints nothing rather than falling back to stdin
+	stdout.Reset()
+	if err := os.WriteFile(manifest, []byte("# nothing changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hasErrors, err = doLint(nil, strings.NewReader("ignored ((\n"))
+	if err != nil || hasErrors {
+		t.Errorf("empty manifest: hasErrors=%v err=%v", hasErrors, err)
+	}
+}
+
+func TestDoLint_FilesFromStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.kql")
+	if err := os.WriteFile(path, []byte("T | where (x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	origStdout := lintStdout
+	lintStdout = io.Discard
+	lintFilesFrom = "-"
+	defer func() {
+		lintStdout = origStdout
+		lintFilesFrom = ""
+	}()
+
+	hasErrors, err := doLint(nil, strings.NewReader(path+"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasErrors {
+		t.Error("expected errors from the listed file")
+	}
+
+	if _, err := doLint([]string{"-"}, strings.NewReader(path+"\n")); err == nil {
+		t.Error("expected an error when stdin is both the manifest and a query")
+	}
+}
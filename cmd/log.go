@@ -0,0 +1,66 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// logLevel is the importance of a message written to stderr alongside a
+// command's result.
+type logLevel int
+
+const (
+	// logWarn is for non-fatal problems, such as a generated query that
+	// failed validation; --quiet hides them
+	logWarn logLevel = iota
+
+	// logNotice is for confirmations, such as a file written; --quiet
+	// hides them
+	logNotice
+
+	// logInfo is for progress and context; only --verbose shows them
+	logInfo
+)
+
+// The global --verbose and --quiet flags.
+var (
+	logVerbose bool
+	logQuiet   bool
+)
+
+// logOutput is where logf writes. The result of a command goes to stdout
+// (or --output), never here.
+var logOutput io.Writer = os.Stderr
+
+// logEnabled reports whether messages at level are shown.
+func logEnabled(level logLevel) bool {
+	if level == logInfo {
+		return logVerbose
+	}
+	return !logQuiet
+}
+
+// logf writes a message at level, adding a trailing newline if missing.
+func logf(level logLevel, format string, args ...any) {
+	if !logEnabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if len(msg) == 0 || msg[len(msg)-1] != '\n' {
+		msg += "\n"
+	}
+	fmt.Fprint(logOutput, msg)
+}
+
+// logWriter returns logOutput if level is shown, or nil, for functions
+// that take an optional writer for their progress.
+func logWriter(level logLevel) io.Writer {
+	if !logEnabled(level) {
+		return nil
+	}
+	return logOutput
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestLogf_Levels(t *testing.T) {
+	defer func(v, q bool, w io.Writer) { logVerbose, logQuiet, logOutput = v, q, w }(logVerbose, logQuiet, logOutput)
+
+	tests := []struct {
+		name           string
+		verbose, quiet bool
+		want           string
+	}{
+		{"default", false, false, "warn\n"},
+		{"verbose", true, false, "warn\ninfo\n"},
+		{"quiet", false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logOutput = &buf
+			logVerbose, logQuiet = tt.verbose, tt.quiet
+
+			logf(logWarn, "warn")
+			logf(logInfo, "info\n")
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+
+			if w := logWriter(logInfo); (w != nil) != tt.verbose {
+				t.Errorf("logWriter(logInfo) = %v with verbose=%v", w, tt.verbose)
+			}
+		})
+	}
+}
+
+func TestFixQuery_LogsToStderrOnly(t *testing.T) {
+	defer func(v bool, w io.Writer) { logVerbose, logOutput = v, w }(logVerbose, logOutput)
+	defer func(v int) { fixRetries = v }(fixRetries)
+	fixRetries = 0
+
+	var out, log bytes.Buffer
+	logOutput = &log
+	logVerbose = true
+	getProvider := func() (ai.Provider, error) {
+		return &sequenceProvider{responses: []string{minimalFix}}, nil
+	}
+	if err := fixQuery(brokenQuery, &out, getProvider); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.String() != minimalFix+"\n" {
+		t.Errorf("expected only the fix on stdout, got %q", out.String())
+	}
+	if !bytes.Contains(log.Bytes(), []byte("Found errors:")) || !bytes.Contains(log.Bytes(), []byte("Attempt 1/1")) {
+		t.Errorf("expected progress in the log, got %q", log.String())
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+	"github.com/spf13/cobra"
+)
+
+var normalizeFile string
+
+var normalizeCmd = &cobra.Command{
+	Use:   "normalize [query]",
+	Short: "Print a canonical form of a KQL query",
+	Long: `Print a canonical form of a KQL query for comparison and deduplication.
+
+Comments are removed, whitespace is collapsed, and each pipe stage is placed
+on its own line. Two queries that differ only cosmetically normalize to the
+same text.
+
+Normalization never changes meaning: keywords, identifiers, and literals are
+kept exactly as written (KQL is case-sensitive) and nothing is reordered.
+
+The query can be provided as an argument, from a file (-f), or via stdin.`,
+	Example: `  # Normalize a file
+  kql normalize -f query.kql
+
+  # Compare two queries ignoring formatting
+  diff <(kql normalize -f a.kql) <(kql normalize -f b.kql)
+
+  # From stdin
+  cat query.kql | kql normalize`,
+	RunE: runNormalize,
+}
+
+func init() {
+	rootCmd.AddCommand(normalizeCmd)
+
+	normalizeCmd.Flags().StringVarP(&normalizeFile, "file", "f", "", "Read query from file")
+	addInputSourceFlags(normalizeCmd)
+}
+
+func runNormalize(cmd *cobra.Command, args []string) error {
+	query, err := getInput(args, normalizeFile)
+	if err != nil {
+		return err
+	}
+
+	normalized, err := kqlfmt.Normalize(query)
+	if err != nil {
+		return fmt.Errorf("normalize failed: %w", err)
+	}
+
+	fmt.Println(normalized)
+	return nil
+}
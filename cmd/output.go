@@ -0,0 +1,101 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// aiOutput is the -o/--output file shared by the AI commands.
+var aiOutput string
+
+// resultOutput is where an AI command writes its result: stdout, or the
+// --output file. Progress and warnings stay on stderr either way.
+type resultOutput struct {
+	w    io.Writer
+	warn io.Writer
+
+	// file is a temporary file next to name, renamed over it by Close so
+	// a failed command leaves name as it was
+	file *os.File
+	name string
+
+	// wrote is set once anything other than whitespace is written
+	wrote bool
+}
+
+// openOutput prepares the named file for the result, or returns stdout
+// when name is empty. warn receives the empty-result warning from Close.
+func openOutput(name string, warn io.Writer) (*resultOutput, error) {
+	if name == "" {
+		return &resultOutput{w: os.Stdout}, nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("creating output file: %w", err)
+	}
+	return &resultOutput{w: f, file: f, name: name, warn: warn}, nil
+}
+
+func (o *resultOutput) Write(p []byte) (int, error) {
+	if len(bytes.TrimSpace(p)) > 0 {
+		o.wrote = true
+	}
+	return o.w.Write(p)
+}
+
+// toFile reports whether the result goes to a file rather than stdout.
+func (o *resultOutput) toFile() bool {
+	return o.file != nil
+}
+
+// Close replaces the output file with the result, warning if it was
+// empty. An existing file keeps its permissions.
+func (o *resultOutput) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	f := o.file
+	o.file = nil
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(o.name); err == nil {
+		mode = info.Mode().Perm()
+	}
+	err := f.Chmod(mode)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), o.name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("writing output file: %w", err)
+	}
+
+	if !o.wrote {
+		fmt.Fprintf(o.warn, "Warning: empty result written to %s\n", o.name)
+	}
+	return nil
+}
+
+// finish ends the output with the command's error: with none, the result
+// is written as by Close; otherwise it is discarded, leaving the output
+// file untouched. It returns err, or the error from Close.
+func (o *resultOutput) finish(err error) error {
+	if err == nil {
+		return o.Close()
+	}
+	if o.file != nil {
+		o.file.Close()
+		os.Remove(o.file.Name())
+		o.file = nil
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenOutput_File(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.kql")
+	if err := os.WriteFile(name, []byte("old contents that should go away\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warn bytes.Buffer
+	out, err := openOutput(name, &warn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.toFile() {
+		t.Error("expected output to a file")
+	}
+	fmt.Fprintln(out, "T | take 10")
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "T | take 10\n" {
+		t.Errorf("file = %q", data)
+	}
+	if warn.Len() != 0 {
+		t.Errorf("expected no warning, got %q", warn.String())
+	}
+}
+
+func TestOpenOutput_EmptyResult(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.kql")
+
+	var warn bytes.Buffer
+	out, err := openOutput(name, &warn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(out, "")
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(name); err != nil {
+		t.Errorf("expected the file to be created: %v", err)
+	}
+	if !strings.Contains(warn.String(), "empty result written to "+name) {
+		t.Errorf("expected an empty result warning, got %q", warn.String())
+	}
+}
+
+func TestOpenOutput_Stdout(t *testing.T) {
+	out, err := openOutput("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.toFile() {
+		t.Error("expected stdout without --output")
+	}
+	if err := out.Close(); err != nil {
+		t.Errorf("closing stdout output: %v", err)
+	}
+}
+
+func TestOpenOutput_BadPath(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing", "out.kql")
+	if _, err := openOutput(name, nil); err == nil || !strings.Contains(err.Error(), "creating output file") {
+		t.Errorf("expected a create error, got %v", err)
+	}
+}
+
+func TestOpenOutput_FailureKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "out.kql")
+	if err := os.WriteFile(name, []byte("T | count\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := openOutput(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(out, "partial")
+	failed := errors.New("provider unavailable")
+	if err := out.finish(failed); err != failed {
+		t.Errorf("expected the command's error back, got %v", err)
+	}
+
+	if data, _ := os.ReadFile(name); string(data) != "T | count\n" {
+		t.Errorf("expected the file to be left as it was, got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected the temporary file to be removed, got %v", entries)
+	}
+}
+
+func TestOpenOutput_KeepsMode(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.kql")
+	if err := os.WriteFile(name, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := openOutput(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(out, "T | take 10")
+	if err := out.finish(nil); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(name); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600 to be kept, got %v, %v", info.Mode(), err)
+	}
+}
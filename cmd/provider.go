@@ -0,0 +1,133 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+// resolveProvider creates the provider for an AI command from the flags,
+// the config file, and the defaults, in that order of precedence. The
+// returned config is the one the provider was created with.
+func resolveProvider() (ai.Provider, ai.Config, error) {
+	cfg, err := loadAIConfig()
+	if err != nil {
+		return nil, cfg, err
+	}
+	cfg, err = applySystemPromptFile(applyCacheFlags(cfg))
+	if err != nil {
+		return nil, cfg, err
+	}
+
+	provider, err := ai.NewProvider(cfg)
+	if err != nil {
+		return nil, cfg, fmt.Errorf("creating AI provider: %w", err)
+	}
+	return provider, cfg, nil
+}
+
+// loadAIConfig returns the AI settings from flags, merged over the config
+// file's selected profile, with the default provider filled in.
+func loadAIConfig() (ai.Config, error) {
+	fileCfg, err := loadAIFileConfig()
+	if err != nil {
+		return ai.Config{}, err
+	}
+	return aiConfigFrom(fileCfg), nil
+}
+
+// aiConfigFrom merges the AI flags and environment variables over an
+// already loaded config file. The default model is filled in once the
+// provider is known, so it is always that provider's.
+func aiConfigFrom(fileCfg *ai.FileConfig) ai.Config {
+	flagCfg := applyAIEnv(buildAIConfig(), aiFlagChanged("temperature"), os.Getenv)
+	cfg := ai.MergeFileConfig(flagCfg, fileCfg)
+	if cfg.Provider == "" {
+		cfg.Provider = ai.DefaultProvider
+	}
+	if cfg.Model == "" {
+		cfg.Model = ai.DefaultModel(cfg.Provider)
+	}
+	return cfg
+}
+
+// applySystemPromptFile sets the system prompt from --system-prompt-file,
+// which like --system-prompt takes precedence over the config file.
+func applySystemPromptFile(cfg ai.Config) (ai.Config, error) {
+	if aiSystemPromptFile == "" {
+		return cfg, nil
+	}
+	if aiSystemPrompt != "" {
+		return cfg, fmt.Errorf("use only one of --system-prompt and --system-prompt-file")
+	}
+
+	data, err := os.ReadFile(aiSystemPromptFile)
+	if err != nil {
+		return cfg, fmt.Errorf("reading system prompt file: %w", err)
+	}
+	cfg.SystemPrompt = strings.TrimSpace(string(data))
+	if cfg.SystemPrompt == "" {
+		return cfg, fmt.Errorf("system prompt file %s is empty", aiSystemPromptFile)
+	}
+	return cfg, nil
+}
+
+// Environment variables for the AI settings. Each applies where the flag
+// wasn't given, ahead of the config file.
+const (
+	envAIProvider          = "KQL_AI_PROVIDER"
+	envAIModel             = "KQL_AI_MODEL"
+	envAITemperature       = "KQL_AI_TEMPERATURE"
+	envOllamaEndpoint      = "KQL_OLLAMA_ENDPOINT"
+	envInstructLabEndpoint = "KQL_INSTRUCTLAB_ENDPOINT"
+	envAzureEndpoint       = "KQL_AZURE_ENDPOINT"
+	envAzureDeployment     = "KQL_AZURE_DEPLOYMENT"
+)
+
+// applyAIEnv fills the settings left empty by flags from the environment.
+// temperatureSet reports whether --temperature was given, since its flag
+// default is never empty; an unparseable KQL_AI_TEMPERATURE is ignored with
+// a warning.
+func applyAIEnv(cfg ai.Config, temperatureSet bool, getenv func(string) string) ai.Config {
+	fill := func(value *string, name string) {
+		if *value == "" {
+			*value = getenv(name)
+		}
+	}
+	fill(&cfg.Provider, envAIProvider)
+	fill(&cfg.Model, envAIModel)
+	fill(&cfg.Ollama.Endpoint, envOllamaEndpoint)
+	fill(&cfg.InstructLab.Endpoint, envInstructLabEndpoint)
+	fill(&cfg.Azure.Endpoint, envAzureEndpoint)
+	fill(&cfg.Azure.Deployment, envAzureDeployment)
+
+	if env := getenv(envAITemperature); env != "" && !temperatureSet {
+		t, err := strconv.ParseFloat(env, 32)
+		if err != nil {
+			logf(logWarn, "Warning: ignoring invalid %s %q", envAITemperature, env)
+		} else {
+			cfg.Temperature = float32(t)
+		}
+	}
+	return cfg
+}
+
+// aiFlagChanged reports whether the named AI flag was given to the
+// running command. Only the command being run has parsed its flags.
+func aiFlagChanged(name string) bool {
+	commands := rootCmd.Commands()
+	for len(commands) > 0 {
+		c := commands[0]
+		commands = append(commands[1:], c.Commands()...)
+		if c.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/spf13/cobra"
+)
+
+// aiProviderInfo is shared by the AI commands: print the resolved provider
+// settings and exit without calling the model.
+var aiProviderInfo bool
+
+// Sources reported by --provider-info.
+const (
+	sourceFlag        = "flag"
+	sourceFlagDefault = "flag default"
+	sourceConfigFile  = "config file"
+	sourceDefault     = "default"
+	sourceUnset       = "unset"
+)
+
+// resolvedSetting is one effective provider setting and where it came from.
+type resolvedSetting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// candidate is a possible value for a setting, in precedence order.
+type candidate struct {
+	value  string
+	source string
+}
+
+// firstSet returns the first candidate with a value.
+func firstSet(name string, candidates ...candidate) resolvedSetting {
+	for _, c := range candidates {
+		if c.value != "" {
+			return resolvedSetting{Name: name, Value: c.value, Source: c.source}
+		}
+	}
+	return resolvedSetting{Name: name, Source: sourceUnset}
+}
+
+// mergeLayers holds the config after each stage of the AI commands'
+// merge: the flags, then applyAIEnv, then ai.MergeFileConfig. Each stage
+// only fills unset settings, so the first stage with a value set it.
+type mergeLayers struct {
+	flags, env, file ai.Config
+}
+
+// layered returns a setting's merged value and the stage that set it.
+// flagName and envName label its flag and the KQL_* variable applyAIEnv
+// reads for it; settings without one never take that branch.
+func (m mergeLayers) layered(flagName, envName string, get func(ai.Config) string) candidate {
+	switch {
+	case get(m.flags) != "":
+		return candidate{get(m.flags), sourceFlag + " --" + flagName}
+	case get(m.env) != "":
+		return candidate{get(m.env), "env " + envName}
+	default:
+		return candidate{get(m.file), sourceConfigFile}
+	}
+}
+
+// temperature returns the merged temperature and where it came from. The
+// flag always has a value, its default when not given, so each stage is
+// checked by whether it changed the value.
+func (m mergeLayers) temperature(changed bool) resolvedSetting {
+	s := resolvedSetting{
+		Name:   "temperature",
+		Value:  strconv.FormatFloat(float64(m.file.Temperature), 'g', -1, 32),
+		Source: sourceFlagDefault,
+	}
+	switch {
+	case m.file.Temperature != m.env.Temperature:
+		s.Source = sourceConfigFile
+	case m.env.Temperature != m.flags.Temperature:
+		s.Source = "env " + envAITemperature
+	case changed:
+		s.Source = sourceFlag + " --temperature"
+	}
+	return s
+}
+
+// resolveProviderInfo runs the same merge as the AI commands (flags, then
+// KQL_* environment variables, then the config file) one stage at a time
+// to record where each value came from, falling back to the providers' own
+// environment variables and built-in defaults. flagCfg is the result of
+// buildAIConfig and changed reports whether a flag was set.
+func resolveProviderInfo(flagCfg ai.Config, changed func(string) bool, fileCfg *ai.FileConfig, getenv func(string) string) []resolvedSetting {
+	m := mergeLayers{flags: flagCfg}
+	m.env = applyAIEnv(m.flags, changed("temperature"), getenv)
+	m.file = ai.MergeFileConfig(m.env, fileCfg)
+
+	env := func(name string) candidate {
+		return candidate{getenv(name), "env " + name}
+	}
+
+	provider := firstSet("provider",
+		m.layered("provider", envAIProvider, func(c ai.Config) string { return c.Provider }),
+		candidate{ai.DefaultProvider, sourceDefault},
+	)
+
+	model := firstSet("model",
+		m.layered("model", envAIModel, func(c ai.Config) string { return c.Model }),
+		candidate{ai.DefaultModel(provider.Value), sourceDefault},
+	)
+
+	temperature := m.temperature(changed("temperature"))
+
+	settings := []resolvedSetting{provider, model, temperature}
+
+	switch provider.Value {
+	case "ollama":
+		settings = append(settings, firstSet("endpoint",
+			m.layered("ollama-endpoint", envOllamaEndpoint, func(c ai.Config) string { return c.Ollama.Endpoint }),
+			candidate{ai.DefaultOllamaEndpoint, sourceDefault},
+		))
+	case "instructlab":
+		settings = append(settings, firstSet("endpoint",
+			m.layered("instructlab-endpoint", envInstructLabEndpoint, func(c ai.Config) string { return c.InstructLab.Endpoint }),
+			candidate{ai.DefaultInstructLabEndpoint, sourceDefault},
+		))
+	case "vertex":
+		settings = append(settings,
+			firstSet("project",
+				m.layered("vertex-project", "", func(c ai.Config) string { return c.Vertex.Project }),
+				env("KQL_GCP_PROJECT"),
+				env("GOOGLE_CLOUD_PROJECT"),
+			),
+			firstSet("location",
+				m.layered("vertex-location", "", func(c ai.Config) string { return c.Vertex.Location }),
+				candidate{ai.DefaultVertexLocation, sourceDefault},
+			),
+		)
+	case "azure":
+		apiKey := firstSet("api key",
+			m.layered("", "", func(c ai.Config) string { return c.Azure.APIKey }),
+			env("AZURE_OPENAI_API_KEY"),
+		)
+		apiKey = maskAPIKey(apiKey)
+		settings = append(settings,
+			firstSet("endpoint",
+				m.layered("azure-endpoint", envAzureEndpoint, func(c ai.Config) string { return c.Azure.Endpoint }),
+				env("AZURE_OPENAI_ENDPOINT"),
+			),
+			firstSet("deployment",
+				m.layered("azure-deployment", envAzureDeployment, func(c ai.Config) string { return c.Azure.Deployment }),
+				env("AZURE_OPENAI_DEPLOYMENT"),
+			),
+			apiKey,
+		)
+	case "openai":
+		apiKey := firstSet("api key",
+			m.layered("openai-api-key", "", func(c ai.Config) string { return c.OpenAI.APIKey }),
+			env("OPENAI_API_KEY"),
+		)
+		apiKey = maskAPIKey(apiKey)
+		settings = append(settings,
+			firstSet("base url",
+				m.layered("", "", func(c ai.Config) string { return c.OpenAI.BaseURL }),
+				candidate{ai.DefaultOpenAIBaseURL, sourceDefault},
+			),
+			apiKey,
+		)
+	case "anthropic":
+		apiKey := firstSet("api key",
+			m.layered("anthropic-api-key", "", func(c ai.Config) string { return c.Anthropic.APIKey }),
+			env("ANTHROPIC_API_KEY"),
+		)
+		apiKey = maskAPIKey(apiKey)
+		settings = append(settings,
+			firstSet("base url",
+				m.layered("", "", func(c ai.Config) string { return c.Anthropic.BaseURL }),
+				candidate{ai.DefaultAnthropicBaseURL, sourceDefault},
+			),
+			apiKey,
+		)
+	}
+
+	return settings
+}
+
+// maskAPIKey hides an API key's value. Keyring references name no secret,
+// so they are shown as written.
+func maskAPIKey(s resolvedSetting) resolvedSetting {
+	s.Value = redactKey(s.Value)
+	return s
+}
+
+// writeProviderInfo prints one aligned line per setting.
+func writeProviderInfo(w io.Writer, settings []resolvedSetting) {
+	for _, s := range settings {
+		value := s.Value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(w, "%-12s %-32s (%s)\n", s.Name+":", value, s.Source)
+	}
+}
+
+// runProviderInfo implements --provider-info for the AI commands.
+func runProviderInfo(cmd *cobra.Command) error {
+	fileCfg, err := loadAIFileConfig()
+	if err != nil {
+		return err
+	}
+
+	settings := resolveProviderInfo(buildAIConfig(), cmd.Flags().Changed, fileCfg, os.Getenv)
+	writeProviderInfo(os.Stdout, settings)
+	return nil
+}
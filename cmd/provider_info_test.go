@@ -0,0 +1,416 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func findSetting(t *testing.T, settings []resolvedSetting, name string) resolvedSetting {
+	t.Helper()
+	for _, s := range settings {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("setting %q not found in %+v", name, settings)
+	return resolvedSetting{}
+}
+
+func noEnv(string) string    { return "" }
+func notChanged(string) bool { return false }
+
+func TestResolveProviderInfo_FlagsWin(t *testing.T) {
+	flagCfg := ai.Config{Provider: "vertex", Model: "gemini-1.5-pro", Temperature: 0.5}
+	flagCfg.Vertex.Project = "flag-project"
+
+	fileCfg := &ai.FileConfig{}
+	fileCfg.AI.Provider = "ollama"
+	fileCfg.AI.Model = "llama3.2"
+	fileCfg.AI.Vertex.Location = "europe-west1"
+
+	changed := func(name string) bool { return name == "temperature" }
+	settings := resolveProviderInfo(flagCfg, changed, fileCfg, noEnv)
+
+	want := map[string]resolvedSetting{
+		"provider":    {"provider", "vertex", "flag --provider"},
+		"model":       {"model", "gemini-1.5-pro", "flag --model"},
+		"temperature": {"temperature", "0.5", "flag --temperature"},
+		"project":     {"project", "flag-project", "flag --vertex-project"},
+		"location":    {"location", "europe-west1", "config file"},
+	}
+	for name, w := range want {
+		if got := findSetting(t, settings, name); got != w {
+			t.Errorf("%s: got %+v, want %+v", name, got, w)
+		}
+	}
+}
+
+func TestResolveProviderInfo_FileEnvAndDefaults(t *testing.T) {
+	fileCfg := &ai.FileConfig{}
+	fileCfg.AI.Provider = "azure"
+	fileCfg.AI.Azure.Deployment = "gpt4o-prod"
+
+	env := map[string]string{
+		"AZURE_OPENAI_ENDPOINT": "https://example.openai.azure.com",
+		"AZURE_OPENAI_API_KEY":  "secret",
+	}
+	settings := resolveProviderInfo(ai.Config{Temperature: 0.2}, notChanged, fileCfg, func(k string) string { return env[k] })
+
+	if got := findSetting(t, settings, "provider"); got.Value != "azure" || got.Source != "config file" {
+		t.Errorf("provider: got %+v", got)
+	}
+	if got := findSetting(t, settings, "model"); got.Value != ai.DefaultAzureModel || got.Source != "default" {
+		t.Errorf("model: got %+v", got)
+	}
+	if got := findSetting(t, settings, "temperature"); got.Source != "flag default" {
+		t.Errorf("temperature: got %+v", got)
+	}
+	if got := findSetting(t, settings, "endpoint"); got.Source != "env AZURE_OPENAI_ENDPOINT" {
+		t.Errorf("endpoint: got %+v", got)
+	}
+	if got := findSetting(t, settings, "deployment"); got.Value != "gpt4o-prod" || got.Source != "config file" {
+		t.Errorf("deployment: got %+v", got)
+	}
+	if got := findSetting(t, settings, "api key"); got.Value != "(set)" {
+		t.Errorf("api key should be masked, got %+v", got)
+	}
+
+	var buf bytes.Buffer
+	writeProviderInfo(&buf, settings)
+	if strings.Contains(buf.String(), "secret") {
+		t.Error("output must not contain the API key")
+	}
+}
+
+func TestResolveProviderInfo_Temperature(t *testing.T) {
+	fileCfg := &ai.FileConfig{}
+	fileCfg.AI.Temperature = 0.6
+	environ := map[string]string{}
+	getenv := func(k string) string { return environ[k] }
+
+	tests := []struct {
+		name       string
+		flag       float32
+		changed    bool
+		env        string
+		wantValue  string
+		wantSource string
+	}{
+		// The non-zero flag default shadows the config file, as in the merge
+		{"flag default over file", 0.2, false, "", "0.2", sourceFlagDefault},
+		{"file when the flag is zero", 0, false, "", "0.6", sourceConfigFile},
+		{"env over flag default", 0.2, false, "0.4", "0.4", "env " + envAITemperature},
+		{"flag over env", 0.3, true, "0.4", "0.3", "flag --temperature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environ[envAITemperature] = tt.env
+			changed := func(name string) bool { return tt.changed && name == "temperature" }
+			flagCfg := ai.Config{Provider: "ollama", Temperature: tt.flag}
+
+			want := ai.MergeFileConfig(applyAIEnv(flagCfg, tt.changed, getenv), fileCfg).Temperature
+			got := findSetting(t, resolveProviderInfo(flagCfg, changed, fileCfg, getenv), "temperature")
+			if got.Value != tt.wantValue || got.Source != tt.wantSource {
+				t.Errorf("got %+v, want %s (%s)", got, tt.wantValue, tt.wantSource)
+			}
+			if got.Value != strconv.FormatFloat(float64(want), 'g', -1, 32) {
+				t.Errorf("reported %s, but the commands would use %v", got.Value, want)
+			}
+		})
+	}
+}
+
+func TestResolveProviderInfo_OpenAI(t *testing.T) {
+	fileCfg := &ai.FileConfig{}
+	fileCfg.AI.OpenAI.BaseURL = "https://proxy.example.com/v1"
+
+	flagCfg := ai.Config{Provider: "openai"}
+	flagCfg.OpenAI.APIKey = "sk-flag"
+	env := map[string]string{"OPENAI_API_KEY": "sk-env"}
+	settings := resolveProviderInfo(flagCfg, notChanged, fileCfg, func(k string) string { return env[k] })
+
+	if got := findSetting(t, settings, "model"); got.Value != ai.DefaultOpenAIModel {
+		t.Errorf("model: got %+v", got)
+	}
+	if got := findSetting(t, settings, "base url"); got.Value != "https://proxy.example.com/v1" || got.Source != "config file" {
+		t.Errorf("base url: got %+v", got)
+	}
+	if got := findSetting(t, settings, "api key"); got.Value != "(set)" || got.Source != "flag --openai-api-key" {
+		t.Errorf("api key: got %+v", got)
+	}
+}
+
+func TestResolveProviderInfo_Anthropic(t *testing.T) {
+	env := map[string]string{"ANTHROPIC_API_KEY": "sk-ant-secret"}
+	settings := resolveProviderInfo(ai.Config{Provider: "anthropic"}, notChanged, nil, func(k string) string { return env[k] })
+
+	if got := findSetting(t, settings, "model"); got.Value != ai.DefaultAnthropicModel {
+		t.Errorf("model: got %+v", got)
+	}
+	if got := findSetting(t, settings, "base url"); got.Value != ai.DefaultAnthropicBaseURL || got.Source != "default" {
+		t.Errorf("base url: got %+v", got)
+	}
+	if got := findSetting(t, settings, "api key"); got.Value != "(set)" || got.Source != "env ANTHROPIC_API_KEY" {
+		t.Errorf("api key: got %+v", got)
+	}
+}
+
+func TestResolveProviderInfo_Unset(t *testing.T) {
+	settings := resolveProviderInfo(ai.Config{Provider: "vertex"}, notChanged, nil, noEnv)
+	if got := findSetting(t, settings, "project"); got.Source != "unset" {
+		t.Errorf("project: got %+v", got)
+	}
+}
+
+func TestWriteProviderInfo(t *testing.T) {
+	settings := resolveProviderInfo(ai.Config{Provider: "ollama", Model: "mistral", Temperature: 0.2}, notChanged, nil, noEnv)
+
+	var buf bytes.Buffer
+	writeProviderInfo(&buf, settings)
+	out := buf.String()
+
+	for _, want := range []string{"provider:", "ollama", "model:", "mistral", "(flag --model)", ai.DefaultOllamaEndpoint} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestMaskAPIKey(t *testing.T) {
+	if got := maskAPIKey(resolvedSetting{Value: "sk-secret"}); got.Value != "(set)" {
+		t.Errorf("plaintext key should be masked, got %q", got.Value)
+	}
+	if got := maskAPIKey(resolvedSetting{Value: "keyring:azure"}); got.Value != "keyring:azure" {
+		t.Errorf("keyring reference should be shown, got %q", got.Value)
+	}
+	if got := maskAPIKey(resolvedSetting{Source: sourceUnset}); got.Value != "" {
+		t.Errorf("unset key should stay empty, got %q", got.Value)
+	}
+}
+
+func TestResolveProvider_DefaultsToOllama(t *testing.T) {
+	// No flags and no config file
+	t.Setenv("HOME", t.TempDir())
+
+	provider, cfg, err := resolveProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Provider != ai.DefaultProvider || provider.Name() != "ollama" {
+		t.Errorf("expected the ollama default, got config %q and provider %q", cfg.Provider, provider.Name())
+	}
+	if provider.Model() != ai.DefaultOllamaModel {
+		t.Errorf("expected the default model, got %q", provider.Model())
+	}
+}
+
+func TestResolveProvider_ConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".kql"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := "ai:\n  provider: instructlab\n  model: granite\n"
+	if err := os.WriteFile(filepath.Join(home, ".kql", "config.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, _, err := resolveProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "instructlab" || provider.Model() != "granite" {
+		t.Errorf("expected the config file's provider, got %s/%s", provider.Name(), provider.Model())
+	}
+}
+
+func TestResolveProvider_SystemPromptFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(s, f string) { aiSystemPrompt, aiSystemPromptFile = s, f }(aiSystemPrompt, aiSystemPromptFile)
+
+	path := filepath.Join(t.TempDir(), "system.txt")
+	if err := os.WriteFile(path, []byte("Our tables live in the Ops database.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	aiSystemPrompt, aiSystemPromptFile = "", path
+
+	provider, cfg, err := resolveProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SystemPrompt != "Our tables live in the Ops database." {
+		t.Errorf("expected the file's system prompt, got %q", cfg.SystemPrompt)
+	}
+	if got := ai.SystemPromptOf(provider); got != cfg.SystemPrompt {
+		t.Errorf("expected the provider to send the system prompt, got %q", got)
+	}
+
+	aiSystemPrompt = "Inline."
+	if _, _, err := resolveProvider(); err == nil || !strings.Contains(err.Error(), "only one of") {
+		t.Errorf("expected --system-prompt and --system-prompt-file to conflict, got %v", err)
+	}
+
+	aiSystemPrompt, aiSystemPromptFile = "", filepath.Join(t.TempDir(), "missing.txt")
+	if _, _, err := resolveProvider(); err == nil {
+		t.Error("expected an error for a missing system prompt file")
+	}
+}
+
+func TestAIEnv_Precedence(t *testing.T) {
+	// Each layer is set or not; the highest one set must win, in the order
+	// flag > env > config file > default
+	tests := []struct {
+		name             string
+		flag, env, file  string
+		wantValue, wantS string
+	}{
+		{"all set", "flag", "env", "file", "flag", "flag"},
+		{"env over file", "", "env", "file", "env", "env"},
+		{"flag over env", "flag", "env", "", "flag", "flag"},
+		{"file only", "", "", "file", "file", sourceConfigFile},
+		{"default", "", "", "", "", sourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flagCfg := ai.Config{Provider: "ollama"}
+			fileCfg := &ai.FileConfig{}
+			environ := map[string]string{}
+			if tt.flag != "" {
+				flagCfg.Model = tt.flag + "-model"
+				flagCfg.Ollama.Endpoint = "http://" + tt.flag + ":11434"
+			}
+			if tt.env != "" {
+				environ[envAIModel] = tt.env + "-model"
+				environ[envOllamaEndpoint] = "http://" + tt.env + ":11434"
+			}
+			if tt.file != "" {
+				fileCfg.AI.Model = tt.file + "-model"
+				fileCfg.AI.Ollama.Endpoint = "http://" + tt.file + ":11434"
+			}
+			getenv := func(name string) string { return environ[name] }
+
+			// The config the commands use
+			cfg := ai.MergeFileConfig(applyAIEnv(flagCfg, false, getenv), fileCfg)
+			wantModel, wantEndpoint := "", ""
+			if tt.wantValue != "" {
+				wantModel = tt.wantValue + "-model"
+				wantEndpoint = "http://" + tt.wantValue + ":11434"
+			}
+			if cfg.Model != wantModel || cfg.Ollama.Endpoint != wantEndpoint {
+				t.Errorf("got model %q and endpoint %q, want %q and %q", cfg.Model, cfg.Ollama.Endpoint, wantModel, wantEndpoint)
+			}
+
+			// --provider-info reports the same winner
+			settings := resolveProviderInfo(flagCfg, notChanged, fileCfg, getenv)
+			model := findSetting(t, settings, "model")
+			endpoint := findSetting(t, settings, "endpoint")
+			if !strings.HasPrefix(model.Source, tt.wantS) || !strings.HasPrefix(endpoint.Source, tt.wantS) {
+				t.Errorf("got sources %q and %q, want %s", model.Source, endpoint.Source, tt.wantS)
+			}
+			if tt.wantValue == "" && (model.Value != ai.DefaultOllamaModel || endpoint.Value != ai.DefaultOllamaEndpoint) {
+				t.Errorf("expected the defaults, got %q and %q", model.Value, endpoint.Value)
+			}
+		})
+	}
+}
+
+func TestApplyAIEnv(t *testing.T) {
+	environ := map[string]string{
+		envAIProvider:          "azure",
+		envAITemperature:       "0.7",
+		envInstructLabEndpoint: "http://ilab:8000",
+		envAzureEndpoint:       "https://myorg.openai.azure.com",
+		envAzureDeployment:     "gpt4o",
+	}
+	getenv := func(name string) string { return environ[name] }
+
+	cfg := applyAIEnv(ai.Config{Temperature: 0.2}, false, getenv)
+	if cfg.Provider != "azure" || cfg.InstructLab.Endpoint != "http://ilab:8000" ||
+		cfg.Azure.Endpoint != "https://myorg.openai.azure.com" || cfg.Azure.Deployment != "gpt4o" {
+		t.Errorf("expected every variable to apply, got %+v", cfg)
+	}
+	if cfg.Temperature != 0.7 {
+		t.Errorf("expected the env temperature over the flag default, got %v", cfg.Temperature)
+	}
+
+	if cfg := applyAIEnv(ai.Config{Temperature: 0.4}, true, getenv); cfg.Temperature != 0.4 {
+		t.Errorf("expected --temperature to win, got %v", cfg.Temperature)
+	}
+
+	defer func(q bool, w io.Writer) { logQuiet, logOutput = q, w }(logQuiet, logOutput)
+	var log bytes.Buffer
+	logOutput = &log
+	environ[envAITemperature] = "warm"
+	if cfg := applyAIEnv(ai.Config{Temperature: 0.2}, false, getenv); cfg.Temperature != 0.2 {
+		t.Errorf("expected an invalid temperature to be ignored, got %v", cfg.Temperature)
+	}
+	if !strings.Contains(log.String(), "ignoring invalid KQL_AI_TEMPERATURE") {
+		t.Errorf("expected a warning about the invalid temperature, got %q", log.String())
+	}
+
+	log.Reset()
+	logQuiet = true
+	applyAIEnv(ai.Config{Temperature: 0.2}, false, getenv)
+	if log.Len() != 0 {
+		t.Errorf("expected --quiet to silence the warning, got %q", log.String())
+	}
+}
+
+func TestResolveProvider_RejectsInvalidSettings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(p string, temp float32) { aiProvider, aiTemperature = p, temp }(aiProvider, aiTemperature)
+
+	aiProvider, aiTemperature = "ollamma", 0.2
+	if _, _, err := resolveProvider(); err == nil || !strings.Contains(err.Error(), "supported: ollama") {
+		t.Errorf("expected the supported providers to be listed, got %v", err)
+	}
+
+	aiProvider, aiTemperature = "ollama", 5
+	if _, _, err := resolveProvider(); err == nil || !strings.Contains(err.Error(), "0.0 to 1.0") {
+		t.Errorf("expected a temperature range error, got %v", err)
+	}
+}
+
+func TestAIConfigFrom_DefaultModelPerProvider(t *testing.T) {
+	defer func(p, m string) { aiProvider, aiModel = p, m }(aiProvider, aiModel)
+	aiModel = ""
+
+	tests := map[string]string{
+		"ollama":      ai.DefaultOllamaModel,
+		"instructlab": ai.DefaultInstructLabModel,
+		"vertex":      ai.DefaultVertexModel,
+		"azure":       ai.DefaultAzureModel,
+		"openai":      ai.DefaultOpenAIModel,
+		"anthropic":   ai.DefaultAnthropicModel,
+	}
+	for provider, want := range tests {
+		aiProvider = provider
+		if got := aiConfigFrom(nil).Model; got != want {
+			t.Errorf("--provider %s without --model: got model %q, want %q", provider, got, want)
+		}
+	}
+
+	// From the config file, too
+	aiProvider = ""
+	file := &ai.FileConfig{AI: ai.AIFileConfig{Provider: "vertex"}}
+	if got := aiConfigFrom(file).Model; got != ai.DefaultVertexModel {
+		t.Errorf("config file provider vertex: got model %q, want %q", got, ai.DefaultVertexModel)
+	}
+
+	// An explicit model is kept
+	aiProvider, aiModel = "azure", "gpt-4.1"
+	if got := aiConfigFrom(nil).Model; got != "gpt-4.1" {
+		t.Errorf("expected the --model value, got %q", got)
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import "github.com/cloudygreybeard/kql/pkg/ai"
+
+// aiRaw is the --raw flag of generate and fix: print the model's response
+// as received instead of the query extracted from it. Validation, --strict,
+// and --max-edits still judge the extracted query.
+var aiRaw bool
+
+// rawResponse returns the response the result's query was extracted from,
+// which with a retry budget need not be the last one. The result must have
+// been generated with ValidationConfig.RecordHistory set.
+func rawResponse(result *ai.GenerateResult) string {
+	for i := len(result.History) - 1; i >= 0; i-- {
+		if result.History[i].Query == result.Query {
+			return result.History[i].Response
+		}
+	}
+	return result.Query
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+)
+
+func TestRawResponse(t *testing.T) {
+	first := "```kql\nStormEvents | count\n```\nCounts the rows."
+	second := "StormEvents | take 10"
+
+	p := &sequenceProvider{responses: []string{first, second}}
+	cfg := ai.DefaultValidationConfig()
+	cfg.RecordHistory = true
+	result, err := ai.GenerateWithValidation(context.Background(), p,
+		ai.GenerateRequest{Prompt: "count events"}, cfg, 0.2,
+		func(r ai.GenerateRequest) string { return r.Prompt },
+		extractKQL, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rawResponse(result); got != first {
+		t.Errorf("expected the first response verbatim, got %q", got)
+	}
+
+	// With a retry budget the best attempt need not be the last
+	result = &ai.GenerateResult{
+		Query: "T | count",
+		History: []ai.AttemptRecord{
+			{Attempt: 1, Response: "best: T | count", Query: "T | count"},
+			{Attempt: 2, Response: "worse: T |", Query: "T |"},
+		},
+	}
+	if got := rawResponse(result); got != "best: T | count" {
+		t.Errorf("expected the response of the returned query, got %q", got)
+	}
+}
+
+func TestFixQuery_Raw(t *testing.T) {
+	defer func(v bool) { aiRaw = v }(aiRaw)
+	defer func(v int) { fixRetries = v }(fixRetries)
+	fixRetries = 0
+
+	response := "Here is the fix:\n```kql\n" + minimalFix + "\n```"
+	getProvider := func() (ai.Provider, error) {
+		return &sequenceProvider{responses: []string{response}}, nil
+	}
+
+	var out bytes.Buffer
+	aiRaw = false
+	if err := fixQuery(brokenQuery, &out, getProvider); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != minimalFix+"\n" {
+		t.Errorf("expected the extracted fix, got %q", out.String())
+	}
+
+	out.Reset()
+	aiRaw = true
+	if err := fixQuery(brokenQuery, &out, getProvider); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != response+"\n" {
+		t.Errorf("expected the raw response, got %q", out.String())
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlref"
+	"github.com/spf13/cobra"
+)
+
+var refList bool
+
+var refCmd = &cobra.Command{
+	Use:   "ref [operator|function]",
+	Short: "Show a quick reference for a KQL operator or function",
+	Long: `Show the syntax, a short description, and an example for a common KQL
+operator or function. The reference is built in and works offline.`,
+	Example: `  # Look up an operator
+  kql ref summarize
+
+  # Look up a function
+  kql ref ago
+
+  # List everything in the reference
+  kql ref --list`,
+	RunE: runRef,
+}
+
+func init() {
+	rootCmd.AddCommand(refCmd)
+
+	refCmd.Flags().BoolVar(&refList, "list", false, "List all available entries")
+}
+
+func runRef(cmd *cobra.Command, args []string) error {
+	if refList {
+		writeRefList(os.Stdout, kqlref.All())
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("expected one operator or function name (or --list)")
+	}
+
+	entry, err := kqlref.Lookup(args[0])
+	if err != nil {
+		return err
+	}
+
+	writeRefEntry(os.Stdout, entry)
+	return nil
+}
+
+func writeRefEntry(w io.Writer, e kqlref.Entry) {
+	fmt.Fprintf(w, "%s (%s)\n\n", e.Name, e.Kind)
+	fmt.Fprintf(w, "  %s\n\n", e.Syntax)
+	fmt.Fprintf(w, "%s\n", e.Description)
+	if e.Example != "" {
+		fmt.Fprintf(w, "\nExample:\n  %s\n", e.Example)
+	}
+}
+
+func writeRefList(w io.Writer, entries []kqlref.Entry) {
+	for _, e := range entries {
+		fmt.Fprintf(w, "%-12s %-9s %s\n", e.Name, e.Kind, e.Syntax)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/link"
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replTimeout   int
+	replCluster   string
+	replDatabase  string
+	replNoHistory bool
+)
+
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "Interactive KQL shell with linting and AI commands",
+	Long: `Start an interactive session that lints each statement as it is entered.
+
+A statement ends with a blank line or a line ending in ';', and its
+diagnostics are printed straight away. Meta-commands act on the statement
+being typed or, if there is none, the last one entered:
+
+  \explain   Explain the statement (as 'kql explain')
+  \fix       Fix syntax errors (as 'kql fix'); the fix becomes the statement
+  \link      Build a deep link (as 'kql link build')
+  \clear     Discard the statement being typed
+  \help      List the meta-commands
+  \quit      Leave the session (or Ctrl-D)
+
+Lines are appended to ~/.kql/history unless --no-history is set.
+
+The AI meta-commands use the same providers and configuration as
+'kql explain'; \link uses the link settings of 'kql link build'.`,
+	Example: `  # Start a session
+  kql repl
+
+  # With semantic checks and a default cluster for \link
+  kql repl --strict -c help -d Samples`,
+	Args: cobra.NoArgs,
+	RunE: runRepl,
+}
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+
+	// Provider selection for the AI meta-commands (reuse from explain)
+	replCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider for \\explain and \\fix (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = replCmd.RegisterFlagCompletionFunc("provider", completeProvider)
+	replCmd.Flags().StringVar(&aiModel, "model", "", "Model name for \\explain and \\fix")
+	replCmd.Flags().IntVar(&replTimeout, "timeout", 60, "Timeout in seconds for each AI request")
+
+	// Link target for \link
+	replCmd.Flags().StringVarP(&replCluster, "cluster", "c", "", "Kusto cluster for \\link (default from KQL_LINK_CLUSTER or config)")
+	replCmd.Flags().StringVarP(&replDatabase, "database", "d", "", "Database for \\link (default from KQL_LINK_DATABASE or config)")
+
+	// Linting
+	replCmd.Flags().BoolVar(&lintStrict, "strict", false, "Enable semantic analysis (type checking, name resolution)")
+
+	replCmd.Flags().BoolVar(&replNoHistory, "no-history", false, "Don't append to ~/.kql/history")
+}
+
+func runRepl(cmd *cobra.Command, args []string) error {
+	fileCfg, err := loadConfigFileOrWarn()
+	if err != nil {
+		return err
+	}
+
+	s := &replSession{
+		in:          bufio.NewScanner(os.Stdin),
+		out:         os.Stdout,
+		interactive: isTerminal(os.Stdin),
+		timeout:     time.Duration(replTimeout) * time.Second,
+		newProvider: func() (ai.Provider, error) {
+			provider, _, err := resolveProvider()
+			return provider, err
+		},
+		linkTarget: func() (link.Config, string, error) {
+			return resolveLinkTarget(link.Config{Cluster: replCluster, Database: replDatabase}, fileCfg)
+		},
+	}
+
+	if !replNoHistory {
+		if history, err := openHistory(); err == nil {
+			defer history.Close()
+			s.history = history
+		} else {
+			logf(logWarn, "Warning: history disabled: %v", err)
+		}
+	}
+
+	return s.run()
+}
+
+// openHistory opens ~/.kql/history for appending.
+func openHistory() (*os.File, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(home, ".kql")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, "history"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+}
+
+// replSession is the state of a kql repl session.
+type replSession struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	// history receives each line entered; nil disables it
+	history io.Writer
+
+	// interactive prints prompts
+	interactive bool
+
+	// timeout bounds each AI request
+	timeout time.Duration
+
+	// newProvider creates the provider for the AI meta-commands on first use
+	newProvider func() (ai.Provider, error)
+	provider    ai.Provider
+
+	// linkTarget resolves the cluster, database, and base URL for \link
+	linkTarget func() (link.Config, string, error)
+
+	// lines is the statement being typed; last is the last one entered
+	lines []string
+	last  string
+}
+
+// run reads and handles lines until \quit or the end of input.
+func (s *replSession) run() error {
+	for {
+		s.prompt()
+		if !s.in.Scan() {
+			// A statement cut off by the end of input is still linted
+			if len(s.lines) > 0 {
+				s.submit()
+			}
+			return s.in.Err()
+		}
+		line := s.in.Text()
+		if s.history != nil && strings.TrimSpace(line) != "" {
+			fmt.Fprintln(s.history, line)
+		}
+
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, `\`) {
+			if quit := s.meta(trimmed); quit {
+				return nil
+			}
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if len(s.lines) > 0 {
+				s.submit()
+			}
+			continue
+		}
+		s.lines = append(s.lines, line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			s.submit()
+		}
+	}
+}
+
+// prompt shows the primary or continuation prompt on a terminal.
+func (s *replSession) prompt() {
+	if !s.interactive {
+		return
+	}
+	if len(s.lines) == 0 {
+		fmt.Fprint(s.out, "kql> ")
+	} else {
+		fmt.Fprint(s.out, "...> ")
+	}
+}
+
+// submit ends the statement being typed and prints its diagnostics.
+func (s *replSession) submit() {
+	s.last = strings.Join(s.lines, "\n")
+	s.lines = nil
+	s.lint(s.last)
+}
+
+// lint prints the diagnostics for a statement, or a check mark if there
+// are none.
+func (s *replSession) lint(query string) {
+	diagnostics, err := lintQuery("repl", query)
+	if err != nil {
+		fmt.Fprintf(s.out, "  error: %v\n", err)
+		return
+	}
+	if len(diagnostics) == 0 {
+		fmt.Fprintln(s.out, "  ✓")
+		return
+	}
+	for _, d := range diagnostics {
+		if d.Code != "" {
+			fmt.Fprintf(s.out, "  %d:%d: %s: %s [%s]\n", d.Line, d.Column, d.Severity, d.Message, d.Code)
+		} else {
+			fmt.Fprintf(s.out, "  %d:%d: %s: %s\n", d.Line, d.Column, d.Severity, d.Message)
+		}
+	}
+}
+
+// current returns the statement the meta-commands act on.
+func (s *replSession) current() string {
+	if len(s.lines) > 0 {
+		return strings.Join(s.lines, "\n")
+	}
+	return s.last
+}
+
+// meta runs a meta-command and reports whether it ends the session.
+// Failures are printed; they never end the session.
+func (s *replSession) meta(command string) bool {
+	name, _, _ := strings.Cut(command, " ")
+	var err error
+	switch name {
+	case `\quit`, `\q`, `\exit`:
+		return true
+	case `\help`, `\?`:
+		s.help()
+	case `\clear`:
+		s.lines = nil
+	case `\explain`:
+		err = s.explain()
+	case `\fix`:
+		err = s.fix()
+	case `\link`:
+		err = s.link()
+	default:
+		err = fmt.Errorf("unknown command %s (\\help lists them)", name)
+	}
+	if err != nil {
+		fmt.Fprintf(s.out, "  error: %v\n", err)
+	}
+	return false
+}
+
+func (s *replSession) help() {
+	fmt.Fprint(s.out, `  \explain   Explain the statement
+  \fix       Fix syntax errors in the statement
+  \link      Build a deep link for the statement
+  \clear     Discard the statement being typed
+  \help      Show this list
+  \quit      Leave the session
+`)
+}
+
+// aiProvider returns the session's provider, creating it on first use.
+func (s *replSession) aiProvider() (ai.Provider, error) {
+	if s.provider == nil {
+		provider, err := s.newProvider()
+		if err != nil {
+			return nil, err
+		}
+		s.provider = provider
+	}
+	return s.provider, nil
+}
+
+func (s *replSession) explain() error {
+	query := s.current()
+	if query == "" {
+		return fmt.Errorf("no statement to explain")
+	}
+	provider, err := s.aiProvider()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if _, err := explainQuery(ctx, provider, query, aiCacheTTL, s.interactive, s.out); err != nil {
+		return aiRequestError(fmt.Errorf("getting explanation: %w", err), s.timeout)
+	}
+	return nil
+}
+
+// fix replaces the statement with a fixed one when it has syntax errors.
+func (s *replSession) fix() error {
+	query := s.current()
+	if query == "" {
+		return fmt.Errorf("no statement to fix")
+	}
+	parsed := kqlparser.Parse("repl", query)
+	if len(parsed.Errors) == 0 {
+		fmt.Fprintln(s.out, "  No syntax errors found.")
+		return nil
+	}
+	provider, err := s.aiProvider()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	outcome, err := runFixLoop(ctx, provider, query, parsed.Errors, linkFixRetries+1, 0, nil)
+	if err != nil {
+		return aiRequestError(err, s.timeout)
+	}
+
+	fmt.Fprintln(s.out, outcome.Query)
+	s.lines, s.last = nil, outcome.Query
+	s.lint(outcome.Query)
+	return nil
+}
+
+func (s *replSession) link() error {
+	query := s.current()
+	if query == "" {
+		return fmt.Errorf("no statement to link")
+	}
+	cfg, baseURL, err := s.linkTarget()
+	if err != nil {
+		return err
+	}
+	url, err := link.Build(query, cfg.Cluster, cfg.Database, baseURL)
+	if err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+	fmt.Fprintln(s.out, url)
+	return nil
+}
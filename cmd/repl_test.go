@@ -0,0 +1,114 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/link"
+)
+
+// newTestSession returns a session reading input, with a fake provider
+// and link target.
+func newTestSession(input string, provider ai.Provider) (*replSession, *bytes.Buffer, *bytes.Buffer) {
+	var out, history bytes.Buffer
+	s := &replSession{
+		in:      bufio.NewScanner(strings.NewReader(input)),
+		out:     &out,
+		history: &history,
+		timeout: time.Minute,
+		newProvider: func() (ai.Provider, error) {
+			if provider == nil {
+				return nil, errors.New("creating AI provider: no provider")
+			}
+			return provider, nil
+		},
+		linkTarget: func() (link.Config, string, error) {
+			return link.Config{Cluster: "help", Database: "Samples"}, link.DefaultBaseURL, nil
+		},
+	}
+	return s, &out, &history
+}
+
+func TestRepl_LintsStatements(t *testing.T) {
+	input := "StormEvents\n| take 10\n\nT | where x >;\nlet n = 5;\n"
+	s, out, history := newTestSession(input, nil)
+	if err := s.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A blank line ends the first statement and ';' the others
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) < 3 || strings.TrimSpace(lines[0]) != "✓" || !strings.Contains(lines[1], "error") || strings.TrimSpace(lines[len(lines)-1]) != "✓" {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if s.last != "let n = 5;" {
+		t.Errorf("last = %q", s.last)
+	}
+
+	// Blank lines aren't recorded
+	if want := "StormEvents\n| take 10\nT | where x >;\nlet n = 5;\n"; history.String() != want {
+		t.Errorf("history = %q, want %q", history.String(), want)
+	}
+}
+
+func TestRepl_LintsStatementAtEOF(t *testing.T) {
+	s, out, _ := newTestSession("T | summarize count( by State", nil)
+	if err := s.run(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "error") {
+		t.Errorf("expected the unterminated statement to be linted, got:\n%s", out.String())
+	}
+}
+
+func TestRepl_MetaCommands(t *testing.T) {
+	p := &fakeProvider{name: "fake", model: "m", response: "StormEvents | summarize count() by State"}
+	defer func(v bool) { aiNoCache = v }(aiNoCache)
+	aiNoCache = true
+
+	input := "StormEvents | summarize count( by State\n\n\\fix\n\\link\n\\explain\n\\bogus\n\\quit\nT | take 1\n"
+	s, out, _ := newTestSession(input, p)
+	if err := s.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"StormEvents | summarize count() by State\n", // the fix
+		"https://dataexplorer.azure.com/clusters/help/databases/Samples?query=",
+		"unknown command \\bogus",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if s.last != "StormEvents | summarize count() by State" {
+		t.Errorf("expected the fix to replace the statement, got %q", s.last)
+	}
+	// \fix and \explain share one provider; \quit stops before the last line
+	if p.calls != 2 || strings.Contains(got, "take 1") {
+		t.Errorf("calls = %d, output:\n%s", p.calls, got)
+	}
+}
+
+func TestRepl_MetaCommandErrors(t *testing.T) {
+	s, out, _ := newTestSession("\\explain\nT | where x >\n\\explain\n\\clear\n\\link\n", nil)
+	if err := s.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"no statement to explain", "creating AI provider: no provider", "no statement to link"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
@@ -40,6 +40,20 @@ Based on the Microsoft Kusto deep link specification:
 https://learn.microsoft.com/en-us/kusto/api/rest/deeplink`,
 }
 
+// Global flags, shared by every command that reads the config file.
+var (
+	configFile    string
+	configProfile string
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&logVerbose, "verbose", "v", false, "Show progress and additional context on stderr")
+	rootCmd.PersistentFlags().BoolVarP(&logQuiet, "quiet", "q", false, "Hide warnings and summaries, leaving results and errors")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file (default $KQL_CONFIG or ~/.kql/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", "", "Config file profile to use for AI settings (default: default_profile, else the ai section)")
+}
+
 // Execute runs the root command.
 func Execute() error {
 	return rootCmd.Execute()
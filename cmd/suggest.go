@@ -5,21 +5,32 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/cloudygreybeard/kql/pkg/ai"
 	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/ast"
 	"github.com/spf13/cobra"
 )
 
 var (
 	suggestInputFile string
-	suggestVerbose   bool
 	suggestTimeout   int
 	suggestFocus     string
+	suggestSecurity  bool
+
+	// suggestRequireValid refuses queries with syntax errors
+	suggestRequireValid bool
+
+	// Rewrite flags
+	suggestApply    bool
+	suggestShowDiff bool
+	suggestNoColor  bool
 )
 
 var suggestCmd = &cobra.Command{
@@ -33,7 +44,19 @@ Suggestion focus areas (--focus):
   - performance:  Query execution speed and efficiency
   - readability:  Code clarity and maintainability
   - correctness:  Potential bugs or logic issues
-  - all:          All of the above (default)
+  - security:     Query-safety risks (missing time bounds, broad scans,
+                  cross-cluster access, external data, exfiltration)
+  - all:          Performance, readability, and correctness, plus missing
+                  time bounds, broad scans, and externaldata (default);
+                  add --include-security for the full security review
+
+Use --dry-run to print the prompt that would be sent (the --apply prompt
+with --apply), without creating a provider.
+
+A query with syntax errors is still reviewed, with the errors in the
+prompt. With --require-valid it is refused instead, exiting 1 without a
+model request, so CI doesn't ask for advice on queries that won't run;
+use 'kql fix' to repair them.
 
 Uses the same AI providers as 'kql explain'.`,
 	Example: `  # Get all suggestions
@@ -42,11 +65,23 @@ Uses the same AI providers as 'kql explain'.`,
   # Focus on performance
   kql suggest --focus performance "T | join kind=inner T2 on Id"
 
+  # Security review
+  kql suggest --focus security "externaldata(Line:string) [@'https://example.com/x.csv']"
+
   # From file
   kql suggest -f query.kql
 
   # Use specific provider
-  kql suggest --provider vertex --model gemini-1.5-pro "T | take 10"`,
+  kql suggest --provider vertex --model gemini-1.5-pro "T | take 10"
+
+  # Rewrite the query and review the change (diff on stderr, query on stdout)
+  kql suggest --apply --show-diff -f query.kql > optimized.kql
+
+  # Review several files in one run
+  kql suggest --batch "queries/*.kql"
+
+  # In CI, fail on queries that don't parse
+  kql suggest --require-valid -f query.kql`,
 	RunE: runSuggest,
 }
 
@@ -54,7 +89,8 @@ func init() {
 	rootCmd.AddCommand(suggestCmd)
 
 	// Provider selection (reuse from explain)
-	suggestCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure)")
+	suggestCmd.Flags().StringVar(&aiProvider, "provider", "", "AI provider (ollama, instructlab, vertex, azure, openai, anthropic)")
+	_ = suggestCmd.RegisterFlagCompletionFunc("provider", completeProvider)
 	suggestCmd.Flags().StringVar(&aiModel, "model", "", "Model name")
 	suggestCmd.Flags().Float32Var(&aiTemperature, "temperature", 0.3, "Temperature (0.0-1.0)")
 
@@ -72,65 +108,151 @@ func init() {
 	// InstructLab
 	suggestCmd.Flags().StringVar(&instructEndpoint, "instructlab-endpoint", "", "InstructLab endpoint URL")
 
+	// OpenAI
+	suggestCmd.Flags().StringVar(&openaiAPIKey, "openai-api-key", "", "OpenAI API key (default from config or OPENAI_API_KEY)")
+
+	// Anthropic
+	suggestCmd.Flags().StringVar(&anthropicAPIKey, "anthropic-api-key", "", "Anthropic API key (default from config or ANTHROPIC_API_KEY)")
+
+	// Network
+	suggestCmd.Flags().StringVar(&aiProxy, "proxy", "", "Proxy URL for AI requests (default from HTTPS_PROXY/HTTP_PROXY)")
+	suggestCmd.Flags().StringVar(&aiCACert, "ca-cert", "", "PEM file of extra CA certificates to trust")
+
+	// System prompt
+	suggestCmd.Flags().StringVar(&aiSystemPrompt, "system-prompt", "", "System message sent ahead of every prompt (default from config)")
+	suggestCmd.Flags().StringVar(&aiSystemPromptFile, "system-prompt-file", "", "Read the system message from a file")
+
+	// Response cache
+	suggestCmd.Flags().BoolVar(&aiCache, "cache", false, "Reuse identical responses from the on-disk cache")
+	suggestCmd.Flags().BoolVar(&aiNoCache, "no-cache", false, "Disable the response cache, overriding --cache and the config file")
+	suggestCmd.Flags().DurationVar(&aiCacheTTL, "cache-ttl", 0, "How long cached responses are reused (default 24h)")
+
+	// Diagnostics
+	suggestCmd.Flags().BoolVar(&aiProviderInfo, "provider-info", false, "Print the resolved provider settings and their sources, then exit")
+
 	// Command options
 	suggestCmd.Flags().StringVarP(&suggestInputFile, "file", "f", "", "Read query from file")
-	suggestCmd.Flags().BoolVarP(&suggestVerbose, "verbose", "v", false, "Show additional context")
+	addInputSourceFlags(suggestCmd)
+	suggestCmd.Flags().StringVarP(&aiOutput, "output", "o", "", "Write the result to a file instead of stdout")
+	suggestCmd.Flags().BoolVar(&aiBatch, "batch", false, "Treat each argument as a query file (globs and directories allowed), printing a header per file")
 	suggestCmd.Flags().IntVar(&suggestTimeout, "timeout", 60, "Timeout in seconds")
-	suggestCmd.Flags().StringVar(&suggestFocus, "focus", "all", "Suggestion focus: performance, readability, correctness, all")
+	suggestCmd.Flags().StringVar(&suggestFocus, "focus", "all", "Suggestion focus: performance, readability, correctness, security, all")
+	suggestCmd.Flags().BoolVar(&suggestSecurity, "include-security", false, "Include security review in --focus all")
+	suggestCmd.Flags().BoolVar(&suggestRequireValid, "require-valid", false, "Exit 1 without a model request if the query has syntax errors")
+	suggestCmd.Flags().BoolVar(&aiDryRun, "dry-run", false, "Print the prompt that would be sent and exit, without contacting the model")
+
+	// Rewrite
+	suggestCmd.Flags().BoolVar(&suggestApply, "apply", false, "Print the optimized query instead of suggestions")
+	suggestCmd.Flags().BoolVar(&suggestShowDiff, "show-diff", false, "With --apply, print a diff against the original to stderr")
+	suggestCmd.Flags().BoolVar(&suggestNoColor, "no-color", false, "Disable colored diff output (also honors NO_COLOR)")
 }
 
-func runSuggest(cmd *cobra.Command, args []string) error {
+func runSuggest(cmd *cobra.Command, args []string) (err error) {
+	if aiProviderInfo {
+		return runProviderInfo(cmd)
+	}
+	if suggestShowDiff && !suggestApply {
+		return fmt.Errorf("--show-diff requires --apply")
+	}
+
 	// Get query input
-	query, err := getInputFrom(args, suggestInputFile, os.Stdin, isTerminal)
+	input, err := readQueryInput(args, suggestInputFile)
 	if err != nil {
 		return err
 	}
 
-	// Build AI config
-	cfg := buildAIConfig()
+	if aiDryRun {
+		return runPromptDryRun(input, func(query string, _ ai.PromptStyle) string {
+			if suggestApply {
+				return buildApplyPrompt(query, suggestFocus, suggestSecurity)
+			}
+			return buildSuggestPrompt(query, getParseContextForSuggest(query), suggestFocus, suggestSecurity)
+		})
+	}
 
-	// Load file config and merge
-	fileCfg, err := ai.LoadConfigFile()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: error loading config file: %v\n", err)
+	// A single query is checked before the provider is created, so a
+	// broken query fails the same way with or without credentials
+	if input.files == nil {
+		if err := checkSuggestInput(input.query); err != nil {
+			return err
+		}
 	}
-	cfg = ai.MergeFileConfig(cfg, fileCfg)
 
-	// Apply defaults if still empty
-	if cfg.Provider == "" {
-		cfg.Provider = "ollama"
+	provider, cfg, err := resolveProvider()
+	if err != nil {
+		return err
 	}
 
-	// Create provider
-	provider, err := ai.NewProvider(cfg)
+	out, err := openOutput(aiOutput, os.Stderr)
 	if err != nil {
-		return fmt.Errorf("creating AI provider: %w", err)
+		return err
 	}
+	defer func() { err = out.finish(err) }()
 
-	// Parse the query for context
-	parseContext := getParseContextForSuggest(query)
+	timeout := time.Duration(suggestTimeout) * time.Second
+	if suggestApply {
+		var diffOut io.Writer
+		if suggestShowDiff {
+			diffOut = os.Stderr
+		}
+		return input.each(out, func(query string) error {
+			if err := checkSuggestInput(query); err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			err := runSuggestApply(ctx, provider, query, cfg.Validation, out, diffOut, useColor(suggestNoColor, os.Stderr))
+			return aiRequestError(err, timeout)
+		})
+	}
 
-	// Build prompt
-	prompt := buildSuggestPrompt(query, parseContext, suggestFocus)
+	// Show progress
+	logf(logInfo, "Using %s provider with model %s...", provider.Name(), provider.Model())
+	logf(logInfo, "Focus: %s", suggestFocus)
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(suggestTimeout)*time.Second)
-	defer cancel()
+	return input.each(out, func(query string) error {
+		if err := checkSuggestInput(query); err != nil {
+			return err
+		}
 
-	// Show progress
-	if suggestVerbose {
-		fmt.Fprintf(os.Stderr, "Using %s provider with model %s...\n", provider.Name(), provider.Model())
-		fmt.Fprintf(os.Stderr, "Focus: %s\n", suggestFocus)
-	}
+		// Parse the query for context
+		parseContext := getParseContextForSuggest(query)
 
-	// Get suggestions
-	suggestions, err := provider.Complete(ctx, prompt)
-	if err != nil {
-		return fmt.Errorf("getting suggestions: %w", err)
-	}
+		// Build prompt
+		prompt := buildSuggestPrompt(query, parseContext, suggestFocus, suggestSecurity)
+
+		// Create context with timeout
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		// Get suggestions
+		suggestions, err := provider.Complete(ctx, prompt)
+		if err != nil {
+			return aiRequestError(fmt.Errorf("getting suggestions: %w", err), timeout)
+		}
 
-	fmt.Println(suggestions)
-	return nil
+		fmt.Fprintln(out, suggestions)
+		return nil
+	})
+}
+
+// checkSuggestInput implements --require-valid: it fails if query has
+// syntax errors, pointing at kql fix.
+func checkSuggestInput(query string) error {
+	if !suggestRequireValid {
+		return nil
+	}
+	errs := kqlparser.Parse("input", query).Errors
+	if len(errs) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "query has %d syntax error(s); repair it with 'kql fix' first:", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(&sb, "\n  - %v", e)
+	}
+	return errors.New(sb.String())
 }
 
 func getParseContextForSuggest(query string) string {
@@ -159,7 +281,60 @@ func getParseContextForSuggest(query string) string {
 	return context.String()
 }
 
+// countOperators lists the pipe operators the query uses, in order of
+// first use, including those in subqueries. A query that doesn't parse
+// falls back to matching "| op" in the text.
 func countOperators(query string) []string {
+	result := kqlparser.Parse("input", query)
+	if len(result.Errors) > 0 || result.AST == nil {
+		return matchOperators(query)
+	}
+
+	var found []string
+	seen := make(map[string]bool)
+	ast.Inspect(result.AST, func(n ast.Node) bool {
+		pipe, ok := n.(*ast.PipeExpr)
+		if !ok {
+			return true
+		}
+		for _, op := range pipe.Operators {
+			name := operatorKeyword(query, op)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				found = append(found, name)
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// operatorKeyword returns the keyword of op as written in query, such as
+// "where" or "mv-expand", lowercased.
+func operatorKeyword(query string, op ast.Operator) string {
+	i := int(op.Pos()) - 1
+	if i < 0 || i >= len(query) {
+		return ""
+	}
+	if query[i] == '|' {
+		i++
+	}
+	for i < len(query) && strings.ContainsRune(" \t\r\n", rune(query[i])) {
+		i++
+	}
+	start := i
+	for i < len(query) && (isLetter(query[i]) || query[i] == '-' && i > start) {
+		i++
+	}
+	return strings.ToLower(query[start:i])
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// matchOperators is the textual fallback of countOperators.
+func matchOperators(query string) []string {
 	// Simple operator detection
 	knownOps := []string{
 		"where", "project", "extend", "summarize", "join", "union",
@@ -180,7 +355,26 @@ func countOperators(query string) []string {
 	return found
 }
 
-func buildSuggestPrompt(query, parseContext, focus string) string {
+func buildSuggestPrompt(query, parseContext, focus string, includeSecurity bool) string {
+	return fmt.Sprintf(`You are a Kusto Query Language (KQL) expert. Analyze the following query and provide specific, actionable suggestions for improvement.
+
+%s
+
+For each suggestion:
+1. Explain the issue or opportunity
+2. Show the specific change (before → after)
+3. Explain the benefit
+
+If the query is already well-optimized, say so and explain why.
+
+%s
+
+Query:
+%s`, suggestFocusInstructions(focus, includeSecurity), parseContext, "```kql\n"+query+"\n```")
+}
+
+// suggestFocusInstructions describes what to look for under --focus.
+func suggestFocusInstructions(focus string, includeSecurity bool) string {
 	var focusInstructions string
 
 	switch focus {
@@ -210,26 +404,33 @@ func buildSuggestPrompt(query, parseContext, focus string) string {
 - Time zone considerations
 - Off-by-one errors in ranges`
 
+	case "security":
+		focusInstructions = `Focus specifically on SECURITY and query-safety risks:
+- Unbounded cross-cluster or cross-database queries (cluster(), database())
+- externaldata from untrusted or unauthenticated URLs
+- Data exfiltration paths (externalize, export, writing to external storage)
+- Missing time filters, so the query scans all retained data
+- Overly broad scans (search *, union *, wildcard table names) and
+  overly broad time ranges over sensitive tables
+- Exposure of secrets, credentials, or personal data in projected columns
+- Missing row-level filters on tenant or customer data`
+
 	default: // "all"
 		focusInstructions = `Analyze the query for:
 1. PERFORMANCE - efficiency and speed improvements
 2. READABILITY - clarity and maintainability
 3. CORRECTNESS - potential bugs or logic issues`
-	}
-
-	return fmt.Sprintf(`You are a Kusto Query Language (KQL) expert. Analyze the following query and provide specific, actionable suggestions for improvement.
-
-%s
-
-For each suggestion:
-1. Explain the issue or opportunity
-2. Show the specific change (before → after)
-3. Explain the benefit
-
-If the query is already well-optimized, say so and explain why.
+		if includeSecurity {
+			focusInstructions += `
+4. SECURITY - query-safety risks such as missing time bounds, broad scans, cross-cluster access, external data, and exfiltration`
+		}
+		focusInstructions += `
 
-%s
+Also check for:
+- Missing time filters, so the query scans all retained data
+- Overly broad scans (search *, union *, wildcard table names)
+- externaldata from untrusted or unauthenticated URLs`
+	}
 
-Query:
-%s`, focusInstructions, parseContext, "```kql\n"+query+"\n```")
+	return focusInstructions
 }
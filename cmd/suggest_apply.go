@@ -0,0 +1,86 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kql/pkg/kqlfmt"
+)
+
+// buildApplyPrompt asks for the optimized query itself rather than a list
+// of suggestions.
+func buildApplyPrompt(query, focus string, includeSecurity bool) string {
+	return fmt.Sprintf(`You are a Kusto Query Language (KQL) expert. Rewrite the following query to apply your suggested improvements.
+
+%s
+
+Rules:
+1. Output ONLY the rewritten KQL query, no explanations
+2. Do NOT wrap the query in backticks or code blocks
+3. Preserve the query's results; change how they are computed, not what they are
+4. If the query is already well-optimized, output it unchanged
+
+Query:
+%s`, suggestFocusInstructions(focus, includeSecurity), "```kql\n"+query+"\n```")
+}
+
+// runSuggestApply implements --apply: the rewritten query is validated
+// (retrying with error feedback like generate), printed to out, and, when
+// diffOut is set, diffed against the original. A rewrite that never
+// validates is discarded and the original query is printed instead.
+func runSuggestApply(ctx context.Context, provider ai.Provider, query string, valCfg ai.ValidationConfig, out, diffOut io.Writer, color bool) error {
+	valCfg.Enabled = true
+
+	result, err := ai.GenerateWithValidation(ctx, provider, ai.GenerateRequest{Prompt: query}, valCfg, 0,
+		func(r ai.GenerateRequest) string {
+			return buildApplyPrompt(r.Prompt, suggestFocus, suggestSecurity)
+		},
+		extractKQL, nil, nil,
+	)
+	if err != nil {
+		return fmt.Errorf("getting optimized query: %w", err)
+	}
+
+	if !result.Valid {
+		logf(logWarn, "%sKeeping the original query.", ai.FormatValidationWarning(result))
+		fmt.Fprintln(out, query)
+		return nil
+	}
+
+	if diffOut != nil {
+		writeQueryDiff(diffOut, query, result.Query, "optimized", color)
+	}
+	fmt.Fprintln(out, result.Query)
+	return nil
+}
+
+// writeQueryDiff prints a line diff between two queries with unified-style
+// headers naming the updated query by label, coloring removed and added
+// lines when color is set.
+func writeQueryDiff(w io.Writer, original, updated, label string, color bool) {
+	diff := kqlfmt.Diff(strings.TrimSpace(original), strings.TrimSpace(updated))
+	if diff == "" {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	fmt.Fprintln(w, "--- original")
+	fmt.Fprintln(w, "+++ "+label)
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(diff, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case color && strings.HasPrefix(line, "- "):
+			fmt.Fprintln(w, ansiRed+line+ansiReset)
+		case color && strings.HasPrefix(line, "+ "):
+			fmt.Fprintln(w, ansiGreen+line+ansiReset)
+		default:
+			fmt.Fprintln(w, line)
+		}
+	}
+}
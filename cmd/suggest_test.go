@@ -0,0 +1,166 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/ai"
+	"github.com/cloudygreybeard/kqlparser"
+)
+
+func TestBuildSuggestPrompt_SecurityFocus(t *testing.T) {
+	prompt := buildSuggestPrompt("T | take 10", "", "security", false)
+
+	for _, want := range []string{"SECURITY", "externaldata", "externalize", "cross-cluster", "time filters", "search *"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected security prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "PERFORMANCE") {
+		t.Error("security focus should not include performance instructions")
+	}
+}
+
+func TestBuildSuggestPrompt_AllFocusSecurity(t *testing.T) {
+	prompt := buildSuggestPrompt("T | take 10", "", "all", false)
+	if strings.Contains(prompt, "SECURITY") {
+		t.Error("expected all focus to exclude the security review by default")
+	}
+	for _, want := range []string{"time filters", "search *", "externaldata"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected all focus to check for %q", want)
+		}
+	}
+
+	prompt = buildSuggestPrompt("T | take 10", "", "all", true)
+	if !strings.Contains(prompt, "4. SECURITY") || !strings.Contains(prompt, "missing time bounds") {
+		t.Error("expected all focus to include security when requested")
+	}
+}
+
+func TestRunSuggestApply_ShowDiff(t *testing.T) {
+	original := "T\n| where A > 0\n| where B > 0\n| project A, B"
+	p := &fakeProvider{name: "fake", model: "fake", response: "T\n| where A > 0 and B > 0\n| project A, B"}
+
+	var stdout, diff strings.Builder
+	if err := runSuggestApply(context.Background(), p, original, ai.DefaultValidationConfig(), &stdout, &diff, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := strings.TrimSpace(stdout.String())
+	if out != "T\n| where A > 0 and B > 0\n| project A, B" {
+		t.Errorf("unexpected optimized query: %q", out)
+	}
+	if res := kqlparser.Parse("optimized.kql", out); len(res.Errors) > 0 {
+		t.Errorf("expected stdout to be a valid query, got errors: %v", res.Errors)
+	}
+
+	for _, want := range []string{"--- original", "+++ optimized", "- | where A > 0", "- | where B > 0", "+ | where A > 0 and B > 0", "  | project A, B"} {
+		if !strings.Contains(diff.String(), want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, diff.String())
+		}
+	}
+	if strings.Contains(diff.String(), "\033[") {
+		t.Error("expected no color codes when color is disabled")
+	}
+}
+
+func TestWriteQueryDiff_Color(t *testing.T) {
+	var b strings.Builder
+	writeQueryDiff(&b, "T | take 10", "T | take 5", "optimized", true)
+	if !strings.Contains(b.String(), ansiRed+"- T | take 10"+ansiReset) {
+		t.Errorf("expected removed line in red, got %q", b.String())
+	}
+	if !strings.Contains(b.String(), ansiGreen+"+ T | take 5"+ansiReset) {
+		t.Errorf("expected added line in green, got %q", b.String())
+	}
+
+	b.Reset()
+	writeQueryDiff(&b, "T | take 10", "T | take 10\n", "optimized", true)
+	if strings.TrimSpace(b.String()) != "No changes." {
+		t.Errorf("expected no changes, got %q", b.String())
+	}
+}
+
+func TestUseColor_NoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if useColor(false, os.Stderr) {
+		t.Error("expected NO_COLOR to disable color")
+	}
+}
+
+func TestSuggest_RequireValid(t *testing.T) {
+	defer func(v bool) { suggestRequireValid = v }(suggestRequireValid)
+	defer func(v string) { aiProvider = v }(aiProvider)
+
+	suggestRequireValid = false
+	if err := checkSuggestInput(brokenQuery); err != nil {
+		t.Errorf("expected broken queries to be reviewed by default, got %v", err)
+	}
+
+	suggestRequireValid = true
+	if err := checkSuggestInput(minimalFix); err != nil {
+		t.Errorf("unexpected error for a valid query: %v", err)
+	}
+
+	// Refused before the provider is resolved, so the bad provider name
+	// is never reached
+	aiProvider = "no-such-provider"
+	err := runSuggest(suggestCmd, []string{brokenQuery})
+	if err == nil || !strings.Contains(err.Error(), "syntax error") || !strings.Contains(err.Error(), "kql fix") {
+		t.Errorf("expected a syntax error pointing at kql fix, got %v", err)
+	}
+}
+
+func TestCountOperators(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "one line",
+			query: "StormEvents | where State == 'TEXAS' | summarize count() by EventType | top 5 by count_",
+			want:  []string{"where", "summarize", "top"},
+		},
+		{
+			name:  "operators on their own lines",
+			query: "StormEvents\n|\n    where State == 'TEXAS'\n|   summarize count() by EventType\n| order by count_",
+			want:  []string{"where", "summarize", "order"},
+		},
+		{
+			name:  "operator names in strings",
+			query: "StormEvents | where EventNarrative has '| join' or EventNarrative has '|render'",
+			want:  []string{"where"},
+		},
+		{
+			name:  "hyphenated operators and repeats",
+			query: "T | mv-expand Tags | where Tags != '' | where Id > 0 | make-series count() on Timestamp step 1h",
+			want:  []string{"mv-expand", "where", "make-series"},
+		},
+		{
+			name:  "subquery",
+			query: "T | join kind=inner (U | project Id, Name) on Id",
+			want:  []string{"join", "project"},
+		},
+		{
+			name:  "syntax errors fall back to text matching",
+			query: "T | where x > | summarize count( by y",
+			want:  []string{"where", "summarize"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := countOperators(tt.query)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("countOperators() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// anthropicVersion is the Messages API version sent in the
+// anthropic-version header.
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens caps the response length, as on Vertex.
+const anthropicMaxTokens = 4096
+
+// AnthropicProvider implements the Provider interface for the Anthropic
+// Messages API.
+type AnthropicProvider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature float32
+	client      *http.Client
+	retry       HTTPRetryConfig
+	usageRecorder
+}
+
+// NewAnthropicProvider creates a new Anthropic provider.
+func NewAnthropicProvider(cfg Config) (*AnthropicProvider, error) {
+	apiKey := cfg.Anthropic.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	apiKey = resolveAPIKey(apiKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("anthropic: API key required (set --anthropic-api-key or ANTHROPIC_API_KEY)")
+	}
+
+	baseURL := cfg.Anthropic.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = DefaultAnthropicModel
+	}
+
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+
+	return &AnthropicProvider{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		apiKey:      apiKey,
+		model:       model,
+		temperature: cfg.Temperature,
+		client:      client,
+		retry:       cfg.HTTPRetry,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+// Model returns the model name.
+func (p *AnthropicProvider) Model() string {
+	return p.model
+}
+
+// Complete sends a prompt and returns the response.
+func (p *AnthropicProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
+
+// CompleteChat sends a chat conversation and returns the response. System
+// messages go in the request's top-level system field, joined in order.
+func (p *AnthropicProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	text, err := p.completeChat(ctx, messages)
+	return text, timeoutError(ctx, err)
+}
+
+func (p *AnthropicProvider) completeChat(ctx context.Context, messages []Message) (string, error) {
+	p.record(Usage{}, false)
+
+	reqBody := claudeRequest{
+		MaxTokens:   anthropicMaxTokens,
+		Temperature: p.temperature,
+	}
+
+	var system []string
+	for _, m := range messages {
+		if m.Role == RoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		reqBody.Messages = append(reqBody.Messages, claudeMessage{
+			Role:    string(m.Role),
+			Content: m.Content,
+		})
+	}
+	reqBody.System = strings.Join(system, "\n\n")
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	resp, err := doWithRetry(ctx, p.client, p.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", p.apiKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		return req, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("sending request to anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("anthropic returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result claudeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no content in response")
+	}
+
+	p.record(result.Usage.value())
+	return text.String(), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicProvider_CompleteChat(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "sk-ant-test" {
+			t.Errorf("unexpected x-api-key %q", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+			t.Errorf("unexpected anthropic-version %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &raw); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		json.NewEncoder(w).Encode(claudeResponse{
+			Content: []claudeContentBlock{{Type: "text", Text: "T | take 10"}},
+		})
+	}))
+	defer srv.Close()
+
+	p, err := NewAnthropicProvider(Config{
+		Model:     "claude-sonnet-4-5",
+		Anthropic: AnthropicConfig{APIKey: "sk-ant-test", BaseURL: srv.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.CompleteChat(context.Background(), []Message{
+		{Role: RoleSystem, Content: "You write KQL."},
+		{Role: RoleUser, Content: "ten rows"},
+		{Role: RoleAssistant, Content: "T | take 5"},
+		{Role: RoleUser, Content: "ten, not five"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "T | take 10" {
+		t.Errorf("got %q", got)
+	}
+
+	if raw["system"] != "You write KQL." {
+		t.Errorf("system = %v, want the system message", raw["system"])
+	}
+	if _, ok := raw["anthropic_version"]; ok {
+		t.Error("anthropic_version belongs in the header, not the body")
+	}
+	messages, _ := raw["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 non-system messages, got %v", raw["messages"])
+	}
+	if first := messages[0].(map[string]any); first["role"] != "user" {
+		t.Errorf("first message role = %v, want user", first["role"])
+	}
+}
+
+func TestAnthropicProvider_ErrorStatus(t *testing.T) {
+	useFakeClock(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type":"error"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	p, err := NewAnthropicProvider(Config{Anthropic: AnthropicConfig{APIKey: "k", BaseURL: srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Complete(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "anthropic returned status 429") {
+		t.Errorf("expected a 429 error, got %v", err)
+	}
+}
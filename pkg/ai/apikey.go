@@ -0,0 +1,20 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import "github.com/cloudygreybeard/kql/pkg/keyring"
+
+// resolveKeyring looks up keyring references. Tests replace it.
+var resolveKeyring = keyring.Resolve
+
+// resolveAPIKey returns the API key named by value, looking up a
+// "keyring:<name>" reference in the OS keyring. A reference that can't be
+// resolved yields "", so the provider reports the key as missing.
+func resolveAPIKey(value string) string {
+	key, err := resolveKeyring(value)
+	if err != nil {
+		return ""
+	}
+	return key
+}
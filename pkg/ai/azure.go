@@ -54,7 +54,11 @@ func NewAzureProvider(cfg Config) (*AzureProvider, error) {
 	}
 
 	// Create the actual client
-	client, err := newAzureOpenAIClient(endpoint, deployment, apiKey)
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("azure: %w", err)
+	}
+	client, err := newAzureOpenAIClient(endpoint, deployment, apiKey, httpClient, cfg.HTTPRetry)
 	if err != nil {
 		return nil, fmt.Errorf("azure: creating client: %w", err)
 	}
@@ -83,7 +87,17 @@ func (p *AzureProvider) Complete(ctx context.Context, prompt string) (string, er
 	return p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: prompt}})
 }
 
+// LastUsage returns the token usage of the most recent completion, as
+// reported by the client.
+func (p *AzureProvider) LastUsage() (Usage, bool) {
+	if r, ok := p.client.(UsageReporter); ok {
+		return r.LastUsage()
+	}
+	return Usage{}, false
+}
+
 // CompleteChat sends a chat conversation and returns the response.
 func (p *AzureProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
-	return p.client.ChatComplete(ctx, messages, p.temperature)
+	text, err := p.client.ChatComplete(ctx, messages, p.temperature)
+	return text, timeoutError(ctx, err)
 }
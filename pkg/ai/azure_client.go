@@ -14,20 +14,26 @@ import (
 	"strings"
 )
 
+// azureAPIVersion is the Azure OpenAI REST API version requested.
+const azureAPIVersion = "2024-02-15-preview"
+
 // azureOpenAIClient uses the Azure OpenAI REST API directly.
 type azureOpenAIClient struct {
 	endpoint   string
 	deployment string
 	apiKey     string
 	client     *http.Client
+	retry      HTTPRetryConfig
+	usageRecorder
 }
 
 // newAzureOpenAIClient creates a new Azure OpenAI client.
-func newAzureOpenAIClient(endpoint, deployment, apiKey string) (*azureOpenAIClient, error) {
+func newAzureOpenAIClient(endpoint, deployment, apiKey string, client *http.Client, retry HTTPRetryConfig) (*azureOpenAIClient, error) {
 	// If no API key provided, try to get from environment
 	if apiKey == "" {
 		apiKey = os.Getenv("AZURE_OPENAI_API_KEY")
 	}
+	apiKey = resolveAPIKey(apiKey)
 
 	if apiKey == "" {
 		return nil, fmt.Errorf("azure: API key required (set --azure-api-key or AZURE_OPENAI_API_KEY)")
@@ -37,12 +43,15 @@ func newAzureOpenAIClient(endpoint, deployment, apiKey string) (*azureOpenAIClie
 		endpoint:   strings.TrimSuffix(endpoint, "/"),
 		deployment: deployment,
 		apiKey:     apiKey,
-		client:     &http.Client{},
+		client:     client,
+		retry:      retry,
 	}, nil
 }
 
 // ChatComplete sends a chat completion request.
 func (c *azureOpenAIClient) ChatComplete(ctx context.Context, messages []Message, temp float32) (string, error) {
+	c.record(Usage{}, false)
+
 	// Convert messages to Azure format
 	azureMessages := make([]azureChatMessage, len(messages))
 	for i, m := range messages {
@@ -63,18 +72,18 @@ func (c *azureOpenAIClient) ChatComplete(ctx context.Context, messages []Message
 	}
 
 	// Azure OpenAI API endpoint format
-	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=2024-02-15-preview",
-		c.endpoint, c.deployment)
+	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		c.endpoint, c.deployment, azureAPIVersion)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", c.apiKey)
-
-	resp, err := c.client.Do(req)
+	resp, err := doWithRetry(ctx, c.client, c.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("api-key", c.apiKey)
+		return req, nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("sending request to azure: %w", err)
 	}
@@ -94,6 +103,7 @@ func (c *azureOpenAIClient) ChatComplete(ctx context.Context, messages []Message
 		return "", fmt.Errorf("no choices in response")
 	}
 
+	c.record(result.Usage.value())
 	return result.Choices[0].Message.Content, nil
 }
 
@@ -111,6 +121,7 @@ type azureChatMessage struct {
 
 type azureChatResponse struct {
 	Choices []azureChatChoice `json:"choices"`
+	Usage   *openaiUsage      `json:"usage,omitempty"`
 }
 
 type azureChatChoice struct {
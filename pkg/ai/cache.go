@@ -0,0 +1,159 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResponseCache stores model responses on disk, keyed by a hash of the request.
+type ResponseCache struct {
+	// Dir is the directory holding cached responses
+	Dir string
+
+	// TTL is how long entries stay valid; zero keeps them indefinitely
+	TTL time.Duration
+}
+
+// DefaultCacheDir returns the default response cache directory
+// (e.g. ~/.cache/kql/responses on Linux).
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kql", "responses"), nil
+}
+
+// NewDefaultCache returns a cache in DefaultCacheDir.
+func NewDefaultCache() (*ResponseCache, error) {
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseCache{Dir: dir}, nil
+}
+
+// CacheKey hashes the given parts into a cache key. Parts are
+// length-delimited so ("ab", "c") and ("a", "bc") produce different keys.
+func CacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p)))
+		h.Write(n[:])
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached response for key, if present and not older than
+// the TTL.
+func (c *ResponseCache) Get(key string) (string, bool) {
+	if c.TTL > 0 {
+		info, err := os.Stat(c.path(key))
+		if err != nil || clock.Now().Sub(info.ModTime()) > c.TTL {
+			return "", false
+		}
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put stores a response under key.
+func (c *ResponseCache) Put(key, response string) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so readers never see partial entries
+	tmp, err := os.CreateTemp(c.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(response); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.Dir, key)
+}
+
+// Complete returns the cached response for key, or sends prompt to the
+// provider and caches the result. With refresh set, the cache is not read
+// but the fresh response is still stored. A nil cache always calls the
+// provider. The second return value reports a cache hit.
+func (c *ResponseCache) Complete(ctx context.Context, provider Provider, key, prompt string, refresh bool) (string, bool, error) {
+	if c != nil && !refresh {
+		if cached, ok := c.Get(key); ok {
+			return cached, true, nil
+		}
+	}
+
+	response, err := provider.Complete(ctx, prompt)
+	if err != nil {
+		return "", false, err
+	}
+
+	if c != nil {
+		// A failed cache write should not fail the command
+		_ = c.Put(key, response)
+	}
+
+	return response, false, nil
+}
+
+// CompleteStream is Complete for output that is shown as it arrives: the
+// response is written to out, streamed when the provider is a
+// StreamingProvider, and cached once complete. The bool reports a cache
+// hit.
+func (c *ResponseCache) CompleteStream(ctx context.Context, provider Provider, key, prompt string, refresh bool, out io.Writer) (bool, error) {
+	sp, ok := provider.(StreamingProvider)
+	if !ok {
+		response, hit, err := c.Complete(ctx, provider, key, prompt, refresh)
+		if err != nil {
+			return false, err
+		}
+		_, err = io.WriteString(out, response)
+		return hit, err
+	}
+
+	if c != nil && !refresh {
+		if cached, ok := c.Get(key); ok {
+			_, err := io.WriteString(out, cached)
+			return true, err
+		}
+	}
+
+	var response strings.Builder
+	if err := sp.CompleteStream(ctx, prompt, io.MultiWriter(out, &response)); err != nil {
+		return false, err
+	}
+
+	if c != nil {
+		// A failed cache write should not fail the command
+		_ = c.Put(key, response.String())
+	}
+
+	return false, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// countingProvider is a fake Provider that records how often it is called.
+type countingProvider struct {
+	response string
+	err      error
+	calls    int
+}
+
+func (p *countingProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	p.calls++
+	return p.response, p.err
+}
+
+func (p *countingProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	p.calls++
+	return p.response, p.err
+}
+
+func (p *countingProvider) Name() string  { return "fake" }
+func (p *countingProvider) Model() string { return "fake-model" }
+
+func TestCacheKey(t *testing.T) {
+	if CacheKey("a", "b") != CacheKey("a", "b") {
+		t.Error("expected identical parts to produce identical keys")
+	}
+	if CacheKey("ab", "c") == CacheKey("a", "bc") {
+		t.Error("expected part boundaries to affect the key")
+	}
+}
+
+func TestResponseCache_GetPut(t *testing.T) {
+	c := &ResponseCache{Dir: t.TempDir()}
+
+	if _, ok := c.Get("missing"); ok {
+		t.Error("expected miss for unknown key")
+	}
+	if err := c.Put("k", "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, ok := c.Get("k"); !ok || got != "value" {
+		t.Errorf("expected hit with 'value', got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestResponseCache_Complete(t *testing.T) {
+	c := &ResponseCache{Dir: t.TempDir()}
+	p := &countingProvider{response: "explained"}
+	ctx := context.Background()
+
+	got, hit, err := c.Complete(ctx, p, "key", "prompt", false)
+	if err != nil || hit || got != "explained" {
+		t.Fatalf("first call: got %q hit=%v err=%v", got, hit, err)
+	}
+
+	got, hit, err = c.Complete(ctx, p, "key", "prompt", false)
+	if err != nil || !hit || got != "explained" {
+		t.Fatalf("second call: got %q hit=%v err=%v", got, hit, err)
+	}
+	if p.calls != 1 {
+		t.Errorf("expected cache hit to skip the provider, got %d calls", p.calls)
+	}
+
+	p.response = "re-explained"
+	got, hit, err = c.Complete(ctx, p, "key", "prompt", true)
+	if err != nil || hit || got != "re-explained" {
+		t.Fatalf("refresh call: got %q hit=%v err=%v", got, hit, err)
+	}
+	if p.calls != 2 {
+		t.Errorf("expected refresh to call the provider, got %d calls", p.calls)
+	}
+
+	// Refresh updates the stored entry
+	if cached, _ := c.Get("key"); cached != "re-explained" {
+		t.Errorf("expected refreshed response to be cached, got %q", cached)
+	}
+}
+
+func TestResponseCache_CompleteErrorNotCached(t *testing.T) {
+	c := &ResponseCache{Dir: t.TempDir()}
+	p := &countingProvider{err: errors.New("boom")}
+
+	if _, _, err := c.Complete(context.Background(), p, "key", "prompt", false); err == nil {
+		t.Fatal("expected provider error")
+	}
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected failed response not to be cached")
+	}
+}
+
+func TestResponseCache_NilCallsProvider(t *testing.T) {
+	var c *ResponseCache
+	p := &countingProvider{response: "ok"}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.Complete(context.Background(), p, "key", "prompt", false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if p.calls != 2 {
+		t.Errorf("expected nil cache to call the provider every time, got %d calls", p.calls)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"io"
+	"strconv"
+)
+
+// CachingProvider wraps a Provider, answering repeated requests from a
+// ResponseCache instead of the network. Requests are keyed by provider,
+// model, temperature, and the full conversation.
+type CachingProvider struct {
+	provider    Provider
+	cache       *ResponseCache
+	temperature float32
+	usageRecorder
+}
+
+// NewCachingProvider wraps provider with cache. temperature is part of the
+// key, since it changes the responses a prompt can produce.
+func NewCachingProvider(provider Provider, cache *ResponseCache, temperature float32) *CachingProvider {
+	return &CachingProvider{provider: provider, cache: cache, temperature: temperature}
+}
+
+// Name returns the wrapped provider's name.
+func (p *CachingProvider) Name() string {
+	return p.provider.Name()
+}
+
+// Model returns the wrapped provider's model.
+func (p *CachingProvider) Model() string {
+	return p.provider.Model()
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CachingProvider) Unwrap() Provider {
+	return p.provider
+}
+
+// Complete returns the cached response to prompt, or asks the wrapped
+// provider and caches its answer.
+func (p *CachingProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	response, hit, err := p.cache.Complete(ctx, p.provider, p.key([]Message{{Role: RoleUser, Content: prompt}}), prompt, false)
+	p.recordUsage(hit)
+	return response, err
+}
+
+// CompleteChat returns the cached response to messages, or asks the
+// wrapped provider and caches its answer.
+func (p *CachingProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	key := p.key(messages)
+	if cached, ok := p.cache.Get(key); ok {
+		p.recordUsage(true)
+		return cached, nil
+	}
+
+	response, err := p.provider.CompleteChat(ctx, messages)
+	p.recordUsage(false)
+	if err != nil {
+		return "", err
+	}
+
+	// A failed cache write should not fail the command
+	_ = p.cache.Put(key, response)
+	return response, nil
+}
+
+// CompleteStream writes the cached response to prompt to out, or streams
+// it from the wrapped provider and caches it once complete. Providers that
+// don't stream are answered whole.
+func (p *CachingProvider) CompleteStream(ctx context.Context, prompt string, out io.Writer) error {
+	hit, err := p.cache.CompleteStream(ctx, p.provider, p.key([]Message{{Role: RoleUser, Content: prompt}}), prompt, false, out)
+	p.recordUsage(hit)
+	return err
+}
+
+// recordUsage notes the usage of the latest request: none for a cache
+// hit, otherwise whatever the wrapped provider reported.
+func (p *CachingProvider) recordUsage(hit bool) {
+	if hit {
+		p.record(Usage{}, true)
+		return
+	}
+	p.record(UsageOf(p.provider))
+}
+
+// key identifies a request. A prompt sent with Complete shares its key
+// with the equivalent one-message conversation, and a system prompt added
+// by a SystemPromptProvider counts as the first message.
+func (p *CachingProvider) key(messages []Message) string {
+	parts := []string{
+		"provider",
+		p.provider.Name(),
+		p.provider.Model(),
+		strconv.FormatFloat(float64(p.temperature), 'g', -1, 32),
+	}
+	if system := SystemPromptOf(p.provider); system != "" {
+		parts = append(parts, string(RoleSystem), system)
+	}
+	for _, m := range messages {
+		parts = append(parts, string(m.Role), m.Content)
+	}
+	return CacheKey(parts...)
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachingProvider_SecondCallIsCached(t *testing.T) {
+	inner := &countingProvider{response: "T | take 10"}
+	p := NewCachingProvider(inner, &ResponseCache{Dir: t.TempDir()}, 0.2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		got, err := p.Complete(ctx, "ten rows")
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i+1, err)
+		}
+		if got != "T | take 10" {
+			t.Errorf("call %d: got %q", i+1, got)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected 1 provider call, got %d", inner.calls)
+	}
+
+	// A one-message conversation is the same request
+	if _, err := p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: "ten rows"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected the chat to hit the cache, got %d calls", inner.calls)
+	}
+
+	// A different prompt is not
+	if _, err := p.Complete(ctx, "twenty rows"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected a new prompt to call the provider, got %d calls", inner.calls)
+	}
+}
+
+func TestCachingProvider_KeyIncludesTemperature(t *testing.T) {
+	inner := &countingProvider{response: "answer"}
+	cache := &ResponseCache{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	if _, err := NewCachingProvider(inner, cache, 0.2).Complete(ctx, "q"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCachingProvider(inner, cache, 0.7).Complete(ctx, "q"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected a different temperature to miss, got %d calls", inner.calls)
+	}
+}
+
+func TestCachingProvider_ErrorNotCached(t *testing.T) {
+	inner := &countingProvider{err: errors.New("unavailable")}
+	p := NewCachingProvider(inner, &ResponseCache{Dir: t.TempDir()}, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: "q"}}); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected failures to be retried, got %d calls", inner.calls)
+	}
+}
+
+func TestCachingProvider_CompleteStream(t *testing.T) {
+	inner := &countingProvider{response: "whole answer"}
+	p := NewCachingProvider(inner, &ResponseCache{Dir: t.TempDir()}, 0)
+	var _ StreamingProvider = p
+
+	for i := 0; i < 2; i++ {
+		var out strings.Builder
+		if err := p.CompleteStream(context.Background(), "q", &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != "whole answer" {
+			t.Errorf("call %d: got %q", i+1, out.String())
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected 1 provider call, got %d", inner.calls)
+	}
+}
+
+func TestResponseCache_TTL(t *testing.T) {
+	c := &ResponseCache{Dir: t.TempDir(), TTL: time.Hour}
+	if err := c.Put("k", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("k"); !ok {
+		t.Fatal("expected a fresh entry to hit")
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(c.Dir, "k"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Error("expected an expired entry to miss")
+	}
+
+	c.TTL = 0
+	if _, ok := c.Get("k"); !ok {
+		t.Error("expected entries to be kept without a TTL")
+	}
+}
+
+func TestNewProvider_Cache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := DefaultConfig()
+	p, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*CachingProvider); ok {
+		t.Error("expected no cache by default")
+	}
+
+	cfg.Cache.Enabled = true
+	p, err = NewProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp, ok := p.(*CachingProvider)
+	if !ok {
+		t.Fatalf("expected a CachingProvider, got %T", p)
+	}
+	if _, ok := cp.Unwrap().(*OllamaProvider); !ok || cp.Name() != "ollama" {
+		t.Errorf("expected the ollama provider inside, got %T", cp.Unwrap())
+	}
+	if cp.cache.TTL != DefaultCacheTTL {
+		t.Errorf("TTL = %v", cp.cache.TTL)
+	}
+}
+
+func TestMergeFileConfig_Cache(t *testing.T) {
+	fileCfg, err := LoadConfigFromPath(writeConfig(t, "ai:\n  cache:\n    enabled: true\n    ttl: 2h\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := MergeFileConfig(DefaultConfig(), fileCfg)
+	if !cfg.Cache.Enabled || cfg.Cache.TTL != 2*time.Hour {
+		t.Errorf("unexpected cache config: %+v", cfg.Cache)
+	}
+}
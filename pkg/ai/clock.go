@@ -0,0 +1,42 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time for time-dependent behavior in this package,
+// such as retry budgets and backoff.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep waits for d, returning early with ctx.Err() if ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// systemClock is the wall clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// clock is the Clock used throughout the package. Tests replace it with a
+// fake to control time without sleeping.
+var clock Clock = systemClock{}
@@ -0,0 +1,71 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when advanced. Sleep advances it
+// immediately instead of blocking.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.Advance(d)
+	return nil
+}
+
+// Advance moves the clock forward by d.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// useFakeClock installs a fake clock for the duration of the test.
+func useFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	fc := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	orig := clock
+	clock = fc
+	t.Cleanup(func() { clock = orig })
+	return fc
+}
+
+func TestFakeClock_Advance(t *testing.T) {
+	fc := useFakeClock(t)
+	start := clock.Now()
+
+	fc.Advance(90 * time.Second)
+	if got := clock.Now().Sub(start); got != 90*time.Second {
+		t.Errorf("expected clock to advance 90s, got %v", got)
+	}
+
+	if err := clock.Sleep(context.Background(), time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := clock.Now().Sub(start); got != 150*time.Second {
+		t.Errorf("expected Sleep to advance the fake clock, got %v", got)
+	}
+}
+
+func TestSystemClock_SleepCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := (systemClock{}).Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected Sleep to return promptly when the context is done")
+	}
+}
@@ -4,15 +4,73 @@
 package ai
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // FileConfig represents the configuration file structure.
 type FileConfig struct {
-	AI AIFileConfig `yaml:"ai"`
+	AI   AIFileConfig   `yaml:"ai"`
+	Link LinkFileConfig `yaml:"link"`
+
+	// Profiles are named alternatives to the ai section, selected with
+	// --profile or default_profile
+	Profiles       map[string]AIFileConfig `yaml:"profiles"`
+	DefaultProfile string                  `yaml:"default_profile"`
+}
+
+// WithProfile returns the config with the named profile in place of the ai
+// section. An empty name selects default_profile, and without one the ai
+// section is kept. f may be nil, in which case only an empty name is
+// accepted.
+func (f *FileConfig) WithProfile(name string) (*FileConfig, error) {
+	if name == "" && f != nil {
+		name = f.DefaultProfile
+	}
+	if name == "" {
+		return f, nil
+	}
+
+	var names []string
+	if f != nil {
+		if profile, ok := f.Profiles[name]; ok {
+			selected := *f
+			selected.AI = profile
+			return &selected, nil
+		}
+		for n := range f.Profiles {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("unknown profile %q (no profiles defined)", name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// LinkFileConfig represents the link section of the configuration file.
+type LinkFileConfig struct {
+	Cluster   string `yaml:"cluster"`
+	Database  string `yaml:"database"`
+	Cloud     string `yaml:"cloud"`
+	BaseURL   string `yaml:"base_url"`
+	MaxLength int    `yaml:"max_length"`
+
+	Shortener struct {
+		URL string `yaml:"url"`
+	} `yaml:"shortener"`
+
+	// DefaultCluster and DefaultDatabase are the earlier names of
+	// Cluster and Database, still read when those are unset
+	DefaultCluster  string `yaml:"default_cluster,omitempty"`
+	DefaultDatabase string `yaml:"default_database,omitempty"`
 }
 
 // AIFileConfig represents the AI section of the configuration file.
@@ -21,6 +79,8 @@ type AIFileConfig struct {
 	Model       string  `yaml:"model"`
 	Temperature float32 `yaml:"temperature"`
 
+	SystemPrompt string `yaml:"system_prompt"`
+
 	Ollama struct {
 		Endpoint string `yaml:"endpoint"`
 	} `yaml:"ollama"`
@@ -40,6 +100,24 @@ type AIFileConfig struct {
 		Endpoint string `yaml:"endpoint"`
 	} `yaml:"instructlab"`
 
+	OpenAI struct {
+		APIKey  string `yaml:"api_key"`
+		BaseURL string `yaml:"base_url"`
+	} `yaml:"openai"`
+
+	Anthropic struct {
+		APIKey  string `yaml:"api_key"`
+		BaseURL string `yaml:"base_url"`
+	} `yaml:"anthropic"`
+
+	Proxy  string `yaml:"proxy"`
+	CACert string `yaml:"ca_cert"`
+
+	Cache struct {
+		Enabled *bool          `yaml:"enabled"`
+		TTL     *time.Duration `yaml:"ttl"`
+	} `yaml:"cache"`
+
 	Validation ValidationFileConfig `yaml:"validation"`
 }
 
@@ -47,12 +125,18 @@ type AIFileConfig struct {
 type ValidationFileConfig struct {
 	Enabled  *bool `yaml:"enabled"`
 	Strict   *bool `yaml:"strict"`
+	Semantic *bool `yaml:"semantic"`
 	Retries  *int  `yaml:"retries"`
 	Feedback struct {
-		Errors      *bool `yaml:"errors"`
-		Hints       *bool `yaml:"hints"`
-		Examples    *bool `yaml:"examples"`
-		Progressive *bool `yaml:"progressive"`
+		Errors         *bool              `yaml:"errors"`
+		Hints          *bool              `yaml:"hints"`
+		Examples       *bool              `yaml:"examples"`
+		Progressive    *bool              `yaml:"progressive"`
+		Rules          []FeedbackRuleFile `yaml:"rules"`
+		MaxErrors      *int               `yaml:"max_errors"`
+		MaxHints       *int               `yaml:"max_hints"`
+		MaxExamples    *int               `yaml:"max_examples"`
+		MaxPromptBytes *int               `yaml:"max_prompt_bytes"`
 	} `yaml:"feedback"`
 	Temperature struct {
 		Adjust    *bool    `yaml:"adjust"`
@@ -61,14 +145,28 @@ type ValidationFileConfig struct {
 	} `yaml:"temperature"`
 }
 
+// FeedbackRuleFile represents a custom retry hint/example rule in the config file.
+type FeedbackRuleFile struct {
+	Match   string `yaml:"match"`
+	Hint    string `yaml:"hint"`
+	Example string `yaml:"example"`
+}
+
+// ConfigFilePath returns the path of the config file, ~/.kql/config.yaml.
+func ConfigFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kql", "config.yaml"), nil
+}
+
 // LoadConfigFile loads configuration from ~/.kql/config.yaml if it exists.
 func LoadConfigFile() (*FileConfig, error) {
-	home, err := os.UserHomeDir()
+	configPath, err := ConfigFilePath()
 	if err != nil {
 		return nil, err
 	}
-
-	configPath := filepath.Join(home, ".kql", "config.yaml")
 	return LoadConfigFromPath(configPath)
 }
 
@@ -113,6 +211,11 @@ func MergeFileConfig(cfg Config, fileCfg *FileConfig) Config {
 		cfg.Temperature = ai.Temperature
 	}
 
+	// System prompt
+	if cfg.SystemPrompt == "" && ai.SystemPrompt != "" {
+		cfg.SystemPrompt = ai.SystemPrompt
+	}
+
 	// Ollama
 	if cfg.Ollama.Endpoint == "" && ai.Ollama.Endpoint != "" {
 		cfg.Ollama.Endpoint = ai.Ollama.Endpoint
@@ -142,6 +245,38 @@ func MergeFileConfig(cfg Config, fileCfg *FileConfig) Config {
 		cfg.InstructLab.Endpoint = ai.InstructLab.Endpoint
 	}
 
+	// OpenAI
+	if cfg.OpenAI.APIKey == "" && ai.OpenAI.APIKey != "" {
+		cfg.OpenAI.APIKey = ai.OpenAI.APIKey
+	}
+	if cfg.OpenAI.BaseURL == "" && ai.OpenAI.BaseURL != "" {
+		cfg.OpenAI.BaseURL = ai.OpenAI.BaseURL
+	}
+
+	// Anthropic
+	if cfg.Anthropic.APIKey == "" && ai.Anthropic.APIKey != "" {
+		cfg.Anthropic.APIKey = ai.Anthropic.APIKey
+	}
+	if cfg.Anthropic.BaseURL == "" && ai.Anthropic.BaseURL != "" {
+		cfg.Anthropic.BaseURL = ai.Anthropic.BaseURL
+	}
+
+	// Transport
+	if cfg.Transport.Proxy == "" && ai.Proxy != "" {
+		cfg.Transport.Proxy = ai.Proxy
+	}
+	if cfg.Transport.CACert == "" && ai.CACert != "" {
+		cfg.Transport.CACert = ai.CACert
+	}
+
+	// Cache settings (flags are applied after the file, as for validation)
+	if ai.Cache.Enabled != nil {
+		cfg.Cache.Enabled = *ai.Cache.Enabled
+	}
+	if ai.Cache.TTL != nil {
+		cfg.Cache.TTL = *ai.Cache.TTL
+	}
+
 	// Validation settings (file config provides defaults, pointers allow explicit false)
 	v := ai.Validation
 	if v.Enabled != nil {
@@ -150,6 +285,9 @@ func MergeFileConfig(cfg Config, fileCfg *FileConfig) Config {
 	if v.Strict != nil {
 		cfg.Validation.Strict = *v.Strict
 	}
+	if v.Semantic != nil {
+		cfg.Validation.Semantic = *v.Semantic
+	}
 	if v.Retries != nil {
 		cfg.Validation.Retries = *v.Retries
 	}
@@ -167,6 +305,25 @@ func MergeFileConfig(cfg Config, fileCfg *FileConfig) Config {
 	if v.Feedback.Progressive != nil {
 		cfg.Validation.Feedback.Progressive = *v.Feedback.Progressive
 	}
+	if v.Feedback.MaxErrors != nil {
+		cfg.Validation.Feedback.MaxErrors = *v.Feedback.MaxErrors
+	}
+	if v.Feedback.MaxHints != nil {
+		cfg.Validation.Feedback.MaxHints = *v.Feedback.MaxHints
+	}
+	if v.Feedback.MaxExamples != nil {
+		cfg.Validation.Feedback.MaxExamples = *v.Feedback.MaxExamples
+	}
+	if v.Feedback.MaxPromptBytes != nil {
+		cfg.Validation.Feedback.MaxPromptBytes = *v.Feedback.MaxPromptBytes
+	}
+	for _, r := range v.Feedback.Rules {
+		cfg.Validation.Feedback.Rules = append(cfg.Validation.Feedback.Rules, FeedbackRule{
+			Match:   r.Match,
+			Hint:    r.Hint,
+			Example: r.Example,
+		})
+	}
 
 	// Temperature adjustment settings
 	if v.Temperature.Adjust != nil {
@@ -0,0 +1,123 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFromPath_Link(t *testing.T) {
+	path := writeConfig(t, `
+ai:
+  provider: ollama
+link:
+  cluster: help
+  database: Samples
+  cloud: usgov
+  base_url: https://adx.example.com
+  max_length: 2000
+  shortener:
+    url: https://short.example.com/api
+`)
+
+	cfg, err := LoadConfigFromPath(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	l := cfg.Link
+	if l.Cluster != "help" || l.Database != "Samples" || l.Cloud != "usgov" {
+		t.Errorf("unexpected link target: %+v", l)
+	}
+	if l.BaseURL != "https://adx.example.com" || l.MaxLength != 2000 || l.Shortener.URL != "https://short.example.com/api" {
+		t.Errorf("unexpected link options: %+v", l)
+	}
+	if cfg.AI.Provider != "ollama" {
+		t.Errorf("expected ai section to load alongside link, got %q", cfg.AI.Provider)
+	}
+}
+
+func TestLoadConfigFromPath_NoLinkSection(t *testing.T) {
+	cfg, err := LoadConfigFromPath(writeConfig(t, "ai:\n  provider: ollama\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Link != (LinkFileConfig{}) {
+		t.Errorf("expected empty link config, got %+v", cfg.Link)
+	}
+}
+
+func TestFileConfigWithProfile(t *testing.T) {
+	var f FileConfig
+	data := `
+ai:
+  provider: ollama
+  model: llama3.2
+profiles:
+  work:
+    provider: azure
+    model: gpt-4o
+  claude:
+    provider: anthropic
+link:
+  cluster: help
+`
+	if err := yaml.Unmarshal([]byte(data), &f); err != nil {
+		t.Fatal(err)
+	}
+
+	// No profile keeps the ai section
+	got, err := f.WithProfile("")
+	if err != nil || got.AI.Provider != "ollama" {
+		t.Fatalf("expected the ai section, got %+v, %v", got, err)
+	}
+
+	got, err = f.WithProfile("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg := MergeFileConfig(Config{}, got); cfg.Provider != "azure" || cfg.Model != "gpt-4o" {
+		t.Errorf("expected the work profile, got %s/%s", cfg.Provider, cfg.Model)
+	}
+	if got.Link.Cluster != "help" {
+		t.Errorf("expected the link section to be kept, got %+v", got.Link)
+	}
+	if f.AI.Provider != "ollama" {
+		t.Error("expected the original config to be unchanged")
+	}
+
+	// default_profile applies when no name is given
+	f.DefaultProfile = "claude"
+	if got, err := f.WithProfile(""); err != nil || got.AI.Provider != "anthropic" {
+		t.Errorf("expected the default profile, got %+v, %v", got, err)
+	}
+
+	_, err = f.WithProfile("home")
+	if err == nil || !strings.Contains(err.Error(), "available: claude, work") {
+		t.Errorf("expected the available profiles to be listed, got %v", err)
+	}
+}
+
+func TestFileConfigWithProfile_NoConfig(t *testing.T) {
+	var f *FileConfig
+	if got, err := f.WithProfile(""); err != nil || got != nil {
+		t.Errorf("expected no config and no error, got %+v, %v", got, err)
+	}
+	if _, err := f.WithProfile("work"); err == nil || !strings.Contains(err.Error(), "no profiles defined") {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HealthChecker is implemented by providers that can check their service
+// is reachable and accepts their credentials, without running a model.
+type HealthChecker interface {
+	// Health returns nil if the service answered, or why it didn't.
+	Health(ctx context.Context) error
+}
+
+// ErrHealthUnsupported is returned by HealthOf for providers that cannot
+// be checked.
+var ErrHealthUnsupported = errors.New("provider has no health check")
+
+// HealthOf checks p, looking through wrappers such as CachingProvider and
+// SystemPromptProvider.
+func HealthOf(ctx context.Context, p Provider) error {
+	for {
+		if h, ok := p.(HealthChecker); ok {
+			return timeoutError(ctx, h.Health(ctx))
+		}
+		u, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			return ErrHealthUnsupported
+		}
+		p = u.Unwrap()
+	}
+}
+
+// healthGet sends a GET to url with the given headers and returns the body
+// of a 200 response. It is not retried: a health check reports the service
+// as it is now. name labels errors.
+func healthGet(ctx context.Context, client *http.Client, name, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response from %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// Health lists the local models and checks the configured one has been
+// pulled.
+func (p *OllamaProvider) Health(ctx context.Context) error {
+	body, err := healthGet(ctx, p.client, "ollama", p.endpoint+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	names := make([]string, len(tags.Models))
+	for i, m := range tags.Models {
+		names[i] = m.Name
+	}
+	// A model pulled without a tag is listed as :latest
+	if !slices.Contains(names, p.model) && !slices.Contains(names, p.model+":latest") {
+		return fmt.Errorf("ollama is running, but model %q is not pulled (run 'ollama pull %s')", p.model, p.model)
+	}
+	return nil
+}
+
+// Health lists the served models.
+func (p *InstructLabProvider) Health(ctx context.Context) error {
+	_, err := healthGet(ctx, p.client, "instructlab", p.endpoint+"/v1/models", nil)
+	return err
+}
+
+// Health lists the models the API key can use.
+func (p *OpenAIProvider) Health(ctx context.Context) error {
+	header := http.Header{"Authorization": {"Bearer " + p.apiKey}}
+	_, err := healthGet(ctx, p.client, "openai", p.baseURL+"/models", header)
+	return err
+}
+
+// Health lists the models the API key can use.
+func (p *AnthropicProvider) Health(ctx context.Context) error {
+	header := http.Header{
+		"X-Api-Key":         {p.apiKey},
+		"Anthropic-Version": {anthropicVersion},
+	}
+	_, err := healthGet(ctx, p.client, "anthropic", p.baseURL+"/v1/models", header)
+	return err
+}
+
+// Health checks the client, if it can be checked.
+func (p *AzureProvider) Health(ctx context.Context) error {
+	if h, ok := p.client.(HealthChecker); ok {
+		return h.Health(ctx)
+	}
+	return ErrHealthUnsupported
+}
+
+// Health lists the models of the resource, which needs only the endpoint
+// and API key.
+func (c *azureOpenAIClient) Health(ctx context.Context) error {
+	url := c.endpoint + "/openai/models?api-version=" + azureAPIVersion
+	_, err := healthGet(ctx, c.client, "azure", url, http.Header{"Api-Key": {c.apiKey}})
+	return err
+}
+
+// Health checks the client, if it can be checked.
+func (p *VertexProvider) Health(ctx context.Context) error {
+	if h, ok := p.client.(HealthChecker); ok {
+		return h.Health(ctx)
+	}
+	return ErrHealthUnsupported
+}
+
+// Health fetches an access token, which needs gcloud to be installed and
+// logged in. Vertex has no cheap call to check the model itself.
+func (c *vertexGenAIClient) Health(ctx context.Context) error {
+	_, err := c.getAccessToken()
+	return err
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// healthServer serves body with status on path and 404 elsewhere,
+// recording the last request.
+func healthServer(t *testing.T, path string, status int, body string) (*httptest.Server, **http.Request) {
+	t.Helper()
+	var last *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &last
+}
+
+func TestOllamaProvider_Health(t *testing.T) {
+	srv, _ := healthServer(t, "/api/tags", http.StatusOK, `{"models":[{"name":"llama3.2:latest"},{"name":"qwen2.5-coder:7b"}]}`)
+
+	for _, model := range []string{"llama3.2", "llama3.2:latest", "qwen2.5-coder:7b"} {
+		p, _ := NewOllamaProvider(Config{Model: model, Ollama: OllamaConfig{Endpoint: srv.URL}})
+		if err := p.Health(context.Background()); err != nil {
+			t.Errorf("%s: unexpected error: %v", model, err)
+		}
+	}
+
+	p, _ := NewOllamaProvider(Config{Model: "mistral", Ollama: OllamaConfig{Endpoint: srv.URL}})
+	err := p.Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "ollama pull mistral") {
+		t.Errorf("expected a missing model error, got %v", err)
+	}
+}
+
+func TestOllamaProvider_HealthUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	err := p.Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "sending request to ollama") {
+		t.Errorf("expected a connection error, got %v", err)
+	}
+}
+
+func TestInstructLabProvider_Health(t *testing.T) {
+	srv, _ := healthServer(t, "/v1/models", http.StatusOK, `{"data":[]}`)
+	p, _ := NewInstructLabProvider(Config{InstructLab: InstructLabConfig{Endpoint: srv.URL}})
+	if err := p.Health(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOpenAIProvider_Health(t *testing.T) {
+	srv, last := healthServer(t, "/v1/models", http.StatusUnauthorized, `{"error":"bad key"}`)
+	p, err := NewOpenAIProvider(Config{OpenAI: OpenAIConfig{APIKey: "sk-test", BaseURL: srv.URL + "/v1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "openai returned status 401") {
+		t.Errorf("expected a 401 error, got %v", err)
+	}
+	if got := (*last).Header.Get("Authorization"); got != "Bearer sk-test" {
+		t.Errorf("expected the API key as a bearer token, got %q", got)
+	}
+}
+
+func TestAnthropicProvider_Health(t *testing.T) {
+	srv, last := healthServer(t, "/v1/models", http.StatusOK, `{"data":[]}`)
+	p, err := NewAnthropicProvider(Config{Anthropic: AnthropicConfig{APIKey: "k", BaseURL: srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Health(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if (*last).Header.Get("x-api-key") != "k" || (*last).Header.Get("anthropic-version") != anthropicVersion {
+		t.Errorf("expected the API key and version headers, got %v", (*last).Header)
+	}
+}
+
+func TestAzureProvider_Health(t *testing.T) {
+	srv, last := healthServer(t, "/openai/models", http.StatusOK, `{"data":[]}`)
+	p, err := NewAzureProvider(Config{Azure: AzureConfig{Endpoint: srv.URL, Deployment: "gpt", APIKey: "key"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Health(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if (*last).Header.Get("api-key") != "key" || (*last).URL.Query().Get("api-version") != azureAPIVersion {
+		t.Errorf("unexpected request: %v %v", (*last).URL, (*last).Header)
+	}
+}
+
+func TestVertexClient_Health(t *testing.T) {
+	c, _ := newTestVertexClient("", "gemini-pro", "token")
+	if err := c.Health(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c, _ = newTestVertexClient("", "gemini-pro")
+	if err := c.Health(context.Background()); err == nil {
+		t.Error("expected an error without an access token")
+	}
+}
+
+func TestHealthOf(t *testing.T) {
+	srv, _ := healthServer(t, "/v1/models", http.StatusOK, `{"data":[]}`)
+	inner, _ := NewInstructLabProvider(Config{InstructLab: InstructLabConfig{Endpoint: srv.URL}})
+
+	// Through wrappers
+	wrapped := NewCachingProvider(NewSystemPromptProvider(inner, "be brief"), &ResponseCache{Dir: t.TempDir()}, 0)
+	if err := HealthOf(context.Background(), wrapped); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := HealthOf(context.Background(), &countingProvider{}); !errors.Is(err, ErrHealthUnsupported) {
+		t.Errorf("expected ErrHealthUnsupported, got %v", err)
+	}
+}
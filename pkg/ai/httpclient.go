@@ -0,0 +1,62 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportConfig controls how providers reach their endpoints. With
+// neither field set, the default transport is used, which already honors
+// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY.
+type TransportConfig struct {
+	// Proxy is the URL of a proxy for all requests, overriding the
+	// environment
+	Proxy string
+
+	// CACert is a PEM file of CA certificates to trust in addition to the
+	// system roots
+	CACert string
+}
+
+// newHTTPClient returns the HTTP client for a provider, applying the proxy
+// and CA settings in cfg.Transport.
+func newHTTPClient(cfg Config) (*http.Client, error) {
+	t := cfg.Transport
+	if t.Proxy == "" && t.CACert == "" {
+		return &http.Client{}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if t.Proxy != "" {
+		proxyURL, err := url.Parse(t.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", t.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if t.CACert != "" {
+		pem, err := os.ReadFile(t.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificate: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
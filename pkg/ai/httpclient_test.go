@@ -0,0 +1,114 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPClient_Default(t *testing.T) {
+	c, err := newHTTPClient(Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Transport != nil {
+		t.Errorf("expected the default transport, got %T", c.Transport)
+	}
+}
+
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	// An HTTP proxy receives the absolute URL of the target
+	var target string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.URL.String()
+		json.NewEncoder(w).Encode(ollamaChatResponse{Message: ollamaChatMessage{Content: "T | take 10"}})
+	}))
+	defer proxy.Close()
+
+	p, err := NewOllamaProvider(Config{
+		Ollama:    OllamaConfig{Endpoint: "http://ollama.internal:11434"},
+		Transport: TransportConfig{Proxy: proxy.URL},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.Complete(context.Background(), "ten rows")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "T | take 10" || target != "http://ollama.internal:11434/api/chat" {
+		t.Errorf("got %q via proxy request for %q", got, target)
+	}
+
+	if _, err := newHTTPClient(Config{Transport: TransportConfig{Proxy: "proxy.example.com"}}); err == nil {
+		t.Error("expected an error for a proxy without a scheme")
+	}
+}
+
+func TestNewHTTPClient_CACert(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// The test server's certificate is self-signed, so untrusted by default
+	plain, _ := newHTTPClient(Config{})
+	if _, err := plain.Get(srv.URL); err == nil {
+		t.Fatal("expected the self-signed certificate to be rejected")
+	}
+
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := newHTTPClient(Config{Transport: TransportConfig{CACert: certFile}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewHTTPClient_CACertErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := newHTTPClient(Config{Transport: TransportConfig{CACert: filepath.Join(dir, "missing.pem")}}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newHTTPClient(Config{Transport: TransportConfig{CACert: notPEM}}); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+}
+
+func TestMergeFileConfig_Transport(t *testing.T) {
+	fileCfg, err := LoadConfigFromPath(writeConfig(t, "ai:\n  proxy: http://proxy:3128\n  ca_cert: /etc/ssl/corp.pem\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := MergeFileConfig(DefaultConfig(), fileCfg)
+	if cfg.Transport.Proxy != "http://proxy:3128" || cfg.Transport.CACert != "/etc/ssl/corp.pem" {
+		t.Errorf("unexpected transport: %+v", cfg.Transport)
+	}
+
+	// Flags win
+	flagCfg := DefaultConfig()
+	flagCfg.Transport.Proxy = "http://flag:8080"
+	if cfg := MergeFileConfig(flagCfg, fileCfg); cfg.Transport.Proxy != "http://flag:8080" {
+		t.Errorf("expected the flag proxy, got %q", cfg.Transport.Proxy)
+	}
+}
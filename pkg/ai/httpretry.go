@@ -0,0 +1,94 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// HTTPRetryConfig controls retries of transient transport failures:
+// network errors, 429 Too Many Requests, and 5xx responses. It is separate
+// from ValidationConfig, which re-prompts when a response is invalid KQL.
+type HTTPRetryConfig struct {
+	// MaxAttempts is the number of tries, including the first (default: 3)
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry, doubling for each
+	// retry after it, with jitter (default: 500ms)
+	BaseDelay time.Duration
+}
+
+// DefaultHTTPRetryConfig returns the transport retry defaults.
+func DefaultHTTPRetryConfig() HTTPRetryConfig {
+	return HTTPRetryConfig{
+		MaxAttempts: DefaultHTTPRetryAttempts,
+		BaseDelay:   DefaultHTTPRetryBaseDelay,
+	}
+}
+
+// withDefaults fills unset fields, so a zero config retries by default.
+func (c HTTPRetryConfig) withDefaults() HTTPRetryConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultHTTPRetryAttempts
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = DefaultHTTPRetryBaseDelay
+	}
+	return c
+}
+
+// backoff returns the wait before retry n (1-based): BaseDelay doubled
+// n-1 times, with jitter drawn from its upper half.
+func (c HTTPRetryConfig) backoff(n int) time.Duration {
+	d := c.BaseDelay << (n - 1)
+	return d/2 + time.Duration(jitter()*float64(d/2))
+}
+
+// jitter returns a value in [0, 1). Tests replace it for exact delays.
+var jitter = rand.Float64
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// doWithRetry sends the request built by newRequest, retrying network
+// errors and retryable statuses with exponential backoff. A fresh request
+// is built for each attempt so its body can be re-sent. After the last
+// attempt, the final response or error is returned as is, for the caller
+// to report. The caller closes the response body.
+func doWithRetry(ctx context.Context, client *http.Client, opts HTTPRetryConfig, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	opts = opts.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		last := attempt >= opts.MaxAttempts
+		switch {
+		case err != nil:
+			// A canceled or expired context is not transient
+			if last || ctx.Err() != nil {
+				return nil, err
+			}
+		case !retryableStatus(resp.StatusCode) || last:
+			return resp, nil
+		default:
+			// Drain so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := clock.Sleep(ctx, opts.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
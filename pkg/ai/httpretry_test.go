@@ -0,0 +1,184 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// noJitter makes backoff delays exact for the duration of the test.
+func noJitter(t *testing.T) {
+	t.Helper()
+	orig := jitter
+	jitter = func() float64 { return 0 }
+	t.Cleanup(func() { jitter = orig })
+}
+
+// statusServer responds with the given statuses in order, then 200.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= len(statuses) {
+			w.WriteHeader(statuses[calls-1])
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func get(ctx context.Context, url string) func() (*http.Request, error) {
+	return func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}
+}
+
+func TestDoWithRetry_RetriesTransientStatus(t *testing.T) {
+	fc := useFakeClock(t)
+	noJitter(t)
+	start := fc.Now()
+
+	srv, calls := statusServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	ctx := context.Background()
+	resp, err := doWithRetry(ctx, srv.Client(), HTTPRetryConfig{MaxAttempts: 3, BaseDelay: time.Second}, get(ctx, srv.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || *calls != 3 {
+		t.Errorf("status %d after %d calls, want 200 after 3", resp.StatusCode, *calls)
+	}
+	// Half of 1s, then half of 2s, with no jitter
+	if waited := fc.Now().Sub(start); waited != 1500*time.Millisecond {
+		t.Errorf("waited %v, want 1.5s", waited)
+	}
+}
+
+func TestDoWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	useFakeClock(t)
+	srv, calls := statusServer(t, 500, 502, 503, 504)
+	ctx := context.Background()
+
+	resp, err := doWithRetry(ctx, srv.Client(), HTTPRetryConfig{MaxAttempts: 3}, get(ctx, srv.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	// The last response is returned for the caller to report
+	if resp.StatusCode != 503 || *calls != 3 {
+		t.Errorf("status %d after %d calls, want 503 after 3", resp.StatusCode, *calls)
+	}
+}
+
+func TestDoWithRetry_NoRetryOnClientError(t *testing.T) {
+	useFakeClock(t)
+	srv, calls := statusServer(t, http.StatusBadRequest)
+	ctx := context.Background()
+
+	resp, err := doWithRetry(ctx, srv.Client(), HTTPRetryConfig{}, get(ctx, srv.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || *calls != 1 {
+		t.Errorf("status %d after %d calls, want 400 after 1", resp.StatusCode, *calls)
+	}
+}
+
+func TestDoWithRetry_NetworkError(t *testing.T) {
+	useFakeClock(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	attempts := 0
+	ctx := context.Background()
+	_, err := doWithRetry(ctx, http.DefaultClient, HTTPRetryConfig{MaxAttempts: 2}, func() (*http.Request, error) {
+		attempts++
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	})
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestDoWithRetry_RequestErrorNotRetried(t *testing.T) {
+	attempts := 0
+	want := errors.New("bad request")
+	_, err := doWithRetry(context.Background(), http.DefaultClient, HTTPRetryConfig{}, func() (*http.Request, error) {
+		attempts++
+		return nil, want
+	})
+	if !errors.Is(err, want) || attempts != 1 {
+		t.Errorf("err=%v attempts=%d, want %v after 1", err, attempts, want)
+	}
+}
+
+func TestDoWithRetry_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	// The real clock: a canceled context must cut the hour-long backoff short
+	_, err := doWithRetry(ctx, srv.Client(), HTTPRetryConfig{MaxAttempts: 3, BaseDelay: time.Hour}, get(ctx, srv.URL))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no retries after cancel, got %d calls", calls)
+	}
+}
+
+func TestHTTPRetryConfig_Backoff(t *testing.T) {
+	c := HTTPRetryConfig{BaseDelay: 100 * time.Millisecond}
+	for n, limit := range []time.Duration{100, 200, 400} {
+		d := c.backoff(n + 1)
+		limit *= time.Millisecond
+		if d < limit/2 || d >= limit {
+			t.Errorf("backoff(%d) = %v, want in [%v, %v)", n+1, d, limit/2, limit)
+		}
+	}
+}
+
+func TestOllamaProvider_RetriesOverload(t *testing.T) {
+	useFakeClock(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "server busy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"T | take 10"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	p, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	got, err := p.Complete(context.Background(), "ten rows")
+	if err != nil || got != "T | take 10" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
@@ -4,11 +4,8 @@
 package ai
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 )
@@ -20,6 +17,8 @@ type InstructLabProvider struct {
 	model       string
 	temperature float32
 	client      *http.Client
+	retry       HTTPRetryConfig
+	usageRecorder
 }
 
 // NewInstructLabProvider creates a new InstructLab provider.
@@ -34,11 +33,17 @@ func NewInstructLabProvider(cfg Config) (*InstructLabProvider, error) {
 		model = DefaultInstructLabModel
 	}
 
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("instructlab: %w", err)
+	}
+
 	return &InstructLabProvider{
 		endpoint:    strings.TrimSuffix(endpoint, "/"),
 		model:       model,
 		temperature: cfg.Temperature,
-		client:      &http.Client{},
+		client:      client,
+		retry:       cfg.HTTPRetry,
 	}, nil
 }
 
@@ -60,72 +65,7 @@ func (p *InstructLabProvider) Complete(ctx context.Context, prompt string) (stri
 // CompleteChat sends a chat conversation and returns the response.
 // Uses OpenAI-compatible API format.
 func (p *InstructLabProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
-	// Convert to OpenAI chat format
-	openaiMessages := make([]openaiChatMessage, len(messages))
-	for i, m := range messages {
-		openaiMessages[i] = openaiChatMessage{
-			Role:    string(m.Role),
-			Content: m.Content,
-		}
-	}
-
-	reqBody := openaiChatRequest{
-		Model:       p.model,
-		Messages:    openaiMessages,
-		Temperature: p.temperature,
-	}
-
-	body, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("marshaling request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v1/chat/completions", bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("sending request to instructlab: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("instructlab returned status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	var result openaiChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("decoding response: %w", err)
-	}
-
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
-	}
-
-	return result.Choices[0].Message.Content, nil
-}
-
-// OpenAI-compatible API types (used by InstructLab)
-
-type openaiChatRequest struct {
-	Model       string              `json:"model"`
-	Messages    []openaiChatMessage `json:"messages"`
-	Temperature float32             `json:"temperature,omitempty"`
-}
-
-type openaiChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type openaiChatResponse struct {
-	Choices []openaiChoice `json:"choices"`
-}
-
-type openaiChoice struct {
-	Message openaiChatMessage `json:"message"`
+	text, usage, err := openaiChatComplete(ctx, p.client, p.retry, "instructlab", p.endpoint+"/v1/chat/completions", "", p.model, p.temperature, messages)
+	p.record(usage.value())
+	return text, timeoutError(ctx, err)
 }
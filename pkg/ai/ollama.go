@@ -19,6 +19,8 @@ type OllamaProvider struct {
 	model       string
 	temperature float32
 	client      *http.Client
+	retry       HTTPRetryConfig
+	usageRecorder
 }
 
 // NewOllamaProvider creates a new Ollama provider.
@@ -33,11 +35,17 @@ func NewOllamaProvider(cfg Config) (*OllamaProvider, error) {
 		model = DefaultOllamaModel
 	}
 
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+
 	return &OllamaProvider{
 		endpoint:    strings.TrimSuffix(endpoint, "/"),
 		model:       model,
 		temperature: cfg.Temperature,
-		client:      &http.Client{},
+		client:      client,
+		retry:       cfg.HTTPRetry,
 	}, nil
 }
 
@@ -58,6 +66,74 @@ func (p *OllamaProvider) Complete(ctx context.Context, prompt string) (string, e
 
 // CompleteChat sends a chat conversation and returns the response.
 func (p *OllamaProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	text, err := p.completeChat(ctx, messages)
+	return text, timeoutError(ctx, err)
+}
+
+func (p *OllamaProvider) completeChat(ctx context.Context, messages []Message) (string, error) {
+	p.record(Usage{}, false)
+
+	resp, err := p.chat(ctx, messages, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+
+	p.record(result.usage())
+	return result.Message.Content, nil
+}
+
+// CompleteStream sends a prompt and writes the response to out as it is
+// generated. If the deadline passes mid-stream, out keeps what was
+// written so far.
+func (p *OllamaProvider) CompleteStream(ctx context.Context, prompt string, out io.Writer) error {
+	return p.CompleteChatStream(ctx, []Message{{Role: RoleUser, Content: prompt}}, out)
+}
+
+// CompleteChatStream is CompleteStream for a chat conversation.
+func (p *OllamaProvider) CompleteChatStream(ctx context.Context, messages []Message, out io.Writer) error {
+	return timeoutError(ctx, p.completeStream(ctx, messages, out))
+}
+
+func (p *OllamaProvider) completeStream(ctx context.Context, messages []Message, out io.Writer) error {
+	p.record(Usage{}, false)
+
+	resp, err := p.chat(ctx, messages, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The stream is newline-delimited JSON, one chunk per object
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaChatResponse
+		if err := dec.Decode(&chunk); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decoding stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		if _, err := io.WriteString(out, chunk.Message.Content); err != nil {
+			return err
+		}
+		if chunk.Done {
+			p.record(chunk.usage())
+			return nil
+		}
+	}
+}
+
+// chat posts messages to the chat endpoint and returns the response once
+// its status is OK. The caller closes the body.
+func (p *OllamaProvider) chat(ctx context.Context, messages []Message, stream bool) (*http.Response, error) {
 	// Convert to Ollama chat format
 	ollamaMessages := make([]ollamaChatMessage, len(messages))
 	for i, m := range messages {
@@ -70,7 +146,7 @@ func (p *OllamaProvider) CompleteChat(ctx context.Context, messages []Message) (
 	reqBody := ollamaChatRequest{
 		Model:    p.model,
 		Messages: ollamaMessages,
-		Stream:   false,
+		Stream:   stream,
 		Options: ollamaOptions{
 			Temperature: p.temperature,
 		},
@@ -78,32 +154,28 @@ func (p *OllamaProvider) CompleteChat(ctx context.Context, messages []Message) (
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("marshaling request: %w", err)
+		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/api/chat", bytes.NewReader(body))
+	resp, err := doWithRetry(ctx, p.client, p.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/api/chat", bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
+		return nil, fmt.Errorf("sending request to ollama: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("sending request to ollama: %w", err)
-	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
-	}
-
-	var result ollamaChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("decoding response: %w", err)
+		return nil, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(respBody))
 	}
 
-	return result.Message.Content, nil
+	return resp, nil
 }
 
 // Ollama API types
@@ -126,4 +198,20 @@ type ollamaOptions struct {
 
 type ollamaChatResponse struct {
 	Message ollamaChatMessage `json:"message"`
+
+	// Done and Error are set on streamed chunks
+	Done  bool   `json:"done"`
+	Error string `json:"error,omitempty"`
+
+	// Token counts, set once the response is done
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
+}
+
+// usage returns the token counts of a finished response.
+func (r ollamaChatResponse) usage() (Usage, bool) {
+	if !r.Done {
+		return Usage{}, false
+	}
+	return Usage{PromptTokens: r.PromptEvalCount, CompletionTokens: r.EvalCount}, true
 }
@@ -0,0 +1,70 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaProvider_CompleteStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("expected stream: true")
+		}
+		for _, piece := range []string{"This query ", "takes ", "10 rows."} {
+			fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", piece)
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true}`)
+	}))
+	defer srv.Close()
+
+	p, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	var _ StreamingProvider = p
+
+	var out strings.Builder
+	if err := p.CompleteStream(context.Background(), "explain", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "This query takes 10 rows." {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestOllamaProvider_CompleteStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"partial"},"done":false}`)
+		fmt.Fprintln(w, `{"error":"model unloaded"}`)
+	}))
+	defer srv.Close()
+
+	p, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	var out strings.Builder
+	err := p.CompleteStream(context.Background(), "explain", &out)
+	if err == nil || !strings.Contains(err.Error(), "model unloaded") {
+		t.Errorf("expected the stream error, got %v", err)
+	}
+}
+
+func TestOllamaProvider_CompleteChat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"T | take 10"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	p, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	got, err := p.Complete(context.Background(), "ten rows")
+	if err != nil || got != "T | take 10" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
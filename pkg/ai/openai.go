@@ -0,0 +1,180 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OpenAIProvider implements the Provider interface for the OpenAI API, or
+// any proxy that serves the same chat completions endpoint.
+type OpenAIProvider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature float32
+	client      *http.Client
+	retry       HTTPRetryConfig
+	usageRecorder
+}
+
+// NewOpenAIProvider creates a new OpenAI provider.
+func NewOpenAIProvider(cfg Config) (*OpenAIProvider, error) {
+	apiKey := cfg.OpenAI.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	apiKey = resolveAPIKey(apiKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("openai: API key required (set --openai-api-key or OPENAI_API_KEY)")
+	}
+
+	baseURL := cfg.OpenAI.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("openai: %w", err)
+	}
+
+	return &OpenAIProvider{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		apiKey:      apiKey,
+		model:       model,
+		temperature: cfg.Temperature,
+		client:      client,
+		retry:       cfg.HTTPRetry,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Model returns the model name.
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+// Complete sends a prompt and returns the response.
+func (p *OpenAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
+
+// CompleteChat sends a chat conversation and returns the response.
+func (p *OpenAIProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	text, usage, err := openaiChatComplete(ctx, p.client, p.retry, "openai", p.baseURL+"/chat/completions", p.apiKey, p.model, p.temperature, messages)
+	p.record(usage.value())
+	return text, timeoutError(ctx, err)
+}
+
+// openaiChatComplete posts messages to an OpenAI-compatible chat
+// completions URL and returns the first choice, with the usage if the
+// response includes it. A non-empty apiKey is sent as a bearer token; name
+// labels errors.
+func openaiChatComplete(ctx context.Context, client *http.Client, retry HTTPRetryConfig, name, url, apiKey, model string, temperature float32, messages []Message) (string, *openaiUsage, error) {
+	// Convert to OpenAI chat format
+	openaiMessages := make([]openaiChatMessage, len(messages))
+	for i, m := range messages {
+		openaiMessages[i] = openaiChatMessage{
+			Role:    string(m.Role),
+			Content: m.Content,
+		}
+	}
+
+	reqBody := openaiChatRequest{
+		Model:       model,
+		Messages:    openaiMessages,
+		Temperature: temperature,
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	resp, err := doWithRetry(ctx, client, retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("sending request to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", nil, fmt.Errorf("%s returned status %d: %s", name, resp.StatusCode, string(respBody))
+	}
+
+	var result openaiChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	if len(result.Choices) == 0 {
+		return "", nil, fmt.Errorf("no choices in response")
+	}
+
+	return result.Choices[0].Message.Content, result.Usage, nil
+}
+
+// OpenAI chat completions API types (also used by InstructLab)
+
+type openaiChatRequest struct {
+	Model       string              `json:"model"`
+	Messages    []openaiChatMessage `json:"messages"`
+	Temperature float32             `json:"temperature,omitempty"`
+}
+
+type openaiChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openaiChatResponse struct {
+	Choices []openaiChoice `json:"choices"`
+	Usage   *openaiUsage   `json:"usage,omitempty"`
+}
+
+// openaiUsage is the token usage reported by OpenAI-compatible APIs,
+// including Azure OpenAI.
+type openaiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// value converts u for a usageRecorder; a nil u was not reported.
+func (u *openaiUsage) value() (Usage, bool) {
+	if u == nil {
+		return Usage{}, false
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}, true
+}
+
+type openaiChoice struct {
+	Message openaiChatMessage `json:"message"`
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIProvider_CompleteChat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		var req openaiChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if req.Model != "gpt-4o-mini" || len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Errorf("unexpected request %+v", req)
+		}
+		json.NewEncoder(w).Encode(openaiChatResponse{
+			Choices: []openaiChoice{{Message: openaiChatMessage{Role: "assistant", Content: "T | take 10"}}},
+		})
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(Config{
+		Model:  "gpt-4o-mini",
+		OpenAI: OpenAIConfig{APIKey: "sk-test", BaseURL: srv.URL + "/v1/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.CompleteChat(context.Background(), []Message{
+		{Role: RoleSystem, Content: "You write KQL."},
+		{Role: RoleUser, Content: "ten rows"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "T | take 10" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestOpenAIProvider_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	p, err := NewOpenAIProvider(Config{OpenAI: OpenAIConfig{APIKey: "bad", BaseURL: srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Complete(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "openai returned status 401") {
+		t.Errorf("expected a 401 error, got %v", err)
+	}
+}
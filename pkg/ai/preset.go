@@ -0,0 +1,75 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Preset is a named adjustment of the validation settings for generated
+// queries.
+type Preset struct {
+	// Name selects the preset, e.g. "thorough"
+	Name string
+
+	// Description says what the preset is for
+	Description string
+
+	apply func(*ValidationConfig)
+}
+
+// presets are the built-in presets, in the order they are listed.
+var presets = []Preset{
+	{
+		Name:        "minimal",
+		Description: "No retries, and no hints or examples in feedback; fastest",
+		apply: func(c *ValidationConfig) {
+			c.Retries = 0
+			c.Feedback.Hints = false
+			c.Feedback.Examples = false
+		},
+	},
+	{
+		Name:        "balanced",
+		Description: "The defaults",
+		apply:       func(*ValidationConfig) {},
+	},
+	{
+		Name:        "thorough",
+		Description: "More retries, with progressively detailed feedback",
+		apply: func(c *ValidationConfig) {
+			c.Retries = 5
+			c.Feedback.Progressive = true
+		},
+	},
+	{
+		Name:        "strict",
+		Description: "Extra retries, and fail if the query is still invalid",
+		apply: func(c *ValidationConfig) {
+			c.Strict = true
+			c.Retries = 3
+		},
+	},
+}
+
+// Presets returns the built-in presets in order.
+func Presets() []Preset {
+	return append([]Preset(nil), presets...)
+}
+
+// ApplyPreset returns cfg adjusted by the named preset.
+func ApplyPreset(name string, cfg ValidationConfig) (ValidationConfig, error) {
+	for _, p := range presets {
+		if p.Name == name {
+			p.apply(&cfg)
+			return cfg, nil
+		}
+	}
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return cfg, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyPreset(t *testing.T) {
+	base := DefaultValidationConfig()
+
+	minimal, err := ApplyPreset("minimal", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minimal.Retries != 0 || minimal.Feedback.Hints || minimal.Feedback.Examples || !minimal.Feedback.Errors {
+		t.Errorf("unexpected minimal settings: %+v", minimal)
+	}
+
+	balanced, err := ApplyPreset("balanced", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balanced.Retries != base.Retries || balanced.Strict != base.Strict ||
+		balanced.Feedback.Hints != base.Feedback.Hints || balanced.Feedback.Examples != base.Feedback.Examples {
+		t.Errorf("expected balanced to keep the defaults, got %+v", balanced)
+	}
+
+	thorough, _ := ApplyPreset("thorough", base)
+	if thorough.Retries != 5 || !thorough.Feedback.Progressive {
+		t.Errorf("unexpected thorough settings: %+v", thorough)
+	}
+
+	strict, _ := ApplyPreset("strict", base)
+	if strict.Retries != 3 || !strict.Strict {
+		t.Errorf("unexpected strict settings: %+v", strict)
+	}
+
+	if base.Retries != DefaultValidationRetries {
+		t.Error("expected the base config to be left unchanged")
+	}
+}
+
+func TestApplyPreset_Unknown(t *testing.T) {
+	_, err := ApplyPreset("fast", DefaultValidationConfig())
+	if err == nil || !strings.Contains(err.Error(), `unknown preset "fast"`) ||
+		!strings.Contains(err.Error(), "minimal, balanced, thorough, strict") {
+		t.Errorf("expected an error listing the presets, got %v", err)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	var names []string
+	for _, p := range Presets() {
+		if p.Description == "" {
+			t.Errorf("preset %s has no description", p.Name)
+		}
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "minimal,balanced,thorough,strict" {
+		t.Errorf("unexpected presets: %s", got)
+	}
+
+	Presets()[0].Name = "changed"
+	if Presets()[0].Name != "minimal" {
+		t.Error("expected Presets to return a copy")
+	}
+}
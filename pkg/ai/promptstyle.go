@@ -0,0 +1,76 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"strings"
+)
+
+// PromptStyle adapts prompt structure to the conventions a model family
+// responds to best. The zero value is the neutral default.
+type PromptStyle int
+
+const (
+	// PromptStyleDefault uses Markdown code fences and plain labels.
+	PromptStyleDefault PromptStyle = iota
+
+	// PromptStyleTagged wraps inputs in XML-style tags, as Claude models
+	// are trained to expect.
+	PromptStyleTagged
+
+	// PromptStyleTerse uses shorter instructions for small models that
+	// lose track of long prompts.
+	PromptStyleTerse
+)
+
+// String returns the style name.
+func (s PromptStyle) String() string {
+	switch s {
+	case PromptStyleTagged:
+		return "tagged"
+	case PromptStyleTerse:
+		return "terse"
+	default:
+		return "default"
+	}
+}
+
+// smallModelMarkers identify models with roughly 3B parameters or fewer.
+var smallModelMarkers = []string{":1b", ":3b", "-1b", "-3b", "tinyllama", "qwen2.5:0.5b"}
+
+// PromptStyleForModel returns the prompt style for a model name.
+func PromptStyleForModel(model string) PromptStyle {
+	m := strings.ToLower(model)
+	if strings.Contains(m, "claude") {
+		return PromptStyleTagged
+	}
+	for _, marker := range smallModelMarkers {
+		if strings.Contains(m, marker) {
+			return PromptStyleTerse
+		}
+	}
+	return PromptStyleDefault
+}
+
+// PromptStyleFor returns the prompt style for a provider's model.
+func PromptStyleFor(p Provider) PromptStyle {
+	return PromptStyleForModel(p.Model())
+}
+
+// Query formats a KQL query for inclusion in a prompt.
+func (s PromptStyle) Query(query string) string {
+	if s == PromptStyleTagged {
+		return "<query>\n" + query + "\n</query>"
+	}
+	return "```kql\n" + query + "\n```"
+}
+
+// Field formats a labeled input, such as the user's description. tag names
+// the field in the tagged style; label is used otherwise.
+func (s PromptStyle) Field(tag, label, value string) string {
+	if s == PromptStyleTagged {
+		return "<" + tag + ">\n" + value + "\n</" + tag + ">"
+	}
+	return label + ": " + value
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"testing"
+)
+
+func TestPromptStyleForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  PromptStyle
+	}{
+		{DefaultVertexModel, PromptStyleTagged},
+		{"Claude-Sonnet-4", PromptStyleTagged},
+		{DefaultAzureModel, PromptStyleDefault},
+		{DefaultOllamaModel, PromptStyleDefault},
+		{"gemini-1.5-pro", PromptStyleDefault},
+		{"llama3.2:1b", PromptStyleTerse},
+		{"qwen2.5-coder:3b", PromptStyleTerse},
+		{"", PromptStyleDefault},
+	}
+	for _, tt := range tests {
+		if got := PromptStyleForModel(tt.model); got != tt.want {
+			t.Errorf("PromptStyleForModel(%q) = %s, want %s", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestPromptStyle_Query(t *testing.T) {
+	if got := PromptStyleTagged.Query("T | take 1"); got != "<query>\nT | take 1\n</query>" {
+		t.Errorf("unexpected tagged query: %q", got)
+	}
+	if got := PromptStyleDefault.Query("T | take 1"); got != "```kql\nT | take 1\n```" {
+		t.Errorf("unexpected default query: %q", got)
+	}
+}
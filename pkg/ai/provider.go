@@ -2,12 +2,19 @@
 // SPDX-License-Identifier: Apache-2.0
 
 // Package ai provides a multi-provider abstraction for LLM integration.
-// Supported providers include Vertex AI, Azure OpenAI, Ollama, and InstructLab.
+// Supported providers include Vertex AI, Azure OpenAI, OpenAI, Anthropic,
+// Ollama, and InstructLab.
 package ai
 
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudygreybeard/kql/pkg/kqlhints"
 )
 
 // Default configuration values.
@@ -37,17 +44,38 @@ const (
 	// Azure defaults
 	DefaultAzureModel = "gpt-4o"
 
+	// OpenAI defaults
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "gpt-4o"
+
+	// Anthropic defaults
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+	DefaultAnthropicModel   = "claude-opus-4-5"
+
 	// Validation defaults
 	DefaultValidationEnabled       = true
 	DefaultValidationStrict        = false
+	DefaultValidationSemantic      = false
 	DefaultValidationRetries       = 2
 	DefaultFeedbackErrors          = true
 	DefaultFeedbackHints           = true
 	DefaultFeedbackExamples        = true
 	DefaultFeedbackProgressive     = true
+	DefaultFeedbackMaxErrors       = 10
+	DefaultFeedbackMaxHints        = 5
+	DefaultFeedbackMaxExamples     = 6
+	DefaultFeedbackMaxPromptBytes  = 16000
 	DefaultRetryTempAdjust         = true
 	DefaultRetryTempIncrement      = 0.1
 	DefaultRetryTempMax    float32 = 0.8
+
+	// Transport retry defaults
+	DefaultHTTPRetryAttempts  = 3
+	DefaultHTTPRetryBaseDelay = 500 * time.Millisecond
+
+	// Response cache defaults
+	DefaultCacheEnabled = false
+	DefaultCacheTTL     = 24 * time.Hour
 )
 
 // Provider defines the interface for AI/LLM providers.
@@ -65,6 +93,27 @@ type Provider interface {
 	Model() string
 }
 
+// StreamingProvider is a Provider that can write a response as it is
+// generated. Callers check for it with a type assertion and fall back to
+// Complete otherwise.
+type StreamingProvider interface {
+	Provider
+
+	// CompleteStream sends a prompt and writes the response to out in
+	// pieces as they arrive.
+	CompleteStream(ctx context.Context, prompt string, out io.Writer) error
+}
+
+// ChatStreamingProvider is a StreamingProvider that can also stream the
+// response to a conversation, as a SystemPromptProvider needs to.
+type ChatStreamingProvider interface {
+	StreamingProvider
+
+	// CompleteChatStream sends messages and writes the response to out in
+	// pieces as they arrive.
+	CompleteChatStream(ctx context.Context, messages []Message, out io.Writer) error
+}
+
 // Message represents a chat message.
 type Message struct {
 	Role    Role
@@ -91,6 +140,10 @@ type Config struct {
 	// Temperature controls randomness (0.0-1.0)
 	Temperature float32
 
+	// SystemPrompt, if set, is sent as a system message ahead of every
+	// request
+	SystemPrompt string
+
 	// Ollama configuration
 	Ollama OllamaConfig
 
@@ -103,8 +156,23 @@ type Config struct {
 	// InstructLab configuration
 	InstructLab InstructLabConfig
 
+	// OpenAI configuration
+	OpenAI OpenAIConfig
+
+	// Anthropic configuration
+	Anthropic AnthropicConfig
+
 	// Validation configuration for generated output
 	Validation ValidationConfig
+
+	// HTTPRetry configures retries of transient HTTP failures
+	HTTPRetry HTTPRetryConfig
+
+	// Transport configures the proxy and CA certificates for HTTP requests
+	Transport TransportConfig
+
+	// Cache configures the on-disk response cache
+	Cache CacheConfig
 }
 
 // OllamaConfig holds Ollama-specific configuration.
@@ -140,6 +208,33 @@ type InstructLabConfig struct {
 	Endpoint string
 }
 
+// OpenAIConfig holds OpenAI-specific configuration.
+type OpenAIConfig struct {
+	// API Key (default: OPENAI_API_KEY)
+	APIKey string
+
+	// Base URL of the API or a compatible proxy (default: https://api.openai.com/v1)
+	BaseURL string
+}
+
+// AnthropicConfig holds Anthropic-specific configuration.
+type AnthropicConfig struct {
+	// API Key (default: ANTHROPIC_API_KEY)
+	APIKey string
+
+	// Base URL of the API (default: https://api.anthropic.com)
+	BaseURL string
+}
+
+// CacheConfig holds response cache settings.
+type CacheConfig struct {
+	// Enabled wraps providers in a CachingProvider (default: false)
+	Enabled bool
+
+	// TTL is how long cached responses are reused (default: 24h)
+	TTL time.Duration
+}
+
 // ValidationConfig holds validation and retry settings for AI-generated output.
 type ValidationConfig struct {
 	// Enabled enables validation of generated KQL (default: true)
@@ -151,11 +246,29 @@ type ValidationConfig struct {
 	// Retries is the number of retry attempts on validation failure (default: 2)
 	Retries int
 
+	// RetryBudget, when positive, replaces Retries: attempts continue until
+	// a query is valid or this much time has passed since the first one
+	RetryBudget time.Duration
+
 	// Feedback controls what information is included in retry prompts
 	Feedback FeedbackConfig
 
 	// Temp controls temperature adjustment on retries
 	Temp TempAdjustConfig
+
+	// Semantic also analyzes syntactically valid queries against Table and
+	// Schema, retrying when they reference columns the schema doesn't
+	// define (default: false)
+	Semantic bool
+
+	// Table and Schema are the target table and its comma-separated
+	// columns ("Name" or "Name:type"); semantic validation needs both
+	Table  string
+	Schema string
+
+	// RecordHistory keeps every attempt in GenerateResult.History
+	// (default: false, to avoid holding each prompt and response)
+	RecordHistory bool
 }
 
 // FeedbackConfig controls what feedback is included in retry prompts.
@@ -171,8 +284,28 @@ type FeedbackConfig struct {
 
 	// Progressive increases detail with each retry (default: true)
 	Progressive bool
+
+	// Rules are custom hint/example rules, applied after the built-in ones
+	Rules []FeedbackRule
+
+	// MaxErrors caps the errors listed, earliest position first (0 = no limit)
+	MaxErrors int
+
+	// MaxHints caps the hints listed (0 = no limit)
+	MaxHints int
+
+	// MaxExamples caps the syntax examples listed (0 = no limit)
+	MaxExamples int
+
+	// MaxPromptBytes caps the retry prompt size (0 = no limit). Examples,
+	// hints, errors and finally the failed query are trimmed to fit.
+	MaxPromptBytes int
 }
 
+// FeedbackRule maps a validation error message to a retry hint and/or example.
+// See kqlhints.Rule for the matching rules.
+type FeedbackRule = kqlhints.Rule
+
 // TempAdjustConfig controls temperature adjustment on retries.
 type TempAdjustConfig struct {
 	// Adjust enables temperature adjustment (default: true)
@@ -188,14 +321,20 @@ type TempAdjustConfig struct {
 // DefaultValidationConfig returns validation config with sensible defaults.
 func DefaultValidationConfig() ValidationConfig {
 	return ValidationConfig{
-		Enabled: DefaultValidationEnabled,
-		Strict:  DefaultValidationStrict,
-		Retries: DefaultValidationRetries,
+		Enabled:  DefaultValidationEnabled,
+		Strict:   DefaultValidationStrict,
+		Semantic: DefaultValidationSemantic,
+		Retries:  DefaultValidationRetries,
 		Feedback: FeedbackConfig{
 			Errors:      DefaultFeedbackErrors,
 			Hints:       DefaultFeedbackHints,
 			Examples:    DefaultFeedbackExamples,
 			Progressive: DefaultFeedbackProgressive,
+
+			MaxErrors:      DefaultFeedbackMaxErrors,
+			MaxHints:       DefaultFeedbackMaxHints,
+			MaxExamples:    DefaultFeedbackMaxExamples,
+			MaxPromptBytes: DefaultFeedbackMaxPromptBytes,
 		},
 		Temp: TempAdjustConfig{
 			Adjust:    DefaultRetryTempAdjust,
@@ -205,8 +344,72 @@ func DefaultValidationConfig() ValidationConfig {
 	}
 }
 
-// NewProvider creates a provider based on the configuration.
+// Providers lists the supported provider names.
+var Providers = []string{"ollama", "instructlab", "vertex", "azure", "openai", "anthropic"}
+
+// DefaultModel returns the model a provider uses when none is configured,
+// or "" for an unknown provider.
+func DefaultModel(provider string) string {
+	switch provider {
+	case "ollama":
+		return DefaultOllamaModel
+	case "instructlab":
+		return DefaultInstructLabModel
+	case "vertex":
+		return DefaultVertexModel
+	case "azure":
+		return DefaultAzureModel
+	case "openai":
+		return DefaultOpenAIModel
+	case "anthropic":
+		return DefaultAnthropicModel
+	}
+	return ""
+}
+
+// MaxTemperature is the highest temperature Validate accepts.
+const MaxTemperature = 1.0
+
+// Validate checks the provider name and temperature, so a typo fails
+// before any provider is set up.
+func (c Config) Validate() error {
+	if !slices.Contains(Providers, c.Provider) {
+		return fmt.Errorf("unknown provider: %q (supported: %s)", c.Provider, strings.Join(Providers, ", "))
+	}
+	if c.Temperature < 0 || c.Temperature > MaxTemperature {
+		return fmt.Errorf("temperature %g is out of range (use a value from 0.0 to %.1f)", c.Temperature, MaxTemperature)
+	}
+	return nil
+}
+
+// NewProvider creates a provider based on the configuration. With
+// cfg.SystemPrompt set, the provider is wrapped in a SystemPromptProvider,
+// and with cfg.Cache enabled, in a CachingProvider.
 func NewProvider(cfg Config) (Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	provider, err := newProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SystemPrompt != "" {
+		provider = NewSystemPromptProvider(provider, cfg.SystemPrompt)
+	}
+	if !cfg.Cache.Enabled {
+		return provider, nil
+	}
+
+	// The cache is best-effort; without a cache directory, go uncached
+	cache, err := NewDefaultCache()
+	if err != nil {
+		return provider, nil
+	}
+	cache.TTL = cfg.Cache.TTL
+	return NewCachingProvider(provider, cache, cfg.Temperature), nil
+}
+
+func newProvider(cfg Config) (Provider, error) {
 	switch cfg.Provider {
 	case "ollama":
 		return NewOllamaProvider(cfg)
@@ -216,16 +419,21 @@ func NewProvider(cfg Config) (Provider, error) {
 		return NewVertexProvider(cfg)
 	case "azure":
 		return NewAzureProvider(cfg)
+	case "openai":
+		return NewOpenAIProvider(cfg)
+	case "anthropic":
+		return NewAnthropicProvider(cfg)
 	default:
-		return nil, fmt.Errorf("unknown provider: %q (supported: ollama, instructlab, vertex, azure)", cfg.Provider)
+		return nil, fmt.Errorf("unknown provider: %q (supported: %s)", cfg.Provider, strings.Join(Providers, ", "))
 	}
 }
 
-// DefaultConfig returns a configuration with sensible defaults.
+// DefaultConfig returns a configuration with sensible defaults. Model is
+// left empty, so whichever provider is selected uses its own default (see
+// DefaultModel) rather than Ollama's.
 func DefaultConfig() Config {
 	return Config{
 		Provider:    DefaultProvider,
-		Model:       DefaultOllamaModel,
 		Temperature: DefaultTemperature,
 		Ollama: OllamaConfig{
 			Endpoint: DefaultOllamaEndpoint,
@@ -237,5 +445,10 @@ func DefaultConfig() Config {
 			Endpoint: DefaultInstructLabEndpoint,
 		},
 		Validation: DefaultValidationConfig(),
+		HTTPRetry:  DefaultHTTPRetryConfig(),
+		Cache: CacheConfig{
+			Enabled: DefaultCacheEnabled,
+			TTL:     DefaultCacheTTL,
+		},
 	}
 }
@@ -4,7 +4,10 @@
 package ai
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/cloudygreybeard/kql/pkg/keyring"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -13,8 +16,8 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Provider != DefaultProvider {
 		t.Errorf("expected provider %q, got %q", DefaultProvider, cfg.Provider)
 	}
-	if cfg.Model != DefaultOllamaModel {
-		t.Errorf("expected model %q, got %q", DefaultOllamaModel, cfg.Model)
+	if cfg.Model != "" {
+		t.Errorf("expected no model, so the provider's default applies, got %q", cfg.Model)
 	}
 	if cfg.Temperature != DefaultTemperature {
 		t.Errorf("expected temperature %f, got %f", DefaultTemperature, cfg.Temperature)
@@ -32,6 +35,42 @@ func TestNewProvider_UnknownProvider(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_Temperature(t *testing.T) {
+	tests := []struct {
+		temperature float32
+		valid       bool
+	}{
+		{0, true},
+		{0.2, true},
+		{1, true},
+		{1.0001, false},
+		{-0.1, false},
+		{5, false},
+	}
+	for _, tt := range tests {
+		err := Config{Provider: "ollama", Temperature: tt.temperature}.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("temperature %g: unexpected error: %v", tt.temperature, err)
+		}
+		if !tt.valid && (err == nil || !strings.Contains(err.Error(), "0.0 to 1.0")) {
+			t.Errorf("temperature %g: expected a range error, got %v", tt.temperature, err)
+		}
+	}
+}
+
+func TestConfigValidate_Provider(t *testing.T) {
+	for _, name := range Providers {
+		if err := (Config{Provider: name}).Validate(); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	err := Config{Provider: "ollamma"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"ollamma"`) || !strings.Contains(err.Error(), strings.Join(Providers, ", ")) {
+		t.Errorf("expected the supported providers to be listed, got %v", err)
+	}
+}
+
 func TestNewOllamaProvider(t *testing.T) {
 	cfg := Config{
 		Provider:    "ollama",
@@ -78,6 +117,106 @@ func TestNewInstructLabProvider(t *testing.T) {
 	}
 }
 
+func TestNewOpenAIProvider(t *testing.T) {
+	cfg := Config{
+		Provider:    "openai",
+		Model:       "gpt-4o-mini",
+		Temperature: 0.3,
+		OpenAI: OpenAIConfig{
+			APIKey: "sk-test",
+		},
+	}
+
+	p, err := NewOpenAIProvider(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Name() != "openai" {
+		t.Errorf("expected name 'openai', got %q", p.Name())
+	}
+	if p.Model() != "gpt-4o-mini" {
+		t.Errorf("expected model 'gpt-4o-mini', got %q", p.Model())
+	}
+	if p.baseURL != DefaultOpenAIBaseURL {
+		t.Errorf("expected base URL %q, got %q", DefaultOpenAIBaseURL, p.baseURL)
+	}
+}
+
+func TestNewOpenAIProvider_APIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, err := NewOpenAIProvider(Config{Provider: "openai"}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-env")
+	p, err := NewOpenAIProvider(Config{Provider: "openai"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.apiKey != "sk-env" {
+		t.Errorf("expected key from OPENAI_API_KEY, got %q", p.apiKey)
+	}
+	if p.Model() != DefaultOpenAIModel {
+		t.Errorf("expected default model %q, got %q", DefaultOpenAIModel, p.Model())
+	}
+}
+
+func TestNewProviders_KeyringAPIKey(t *testing.T) {
+	orig := resolveKeyring
+	defer func() { resolveKeyring = orig }()
+	resolveKeyring = func(value string) (string, error) {
+		switch value {
+		case "keyring:openai":
+			return "sk-from-keyring", nil
+		case "keyring:missing":
+			return "", keyring.ErrNotFound
+		}
+		return value, nil
+	}
+
+	p, err := NewOpenAIProvider(Config{OpenAI: OpenAIConfig{APIKey: "keyring:openai"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.apiKey != "sk-from-keyring" {
+		t.Errorf("expected the keyring secret, got %q", p.apiKey)
+	}
+
+	// An unresolvable reference is a missing key
+	_, err = NewAnthropicProvider(Config{Anthropic: AnthropicConfig{APIKey: "keyring:missing"}})
+	if err == nil || !strings.Contains(err.Error(), "API key required") {
+		t.Errorf("expected the missing key error, got %v", err)
+	}
+	_, err = NewAzureProvider(Config{Azure: AzureConfig{Endpoint: "https://x", Deployment: "d", APIKey: "keyring:missing"}})
+	if err == nil || !strings.Contains(err.Error(), "API key required") {
+		t.Errorf("expected the missing key error, got %v", err)
+	}
+}
+
+func TestNewAnthropicProvider(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := NewAnthropicProvider(Config{Provider: "anthropic"}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+
+	cfg := Config{
+		Provider:  "anthropic",
+		Anthropic: AnthropicConfig{APIKey: "sk-ant-test"},
+	}
+	p, err := NewAnthropicProvider(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Name() != "anthropic" {
+		t.Errorf("expected name 'anthropic', got %q", p.Name())
+	}
+	if p.Model() != DefaultAnthropicModel {
+		t.Errorf("expected model %q, got %q", DefaultAnthropicModel, p.Model())
+	}
+}
+
 func TestMergeFileConfig(t *testing.T) {
 	fileCfg := &FileConfig{
 		AI: AIFileConfig{
@@ -133,3 +272,20 @@ func TestMergeFileConfig_NilFileConfig(t *testing.T) {
 		t.Errorf("expected provider 'ollama', got %q", merged.Provider)
 	}
 }
+
+func TestDefaultConfig_ProviderDefaultModel(t *testing.T) {
+	// Selecting a provider on the defaults must not carry Ollama's model
+	for _, provider := range []string{"vertex", "azure"} {
+		fileCfg := &FileConfig{AI: AIFileConfig{Provider: provider}}
+		cfg := MergeFileConfig(DefaultConfig(), fileCfg)
+		if cfg.Model == DefaultOllamaModel {
+			t.Errorf("%s: got Ollama's default model %q", provider, cfg.Model)
+		}
+	}
+	if got := DefaultModel("vertex"); got != DefaultVertexModel {
+		t.Errorf("DefaultModel(vertex) = %q, want %q", got, DefaultVertexModel)
+	}
+	if got := DefaultModel("azure"); got != DefaultAzureModel {
+		t.Errorf("DefaultModel(azure) = %q, want %q", got, DefaultAzureModel)
+	}
+}
@@ -5,13 +5,20 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/cloudygreybeard/kql/pkg/kqlhints"
 	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/lexer"
+	"github.com/cloudygreybeard/kqlparser/parser"
 )
 
 // GenerateResult holds the result of a generation with validation.
@@ -25,8 +32,63 @@ type GenerateResult struct {
 	// Errors contains validation errors (if any)
 	Errors []ValidationError
 
+	// Kind is what the errors are: syntax errors, or with semantic
+	// validation, names the schema doesn't define
+	Kind ErrorKind
+
 	// Attempts is the number of generation attempts made
 	Attempts int
+
+	// Usage is the token usage of all attempts, or nil if the provider
+	// doesn't report it
+	Usage *Usage
+
+	// History records each attempt in order, when
+	// ValidationConfig.RecordHistory is set
+	History []AttemptRecord
+}
+
+// ErrorKind is the kind of validation errors a generated query has.
+type ErrorKind string
+
+const (
+	ErrorKindSyntax   ErrorKind = "syntax"
+	ErrorKindSemantic ErrorKind = "semantic"
+)
+
+// problem describes errors of kind k, for prompts and messages. The zero
+// value is taken as syntax, the only kind without semantic validation.
+func (k ErrorKind) problem() string {
+	if k == ErrorKindSemantic {
+		return "references to columns or tables not in the schema"
+	}
+	return "syntax errors"
+}
+
+// AttemptRecord is one generation attempt: what was sent, what came back,
+// and what validation found.
+type AttemptRecord struct {
+	// Attempt is the attempt number, starting at 1
+	Attempt int
+
+	// Prompt is the prompt sent to the model
+	Prompt string
+
+	// Response is the model's raw response
+	Response string
+
+	// Query is the KQL extracted from the response
+	Query string
+
+	// Errors are the validation errors of Query; empty if it was valid or
+	// validation is disabled
+	Errors []ValidationError
+}
+
+// writeDebug prints the raw response and extracted query of the attempt.
+func (r AttemptRecord) writeDebug(w io.Writer) {
+	fmt.Fprintf(w, "--- Raw LLM Response (attempt %d) ---\n%s\n--- End Raw Response ---\n", r.Attempt, r.Response)
+	fmt.Fprintf(w, "--- Extracted KQL ---\n%s\n--- End Extracted ---\n\n", r.Query)
 }
 
 // ValidationError represents a single validation error.
@@ -49,6 +111,11 @@ type GenerateRequest struct {
 }
 
 // GenerateWithValidation generates KQL with validation and retry logic.
+// Retries are bounded by cfg.Retries, or by cfg.RetryBudget when set; the
+// budget is checked between attempts, so the last attempt may finish after
+// it runs out. Token usage is totaled across attempts and, with verbose
+// set, printed for each attempt and overall. With cfg.RecordHistory set,
+// the result also holds every attempt's prompt, response, and errors.
 func GenerateWithValidation(
 	ctx context.Context,
 	provider Provider,
@@ -60,6 +127,23 @@ func GenerateWithValidation(
 	verbose io.Writer,
 	debug io.Writer,
 ) (*GenerateResult, error) {
+	var usage usageTally
+	var history []AttemptRecord
+	record := func(r AttemptRecord) {
+		if debug != nil {
+			r.writeDebug(debug)
+		}
+		if cfg.RecordHistory {
+			history = append(history, r)
+		}
+	}
+	finish := func(result *GenerateResult) *GenerateResult {
+		result.Usage = usage.result()
+		result.History = history
+		usage.report(verbose)
+		return result
+	}
+
 	if !cfg.Enabled {
 		// Validation disabled: single attempt, no validation
 		prompt := buildPrompt(req)
@@ -67,24 +151,43 @@ func GenerateWithValidation(
 		if err != nil {
 			return nil, fmt.Errorf("generating query: %w", err)
 		}
-		return &GenerateResult{
-			Query:    extractKQL(response),
+		usage.add(provider, nil)
+		kql := extractKQL(response)
+		record(AttemptRecord{Attempt: 1, Prompt: prompt, Response: response, Query: kql})
+		return finish(&GenerateResult{
+			Query:    kql,
 			Valid:    true, // Assume valid when not checking
 			Attempts: 1,
-		}, nil
+		}), nil
 	}
 
 	var lastKQL string
 	var lastErrors []ValidationError
+	var lastKind ErrorKind
 	maxAttempts := cfg.Retries + 1
 
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
+	var deadline time.Time
+	var best *GenerateResult
+	if cfg.RetryBudget > 0 {
+		deadline = clock.Now().Add(cfg.RetryBudget)
+	}
+
+	attempt := 1
+	for ; ; attempt++ {
+		if deadline.IsZero() {
+			if attempt > maxAttempts {
+				break
+			}
+		} else if attempt > 1 && !clock.Now().Before(deadline) {
+			break
+		}
+
 		// Build prompt (with retry feedback if applicable)
 		var prompt string
 		if attempt == 1 {
 			prompt = buildPrompt(req)
 		} else {
-			prompt = buildRetryPrompt(req, lastKQL, lastErrors, attempt, cfg.Feedback, buildPrompt)
+			prompt = buildRetryPrompt(req, lastKQL, lastErrors, lastKind, attempt, cfg.Feedback, buildPrompt)
 		}
 
 		// Adjust temperature on retries
@@ -98,10 +201,14 @@ func GenerateWithValidation(
 
 		// Log attempt if verbose
 		if verbose != nil {
+			label := fmt.Sprintf("%d/%d", attempt, maxAttempts)
+			if !deadline.IsZero() {
+				label = fmt.Sprintf("%d (%.0fs left)", attempt, deadline.Sub(clock.Now()).Seconds())
+			}
 			if attempt == 1 {
-				fmt.Fprintf(verbose, "Attempt %d/%d: generating...\n", attempt, maxAttempts)
+				fmt.Fprintf(verbose, "Attempt %s: generating...\n", label)
 			} else {
-				fmt.Fprintf(verbose, "Attempt %d/%d: retrying with error feedback (temp=%.2f)...\n", attempt, maxAttempts, temp)
+				fmt.Fprintf(verbose, "Attempt %s: retrying with error feedback (temp=%.2f)...\n", label, temp)
 			}
 		}
 
@@ -110,237 +217,266 @@ func GenerateWithValidation(
 		if err != nil {
 			return nil, fmt.Errorf("generating query (attempt %d): %w", attempt, err)
 		}
-
-		// Debug: show raw response
-		if debug != nil {
-			fmt.Fprintf(debug, "--- Raw LLM Response (attempt %d) ---\n%s\n--- End Raw Response ---\n", attempt, response)
-		}
+		usage.add(provider, verbose)
 
 		kql := extractKQL(response)
 		lastKQL = kql
 
-		// Debug: show extracted KQL
-		if debug != nil {
-			fmt.Fprintf(debug, "--- Extracted KQL ---\n%s\n--- End Extracted ---\n\n", kql)
-		}
-
-		// Validate
+		// Validate syntax, then, if enabled, semantics
+		lastKind = ErrorKindSyntax
 		parseResult := kqlparser.Parse("generated.kql", kql)
 		if len(parseResult.Errors) == 0 {
+			lastErrors = semanticErrors(kql, cfg)
+			lastKind = ErrorKindSemantic
+		} else {
+			// Convert errors (parse error message format: "file:line:col: message")
+			lastErrors = make([]ValidationError, len(parseResult.Errors))
+			for i, e := range parseResult.Errors {
+				lastErrors[i] = parseErrorToValidationError(e)
+			}
+		}
+		record(AttemptRecord{
+			Attempt:  attempt,
+			Prompt:   prompt,
+			Response: response,
+			Query:    kql,
+			Errors:   lastErrors,
+		})
+
+		if len(lastErrors) == 0 {
 			if verbose != nil {
 				fmt.Fprintf(verbose, "  ✓ Valid KQL\n")
 			}
-			return &GenerateResult{
+			return finish(&GenerateResult{
 				Query:    kql,
 				Valid:    true,
 				Attempts: attempt,
-			}, nil
-		}
-
-		// Convert errors (parse error message format: "file:line:col: message")
-		lastErrors = make([]ValidationError, len(parseResult.Errors))
-		for i, e := range parseResult.Errors {
-			lastErrors[i] = parseErrorToValidationError(e)
+			}), nil
 		}
 
 		if verbose != nil {
-			fmt.Fprintf(verbose, "  ✗ %d syntax error(s)\n", len(lastErrors))
+			fmt.Fprintf(verbose, "  ✗ %d %s error(s)\n", len(lastErrors), lastKind)
 			for _, e := range lastErrors {
 				fmt.Fprintf(verbose, "    Line %d, Col %d: %s\n", e.Line, e.Column, e.Message)
 			}
 		}
+
+		// With a budget, keep the attempt with the fewest errors
+		if best == nil || len(lastErrors) < len(best.Errors) {
+			best = &GenerateResult{Query: kql, Errors: lastErrors, Kind: lastKind}
+		}
+	}
+
+	if !deadline.IsZero() {
+		best.Attempts = attempt - 1
+		return finish(best), nil
 	}
 
 	// All attempts exhausted
-	return &GenerateResult{
+	return finish(&GenerateResult{
 		Query:    lastKQL,
 		Valid:    false,
 		Errors:   lastErrors,
+		Kind:     lastKind,
 		Attempts: maxAttempts,
-	}, nil
+	}), nil
 }
 
 // buildRetryPrompt builds a prompt that includes error feedback from previous attempt.
+// The feedback is capped by the FeedbackConfig limits so that queries with many
+// errors don't produce prompts that exceed the model's context.
 func buildRetryPrompt(
 	req GenerateRequest,
 	failedKQL string,
 	errors []ValidationError,
+	kind ErrorKind,
 	attempt int,
 	feedback FeedbackConfig,
 	buildPrompt func(GenerateRequest) string,
 ) string {
-	var sb strings.Builder
-
-	// Start with original prompt
-	sb.WriteString(buildPrompt(req))
-	sb.WriteString("\n\n---\n\n")
-	sb.WriteString("Your previous attempt had syntax errors:\n\n```kql\n")
-	sb.WriteString(failedKQL)
-	sb.WriteString("\n```\n\n")
-
-	// Include error messages
-	if feedback.Errors {
-		sb.WriteString("Errors:\n")
-		for _, e := range errors {
-			fmt.Fprintf(&sb, "- Line %d, Column %d: %s\n", e.Line, e.Column, e.Message)
+	// Earliest errors first: later ones are often caused by earlier ones
+	sorted := make([]ValidationError, len(errors))
+	copy(sorted, errors)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Line != sorted[j].Line {
+			return sorted[i].Line < sorted[j].Line
 		}
-		sb.WriteString("\n")
+		return sorted[i].Column < sorted[j].Column
+	})
+
+	parts := retryPromptParts{
+		base:       buildPrompt(req),
+		failedKQL:  failedKQL,
+		kind:       kind,
+		showErrors: feedback.Errors,
+		emphasis:   feedback.Progressive && attempt >= 3,
 	}
-
-	// Include hints for error types
+	parts.errors, parts.errorsOmitted = capList(sorted, feedback.MaxErrors)
 	if feedback.Hints {
-		hints := getErrorHints(errors)
-		if len(hints) > 0 {
-			sb.WriteString("Hints:\n")
-			for _, h := range hints {
-				fmt.Fprintf(&sb, "- %s\n", h)
-			}
-			sb.WriteString("\n")
-		}
+		parts.hints, parts.hintsOmitted = capList(getErrorHints(sorted, feedback.Rules), feedback.MaxHints)
 	}
-
-	// Include syntax examples (more on later attempts if progressive)
 	if feedback.Examples {
-		examples := getErrorExamples(errors, attempt, feedback.Progressive)
-		if len(examples) > 0 {
-			sb.WriteString("Correct syntax examples:\n")
-			for _, ex := range examples {
-				fmt.Fprintf(&sb, "%s\n", ex)
-			}
-			sb.WriteString("\n")
-		}
+		examples := getErrorExamples(sorted, attempt, feedback.Progressive, feedback.Rules)
+		parts.examples, parts.examplesOmitted = capList(examples, feedback.MaxExamples)
 	}
 
-	// Progressive: add more emphasis on later attempts
-	if feedback.Progressive && attempt >= 3 {
-		sb.WriteString("IMPORTANT: Please carefully check all parentheses, pipes, and operator syntax.\n\n")
+	prompt := parts.String()
+	if feedback.MaxPromptBytes > 0 {
+		for len(prompt) > feedback.MaxPromptBytes && parts.shrink() {
+			prompt = parts.String()
+		}
 	}
-
-	sb.WriteString("Please fix these errors and provide a corrected query.")
-
-	return sb.String()
+	return prompt
 }
 
-// getErrorHints returns contextual hints based on error types.
-func getErrorHints(errors []ValidationError) []string {
-	hints := make(map[string]bool)
-
-	for _, e := range errors {
-		msg := strings.ToLower(e.Message)
-
-		// Parenthesis issues
-		if strings.Contains(msg, "expected ')'") || strings.Contains(msg, "expected '('") ||
-			strings.Contains(msg, "unclosed") || strings.Contains(msg, "unmatched") {
-			hints["Ensure all parentheses are balanced"] = true
-		}
-
-		// Pipe issues
-		if strings.Contains(msg, "expected '|'") || strings.Contains(msg, "pipe") {
-			hints["Each operator should be on a new line starting with |"] = true
-		}
+// capList returns at most limit items (all of them when limit <= 0) and
+// the number left out.
+func capList[T any](items []T, limit int) ([]T, int) {
+	if limit <= 0 || len(items) <= limit {
+		return items, 0
+	}
+	return items[:limit], len(items) - limit
+}
 
-		// Comma issues
-		if strings.Contains(msg, "expected ','") {
-			hints["Multiple arguments should be separated by commas"] = true
-		}
+// retryPromptParts holds the sections of a retry prompt so they can be
+// trimmed to fit a size limit.
+type retryPromptParts struct {
+	base         string
+	failedKQL    string
+	kqlTruncated bool
+	kind         ErrorKind
 
-		// Operator issues
-		if strings.Contains(msg, "expected operator") || strings.Contains(msg, "unknown operator") {
-			hints["Common operators: where, project, summarize, extend, join, take, top, sort"] = true
-		}
+	showErrors    bool
+	errors        []ValidationError
+	errorsOmitted int
 
-		// By clause issues
-		if strings.Contains(msg, "by") {
-			hints["The 'by' clause is used with summarize, top, and order operators"] = true
-		}
+	hints        []string
+	hintsOmitted int
 
-		// String literal issues
-		if strings.Contains(msg, "string") || strings.Contains(msg, "quote") {
-			hints["Use single or double quotes for string literals"] = true
-		}
+	examples        []string
+	examplesOmitted int
 
-		// Backtick/multi-line string issues (LLM wrapping output in backticks)
-		if strings.Contains(msg, "triple delimiter") || strings.Contains(msg, "multi-line string") ||
-			strings.Contains(msg, "illegal") {
-			hints["Do NOT wrap output in backticks - output raw KQL only"] = true
-		}
+	emphasis bool
+}
 
-		// Datetime issues
-		if strings.Contains(msg, "datetime") || strings.Contains(msg, "date") {
-			hints["Use datetime() for date values, e.g., datetime(2024-01-01)"] = true
+// minTruncatedKQL is the shortest the failed query is cut down to.
+const minTruncatedKQL = 200
+
+// shrink removes the least useful remaining content: examples, then hints,
+// then half of the listed errors, then half of the failed query. It
+// reports false when nothing more can be removed.
+func (p *retryPromptParts) shrink() bool {
+	switch {
+	case len(p.examples) > 0:
+		p.examplesOmitted += len(p.examples)
+		p.examples = nil
+	case len(p.hints) > 0:
+		p.hintsOmitted += len(p.hints)
+		p.hints = nil
+	case p.showErrors && len(p.errors) > 1:
+		keep := len(p.errors) / 2
+		p.errorsOmitted += len(p.errors) - keep
+		p.errors = p.errors[:keep]
+	case len(p.failedKQL) > minTruncatedKQL:
+		cut := len(p.failedKQL) / 2
+		if cut < minTruncatedKQL {
+			cut = minTruncatedKQL
 		}
-
-		// Timespan issues
-		if strings.Contains(msg, "timespan") || strings.Contains(msg, "ago") {
-			hints["Use timespan literals like 1h, 7d, 30m or the ago() function"] = true
+		for cut > 0 && !utf8.RuneStart(p.failedKQL[cut]) {
+			cut--
 		}
+		p.failedKQL = p.failedKQL[:cut]
+		p.kqlTruncated = true
+	default:
+		return false
 	}
-
-	result := make([]string, 0, len(hints))
-	for h := range hints {
-		result = append(result, h)
-	}
-	return result
+	return true
 }
 
-// getErrorExamples returns syntax examples based on error types.
-func getErrorExamples(errors []ValidationError, attempt int, progressive bool) []string {
-	examples := make(map[string]bool)
+func (p retryPromptParts) String() string {
+	var sb strings.Builder
 
-	for _, e := range errors {
-		msg := strings.ToLower(e.Message)
+	// Start with original prompt
+	sb.WriteString(p.base)
+	sb.WriteString("\n\n---\n\n")
+	fmt.Fprintf(&sb, "Your previous attempt had %s:\n\n```kql\n", p.kind.problem())
+	sb.WriteString(p.failedKQL)
+	if p.kqlTruncated {
+		sb.WriteString("\n... (query truncated)")
+	}
+	sb.WriteString("\n```\n\n")
 
-		// Summarize syntax
-		if strings.Contains(msg, "summarize") || strings.Contains(msg, "count") ||
-			strings.Contains(msg, "sum") || strings.Contains(msg, "avg") {
-			examples["T | summarize count() by Column"] = true
-			examples["T | summarize Total=sum(Value) by Category"] = true
+	// Include error messages
+	if p.showErrors {
+		sb.WriteString("Errors:\n")
+		for _, e := range p.errors {
+			fmt.Fprintf(&sb, "- Line %d, Column %d: %s\n", e.Line, e.Column, e.Message)
 		}
-
-		// Where syntax
-		if strings.Contains(msg, "where") || strings.Contains(msg, "filter") {
-			examples["T | where Column > 10"] = true
-			examples["T | where Name == 'value'"] = true
+		if p.errorsOmitted > 0 {
+			fmt.Fprintf(&sb, "(%d more errors omitted)\n", p.errorsOmitted)
 		}
+		sb.WriteString("\n")
+	}
 
-		// Project syntax
-		if strings.Contains(msg, "project") {
-			examples["T | project Column1, Column2"] = true
-			examples["T | project NewName = OldName"] = true
+	// Include hints for error types
+	if len(p.hints) > 0 {
+		sb.WriteString("Hints:\n")
+		for _, h := range p.hints {
+			fmt.Fprintf(&sb, "- %s\n", h)
 		}
-
-		// Join syntax
-		if strings.Contains(msg, "join") {
-			examples["T1 | join kind=inner T2 on CommonColumn"] = true
+		if p.hintsOmitted > 0 {
+			fmt.Fprintf(&sb, "(%d more hints omitted)\n", p.hintsOmitted)
 		}
+		sb.WriteString("\n")
+	}
 
-		// Extend syntax
-		if strings.Contains(msg, "extend") {
-			examples["T | extend NewColumn = Expression"] = true
+	// Include syntax examples (more on later attempts if progressive)
+	if len(p.examples) > 0 {
+		sb.WriteString("Correct syntax examples:\n")
+		for _, ex := range p.examples {
+			fmt.Fprintf(&sb, "%s\n", ex)
 		}
-
-		// General parenthesis
-		if strings.Contains(msg, "expected ')'") || strings.Contains(msg, "expected '('") {
-			examples["Function calls: func(arg1, arg2)"] = true
+		if p.examplesOmitted > 0 {
+			fmt.Fprintf(&sb, "(%d more examples omitted)\n", p.examplesOmitted)
 		}
+		sb.WriteString("\n")
+	}
 
-		// Progressive: add more examples on later attempts
-		if progressive && attempt >= 3 {
-			examples["// Multi-line query structure:\nTable\n| where Condition\n| summarize count() by Column"] = true
-		}
+	// Progressive: add more emphasis on later attempts
+	if p.emphasis {
+		sb.WriteString("IMPORTANT: Please carefully check all parentheses, pipes, and operator syntax.\n\n")
 	}
 
-	result := make([]string, 0, len(examples))
-	for ex := range examples {
-		result = append(result, ex)
+	sb.WriteString("Please fix these errors and provide a corrected query.")
+
+	return sb.String()
+}
+
+// errorMessages returns the messages of errors, for the kqlhints lookups.
+func errorMessages(errors []ValidationError) []string {
+	msgs := make([]string, len(errors))
+	for i, e := range errors {
+		msgs[i] = e.Message
 	}
-	return result
+	return msgs
+}
+
+// getErrorHints returns contextual hints based on error types, in first-seen order.
+// Custom rules contribute hints after the built-in ones.
+func getErrorHints(errors []ValidationError, rules []FeedbackRule) []string {
+	return kqlhints.HintsForMessages(errorMessages(errors), rules...)
+}
+
+// getErrorExamples returns syntax examples based on error types, in first-seen order.
+// Custom rules contribute examples after the built-in ones.
+func getErrorExamples(errors []ValidationError, attempt int, progressive bool, rules []FeedbackRule) []string {
+	return kqlhints.ExamplesForMessages(errorMessages(errors), attempt, progressive, rules...)
 }
 
 // FormatValidationWarning formats validation errors for stderr output.
 func FormatValidationWarning(result *GenerateResult) string {
 	var sb strings.Builder
-	fmt.Fprintf(&sb, "⚠ Warning: generated query has syntax errors (after %d attempt(s))\n", result.Attempts)
+	fmt.Fprintf(&sb, "⚠ Warning: generated query has %s (after %d attempt(s))\n", result.Kind.problem(), result.Attempts)
 	for _, e := range result.Errors {
 		fmt.Fprintf(&sb, "  Line %d, Column %d: %s\n", e.Line, e.Column, e.Message)
 	}
@@ -350,7 +486,7 @@ func FormatValidationWarning(result *GenerateResult) string {
 // FormatValidationError formats validation errors for strict mode.
 func FormatValidationError(result *GenerateResult) string {
 	var sb strings.Builder
-	fmt.Fprintf(&sb, "Error: failed to generate valid query after %d attempt(s)\n", result.Attempts)
+	fmt.Fprintf(&sb, "Error: failed to generate valid query after %d attempt(s): it has %s\n", result.Attempts, result.Kind.problem())
 	for _, e := range result.Errors {
 		fmt.Fprintf(&sb, "  Line %d, Column %d: %s\n", e.Line, e.Column, e.Message)
 	}
@@ -358,26 +494,41 @@ func FormatValidationError(result *GenerateResult) string {
 }
 
 // parseErrorToValidationError converts a parser error to ValidationError.
-// Parser errors have format: "file:line:col: message"
 func parseErrorToValidationError(err error) ValidationError {
-	msg := err.Error()
-
-	// Pattern: "filename:line:col: message"
-	re := regexp.MustCompile(`^[^:]+:(\d+):(\d+): (.+)$`)
-	if matches := re.FindStringSubmatch(msg); len(matches) == 4 {
-		line, _ := strconv.Atoi(matches[1])
-		col, _ := strconv.Atoi(matches[2])
-		return ValidationError{
-			Line:    line,
-			Column:  col,
-			Message: matches[3],
-		}
+	line, col, msg, ok := PositionFromError(err)
+	if !ok {
+		// Fallback: just use the whole message
+		line, col = 1, 1
 	}
-
-	// Fallback: just use the whole message
 	return ValidationError{
-		Line:    1,
-		Column:  1,
+		Line:    line,
+		Column:  col,
 		Message: msg,
 	}
 }
+
+// errPosRegex matches errors formatted as "file:line:col: message".
+var errPosRegex = regexp.MustCompile(`^[^:]+:(\d+):(\d+): (.+)$`)
+
+// PositionFromError returns the position and message of a kqlparser error.
+// Errors of other types are matched against the "file:line:col: message"
+// format the parser uses. If no position is found, ok is false and msg is
+// the whole error message.
+func PositionFromError(err error) (line, col int, msg string, ok bool) {
+	var perr parser.Error
+	if errors.As(err, &perr) && perr.Pos.IsValid() {
+		return perr.Pos.Line, perr.Pos.Column, perr.Msg, true
+	}
+	var lerr lexer.Error
+	if errors.As(err, &lerr) && lerr.Pos.IsValid() {
+		return lerr.Pos.Line, lerr.Pos.Column, lerr.Msg, true
+	}
+
+	msg = err.Error()
+	if matches := errPosRegex.FindStringSubmatch(msg); matches != nil {
+		line, _ = strconv.Atoi(matches[1])
+		col, _ = strconv.Atoi(matches[2])
+		return line, col, matches[3], true
+	}
+	return 0, 0, msg, false
+}
@@ -0,0 +1,397 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/parser"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+var orderingErrors = []ValidationError{
+	{Line: 1, Column: 5, Message: "expected ')' in summarize count"},
+	{Line: 2, Column: 1, Message: "expected ',' before string literal"},
+	{Line: 3, Column: 9, Message: "unknown operator near where"},
+	{Line: 4, Column: 2, Message: "invalid datetime in join"},
+}
+
+func TestGetErrorHints_StableOrder(t *testing.T) {
+	first := getErrorHints(orderingErrors, nil)
+	if len(first) < 2 {
+		t.Fatalf("expected several hints, got %d", len(first))
+	}
+
+	for i := 0; i < 50; i++ {
+		if got := getErrorHints(orderingErrors, nil); !reflect.DeepEqual(got, first) {
+			t.Fatalf("hint order changed on run %d:\n  first: %q\n  got:   %q", i, first, got)
+		}
+	}
+
+	// First-seen order: the parenthesis hint comes from the first error
+	if first[0] != "Ensure all parentheses are balanced" {
+		t.Errorf("expected parenthesis hint first, got %q", first[0])
+	}
+}
+
+func TestGetErrorExamples_StableOrder(t *testing.T) {
+	first := getErrorExamples(orderingErrors, 3, true, nil)
+	if len(first) < 2 {
+		t.Fatalf("expected several examples, got %d", len(first))
+	}
+
+	for i := 0; i < 50; i++ {
+		if got := getErrorExamples(orderingErrors, 3, true, nil); !reflect.DeepEqual(got, first) {
+			t.Fatalf("example order changed on run %d:\n  first: %q\n  got:   %q", i, first, got)
+		}
+	}
+
+	if first[0] != "T | summarize count() by Column" {
+		t.Errorf("expected summarize example first, got %q", first[0])
+	}
+}
+
+func TestGetErrorHints_Deduplicates(t *testing.T) {
+	errs := []ValidationError{
+		{Message: "expected ')'"},
+		{Message: "expected ')'"},
+	}
+	hints := getErrorHints(errs, nil)
+	if len(hints) != 1 {
+		t.Errorf("expected 1 hint, got %d: %q", len(hints), hints)
+	}
+}
+
+func TestBuildRetryPrompt_Reproducible(t *testing.T) {
+	build := func(r GenerateRequest) string { return "Generate: " + r.Prompt }
+	req := GenerateRequest{Prompt: "count by state"}
+	fb := DefaultValidationConfig().Feedback
+
+	first := buildRetryPrompt(req, "T | summarize count( by State", orderingErrors, ErrorKindSyntax, 3, fb, build)
+	for i := 0; i < 20; i++ {
+		if got := buildRetryPrompt(req, "T | summarize count( by State", orderingErrors, ErrorKindSyntax, 3, fb, build); got != first {
+			t.Fatalf("retry prompt changed on run %d", i)
+		}
+	}
+}
+
+func TestGetErrorHints_CustomRule(t *testing.T) {
+	rules := []FeedbackRule{
+		{Match: "SigninLogs", Hint: "SigninLogs lives in the security workspace; use workspace('sec').SigninLogs"},
+		{Match: "/unknown function '?parse_ua'?/", Hint: "Use parse_user_agent() instead of parse_ua()"},
+		{Match: "never matches", Hint: "should not appear"},
+	}
+	errs := []ValidationError{
+		{Message: "unresolved table signinlogs"},
+		{Message: "unknown function 'parse_ua'"},
+	}
+
+	hints := getErrorHints(errs, rules)
+
+	want := []string{
+		"SigninLogs lives in the security workspace; use workspace('sec').SigninLogs",
+		"Use parse_user_agent() instead of parse_ua()",
+	}
+	if !reflect.DeepEqual(hints, want) {
+		t.Errorf("unexpected hints:\n  got:  %q\n  want: %q", hints, want)
+	}
+}
+
+func TestGetErrorExamples_CustomRule(t *testing.T) {
+	rules := []FeedbackRule{
+		{Match: "mv-expand", Example: "T | mv-expand Tags to typeof(string)"},
+	}
+	errs := []ValidationError{{Message: "expected expression after mv-expand"}}
+
+	examples := getErrorExamples(errs, 1, false, rules)
+	if len(examples) != 1 || examples[0] != "T | mv-expand Tags to typeof(string)" {
+		t.Errorf("expected custom example, got %q", examples)
+	}
+}
+
+func TestMergeFileConfig_FeedbackRules(t *testing.T) {
+	fileCfg := &FileConfig{}
+	fileCfg.AI.Validation.Feedback.Rules = []FeedbackRuleFile{
+		{Match: "SigninLogs", Hint: "use the security workspace"},
+	}
+
+	merged := MergeFileConfig(DefaultConfig(), fileCfg)
+	rules := merged.Validation.Feedback.Rules
+	if len(rules) != 1 || rules[0].Hint != "use the security workspace" {
+		t.Errorf("expected custom rule to be merged, got %+v", rules)
+	}
+}
+
+// manyErrors returns n distinct errors in reverse position order.
+func manyErrors(n int) []ValidationError {
+	errs := make([]ValidationError, n)
+	for i := range errs {
+		errs[i] = ValidationError{
+			Line:    n - i,
+			Column:  1,
+			Message: fmt.Sprintf("expected ')' near token %d in summarize", n-i),
+		}
+	}
+	return errs
+}
+
+func TestBuildRetryPrompt_CapsErrors(t *testing.T) {
+	build := func(r GenerateRequest) string { return "Generate: " + r.Prompt }
+	fb := DefaultValidationConfig().Feedback
+	fb.MaxErrors = 3
+	fb.MaxPromptBytes = 0
+
+	prompt := buildRetryPrompt(GenerateRequest{Prompt: "p"}, "T | x", manyErrors(8), ErrorKindSyntax, 2, fb, build)
+
+	if !strings.Contains(prompt, "(5 more errors omitted)") {
+		t.Errorf("expected omission note, got:\n%s", prompt)
+	}
+	// Earliest positions are kept
+	if !strings.Contains(prompt, "- Line 1, Column 1:") || !strings.Contains(prompt, "- Line 3, Column 1:") {
+		t.Errorf("expected first three errors by position, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "- Line 4, Column 1:") {
+		t.Errorf("expected later errors to be omitted, got:\n%s", prompt)
+	}
+}
+
+func TestBuildRetryPrompt_MaxPromptBytes(t *testing.T) {
+	build := func(r GenerateRequest) string { return "Generate: " + r.Prompt }
+	fb := DefaultValidationConfig().Feedback
+	fb.MaxErrors = 0
+	fb.MaxPromptBytes = 1500
+
+	failed := strings.Repeat("T | summarize count( by State\n", 100)
+	prompt := buildRetryPrompt(GenerateRequest{Prompt: "count by state"}, failed, manyErrors(200), ErrorKindSyntax, 3, fb, build)
+
+	if len(prompt) > fb.MaxPromptBytes {
+		t.Errorf("prompt is %d bytes, want <= %d", len(prompt), fb.MaxPromptBytes)
+	}
+	if !strings.Contains(prompt, "more errors omitted)") {
+		t.Error("expected errors omission note")
+	}
+	if !strings.Contains(prompt, "(query truncated)") {
+		t.Error("expected truncated query note")
+	}
+	if !strings.HasPrefix(prompt, "Generate: count by state") || !strings.HasSuffix(prompt, "provide a corrected query.") {
+		t.Error("expected the request and closing instruction to be kept")
+	}
+}
+
+func TestBuildRetryPrompt_UnderLimitUnchanged(t *testing.T) {
+	build := func(r GenerateRequest) string { return "Generate: " + r.Prompt }
+	fb := DefaultValidationConfig().Feedback
+
+	limited := buildRetryPrompt(GenerateRequest{Prompt: "p"}, "T | x", orderingErrors, ErrorKindSyntax, 2, fb, build)
+	fb.MaxErrors, fb.MaxHints, fb.MaxExamples, fb.MaxPromptBytes = 0, 0, 0, 0
+	unlimited := buildRetryPrompt(GenerateRequest{Prompt: "p"}, "T | x", orderingErrors, ErrorKindSyntax, 2, fb, build)
+
+	if limited != unlimited {
+		t.Errorf("small prompts should not be trimmed:\n  limited:   %q\n  unlimited: %q", limited, unlimited)
+	}
+	if strings.Contains(limited, "omitted") {
+		t.Error("unexpected omission note")
+	}
+}
+
+func TestMergeFileConfig_FeedbackLimits(t *testing.T) {
+	maxErrors, maxBytes := 4, 0
+	fileCfg := &FileConfig{}
+	fileCfg.AI.Validation.Feedback.MaxErrors = &maxErrors
+	fileCfg.AI.Validation.Feedback.MaxPromptBytes = &maxBytes
+
+	fb := MergeFileConfig(DefaultConfig(), fileCfg).Validation.Feedback
+	if fb.MaxErrors != 4 || fb.MaxPromptBytes != 0 {
+		t.Errorf("expected file limits to apply, got %+v", fb)
+	}
+	if fb.MaxHints != DefaultFeedbackMaxHints {
+		t.Errorf("expected default max hints, got %d", fb.MaxHints)
+	}
+}
+
+// scriptedProvider returns invalid KQL until call validFrom, then valid KQL.
+// Each call advances the fake clock by step.
+type scriptedProvider struct {
+	validFrom int
+	calls     int
+	clock     *fakeClock
+	step      time.Duration
+}
+
+func (p *scriptedProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	p.calls++
+	p.clock.Advance(p.step)
+	if p.validFrom > 0 && p.calls >= p.validFrom {
+		return "T | take 10", nil
+	}
+	if p.calls == 2 {
+		return "T | where (x > 1", nil // one error: the best attempt
+	}
+	return "T | where ((x > 1 | summarize count( by", nil
+}
+
+func (p *scriptedProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	return p.Complete(ctx, messages[len(messages)-1].Content)
+}
+
+func (p *scriptedProvider) Name() string  { return "scripted" }
+func (p *scriptedProvider) Model() string { return "scripted" }
+
+func generateScripted(t *testing.T, p Provider, cfg ValidationConfig) *GenerateResult {
+	t.Helper()
+	result, err := GenerateWithValidation(context.Background(), p, GenerateRequest{Prompt: "count rows"}, cfg, 0.2,
+		func(r GenerateRequest) string { return r.Prompt },
+		func(s string) string { return s },
+		nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
+
+func TestGenerateWithValidation_RetryBudgetSucceeds(t *testing.T) {
+	fc := useFakeClock(t)
+	p := &scriptedProvider{validFrom: 5, clock: fc, step: time.Second}
+
+	cfg := DefaultValidationConfig()
+	cfg.Retries = 1 // ignored with a budget
+	cfg.RetryBudget = 10 * time.Second
+
+	result := generateScripted(t, p, cfg)
+	if !result.Valid || result.Attempts != 5 {
+		t.Errorf("expected a valid query on attempt 5, got valid=%t attempts=%d", result.Valid, result.Attempts)
+	}
+}
+
+func TestGenerateWithValidation_RetryBudgetExhausted(t *testing.T) {
+	fc := useFakeClock(t)
+	p := &scriptedProvider{clock: fc, step: time.Second}
+
+	cfg := DefaultValidationConfig()
+	cfg.RetryBudget = 3 * time.Second
+
+	result := generateScripted(t, p, cfg)
+	if result.Valid {
+		t.Fatal("expected an invalid result")
+	}
+	if result.Attempts != 3 || p.calls != 3 {
+		t.Errorf("expected 3 attempts within the budget, got %d (%d calls)", result.Attempts, p.calls)
+	}
+	if result.Query != "T | where (x > 1" {
+		t.Errorf("expected the attempt with the fewest errors, got %q", result.Query)
+	}
+}
+
+func TestGenerateWithValidation_RetriesWithoutBudget(t *testing.T) {
+	fc := useFakeClock(t)
+	p := &scriptedProvider{validFrom: 5, clock: fc, step: time.Second}
+
+	cfg := DefaultValidationConfig()
+	cfg.Retries = 1
+
+	result := generateScripted(t, p, cfg)
+	if result.Valid || result.Attempts != 2 || p.calls != 2 {
+		t.Errorf("expected 2 count-bounded attempts, got valid=%t attempts=%d calls=%d", result.Valid, result.Attempts, p.calls)
+	}
+}
+
+func TestGenerateWithValidation_RecordsHistory(t *testing.T) {
+	fc := useFakeClock(t)
+	cfg := DefaultValidationConfig()
+	cfg.Retries = 3
+
+	// Off by default
+	result := generateScripted(t, &scriptedProvider{validFrom: 3, clock: fc}, cfg)
+	if result.History != nil {
+		t.Errorf("expected no history without RecordHistory, got %d records", len(result.History))
+	}
+
+	cfg.RecordHistory = true
+	result = generateScripted(t, &scriptedProvider{validFrom: 3, clock: fc}, cfg)
+	if len(result.History) != result.Attempts || result.Attempts != 3 {
+		t.Fatalf("expected a record per attempt (3), got %d records for %d attempts", len(result.History), result.Attempts)
+	}
+
+	first, second, last := result.History[0], result.History[1], result.History[2]
+	if first.Attempt != 1 || first.Prompt != "count rows" || first.Query != first.Response {
+		t.Errorf("unexpected first record: %+v", first)
+	}
+	if len(first.Errors) == 0 || len(second.Errors) != 1 {
+		t.Errorf("expected the failed attempts' errors, got %d and %d", len(first.Errors), len(second.Errors))
+	}
+	if !strings.Contains(second.Prompt, first.Query) {
+		t.Errorf("expected the retry prompt to carry the failed query, got:\n%s", second.Prompt)
+	}
+	if last.Query != "T | take 10" || len(last.Errors) != 0 || last.Query != result.Query {
+		t.Errorf("expected the valid attempt last, got %+v", last)
+	}
+}
+
+func TestGenerateWithValidation_HistoryWithoutValidation(t *testing.T) {
+	cfg := ValidationConfig{RecordHistory: true}
+	result := generateScripted(t, &scriptedProvider{validFrom: 1, clock: useFakeClock(t)}, cfg)
+	if len(result.History) != 1 || result.History[0].Response != "T | take 10" {
+		t.Errorf("expected the single attempt to be recorded, got %+v", result.History)
+	}
+}
+
+func TestGenerateWithValidation_DebugOutput(t *testing.T) {
+	cfg := DefaultValidationConfig()
+	cfg.Retries = 1
+
+	var debug strings.Builder
+	_, err := GenerateWithValidation(context.Background(), &scriptedProvider{clock: useFakeClock(t)}, GenerateRequest{Prompt: "count rows"}, cfg, 0.2,
+		func(r GenerateRequest) string { return r.Prompt },
+		func(s string) string { return s },
+		nil, &debug,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--- Raw LLM Response (attempt 1) ---", "--- Raw LLM Response (attempt 2) ---", "--- Extracted KQL ---\nT | where (x > 1\n"} {
+		if !strings.Contains(debug.String(), want) {
+			t.Errorf("expected %q in debug output:\n%s", want, debug.String())
+		}
+	}
+}
+
+func TestPositionFromError_Typed(t *testing.T) {
+	// A colon in the filename defeats the "file:line:col" pattern, so
+	// this only passes through the typed path
+	perr := parser.Error{Pos: token.Position{Filename: `C:\q.kql`, Line: 3, Column: 7}, Msg: "expected ')'"}
+	for _, err := range []error{perr, fmt.Errorf("parsing: %w", perr)} {
+		line, col, msg, ok := PositionFromError(err)
+		if !ok || line != 3 || col != 7 || msg != "expected ')'" {
+			t.Errorf("PositionFromError(%v) = %d, %d, %q, %t", err, line, col, msg, ok)
+		}
+	}
+
+	// The errors kqlparser actually returns
+	result := kqlparser.Parse("q.kql", "T\n| where (x")
+	if len(result.Errors) == 0 {
+		t.Fatal("expected a parse error")
+	}
+	if line, _, _, ok := PositionFromError(result.Errors[0]); !ok || line != 2 {
+		t.Errorf("expected a position on line 2, got line %d (ok %t)", line, ok)
+	}
+}
+
+func TestPositionFromError_Untyped(t *testing.T) {
+	line, col, msg, ok := PositionFromError(fmt.Errorf("q.kql:5:10: unexpected token"))
+	if !ok || line != 5 || col != 10 || msg != "unexpected token" {
+		t.Errorf("got %d, %d, %q, %t", line, col, msg, ok)
+	}
+
+	_, _, msg, ok = PositionFromError(fmt.Errorf("no position here"))
+	if ok || msg != "no position here" {
+		t.Errorf("expected no position and the whole message, got %q, %t", msg, ok)
+	}
+}
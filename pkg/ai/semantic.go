@@ -0,0 +1,76 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"strings"
+
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/symbol"
+	"github.com/cloudygreybeard/kqlparser/types"
+)
+
+// schemaTypes maps the column type names accepted in a schema.
+var schemaTypes = map[string]types.Type{
+	"bool":     types.Typ_Bool,
+	"boolean":  types.Typ_Bool,
+	"int":      types.Typ_Int,
+	"long":     types.Typ_Long,
+	"real":     types.Typ_Real,
+	"double":   types.Typ_Real,
+	"decimal":  types.Typ_Decimal,
+	"string":   types.Typ_String,
+	"datetime": types.Typ_DateTime,
+	"date":     types.Typ_DateTime,
+	"timespan": types.Typ_TimeSpan,
+	"time":     types.Typ_TimeSpan,
+	"guid":     types.Typ_Guid,
+	"dynamic":  types.Typ_Dynamic,
+}
+
+// schemaGlobals builds the analyzer context for a single table from a
+// comma-separated schema, as given to generate --schema. Each column is
+// "Name" or "Name:type"; columns without a known type are dynamic, so only
+// their names are checked.
+func schemaGlobals(table, schema string) *kqlparser.Globals {
+	var columns []*types.Column
+	for _, field := range strings.Split(schema, ",") {
+		name, typeName, _ := strings.Cut(strings.TrimSpace(field), ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		typ, ok := schemaTypes[strings.ToLower(strings.TrimSpace(typeName))]
+		if !ok {
+			typ = types.Typ_Dynamic
+		}
+		columns = append(columns, types.NewColumn(name, typ))
+	}
+
+	globals := kqlparser.NewGlobals()
+	globals.Database = symbol.NewDatabase("generate")
+	globals.Database.AddTable(symbol.NewTable(table, columns...))
+	return globals
+}
+
+// semanticErrors analyzes a syntactically valid query against the table
+// and schema in cfg. Names the schema doesn't define are errors. It returns
+// nil unless cfg.Semantic is set with both a table and a schema.
+func semanticErrors(kql string, cfg ValidationConfig) []ValidationError {
+	if !cfg.Semantic || cfg.Table == "" || strings.TrimSpace(cfg.Schema) == "" {
+		return nil
+	}
+
+	result := kqlparser.ParseAndAnalyzeWithOptions("generated.kql", kql, schemaGlobals(cfg.Table, cfg.Schema), &kqlparser.Options{StrictMode: true})
+
+	var errs []ValidationError
+	for _, d := range result.Errors() {
+		errs = append(errs, ValidationError{
+			Line:    d.Pos.Line,
+			Column:  d.Pos.Column,
+			Message: d.Message,
+		})
+	}
+	return errs
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// sequenceProvider returns its responses in order, repeating the last, and
+// records the prompts it was sent.
+type sequenceProvider struct {
+	responses []string
+	prompts   []string
+}
+
+func (p *sequenceProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	i := len(p.prompts) - 1
+	if i >= len(p.responses) {
+		i = len(p.responses) - 1
+	}
+	return p.responses[i], nil
+}
+
+func (p *sequenceProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	return p.Complete(ctx, messages[len(messages)-1].Content)
+}
+
+func (p *sequenceProvider) Name() string  { return "sequence" }
+func (p *sequenceProvider) Model() string { return "sequence" }
+
+func semanticConfig() ValidationConfig {
+	cfg := DefaultValidationConfig()
+	cfg.Semantic = true
+	cfg.Table = "StormEvents"
+	cfg.Schema = "State:string, StartTime:datetime, DamageProperty"
+	return cfg
+}
+
+func TestSemanticErrors(t *testing.T) {
+	cfg := semanticConfig()
+
+	if errs := semanticErrors("StormEvents | where State == 'TX' | project StartTime, DamageProperty", cfg); len(errs) != 0 {
+		t.Errorf("expected no errors for known columns, got %+v", errs)
+	}
+	// Untyped columns are dynamic, so comparing them doesn't fail on type
+	if errs := semanticErrors("StormEvents | where DamageProperty > 1000", cfg); len(errs) != 0 {
+		t.Errorf("expected untyped columns to accept any use, got %+v", errs)
+	}
+
+	errs := semanticErrors("StormEvents | where EventType == 'Flood'", cfg)
+	if len(errs) == 0 {
+		t.Fatal("expected an error for a column not in the schema")
+	}
+	if !strings.Contains(errs[0].Message, "EventType") || errs[0].Line != 1 {
+		t.Errorf("unexpected error: %+v", errs[0])
+	}
+
+	// Off by default, and without a table or schema
+	for _, c := range []ValidationConfig{DefaultValidationConfig(), {Semantic: true, Table: "StormEvents"}, {Semantic: true, Schema: "State"}} {
+		if errs := semanticErrors("StormEvents | where EventType == 'Flood'", c); errs != nil {
+			t.Errorf("expected no semantic check for %+v, got %+v", c, errs)
+		}
+	}
+}
+
+func TestGenerateWithValidation_SemanticRetry(t *testing.T) {
+	p := &sequenceProvider{responses: []string{
+		"StormEvents | where EventType == 'Flood'",
+		"StormEvents | where State == 'TX'",
+	}}
+
+	result := generateScripted(t, p, semanticConfig())
+	if !result.Valid || result.Attempts != 2 {
+		t.Fatalf("expected a valid query on attempt 2, got valid=%t attempts=%d", result.Valid, result.Attempts)
+	}
+	if len(p.prompts) != 2 || !strings.Contains(p.prompts[1], "EventType") {
+		t.Errorf("expected the retry prompt to report the unknown column, got:\n%s", p.prompts[len(p.prompts)-1])
+	}
+	if retry := p.prompts[len(p.prompts)-1]; strings.Contains(retry, "syntax errors") || !strings.Contains(retry, "not in the schema") {
+		t.Errorf("expected the retry prompt to describe a schema error, got:\n%s", retry)
+	}
+}
+
+func TestGenerateWithValidation_SemanticFailureMessages(t *testing.T) {
+	p := &sequenceProvider{responses: []string{"StormEvents | where EventType == 'Flood'"}}
+
+	result := generateScripted(t, p, semanticConfig())
+	if result.Valid || result.Kind != ErrorKindSemantic {
+		t.Fatalf("expected a semantic failure, got valid=%t kind=%q", result.Valid, result.Kind)
+	}
+	for _, msg := range []string{FormatValidationWarning(result), FormatValidationError(result)} {
+		if strings.Contains(msg, "syntax errors") || !strings.Contains(msg, "not in the schema") {
+			t.Errorf("expected the message to describe a schema error, got %q", msg)
+		}
+	}
+
+	syntax := &GenerateResult{Attempts: 1, Kind: ErrorKindSyntax}
+	if msg := FormatValidationWarning(syntax); !strings.Contains(msg, "syntax errors") {
+		t.Errorf("expected a syntax warning, got %q", msg)
+	}
+}
+
+func TestGenerateWithValidation_SyntaxOnlyByDefault(t *testing.T) {
+	p := &sequenceProvider{responses: []string{"StormEvents | where EventType == 'Flood'"}}
+
+	cfg := DefaultValidationConfig()
+	cfg.Table = "StormEvents"
+	cfg.Schema = "State:string"
+
+	result := generateScripted(t, p, cfg)
+	if !result.Valid || result.Attempts != 1 {
+		t.Errorf("expected unknown columns to pass syntax-only validation, got valid=%t attempts=%d", result.Valid, result.Attempts)
+	}
+}
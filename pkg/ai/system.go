@@ -0,0 +1,96 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"io"
+)
+
+// SystemPromptProvider wraps a Provider, sending a fixed system message
+// ahead of every request. Complete becomes a two-message CompleteChat, so
+// providers without a system role (Vertex) fold it into the prompt as
+// they do for any conversation.
+type SystemPromptProvider struct {
+	provider Provider
+	system   string
+}
+
+// NewSystemPromptProvider wraps provider so every request starts with the
+// system message.
+func NewSystemPromptProvider(provider Provider, system string) *SystemPromptProvider {
+	return &SystemPromptProvider{provider: provider, system: system}
+}
+
+// Name returns the wrapped provider's name.
+func (p *SystemPromptProvider) Name() string {
+	return p.provider.Name()
+}
+
+// Model returns the wrapped provider's model.
+func (p *SystemPromptProvider) Model() string {
+	return p.provider.Model()
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SystemPromptProvider) Unwrap() Provider {
+	return p.provider
+}
+
+// SystemPrompt returns the system message sent with every request.
+func (p *SystemPromptProvider) SystemPrompt() string {
+	return p.system
+}
+
+// Complete sends the system message and prompt as a conversation.
+func (p *SystemPromptProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
+
+// CompleteChat sends messages with the system message first.
+func (p *SystemPromptProvider) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	return p.provider.CompleteChat(ctx, p.conversation(messages))
+}
+
+// CompleteStream streams the response to the system message and prompt
+// when the wrapped provider can stream a conversation. Other providers
+// are answered whole.
+func (p *SystemPromptProvider) CompleteStream(ctx context.Context, prompt string, out io.Writer) error {
+	return p.CompleteChatStream(ctx, []Message{{Role: RoleUser, Content: prompt}}, out)
+}
+
+// CompleteChatStream is CompleteStream for a chat conversation.
+func (p *SystemPromptProvider) CompleteChatStream(ctx context.Context, messages []Message, out io.Writer) error {
+	if sp, ok := p.provider.(ChatStreamingProvider); ok {
+		return sp.CompleteChatStream(ctx, p.conversation(messages), out)
+	}
+	response, err := p.CompleteChat(ctx, messages)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, response)
+	return err
+}
+
+// LastUsage returns the wrapped provider's usage, if it reports any.
+func (p *SystemPromptProvider) LastUsage() (Usage, bool) {
+	return UsageOf(p.provider)
+}
+
+// conversation returns messages with the system message prepended.
+func (p *SystemPromptProvider) conversation(messages []Message) []Message {
+	return append([]Message{{Role: RoleSystem, Content: p.system}}, messages...)
+}
+
+// SystemPromptOf returns the system message that provider sends with every
+// request, looking through a CachingProvider, or "" if there is none.
+func SystemPromptOf(provider Provider) string {
+	if c, ok := provider.(*CachingProvider); ok {
+		provider = c.Unwrap()
+	}
+	if s, ok := provider.(*SystemPromptProvider); ok {
+		return s.system
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// chatRecorder is a fake Provider that records the conversations it is sent.
+type chatRecorder struct {
+	countingProvider
+	chats [][]Message
+}
+
+func (p *chatRecorder) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.CompleteChat(ctx, []Message{{Role: RoleUser, Content: prompt}})
+}
+
+func (p *chatRecorder) CompleteChat(ctx context.Context, messages []Message) (string, error) {
+	p.chats = append(p.chats, messages)
+	return p.countingProvider.CompleteChat(ctx, messages)
+}
+
+func TestSystemPromptProvider_PrependsSystemMessage(t *testing.T) {
+	inner := &chatRecorder{countingProvider: countingProvider{response: "ok"}}
+	p := NewSystemPromptProvider(inner, "Answer tersely.")
+	ctx := context.Background()
+
+	if _, err := p.Complete(ctx, "explain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.CompleteChat(ctx, []Message{
+		{Role: RoleUser, Content: "fix"},
+		{Role: RoleAssistant, Content: "T |"},
+		{Role: RoleUser, Content: "again"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inner.chats) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(inner.chats))
+	}
+	for i, chat := range inner.chats {
+		if chat[0] != (Message{Role: RoleSystem, Content: "Answer tersely."}) {
+			t.Errorf("request %d: expected the system message first, got %+v", i+1, chat[0])
+		}
+	}
+	if got := inner.chats[0]; len(got) != 2 || got[1] != (Message{Role: RoleUser, Content: "explain"}) {
+		t.Errorf("expected Complete to send the prompt as a user message, got %+v", got)
+	}
+	if got := inner.chats[1]; len(got) != 4 || got[3].Content != "again" {
+		t.Errorf("expected the conversation to follow the system message, got %+v", got)
+	}
+	if p.Name() != "fake" || p.Model() != "fake-model" {
+		t.Errorf("expected the wrapped provider's name and model, got %s/%s", p.Name(), p.Model())
+	}
+}
+
+// chatStreamRecorder is a chatRecorder that streams its response.
+type chatStreamRecorder struct {
+	chatRecorder
+	streamed [][]Message
+}
+
+func (p *chatStreamRecorder) CompleteStream(ctx context.Context, prompt string, out io.Writer) error {
+	return p.CompleteChatStream(ctx, []Message{{Role: RoleUser, Content: prompt}}, out)
+}
+
+func (p *chatStreamRecorder) CompleteChatStream(ctx context.Context, messages []Message, out io.Writer) error {
+	p.streamed = append(p.streamed, messages)
+	_, err := io.WriteString(out, p.response)
+	return err
+}
+
+func TestSystemPromptProvider_CompleteStream(t *testing.T) {
+	ctx := context.Background()
+
+	streaming := &chatStreamRecorder{chatRecorder: chatRecorder{countingProvider: countingProvider{response: "streamed"}}}
+	var p StreamingProvider = NewSystemPromptProvider(streaming, "Answer tersely.")
+	var out strings.Builder
+	if err := p.CompleteStream(ctx, "explain", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "streamed" || len(streaming.chats) != 0 {
+		t.Errorf("expected the response to be streamed, got %q after %d whole requests", out.String(), len(streaming.chats))
+	}
+	if len(streaming.streamed) != 1 || len(streaming.streamed[0]) != 2 || streaming.streamed[0][0].Role != RoleSystem {
+		t.Errorf("expected the system message and prompt to be streamed, got %+v", streaming.streamed)
+	}
+
+	// A provider that can't stream is answered whole, still with the
+	// system message
+	whole := &chatRecorder{countingProvider: countingProvider{response: "whole"}}
+	out.Reset()
+	if err := NewSystemPromptProvider(whole, "Answer tersely.").CompleteStream(ctx, "explain", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "whole" || len(whole.chats) != 1 || whole.chats[0][0].Role != RoleSystem {
+		t.Errorf("expected a whole response with the system message, got %q and %+v", out.String(), whole.chats)
+	}
+}
+
+func TestSystemPromptOf(t *testing.T) {
+	inner := &countingProvider{}
+	system := NewSystemPromptProvider(inner, "Be brief.")
+
+	if got := SystemPromptOf(inner); got != "" {
+		t.Errorf("expected no system prompt, got %q", got)
+	}
+	if got := SystemPromptOf(system); got != "Be brief." {
+		t.Errorf("got %q", got)
+	}
+	if got := SystemPromptOf(NewCachingProvider(system, nil, 0)); got != "Be brief." {
+		t.Errorf("expected the system prompt through the cache, got %q", got)
+	}
+}
+
+func TestCachingProvider_KeyIncludesSystemPrompt(t *testing.T) {
+	inner := &countingProvider{response: "T | take 10"}
+	cache := &ResponseCache{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	for _, system := range []string{"Be brief.", "Be thorough.", "Be brief."} {
+		p := NewCachingProvider(NewSystemPromptProvider(inner, system), cache, 0)
+		if _, err := p.Complete(ctx, "ten rows"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected one call per distinct system prompt, got %d", inner.calls)
+	}
+}
+
+func TestNewProvider_SystemPrompt(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Provider = "ollama"
+	cfg.SystemPrompt = "Use our table names."
+	cfg.Cache.Enabled = false
+
+	p, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*SystemPromptProvider); !ok {
+		t.Errorf("expected a SystemPromptProvider, got %T", p)
+	}
+
+	cfg.SystemPrompt = ""
+	if p, _ = NewProvider(cfg); SystemPromptOf(p) != "" {
+		t.Errorf("expected no system prompt wrapper, got %T", p)
+	}
+}
+
+func TestMergeFileConfig_SystemPrompt(t *testing.T) {
+	file := &FileConfig{}
+	file.AI.SystemPrompt = "From the file."
+
+	if got := MergeFileConfig(Config{}, file).SystemPrompt; got != "From the file." {
+		t.Errorf("expected the file's system prompt, got %q", got)
+	}
+	if got := MergeFileConfig(Config{SystemPrompt: "From a flag."}, file).SystemPrompt; got != "From a flag." {
+		t.Errorf("expected the flag to win, got %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTimeout is returned, wrapped, when a request's context deadline passes
+// before the provider finishes responding.
+var ErrTimeout = errors.New("AI request timed out")
+
+// timeoutError marks err as ErrTimeout if ctx's deadline has passed, so
+// callers can tell an expired --timeout from other transport failures.
+// Other errors, including plain cancellation, are returned unchanged.
+func timeoutError(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, ErrTimeout) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrTimeout, err)
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stalledServer accepts requests and never answers, until the test ends.
+func stalledServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	// Cleanups run last-registered first: release handlers, then close
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+	return srv
+}
+
+func TestProviders_Timeout(t *testing.T) {
+	srv := stalledServer(t)
+
+	providers := map[string]func() (Provider, error){
+		"ollama": func() (Provider, error) {
+			return NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+		},
+		"instructlab": func() (Provider, error) {
+			return NewInstructLabProvider(Config{InstructLab: InstructLabConfig{Endpoint: srv.URL}})
+		},
+		"openai": func() (Provider, error) {
+			return NewOpenAIProvider(Config{OpenAI: OpenAIConfig{APIKey: "k", BaseURL: srv.URL}})
+		},
+		"anthropic": func() (Provider, error) {
+			return NewAnthropicProvider(Config{Anthropic: AnthropicConfig{APIKey: "k", BaseURL: srv.URL}})
+		},
+	}
+
+	for name, newProvider := range providers {
+		t.Run(name, func(t *testing.T) {
+			p, err := newProvider()
+			if err != nil {
+				t.Fatalf("creating provider: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err = p.Complete(ctx, "ten rows")
+			if !errors.Is(err, ErrTimeout) {
+				t.Errorf("expected ErrTimeout, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOllamaProvider_CompleteStreamTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"partial"},"done":false}`)
+		w.(http.Flusher).Flush()
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	p, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var out strings.Builder
+	err := p.CompleteStream(ctx, "explain", &out)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	if out.String() != "partial" {
+		t.Errorf("expected the partial stream to be kept, got %q", out.String())
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	boom := errors.New("boom")
+
+	if err := timeoutError(context.Background(), boom); err != boom {
+		t.Errorf("live context: got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := timeoutError(canceled, boom); errors.Is(err, ErrTimeout) {
+		t.Errorf("cancellation is not a timeout: got %v", err)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if err := timeoutError(expired, nil); err != nil {
+		t.Errorf("nil error: got %v", err)
+	}
+	err := timeoutError(expired, boom)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, boom) {
+		t.Errorf("expected ErrTimeout wrapping boom, got %v", err)
+	}
+	if again := timeoutError(expired, err); again != err {
+		t.Errorf("expected no double wrapping, got %v", again)
+	}
+}
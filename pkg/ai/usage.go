@@ -0,0 +1,111 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Usage is the token count of one or more completions.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Total returns the prompt and completion tokens together.
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// String formats the usage for progress output.
+func (u Usage) String() string {
+	return fmt.Sprintf("%d prompt + %d completion = %d tokens", u.PromptTokens, u.CompletionTokens, u.Total())
+}
+
+// UsageReporter is implemented by providers whose APIs report token usage.
+type UsageReporter interface {
+	// LastUsage returns the usage of the most recent completion, and
+	// whether it was reported.
+	LastUsage() (Usage, bool)
+}
+
+// UsageOf returns the usage of p's most recent completion, if p reports it.
+func UsageOf(p Provider) (Usage, bool) {
+	if r, ok := p.(UsageReporter); ok {
+		return r.LastUsage()
+	}
+	return Usage{}, false
+}
+
+// usageRecorder implements UsageReporter for embedding in providers.
+type usageRecorder struct {
+	mu    sync.Mutex
+	usage Usage
+	ok    bool
+}
+
+// record stores the usage of the latest completion; ok is false when the
+// response didn't include any.
+func (r *usageRecorder) record(u Usage, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage, r.ok = u, ok
+}
+
+// LastUsage returns the usage of the most recent completion.
+func (r *usageRecorder) LastUsage() (Usage, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage, r.ok
+}
+
+// usageTally accumulates usage across attempts. Once any attempt lacks
+// usage data, the total is unknown.
+type usageTally struct {
+	total    Usage
+	attempts int
+	unknown  bool
+}
+
+// add records the usage of provider's latest completion, printing it to
+// verbose if set.
+func (t *usageTally) add(provider Provider, verbose io.Writer) {
+	u, ok := UsageOf(provider)
+	t.attempts++
+	if !ok {
+		t.unknown = true
+		if verbose != nil {
+			fmt.Fprintf(verbose, "  Usage unavailable\n")
+		}
+		return
+	}
+	t.total.PromptTokens += u.PromptTokens
+	t.total.CompletionTokens += u.CompletionTokens
+	if verbose != nil {
+		fmt.Fprintf(verbose, "  Usage: %s\n", u)
+	}
+}
+
+// result returns the total, or nil if it is unknown.
+func (t *usageTally) result() *Usage {
+	if t.unknown || t.attempts == 0 {
+		return nil
+	}
+	u := t.total
+	return &u
+}
+
+// report prints the total to verbose, if set.
+func (t *usageTally) report(verbose io.Writer) {
+	if verbose == nil {
+		return
+	}
+	if u := t.result(); u != nil {
+		fmt.Fprintf(verbose, "Total usage: %s\n", u)
+	} else {
+		fmt.Fprintf(verbose, "Total usage: usage unavailable\n")
+	}
+}
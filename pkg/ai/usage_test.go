@@ -0,0 +1,151 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jsonServer answers every request with body.
+func jsonServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProviders_LastUsage(t *testing.T) {
+	openaiBody := `{"choices":[{"message":{"role":"assistant","content":"T"}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`
+
+	tests := []struct {
+		name        string
+		newProvider func(url string) (Provider, error)
+		body        string
+	}{
+		{"openai", func(url string) (Provider, error) {
+			return NewOpenAIProvider(Config{OpenAI: OpenAIConfig{APIKey: "k", BaseURL: url}})
+		}, openaiBody},
+		{"instructlab", func(url string) (Provider, error) {
+			return NewInstructLabProvider(Config{InstructLab: InstructLabConfig{Endpoint: url}})
+		}, openaiBody},
+		{"azure", func(url string) (Provider, error) {
+			return NewAzureProvider(Config{Azure: AzureConfig{Endpoint: url, Deployment: "d", APIKey: "k"}})
+		}, openaiBody},
+		{"anthropic", func(url string) (Provider, error) {
+			return NewAnthropicProvider(Config{Anthropic: AnthropicConfig{APIKey: "k", BaseURL: url}})
+		}, `{"content":[{"type":"text","text":"T"}],"usage":{"input_tokens":12,"output_tokens":3}}`},
+		{"ollama", func(url string) (Provider, error) {
+			return NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: url}})
+		}, `{"message":{"role":"assistant","content":"T"},"done":true,"prompt_eval_count":12,"eval_count":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.newProvider(jsonServer(t, tt.body).URL)
+			if err != nil {
+				t.Fatalf("creating provider: %v", err)
+			}
+			if _, ok := UsageOf(p); ok {
+				t.Error("expected no usage before a completion")
+			}
+			if _, err := p.Complete(context.Background(), "q"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			u, ok := UsageOf(p)
+			if !ok || u != (Usage{PromptTokens: 12, CompletionTokens: 3}) {
+				t.Errorf("got %+v (ok=%v)", u, ok)
+			}
+		})
+	}
+}
+
+func TestOpenAIProvider_UsageMissing(t *testing.T) {
+	srv := jsonServer(t, `{"choices":[{"message":{"role":"assistant","content":"T"}}]}`)
+	p, _ := NewOpenAIProvider(Config{OpenAI: OpenAIConfig{APIKey: "k", BaseURL: srv.URL}})
+	if _, err := p.Complete(context.Background(), "q"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.LastUsage(); ok {
+		t.Error("expected no usage when the response omits it")
+	}
+}
+
+// usageScriptedProvider is a scriptedProvider whose completions each use
+// 100 prompt and 10 completion tokens.
+type usageScriptedProvider struct {
+	scriptedProvider
+}
+
+func (p *usageScriptedProvider) LastUsage() (Usage, bool) {
+	return Usage{PromptTokens: 100, CompletionTokens: 10}, p.calls > 0
+}
+
+func TestGenerateWithValidation_Usage(t *testing.T) {
+	fc := useFakeClock(t)
+	p := &usageScriptedProvider{scriptedProvider{validFrom: 3, clock: fc}}
+
+	var verbose bytes.Buffer
+	result, err := GenerateWithValidation(context.Background(), p, GenerateRequest{Prompt: "count rows"}, DefaultValidationConfig(), 0.2,
+		func(r GenerateRequest) string { return r.Prompt },
+		func(s string) string { return s },
+		&verbose, nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Usage == nil || *result.Usage != (Usage{PromptTokens: 300, CompletionTokens: 30}) {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+	out := verbose.String()
+	if strings.Count(out, "Usage: 100 prompt + 10 completion = 110 tokens") != 3 {
+		t.Errorf("expected per-attempt usage, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Total usage: 300 prompt + 30 completion = 330 tokens") {
+		t.Errorf("expected total usage, got:\n%s", out)
+	}
+}
+
+func TestGenerateWithValidation_UsageUnavailable(t *testing.T) {
+	fc := useFakeClock(t)
+	p := &scriptedProvider{validFrom: 1, clock: fc}
+
+	var verbose bytes.Buffer
+	result, err := GenerateWithValidation(context.Background(), p, GenerateRequest{Prompt: "count rows"}, DefaultValidationConfig(), 0.2,
+		func(r GenerateRequest) string { return r.Prompt },
+		func(s string) string { return s },
+		&verbose, nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Usage != nil {
+		t.Errorf("expected no usage, got %+v", result.Usage)
+	}
+	if !strings.Contains(verbose.String(), "Total usage: usage unavailable") {
+		t.Errorf("got:\n%s", verbose.String())
+	}
+}
+
+func TestCachingProvider_LastUsage(t *testing.T) {
+	srv := jsonServer(t, `{"message":{"role":"assistant","content":"T"},"done":true,"prompt_eval_count":12,"eval_count":3}`)
+	inner, _ := NewOllamaProvider(Config{Ollama: OllamaConfig{Endpoint: srv.URL}})
+	p := NewCachingProvider(inner, &ResponseCache{Dir: t.TempDir()}, 0)
+
+	for i, want := range []Usage{{12, 3}, {}} {
+		if _, err := p.Complete(context.Background(), "q"); err != nil {
+			t.Fatal(err)
+		}
+		if u, ok := p.LastUsage(); !ok || u != want {
+			t.Errorf("call %d: got %+v (ok=%v), want %+v", i+1, u, ok, want)
+		}
+	}
+}
@@ -51,7 +51,11 @@ func NewVertexProvider(cfg Config) (*VertexProvider, error) {
 	}
 
 	// Create the actual client
-	client, err := newVertexGenAIClient(context.Background(), project, location, model)
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("vertex: %w", err)
+	}
+	client, err := newVertexGenAIClient(context.Background(), project, location, model, httpClient, cfg.HTTPRetry)
 	if err != nil {
 		return nil, fmt.Errorf("vertex: creating client: %w", err)
 	}
@@ -77,7 +81,8 @@ func (p *VertexProvider) Model() string {
 
 // Complete sends a prompt and returns the response.
 func (p *VertexProvider) Complete(ctx context.Context, prompt string) (string, error) {
-	return p.client.GenerateContent(ctx, prompt, p.temperature)
+	text, err := p.client.GenerateContent(ctx, prompt, p.temperature)
+	return text, timeoutError(ctx, err)
 }
 
 // CompleteChat sends a chat conversation and returns the response.
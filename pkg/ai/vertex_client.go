@@ -7,33 +7,53 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
+// errVertexUnauthorized is returned when Vertex AI rejects the access token.
+var errVertexUnauthorized = errors.New("vertex returned status 401")
+
 // vertexGenAIClient uses the Vertex AI REST API with gcloud auth.
 type vertexGenAIClient struct {
 	project   string
 	location  string
 	modelName string
 	client    *http.Client
+	retry     HTTPRetryConfig
+
+	// baseURL overrides the regional endpoint (used in tests)
+	baseURL string
+
+	// tokenSource fetches a fresh access token (default: gcloud)
+	tokenSource func() (string, error)
+
+	mu    sync.Mutex
+	token string
 }
 
 // newVertexGenAIClient creates a new Vertex AI client.
-func newVertexGenAIClient(ctx context.Context, project, location, modelName string) (*vertexGenAIClient, error) {
+func newVertexGenAIClient(ctx context.Context, project, location, modelName string, client *http.Client, retry HTTPRetryConfig) (*vertexGenAIClient, error) {
 	return &vertexGenAIClient{
-		project:   project,
-		location:  location,
-		modelName: modelName,
-		client:    &http.Client{},
+		project:     project,
+		location:    location,
+		modelName:   modelName,
+		client:      client,
+		retry:       retry,
+		tokenSource: gcloudAccessToken,
 	}, nil
 }
 
-// getAccessToken retrieves an access token using gcloud.
-func (c *vertexGenAIClient) getAccessToken() (string, error) {
+// gcloudAccessToken retrieves an access token using gcloud.
+func gcloudAccessToken() (string, error) {
+	if _, err := exec.LookPath("gcloud"); err != nil {
+		return "", fmt.Errorf("gcloud not found in PATH (install the Google Cloud CLI and run 'gcloud auth login')")
+	}
 	cmd := exec.Command("gcloud", "auth", "print-access-token")
 	out, err := cmd.Output()
 	if err != nil {
@@ -42,13 +62,64 @@ func (c *vertexGenAIClient) getAccessToken() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// getAccessToken returns the cached access token, fetching one if needed.
+func (c *vertexGenAIClient) getAccessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	token, err := c.tokenSource()
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	return token, nil
+}
+
+// invalidateToken drops the cached access token so the next call refreshes it.
+func (c *vertexGenAIClient) invalidateToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// endpoint returns the base URL for Vertex AI requests.
+func (c *vertexGenAIClient) endpoint() string {
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", c.location)
+}
+
 // GenerateContent generates content using the Vertex AI model.
+//
+// If the request is rejected with 401 (e.g. the cached token expired
+// mid-run), the token is refreshed once and the request retried.
 func (c *vertexGenAIClient) GenerateContent(ctx context.Context, prompt string, temp float32) (string, error) {
 	token, err := c.getAccessToken()
 	if err != nil {
 		return "", err
 	}
 
+	text, err := c.generate(ctx, token, prompt, temp)
+	if !errors.Is(err, errVertexUnauthorized) {
+		return text, err
+	}
+
+	c.invalidateToken()
+	token, err = c.getAccessToken()
+	if err != nil {
+		return "", fmt.Errorf("refreshing access token after 401: %w", err)
+	}
+
+	return c.generate(ctx, token, prompt, temp)
+}
+
+// generate dispatches to the API format for the configured model.
+func (c *vertexGenAIClient) generate(ctx context.Context, token, prompt string, temp float32) (string, error) {
 	// Detect Claude models (use Anthropic API format on Vertex)
 	if c.isClaude() {
 		return c.generateClaudeContent(ctx, token, prompt, temp)
@@ -65,8 +136,8 @@ func (c *vertexGenAIClient) isClaude() bool {
 // generateGeminiContent uses the Gemini/PaLM API format.
 func (c *vertexGenAIClient) generateGeminiContent(ctx context.Context, token, prompt string, temp float32) (string, error) {
 	url := fmt.Sprintf(
-		"https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/google/models/%s:generateContent",
-		c.location, c.project, c.location, c.modelName,
+		"%s/v1/projects/%s/locations/%s/publishers/google/models/%s:generateContent",
+		c.endpoint(), c.project, c.location, c.modelName,
 	)
 
 	reqBody := vertexRequest{
@@ -86,20 +157,24 @@ func (c *vertexGenAIClient) generateGeminiContent(ctx context.Context, token, pr
 		return "", fmt.Errorf("marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := c.client.Do(req)
+	resp, err := doWithRetry(ctx, c.client, c.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("sending request to vertex: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%w: %s", errVertexUnauthorized, string(respBody))
+	}
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("vertex returned status %d: %s", resp.StatusCode, string(respBody))
@@ -126,8 +201,8 @@ func (c *vertexGenAIClient) generateGeminiContent(ctx context.Context, token, pr
 func (c *vertexGenAIClient) generateClaudeContent(ctx context.Context, token, prompt string, temp float32) (string, error) {
 	// Claude on Vertex uses the Anthropic publisher endpoint
 	url := fmt.Sprintf(
-		"https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/anthropic/models/%s:rawPredict",
-		c.location, c.project, c.location, c.modelName,
+		"%s/v1/projects/%s/locations/%s/publishers/anthropic/models/%s:rawPredict",
+		c.endpoint(), c.project, c.location, c.modelName,
 	)
 
 	reqBody := claudeRequest{
@@ -145,20 +220,24 @@ func (c *vertexGenAIClient) generateClaudeContent(ctx context.Context, token, pr
 		return "", fmt.Errorf("marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := c.client.Do(req)
+	resp, err := doWithRetry(ctx, c.client, c.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("sending request to vertex: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%w: %s", errVertexUnauthorized, string(respBody))
+	}
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("vertex (claude) returned status %d: %s", resp.StatusCode, string(respBody))
@@ -209,10 +288,13 @@ type vertexCandidate struct {
 	Content vertexContent `json:"content"`
 }
 
-// Claude API types (for Vertex AI Anthropic endpoint)
+// Claude Messages API types (for Vertex AI and the Anthropic API)
 
 type claudeRequest struct {
-	AnthropicVersion string          `json:"anthropic_version"`
+	// AnthropicVersion is set in the body on Vertex only; the Anthropic
+	// API takes it as a header
+	AnthropicVersion string          `json:"anthropic_version,omitempty"`
+	System           string          `json:"system,omitempty"`
 	Messages         []claudeMessage `json:"messages"`
 	MaxTokens        int             `json:"max_tokens"`
 	Temperature      float32         `json:"temperature,omitempty"`
@@ -225,6 +307,20 @@ type claudeMessage struct {
 
 type claudeResponse struct {
 	Content []claudeContentBlock `json:"content"`
+	Usage   *claudeUsage         `json:"usage,omitempty"`
+}
+
+type claudeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// value converts u for a usageRecorder; a nil u was not reported.
+func (u *claudeUsage) value() (Usage, bool) {
+	if u == nil {
+		return Usage{}, false
+	}
+	return Usage{PromptTokens: u.InputTokens, CompletionTokens: u.OutputTokens}, true
 }
 
 type claudeContentBlock struct {
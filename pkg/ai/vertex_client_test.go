@@ -0,0 +1,119 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestVertexClient returns a client pointed at the given server whose
+// token source hands out the given tokens in order.
+func newTestVertexClient(serverURL, model string, tokens ...string) (*vertexGenAIClient, *int) {
+	fetches := 0
+	c := &vertexGenAIClient{
+		project:   "test-project",
+		location:  "us-east5",
+		modelName: model,
+		client:    &http.Client{},
+		baseURL:   serverURL,
+		tokenSource: func() (string, error) {
+			if fetches >= len(tokens) {
+				return "", errors.New("no more tokens")
+			}
+			tok := tokens[fetches]
+			fetches++
+			return tok, nil
+		},
+	}
+	return c, &fetches
+}
+
+func TestVertexClient_RetriesAfter401(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"token expired"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(vertexResponse{
+			Candidates: []vertexCandidate{{
+				Content: vertexContent{Parts: []vertexPart{{Text: "T | take 10"}}},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	c, fetches := newTestVertexClient(srv.URL, "gemini-1.5-pro", "stale", "fresh")
+
+	got, err := c.GenerateContent(context.Background(), "prompt", 0.2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "T | take 10" {
+		t.Errorf("expected 'T | take 10', got %q", got)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+	if *fetches != 2 {
+		t.Errorf("expected 2 token fetches, got %d", *fetches)
+	}
+
+	// The refreshed token is cached for subsequent calls
+	if _, err := c.GenerateContent(context.Background(), "prompt", 0.2); err != nil {
+		t.Fatalf("unexpected error on second call: %v", err)
+	}
+	if *fetches != 2 {
+		t.Errorf("expected cached token to be reused, got %d fetches", *fetches)
+	}
+}
+
+func TestVertexClient_ClaudeRetriesAfter401(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(claudeResponse{
+			Content: []claudeContentBlock{{Type: "text", Text: "T | count"}},
+		})
+	}))
+	defer srv.Close()
+
+	c, _ := newTestVertexClient(srv.URL, "claude-opus-4-5", "stale", "fresh")
+
+	got, err := c.GenerateContent(context.Background(), "prompt", 0.2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "T | count" {
+		t.Errorf("expected 'T | count', got %q", got)
+	}
+}
+
+func TestVertexClient_GivesUpAfterSecond401(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c, _ := newTestVertexClient(srv.URL, "gemini-1.5-pro", "stale", "also-stale")
+
+	_, err := c.GenerateContent(context.Background(), "prompt", 0.2)
+	if !errors.Is(err, errVertexUnauthorized) {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected exactly one retry (2 requests), got %d", calls)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inputsource resolves command input from positional arguments,
+// files, the clipboard, an editor, or standard input.
+//
+// Every kql command that accepts a query or description reads it through
+// Read, so the priority order and TTY handling are defined in one place.
+package inputsource
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Source identifies where input was read from.
+type Source string
+
+const (
+	SourceArgs      Source = "args"
+	SourceFile      Source = "file"
+	SourceStdin     Source = "stdin"
+	SourceClipboard Source = "clipboard"
+	SourceEditor    Source = "editor"
+)
+
+// StdinSentinel is the file name that explicitly selects standard input.
+const StdinSentinel = "-"
+
+// ErrNoInput is returned when no source provided any input.
+var ErrNoInput = errors.New("no input provided (use -f <file>, stdin, --paste, --editor, or pass query as argument)")
+
+// Spec describes the input sources requested by a command.
+type Spec struct {
+	// Args are the positional arguments, joined with spaces
+	Args []string
+
+	// File is the path given with -f/--file ("-" reads stdin)
+	File string
+
+	// Clipboard reads input from the system clipboard
+	Clipboard bool
+
+	// Editor opens $VISUAL/$EDITOR to compose input
+	Editor bool
+}
+
+// Env provides the process resources used to read input.
+// Nil fields fall back to the real implementation.
+type Env struct {
+	// Stdin is the standard input stream (default: os.Stdin)
+	Stdin io.Reader
+
+	// IsTerminal reports whether stdin is interactive (default: IsTerminal)
+	IsTerminal func(*os.File) bool
+
+	// ReadFile reads a file (default: os.ReadFile)
+	ReadFile func(string) ([]byte, error)
+
+	// ReadClipboard returns the clipboard contents (default: ReadClipboard)
+	ReadClipboard func() (string, error)
+
+	// Edit opens an editor and returns what was written (default: Edit)
+	Edit func() (string, error)
+}
+
+// Read returns the trimmed input text and the source it came from.
+//
+// Sources are tried in priority order:
+//  1. Positional arguments
+//  2. File (-f); "-" reads stdin, even when it is a terminal
+//  3. Clipboard (--clipboard)
+//  4. Editor (--editor)
+//  5. Stdin, only when it is not a terminal
+func Read(spec Spec, env Env) (string, Source, error) {
+	env = env.withDefaults()
+
+	// Priority 1: positional argument
+	if len(spec.Args) > 0 {
+		return strings.TrimSpace(strings.Join(spec.Args, " ")), SourceArgs, nil
+	}
+
+	// Priority 2: file ("-" is stdin, explicitly requested)
+	if spec.File == StdinSentinel {
+		input, err := readStdin(env.Stdin)
+		return input, SourceStdin, err
+	}
+	if spec.File != "" {
+		data, err := env.ReadFile(spec.File)
+		if err != nil {
+			return "", SourceFile, fmt.Errorf("reading file: %w", err)
+		}
+		result := strings.TrimSpace(string(data))
+		if result == "" {
+			return "", SourceFile, fmt.Errorf("file is empty: %s", spec.File)
+		}
+		return result, SourceFile, nil
+	}
+
+	// Priority 3: clipboard
+	if spec.Clipboard {
+		text, err := env.ReadClipboard()
+		if err != nil {
+			return "", SourceClipboard, fmt.Errorf("reading clipboard: %w", err)
+		}
+		result := strings.TrimSpace(text)
+		if result == "" {
+			return "", SourceClipboard, fmt.Errorf("clipboard is empty")
+		}
+		return result, SourceClipboard, nil
+	}
+
+	// Priority 4: editor
+	if spec.Editor {
+		text, err := env.Edit()
+		if err != nil {
+			return "", SourceEditor, fmt.Errorf("reading from editor: %w", err)
+		}
+		result := strings.TrimSpace(text)
+		if result == "" {
+			return "", SourceEditor, fmt.Errorf("empty input from editor")
+		}
+		return result, SourceEditor, nil
+	}
+
+	// Priority 5: stdin (only if not a terminal)
+	if f, ok := env.Stdin.(*os.File); ok {
+		if env.IsTerminal(f) {
+			return "", "", ErrNoInput
+		}
+	}
+
+	input, err := readStdin(env.Stdin)
+	return input, SourceStdin, err
+}
+
+func readStdin(stdin io.Reader) (string, error) {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading stdin: %w", err)
+	}
+
+	result := strings.TrimSpace(string(data))
+	if result == "" {
+		return "", fmt.Errorf("empty input from stdin")
+	}
+
+	return result, nil
+}
+
+func (e Env) withDefaults() Env {
+	if e.Stdin == nil {
+		e.Stdin = os.Stdin
+	}
+	if e.IsTerminal == nil {
+		e.IsTerminal = IsTerminal
+	}
+	if e.ReadFile == nil {
+		e.ReadFile = os.ReadFile
+	}
+	if e.ReadClipboard == nil {
+		e.ReadClipboard = ReadClipboard
+	}
+	if e.Edit == nil {
+		e.Edit = Edit
+	}
+	return e
+}
+
+// IsTerminal checks if the given file is a terminal.
+func IsTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
@@ -0,0 +1,340 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package inputsource
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeEnv builds an Env whose sources return fixed values.
+type fakeEnv struct {
+	stdin     string
+	stdinErr  bool
+	terminal  bool
+	clipboard string
+	clipErr   bool
+	editor    string
+	editorErr bool
+}
+
+func (f fakeEnv) env(t *testing.T) Env {
+	t.Helper()
+
+	var stdin io.Reader = strings.NewReader(f.stdin)
+	if f.stdinErr {
+		stdin = errorReader{}
+	}
+	if f.terminal {
+		// IsTerminal is only consulted for *os.File stdin
+		stdin = os.Stdin
+	}
+
+	return Env{
+		Stdin:      stdin,
+		IsTerminal: func(*os.File) bool { return f.terminal },
+		ReadClipboard: func() (string, error) {
+			if f.clipErr {
+				return "", errors.New("no clipboard")
+			}
+			return f.clipboard, nil
+		},
+		Edit: func() (string, error) {
+			if f.editorErr {
+				return "", errors.New("editor failed")
+			}
+			return f.editor, nil
+		},
+	}
+}
+
+type errorReader struct{}
+
+func (errorReader) Read(p []byte) (int, error) {
+	return 0, os.ErrPermission
+}
+
+func TestRead_Matrix(t *testing.T) {
+	dir := t.TempDir()
+	queryFile := filepath.Join(dir, "query.kql")
+	if err := os.WriteFile(queryFile, []byte("  T | take 10  \n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	emptyFile := filepath.Join(dir, "empty.kql")
+	if err := os.WriteFile(emptyFile, []byte("   \n  "), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	missingFile := filepath.Join(dir, "missing.kql")
+
+	tests := []struct {
+		name       string
+		spec       Spec
+		env        fakeEnv
+		want       string
+		wantSource Source
+		wantErr    string
+	}{
+		// Positional arguments
+		{
+			name:       "args only",
+			spec:       Spec{Args: []string{"T", "|", "take", "1"}},
+			want:       "T | take 1",
+			wantSource: SourceArgs,
+		},
+		{
+			name:       "args trimmed",
+			spec:       Spec{Args: []string{"  T | count  "}},
+			want:       "T | count",
+			wantSource: SourceArgs,
+		},
+		{
+			name:       "args beat file",
+			spec:       Spec{Args: []string{"from args"}, File: queryFile},
+			want:       "from args",
+			wantSource: SourceArgs,
+		},
+		{
+			name:       "args beat clipboard and editor",
+			spec:       Spec{Args: []string{"from args"}, Clipboard: true, Editor: true},
+			env:        fakeEnv{clipboard: "from clipboard", editor: "from editor"},
+			want:       "from args",
+			wantSource: SourceArgs,
+		},
+		{
+			name:       "args beat stdin",
+			spec:       Spec{Args: []string{"from args"}},
+			env:        fakeEnv{stdin: "from stdin"},
+			want:       "from args",
+			wantSource: SourceArgs,
+		},
+
+		// File
+		{
+			name:       "file only",
+			spec:       Spec{File: queryFile},
+			want:       "T | take 10",
+			wantSource: SourceFile,
+		},
+		{
+			name:       "file beats clipboard",
+			spec:       Spec{File: queryFile, Clipboard: true},
+			env:        fakeEnv{clipboard: "from clipboard"},
+			want:       "T | take 10",
+			wantSource: SourceFile,
+		},
+		{
+			name:       "file beats stdin",
+			spec:       Spec{File: queryFile},
+			env:        fakeEnv{stdin: "from stdin"},
+			want:       "T | take 10",
+			wantSource: SourceFile,
+		},
+		{
+			name:    "empty file",
+			spec:    Spec{File: emptyFile},
+			wantErr: "file is empty",
+		},
+		{
+			name:    "missing file",
+			spec:    Spec{File: missingFile},
+			wantErr: "reading file",
+		},
+
+		// Stdin sentinel
+		{
+			name:       "dash reads stdin",
+			spec:       Spec{File: "-"},
+			env:        fakeEnv{stdin: "from stdin\n"},
+			want:       "from stdin",
+			wantSource: SourceStdin,
+		},
+		{
+			name:       "dash beats clipboard",
+			spec:       Spec{File: "-", Clipboard: true},
+			env:        fakeEnv{stdin: "from stdin", clipboard: "from clipboard"},
+			want:       "from stdin",
+			wantSource: SourceStdin,
+		},
+		{
+			name:    "dash with empty stdin",
+			spec:    Spec{File: "-"},
+			env:     fakeEnv{stdin: "  \n"},
+			wantErr: "empty input from stdin",
+		},
+		{
+			name:    "dash with stdin read error",
+			spec:    Spec{File: "-"},
+			env:     fakeEnv{stdinErr: true},
+			wantErr: "reading stdin",
+		},
+
+		// Clipboard
+		{
+			name:       "clipboard only",
+			spec:       Spec{Clipboard: true},
+			env:        fakeEnv{clipboard: " T | take 5 \n"},
+			want:       "T | take 5",
+			wantSource: SourceClipboard,
+		},
+		{
+			name:       "clipboard beats editor",
+			spec:       Spec{Clipboard: true, Editor: true},
+			env:        fakeEnv{clipboard: "from clipboard", editor: "from editor"},
+			want:       "from clipboard",
+			wantSource: SourceClipboard,
+		},
+		{
+			name:       "clipboard beats stdin",
+			spec:       Spec{Clipboard: true},
+			env:        fakeEnv{clipboard: "from clipboard", stdin: "from stdin"},
+			want:       "from clipboard",
+			wantSource: SourceClipboard,
+		},
+		{
+			name:    "empty clipboard",
+			spec:    Spec{Clipboard: true},
+			env:     fakeEnv{clipboard: "  "},
+			wantErr: "clipboard is empty",
+		},
+		{
+			name:    "clipboard error",
+			spec:    Spec{Clipboard: true},
+			env:     fakeEnv{clipErr: true},
+			wantErr: "reading clipboard",
+		},
+
+		// Editor
+		{
+			name:       "editor only",
+			spec:       Spec{Editor: true},
+			env:        fakeEnv{editor: "T | take 3\n"},
+			want:       "T | take 3",
+			wantSource: SourceEditor,
+		},
+		{
+			name:       "editor beats stdin",
+			spec:       Spec{Editor: true},
+			env:        fakeEnv{editor: "from editor", stdin: "from stdin"},
+			want:       "from editor",
+			wantSource: SourceEditor,
+		},
+		{
+			name:    "empty editor buffer",
+			spec:    Spec{Editor: true},
+			env:     fakeEnv{editor: "\n\n"},
+			wantErr: "empty input from editor",
+		},
+		{
+			name:    "editor error",
+			spec:    Spec{Editor: true},
+			env:     fakeEnv{editorErr: true},
+			wantErr: "reading from editor",
+		},
+
+		// Stdin fallback
+		{
+			name:       "stdin only",
+			env:        fakeEnv{stdin: "query from stdin"},
+			want:       "query from stdin",
+			wantSource: SourceStdin,
+		},
+		{
+			name:    "empty stdin",
+			env:     fakeEnv{stdin: "   \n  "},
+			wantErr: "empty input from stdin",
+		},
+		{
+			name:    "stdin read error",
+			env:     fakeEnv{stdinErr: true},
+			wantErr: "reading stdin",
+		},
+		{
+			name:    "terminal with no input",
+			env:     fakeEnv{terminal: true},
+			wantErr: "no input provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source, err := Read(tt.spec, tt.env.env(t))
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %q", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if source != tt.wantSource {
+				t.Errorf("expected source %q, got %q", tt.wantSource, source)
+			}
+		})
+	}
+}
+
+func TestRead_TerminalReturnsErrNoInput(t *testing.T) {
+	_, _, err := Read(Spec{}, fakeEnv{terminal: true}.env(t))
+	if !errors.Is(err, ErrNoInput) {
+		t.Errorf("expected ErrNoInput, got %v", err)
+	}
+}
+
+func TestRead_DashIgnoresTerminal(t *testing.T) {
+	// "-" explicitly requests stdin, so the TTY check must not apply
+	env := Env{
+		Stdin:      strings.NewReader("typed at terminal"),
+		IsTerminal: func(*os.File) bool { return true },
+	}
+	got, _, err := Read(Spec{File: StdinSentinel}, env)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "typed at terminal" {
+		t.Errorf("expected 'typed at terminal', got %q", got)
+	}
+}
+
+func TestClipboardTools(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	wayland := func(k string) string {
+		if k == "WAYLAND_DISPLAY" {
+			return "wayland-0"
+		}
+		return ""
+	}
+
+	first := func(tools []clipboardTool) (string, string) {
+		return strings.Join(tools[0].paste, " "), strings.Join(tools[0].copy, " ")
+	}
+	tests := []struct {
+		goos                string
+		getenv              func(string) string
+		wantPaste, wantCopy string
+		wantTools           int
+	}{
+		{"darwin", noEnv, "pbpaste", "pbcopy", 1},
+		{"windows", noEnv, "powershell -NoProfile -Command Get-Clipboard", "clip", 1},
+		{"linux", noEnv, "xclip -selection clipboard -o", "xclip -selection clipboard", 2},
+		{"linux", wayland, "wl-paste --no-newline", "wl-copy", 3},
+	}
+	for _, tt := range tests {
+		tools := clipboardTools(tt.goos, tt.getenv)
+		paste, copy := first(tools)
+		if paste != tt.wantPaste || copy != tt.wantCopy || len(tools) != tt.wantTools {
+			t.Errorf("%s: got %q and %q of %d tools, want %q and %q of %d", tt.goos, paste, copy, len(tools), tt.wantPaste, tt.wantCopy, tt.wantTools)
+		}
+	}
+}
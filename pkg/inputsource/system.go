@@ -0,0 +1,113 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package inputsource
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardTool is a clipboard utility's paste and copy commands.
+type clipboardTool struct {
+	paste, copy []string
+}
+
+// clipboardTools lists the clipboard utilities for goos, in order of
+// preference. On Wayland, wl-paste and wl-copy are tried first.
+func clipboardTools(goos string, getenv func(string) string) []clipboardTool {
+	switch goos {
+	case "darwin":
+		return []clipboardTool{{paste: []string{"pbpaste"}, copy: []string{"pbcopy"}}}
+	case "windows":
+		return []clipboardTool{{paste: []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}, copy: []string{"clip"}}}
+	}
+	tools := []clipboardTool{
+		{paste: []string{"xclip", "-selection", "clipboard", "-o"}, copy: []string{"xclip", "-selection", "clipboard"}},
+		{paste: []string{"xsel", "--clipboard", "--output"}, copy: []string{"xsel", "--clipboard", "--input"}},
+	}
+	if getenv("WAYLAND_DISPLAY") != "" {
+		tools = append([]clipboardTool{{paste: []string{"wl-paste", "--no-newline"}, copy: []string{"wl-copy"}}}, tools...)
+	}
+	return tools
+}
+
+// clipboardCommand returns the first installed command picked from the
+// platform's clipboard tools.
+func clipboardCommand(pick func(clipboardTool) []string) (*exec.Cmd, error) {
+	for _, tool := range clipboardTools(runtime.GOOS, os.Getenv) {
+		args := pick(tool)
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		return exec.Command(args[0], args[1:]...), nil
+	}
+	return nil, fmt.Errorf("no clipboard utility found for %s", runtime.GOOS)
+}
+
+// ReadClipboard returns the system clipboard contents using the platform's
+// paste utility (pbpaste, wl-paste, xclip, xsel, or PowerShell).
+func ReadClipboard() (string, error) {
+	cmd, err := clipboardCommand(func(t clipboardTool) []string { return t.paste })
+	if err != nil {
+		return "", err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return string(out), nil
+}
+
+// WriteClipboard copies text to the system clipboard using the platform's
+// copy utility (pbcopy, wl-copy, xclip, xsel, or clip).
+func WriteClipboard(text string) error {
+	cmd, err := clipboardCommand(func(t clipboardTool) []string { return t.copy })
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// Edit opens $VISUAL or $EDITOR (default: vi) on a temporary .kql file
+// and returns its contents once the editor exits.
+func Edit() (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "kql-*.kql")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	// EDITOR may include arguments (e.g. "code --wait")
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running %s: %w", parts[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading temp file: %w", err)
+	}
+	return string(data), nil
+}
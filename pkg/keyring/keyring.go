@@ -0,0 +1,121 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+// Package keyring stores and retrieves secrets, such as provider API keys,
+// in the operating system's credential store.
+//
+// Secrets are kept under the "kql" service, one per name. Configuration
+// values of the form "keyring:<name>" refer to them, so API keys need not
+// be written to the config file in plaintext.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service is the keyring service that kql secrets are stored under.
+const Service = "kql"
+
+// Prefix marks a configuration value as a reference to a keyring secret.
+const Prefix = "keyring:"
+
+// ErrNotFound is returned when the keyring has no secret by that name.
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// runFunc runs a command with the given standard input and returns its
+// standard output.
+type runFunc func(stdin, name string, args ...string) (string, error)
+
+// run executes keyring commands. Tests replace it with a fake.
+var run runFunc = runCommand
+
+// goos selects the platform's keyring commands. Tests replace it.
+var goos = runtime.GOOS
+
+func runCommand(stdin, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("keyring: %s not found (needed for the %s keyring)", name, goos)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// IsRef reports whether value refers to a keyring secret.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Resolve returns value itself, or for a "keyring:<name>" reference, the
+// secret stored under name.
+func Resolve(value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+	name := strings.TrimPrefix(value, Prefix)
+	if name == "" {
+		return "", fmt.Errorf("keyring: empty name in %q", value)
+	}
+	return Get(name)
+}
+
+// Get returns the secret stored under name: with the security tool on
+// macOS, or secret-tool (libsecret) on Linux.
+func Get(name string) (string, error) {
+	var out string
+	var err error
+	switch goos {
+	case "darwin":
+		out, err = run("", "security", "find-generic-password", "-s", Service, "-a", name, "-w")
+	case "linux":
+		out, err = run("", "secret-tool", "lookup", "service", Service, "username", name)
+	default:
+		return "", fmt.Errorf("keyring: not supported on %s", goos)
+	}
+
+	// Both tools exit non-zero when no secret matches
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.TrimRight(out, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return secret, nil
+}
+
+// Set stores secret under name, replacing any previous value. The secret
+// is passed on standard input, never as a command-line argument.
+func Set(name, secret string) error {
+	var err error
+	switch goos {
+	case "darwin":
+		// security -i reads commands from stdin, keeping the secret out of
+		// the process list
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(Service), quote(name), quote(secret))
+		_, err = run(command, "security", "-i")
+	case "linux":
+		_, err = run(secret, "secret-tool", "store", "--label", "kql API key ("+name+")", "service", Service, "username", name)
+	default:
+		return fmt.Errorf("keyring: not supported on %s", goos)
+	}
+	if err != nil {
+		return fmt.Errorf("keyring: storing %s: %w", name, err)
+	}
+	return nil
+}
+
+// quote single-quotes s for the security tool's interactive parser.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
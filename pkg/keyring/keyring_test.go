@@ -0,0 +1,114 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package keyring
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring records commands and answers lookups from a map.
+type fakeKeyring struct {
+	secrets map[string]string
+	calls   []string
+	stdin   []string
+}
+
+func (f *fakeKeyring) run(stdin, name string, args ...string) (string, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	f.stdin = append(f.stdin, stdin)
+	if len(args) > 0 && (args[0] == "lookup" || args[0] == "find-generic-password") {
+		user := args[len(args)-1]
+		if args[0] == "find-generic-password" {
+			user = args[len(args)-2]
+		}
+		secret, ok := f.secrets[user]
+		if !ok {
+			return "", &exec.ExitError{}
+		}
+		return secret + "\n", nil
+	}
+	return "", nil
+}
+
+func useFakeKeyring(t *testing.T, platform string, secrets map[string]string) *fakeKeyring {
+	t.Helper()
+	f := &fakeKeyring{secrets: secrets}
+	origRun, origGOOS := run, goos
+	run, goos = f.run, platform
+	t.Cleanup(func() { run, goos = origRun, origGOOS })
+	return f
+}
+
+func TestResolve(t *testing.T) {
+	useFakeKeyring(t, "linux", map[string]string{"azure": "s3cret"})
+
+	if got, err := Resolve("plain-key"); err != nil || got != "plain-key" {
+		t.Errorf("plaintext: got %q, %v", got, err)
+	}
+	if got, err := Resolve("keyring:azure"); err != nil || got != "s3cret" {
+		t.Errorf("reference: got %q, %v", got, err)
+	}
+	if _, err := Resolve("keyring:openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing: expected ErrNotFound, got %v", err)
+	}
+	if _, err := Resolve("keyring:"); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
+
+func TestGet_Platforms(t *testing.T) {
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"darwin", "security find-generic-password -s kql -a azure -w"},
+		{"linux", "secret-tool lookup service kql username azure"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			f := useFakeKeyring(t, tt.goos, map[string]string{"azure": "s3cret"})
+			got, err := Get("azure")
+			if err != nil || got != "s3cret" {
+				t.Errorf("got %q, %v", got, err)
+			}
+			if len(f.calls) != 1 || f.calls[0] != tt.want {
+				t.Errorf("calls = %q, want %q", f.calls, tt.want)
+			}
+		})
+	}
+
+	useFakeKeyring(t, "plan9", nil)
+	if _, err := Get("azure"); err == nil {
+		t.Error("expected an error on an unsupported platform")
+	}
+}
+
+func TestSet_SecretNotInArgs(t *testing.T) {
+	for _, platform := range []string{"darwin", "linux"} {
+		t.Run(platform, func(t *testing.T) {
+			f := useFakeKeyring(t, platform, nil)
+			if err := Set("openai", "sk-it's"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(f.calls) != 1 {
+				t.Fatalf("calls = %q", f.calls)
+			}
+			if strings.Contains(f.calls[0], "sk-it") {
+				t.Errorf("secret leaked into arguments: %q", f.calls[0])
+			}
+			if !strings.Contains(f.stdin[0], "sk-it") {
+				t.Errorf("expected the secret on stdin, got %q", f.stdin[0])
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := quote("it's"); got != `'it'"'"'s'` {
+		t.Errorf("quote = %s", got)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff returns a line diff from a to b, or "" if they are equal. Lines only
+// in a are prefixed with "- ", lines only in b with "+ ", and shared lines
+// with two spaces. It is meant for short texts such as normalized queries.
+func Diff(a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, l := range diffLines(a, b) {
+		sb.WriteString(string(l.op) + " " + l.text + "\n")
+	}
+	return sb.String()
+}
+
+// unifiedContext is the number of unchanged lines around each hunk of a
+// unified diff.
+const unifiedContext = 3
+
+// UnifiedDiff returns a unified diff from a to b, as printed by diff -u,
+// with aName and bName in the header. It returns "" if they are equal.
+func UnifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	lines := diffLines(a, b)
+
+	// A line belongs to a hunk if it is within unifiedContext lines of a
+	// change; hunks whose context would overlap merge
+	inHunk := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := max(i-unifiedContext, 0); k <= min(i+unifiedContext, len(lines)-1); k++ {
+			inHunk[k] = true
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	// aLine and bLine count the lines of a and b before lines[i]
+	aLine, bLine := 0, 0
+	for i := 0; i < len(lines); {
+		if !inHunk[i] {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(lines) && inHunk[i] {
+			i++
+		}
+		hunk := lines[start:i]
+
+		aCount, bCount := 0, 0
+		for _, l := range hunk {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, l := range hunk {
+			sb.WriteString(string(l.op) + l.text + "\n")
+		}
+		aLine += aCount
+		bLine += bCount
+	}
+	return sb.String()
+}
+
+// hunkRange formats the range of a hunk that follows the first before
+// lines of a file and spans count lines.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLine is a line of a diff: op is ' ' for a shared line, '-' for a line
+// only in the old text and '+' for a line only in the new text.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the line diff from a to b, based on a longest common
+// subsequence of their lines.
+func diffLines(a, b string) []diffLine {
+	x := strings.Split(a, "\n")
+	y := strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, diffLine{' ', x[i]})
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', x[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', y[j]})
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "T\n| take 10",
+			b:    "T\n| take 10",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "T\n| where x > 1\n| take 10",
+			b:    "T\n| where x > 2\n| take 10",
+			want: "  T\n- | where x > 1\n+ | where x > 2\n  | take 10\n",
+		},
+		{
+			name: "added line",
+			a:    "T\n| take 10",
+			b:    "T\n| where x > 1\n| take 10",
+			want: "  T\n+ | where x > 1\n  | take 10\n",
+		},
+		{
+			name: "removed line",
+			a:    "T\n| where x > 1\n| take 10",
+			b:    "T\n| take 10",
+			want: "  T\n- | where x > 1\n  | take 10\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.a, tt.b); got != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	if got := UnifiedDiff("a", "b", "T\n| take 10", "T\n| take 10"); got != "" {
+		t.Errorf("expected no diff for equal texts, got:\n%s", got)
+	}
+
+	a := "T\n| where x > 1\n| take 10"
+	b := "T\n| where x > 2\n| take 10"
+	want := "--- old.kql\n+++ new.kql\n@@ -1,3 +1,3 @@\n T\n-| where x > 1\n+| where x > 2\n | take 10\n"
+	if got := UnifiedDiff("old.kql", "new.kql", a, b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_Hunks(t *testing.T) {
+	// Changes more than six shared lines apart get separate hunks, each
+	// with three lines of context
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	b := "1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,5 +1,5 @@\n 1\n-2\n+TWO\n 3\n 4\n 5\n" +
+		"@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n"
+	if got := UnifiedDiff("a", "b", a, b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"github.com/cloudygreybeard/kqlparser/lexer"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+// TokenEditDistance returns the number of token insertions, deletions and
+// substitutions needed to turn a into b. Whitespace and comments are
+// ignored, so reformatting a query costs nothing. Unlike Normalize it
+// accepts queries with lexical errors, since it is used to compare a
+// broken query with its fix.
+func TokenEditDistance(a, b string) int {
+	x, y := literals(a), literals(b)
+
+	prev := make([]int, len(y)+1)
+	curr := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		curr[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(y)]
+}
+
+// literals returns the token literals of src, including illegal ones.
+func literals(src string) []string {
+	l := lexer.New("", src)
+
+	var lits []string
+	for {
+		t := l.Scan()
+		if t.Type == token.EOF {
+			return lits
+		}
+		lits = append(lits, t.Lit)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"testing"
+)
+
+func TestTokenEditDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{"identical", "T | take 10", "T | take 10", 0},
+		{"layout and comments", "T | take 10", "// rows\nT\n|   take 10", 0},
+		{"substitution", "T | where State = 'TX'", "T | where State == 'TX'", 1},
+		{"insertion", "T | summarize count( by State", "T | summarize count() by State", 1},
+		{"deletion", "T | where x > 1 | take 10", "T | take 10", 5},
+		{"broken input", "T | where Name == 'TX", "T | where Name == 'TX'", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TokenEditDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("TokenEditDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/kqlparser"
+	"github.com/cloudygreybeard/kqlparser/lexer"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+// indent is one level of indentation in formatted output.
+const indent = "    "
+
+// Format returns query laid out for reading. Each pipe stage starts on its
+// own line, indented by its nesting inside parentheses or braces, each
+// statement after a ";" starts on a new line, and spacing around operators
+// is normalized as in Normalize.
+//
+// Unlike Normalize, Format keeps comments, and keeps a single blank line
+// where statements or comment blocks were separated by one. String literals
+// are copied from the source byte for byte. Formatting formatted output
+// changes nothing.
+//
+// A query the parser rejects is not reflowed; Format only tidies its lines
+// (see formatLines), so a half-written query is never rearranged.
+func Format(query string) (string, error) {
+	if kqlparser.Parse("", query).HasErrors() {
+		return formatLines(query), nil
+	}
+
+	toks, err := scan(query)
+	if err != nil {
+		return formatLines(query), nil
+	}
+
+	out := formatTokens(query, toks)
+
+	// Guard against any layout decision that changes the query
+	got, err := scan(out)
+	if err != nil || !sameTokens(toks, got) || !sameComments(query, toks, out, got) {
+		return "", fmt.Errorf("formatting would change the query")
+	}
+
+	return out, nil
+}
+
+// formatTokens lays out the tokens of src, carrying over the comments in
+// the gaps between them.
+func formatTokens(src string, toks []scanned) string {
+	var sb strings.Builder
+	depth := 0
+	prevEnd := 0
+
+	newline := func(blank bool, level int) {
+		sb.WriteString("\n")
+		if blank {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.Repeat(indent, level))
+	}
+
+	for i, t := range toks {
+		start := int(t.Pos) - 1
+		g := splitGap(src[prevEnd:start], i > 0)
+
+		switch t.Type {
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		}
+
+		if i == 0 {
+			for _, c := range g.leading {
+				sb.WriteString(c + "\n")
+			}
+		} else {
+			if g.trailing != "" {
+				sb.WriteString(" " + g.trailing)
+			}
+
+			stage := t.Type == token.PIPE || toks[i-1].Type == token.SEMI
+			switch {
+			case stage:
+				// Blank lines only survive between statements
+				blank := g.blank && toks[i-1].Type == token.SEMI
+				newline(blank, depth)
+				for _, c := range g.leading {
+					sb.WriteString(c)
+					newline(false, depth)
+				}
+			case g.trailing != "" || len(g.leading) > 0:
+				// A comment ends the line mid-expression: continue indented
+				newline(g.blank && len(g.leading) > 0, depth+1)
+				for _, c := range g.leading {
+					sb.WriteString(c)
+					newline(false, depth+1)
+				}
+			default:
+				sb.WriteString(spacing(toks, i))
+			}
+		}
+
+		sb.WriteString(src[start:t.end])
+
+		switch t.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		}
+		prevEnd = t.end
+	}
+
+	// Comments after the last token
+	g := splitGap(src[prevEnd:], len(toks) > 0)
+	if g.trailing != "" {
+		sb.WriteString(" " + g.trailing)
+	}
+	for i, c := range g.leading {
+		if i > 0 || len(toks) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(c)
+	}
+
+	return sb.String()
+}
+
+// spacing returns the text between toks[i-1] and toks[i] on one line. It
+// follows separator, except that operator parameters such as kind=inner
+// stay joined and a space written between a name and "(" is kept, as in
+// join kind=inner (T).
+func spacing(toks []scanned, i int) string {
+	prev, next := toks[i-1], toks[i]
+	switch {
+	case next.Type == token.ASSIGN && isParam(prev.Type):
+		return ""
+	case prev.Type == token.ASSIGN && i > 1 && isParam(toks[i-2].Type):
+		return ""
+	case next.Type == token.LPAREN && prev.Type == token.IDENT && !next.adjacent:
+		return " "
+	}
+	return separator(prev, next)
+}
+
+// isParam reports whether t names an operator parameter.
+func isParam(t token.Token) bool {
+	return t == token.KIND || t == token.WITHSOURCE
+}
+
+// gap is the whitespace and comments between two tokens.
+type gap struct {
+	// trailing is a comment on the same line as the previous token
+	trailing string
+	// leading are comments on their own lines before the next token
+	leading []string
+	// blank is set when the gap contains an empty line
+	blank bool
+}
+
+// splitGap sorts the comments in text, which the lexer skipped between
+// two tokens. afterToken is set when a token precedes the gap.
+func splitGap(text string, afterToken bool) gap {
+	var g gap
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			// Lines strictly between the first and last are empty lines
+			if i > 0 && i < len(lines)-1 {
+				g.blank = true
+			}
+		case i == 0 && afterToken:
+			g.trailing = line
+		default:
+			g.leading = append(g.leading, line)
+		}
+	}
+	return g
+}
+
+// comments returns the comments in src, given its tokens.
+func comments(src string, toks []scanned) []string {
+	var all []string
+	prevEnd := 0
+	for _, t := range toks {
+		g := splitGap(src[prevEnd:int(t.Pos)-1], true)
+		if g.trailing != "" {
+			all = append(all, g.trailing)
+		}
+		all = append(all, g.leading...)
+		prevEnd = t.end
+	}
+	g := splitGap(src[prevEnd:], true)
+	if g.trailing != "" {
+		all = append(all, g.trailing)
+	}
+	return append(all, g.leading...)
+}
+
+// sameComments reports whether a and b, with tokens at and bt, contain
+// the same comments in the same order.
+func sameComments(a string, at []scanned, b string, bt []scanned) bool {
+	ca, cb := comments(a, at), comments(b, bt)
+	if len(ca) != len(cb) {
+		return false
+	}
+	for i := range ca {
+		if ca[i] != cb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatLines tidies a query without reflowing it, for input the parser
+// rejects: trailing whitespace is removed, runs of empty lines become one,
+// and lines starting with a pipe become "| stage" with no indentation.
+// Lines that are part of a multi-line string literal are left alone.
+func formatLines(query string) string {
+	literal := literalLines(query)
+
+	lines := strings.Split(query, "\n")
+	var out []string
+	for i, line := range lines {
+		if literal[i+1] {
+			out = append(out, line)
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "|") {
+			line = "| " + strings.TrimLeft(trimmed[1:], " \t")
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// literalLines returns the 1-based numbers of lines that a string literal
+// spans, as far as the lexer can tell.
+func literalLines(src string) map[int]bool {
+	lines := make(map[int]bool)
+	l := lexer.New("", src)
+	for t := l.Scan(); t.Type != token.EOF; t = l.Scan() {
+		if t.Type != token.STRING {
+			continue
+		}
+		first := l.File().Position(t.Pos).Line
+		last := first + strings.Count(src[int(t.Pos)-1:l.Offset()], "\n")
+		if last == first {
+			continue
+		}
+		for n := first; n <= last; n++ {
+			lines[n] = true
+		}
+	}
+	return lines
+}
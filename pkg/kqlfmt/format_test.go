@@ -0,0 +1,121 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "pipes and spacing",
+			in:   "StormEvents|where State=='TEXAS'   |summarize count()by EventType",
+			want: "StormEvents\n| where State == 'TEXAS'\n| summarize count() by EventType",
+		},
+		{
+			name: "let bindings",
+			in:   "let threshold=5 ; let recent=ago( 1d );\nT|where Count>threshold and Timestamp>recent",
+			want: "let threshold = 5;\nlet recent = ago(1d);\nT\n| where Count > threshold and Timestamp > recent",
+		},
+		{
+			name: "blank line between statements kept once",
+			in:   "let n = 5;\n\n\n\nT | take n",
+			want: "let n = 5;\n\nT\n| take n",
+		},
+		{
+			name: "comments kept",
+			in:   "// top rows\nlet n = 5; // five\nT // source\n| take n\n// end",
+			want: "// top rows\nlet n = 5; // five\nT // source\n| take n\n// end",
+		},
+		{
+			name: "comment mid-expression",
+			in:   "T | where a == 1 // first\n and b == 2",
+			want: "T\n| where a == 1 // first\n    and b == 2",
+		},
+		{
+			name: "nested pipes indented",
+			in:   "T | join kind = inner (U | where x > 1) on Key",
+			want: "T\n| join kind=inner (U\n    | where x > 1) on Key",
+		},
+		{
+			name: "string literals untouched",
+			in:   "T | where Msg == \"a  |  b // c\" | extend s = ```x  \n  | y```",
+			want: "T\n| where Msg == \"a  |  b // c\"\n| extend s = ```x  \n  | y```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.in)
+			if err != nil {
+				t.Fatalf("Format error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Idempotent(t *testing.T) {
+	inputs := []string{
+		"let a=1;let b=a+1;\n\n// result\nT|where x>b // filter\n|project x,y",
+		"let f = (n: long) { T | take n };\nf(10) | count",
+		"T | where a == 1 // first\n and b == 2 // second\n| take 1",
+		"T |  where (x >\n\n\n   |take 10", // unparseable: line-based fallback
+	}
+	for _, in := range inputs {
+		once, err := Format(in)
+		if err != nil {
+			t.Fatalf("Format(%q) error: %v", in, err)
+		}
+		twice, err := Format(once)
+		if err != nil {
+			t.Fatalf("Format(formatted) error: %v", err)
+		}
+		if once != twice {
+			t.Errorf("not idempotent for %q:\nonce:\n%s\ntwice:\n%s", in, once, twice)
+		}
+	}
+}
+
+func TestFormat_PreservesTokensAndComments(t *testing.T) {
+	in := "let n = 5; // five\nT // source\n| where s == '// not a comment' | take n // last"
+	got, err := Format(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := Normalize(in)
+	if norm, _ := Normalize(got); norm != want {
+		t.Errorf("formatting changed the query:\n%s", got)
+	}
+	for _, c := range []string{"// five", "// source", "// last", "'// not a comment'"} {
+		if !strings.Contains(got, c) {
+			t.Errorf("expected %q to be kept, got:\n%s", c, got)
+		}
+	}
+}
+
+func TestFormat_Fallback(t *testing.T) {
+	in := "T  \n   |where x >   \n\n\n  |  take 10\n\n"
+	got, err := Format(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "T\n| where x >\n\n| take 10"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Lines inside a multi-line string are content, even when the query
+	// doesn't parse
+	in = "T | extend s = ```a  \n   | b``` | where ("
+	if got, _ := Format(in); !strings.Contains(got, "```a  \n   | b```") {
+		t.Errorf("multi-line string changed: %q", got)
+	}
+}
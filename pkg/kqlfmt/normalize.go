@@ -0,0 +1,151 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kqlfmt rewrites KQL source into canonical forms.
+package kqlfmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudygreybeard/kqlparser/lexer"
+	"github.com/cloudygreybeard/kqlparser/token"
+)
+
+// Normalize returns a canonical form of query for comparison and
+// deduplication. Comments are removed, whitespace is collapsed, each pipe
+// stage starts on its own line, and statements after a ";" start on a new
+// line.
+//
+// Normalization is conservative: it never reorders, renames or recases
+// anything, since KQL keywords and identifiers are case-sensitive and
+// column order is significant. The result is re-tokenized and must produce
+// exactly the same token stream as the input, otherwise an error is
+// returned.
+func Normalize(query string) (string, error) {
+	toks, err := scan(query)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			sb.WriteString(separator(toks[i-1], t))
+		}
+		sb.WriteString(t.Lit)
+	}
+	out := sb.String()
+
+	// Guard against any spacing decision that changes how the query lexes
+	got, err := scan(out)
+	if err != nil || !sameTokens(toks, got) {
+		return "", fmt.Errorf("normalization would change the query's tokens")
+	}
+
+	return out, nil
+}
+
+// scanned is a token plus the context needed to space it.
+type scanned struct {
+	lexer.Token
+	// adjacent is set when the token directly followed the previous one in
+	// the source, with no whitespace or comment in between
+	adjacent bool
+	// unary is set for a sign that cannot be a binary operator, as in -1
+	unary bool
+	// end is the offset just past the token in the source
+	end int
+}
+
+// scan tokenizes src, rejecting input the lexer cannot fully understand.
+func scan(src string) ([]scanned, error) {
+	l := lexer.New("", src)
+
+	var toks []scanned
+	prevEnd := -1
+	for {
+		t := l.Scan()
+		if t.Type == token.EOF {
+			break
+		}
+		if t.Type == token.ILLEGAL {
+			pos := l.File().Position(t.Pos)
+			return nil, fmt.Errorf("%d:%d: unexpected %q", pos.Line, pos.Column, t.Lit)
+		}
+		s := scanned{Token: t, adjacent: int(t.Pos)-1 == prevEnd, end: l.Offset()}
+		if t.Type == token.SUB || t.Type == token.ADD {
+			s.unary = len(toks) == 0 || opensOperand(toks[len(toks)-1].Type)
+		}
+		toks = append(toks, s)
+		prevEnd = l.Offset()
+	}
+
+	if errs := l.Errors(); len(errs) > 0 {
+		return nil, errs.Err()
+	}
+
+	return toks, nil
+}
+
+// separator returns the text to emit between prev and next.
+func separator(prev, next scanned) string {
+	switch {
+	case next.Type == token.PIPE:
+		return "\n"
+	case prev.Type == token.SEMI:
+		return "\n"
+	}
+
+	switch prev.Type {
+	case token.LPAREN, token.LBRACKET, token.DOT, token.DOTDOT:
+		return ""
+	case token.SUB, token.ADD:
+		// Keep "- -1" from becoming the "--" edge operator
+		if prev.unary && next.Type != token.SUB && next.Type != token.ADD {
+			return ""
+		}
+	}
+
+	switch next.Type {
+	case token.COMMA, token.SEMI, token.COLON, token.RPAREN, token.RBRACKET, token.DOT, token.DOTDOT:
+		return ""
+	case token.LPAREN:
+		// Function calls: always for identifiers, and for keywords such as
+		// count() or typeof() only when written that way
+		if prev.Type == token.IDENT || (prev.Type.IsKeyword() && next.adjacent) {
+			return ""
+		}
+	case token.LBRACKET:
+		// Indexing: x[0], d["key"], f()[0]
+		switch prev.Type {
+		case token.IDENT, token.RPAREN, token.RBRACKET:
+			return ""
+		}
+	}
+
+	return " "
+}
+
+// opensOperand reports whether a token of type t is followed by an
+// operand rather than an operator.
+func opensOperand(t token.Token) bool {
+	switch t {
+	case token.RPAREN, token.RBRACKET, token.RBRACE:
+		return false
+	}
+	return t.IsOperator()
+}
+
+// sameTokens reports whether a and b have the same types and literals.
+func sameTokens(a, b []scanned) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Lit != b[i].Lit {
+			return false
+		}
+	}
+	return true
+}
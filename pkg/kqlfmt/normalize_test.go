@@ -0,0 +1,121 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlfmt
+
+import (
+	"testing"
+)
+
+func TestNormalize_EquivalentQueries(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{
+			name: "whitespace and line breaks",
+			a:    "StormEvents | where State == 'TEXAS' | summarize count() by EventType",
+			b:    "StormEvents\n|   where State=='TEXAS'\n\n|summarize count()   by EventType\n",
+		},
+		{
+			name: "comments",
+			a:    "T | take 10",
+			b:    "// sample rows\nT // the table\n| take 10 // ten is enough",
+		},
+		{
+			name: "function calls and lists",
+			a:    "T | where Timestamp > ago(1d) and Level in ('Error', 'Warning')",
+			b:    "T\n| where Timestamp>ago( 1d )and Level in ( 'Error' ,'Warning' )",
+		},
+		{
+			name: "let statements",
+			a:    "let n = 5;\nT | take n",
+			b:    "let n=5 ; T|take n",
+		},
+		{
+			name: "unary minus",
+			a:    "T | where Delta > -1",
+			b:    "T | where Delta >- 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			na, err := Normalize(tt.a)
+			if err != nil {
+				t.Fatalf("Normalize(a) error: %v", err)
+			}
+			nb, err := Normalize(tt.b)
+			if err != nil {
+				t.Fatalf("Normalize(b) error: %v", err)
+			}
+			if na != nb {
+				t.Errorf("expected equal normal forms:\n  a: %q\n  b: %q", na, nb)
+			}
+		})
+	}
+}
+
+func TestNormalize_Output(t *testing.T) {
+	got, err := Normalize("let n=5;T|where x>-1 and f(a,b)[0]=='x'|project A,B|take n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "let n = 5;\nT\n| where x > -1 and f(a, b)[0] == 'x'\n| project A, B\n| take n"
+	if got != want {
+		t.Errorf("unexpected output:\n  got:  %q\n  want: %q", got, want)
+	}
+}
+
+func TestNormalize_Idempotent(t *testing.T) {
+	once, err := Normalize("T | extend d = datetime(2024-01-01) | project-away X | where Name matches   regex 'a.*'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	twice, err := Normalize(once)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if once != twice {
+		t.Errorf("normalize is not idempotent:\n  once:  %q\n  twice: %q", once, twice)
+	}
+}
+
+func TestNormalize_PreservesSemantics(t *testing.T) {
+	// Different casing, quoting, and column order are real differences
+	pairs := [][2]string{
+		{"T | project A, B", "T | project B, A"},
+		{"T | where Name == 'a'", "T | where Name == 'A'"},
+		{"T | where Name == 'a'", "T | where name == 'a'"},
+		{"T | take 10", "T | take 100"},
+	}
+	for _, p := range pairs {
+		a, err := Normalize(p[0])
+		if err != nil {
+			t.Fatalf("Normalize(%q) error: %v", p[0], err)
+		}
+		b, err := Normalize(p[1])
+		if err != nil {
+			t.Fatalf("Normalize(%q) error: %v", p[1], err)
+		}
+		if a == b {
+			t.Errorf("expected %q and %q to stay distinct", p[0], p[1])
+		}
+	}
+}
+
+func TestNormalize_KeepsStringContents(t *testing.T) {
+	got, err := Normalize("T | where Msg == 'a  //  b'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "T\n| where Msg == 'a  //  b'" {
+		t.Errorf("string literal was altered: %q", got)
+	}
+}
+
+func TestNormalize_IllegalInput(t *testing.T) {
+	if _, err := Normalize("T | where x == #"); err == nil {
+		t.Error("expected error for illegal character")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kqlhints maps KQL parser error messages to hints, syntax examples,
+// and plain-language explanations. It is shared by the generate/fix retry
+// loop, which feeds hints and examples back to the model, and by lint,
+// which explains errors to people.
+package kqlhints
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rule is a user-defined mapping from an error message to a hint and/or
+// example, applied after the built-in knowledge base.
+type Rule struct {
+	// Match is a case-insensitive substring, or a regular expression
+	// when wrapped in slashes (e.g. "/unknown function '\w+'/")
+	Match string
+
+	// Hint is added to the hints when the rule matches
+	Hint string
+
+	// Example is added to the syntax examples when the rule matches
+	Example string
+}
+
+// Matches reports whether the rule applies to an error message.
+// Patterns wrapped in slashes are regular expressions; anything else
+// (including an invalid regex) is a case-insensitive substring match.
+func (r Rule) Matches(msg string) bool {
+	if r.Match == "" {
+		return false
+	}
+	if len(r.Match) > 2 && strings.HasPrefix(r.Match, "/") && strings.HasSuffix(r.Match, "/") {
+		if re, err := regexp.Compile(r.Match[1 : len(r.Match)-1]); err == nil {
+			return re.MatchString(msg)
+		}
+	}
+	return strings.Contains(strings.ToLower(msg), strings.ToLower(r.Match))
+}
+
+// substrings are matched against a lowercased error message.
+type substrings []string
+
+// matches reports whether lowerMsg contains any of s.
+func (s substrings) matches(lowerMsg string) bool {
+	for _, sub := range s {
+		if strings.Contains(lowerMsg, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// pattern links error messages to a hint for the model and a
+// plain-language explanation for people.
+type pattern struct {
+	substrings  substrings
+	hint        string
+	explanation string
+}
+
+// patterns is the built-in knowledge base of common KQL errors.
+var patterns = []pattern{
+	{
+		substrings:  substrings{"expected ')'", "expected '('", "unclosed", "unmatched"},
+		hint:        "Ensure all parentheses are balanced",
+		explanation: "A parenthesis is missing or unmatched. Every '(' needs a closing ')', as in count() or bin(Timestamp, 1h).",
+	},
+	{
+		substrings:  substrings{"expected '|'", "pipe"},
+		hint:        "Each operator should be on a new line starting with |",
+		explanation: "Query operators are chained with '|', as in T | where x > 1 | take 10.",
+	},
+	{
+		substrings:  substrings{"expected ','"},
+		hint:        "Multiple arguments should be separated by commas",
+		explanation: "Function arguments and list items are separated by commas, as in bin(Timestamp, 1h).",
+	},
+	{
+		substrings:  substrings{"expected operator", "unknown operator"},
+		hint:        "Common operators: where, project, summarize, extend, join, take, top, sort",
+		explanation: "A query operator was expected after '|'. Common operators are where, project, summarize, extend, join, take, top and sort.",
+	},
+	{
+		substrings:  substrings{"by"},
+		hint:        "The 'by' clause is used with summarize, top, and order operators",
+		explanation: "A 'by' clause belongs to operators such as summarize, top and sort, as in summarize count() by State.",
+	},
+	{
+		substrings:  substrings{"string", "quote"},
+		hint:        "Use single or double quotes for string literals",
+		explanation: "A string literal is malformed. Enclose strings in single or double quotes, as in 'TX' or \"TX\", and close every quote.",
+	},
+	{
+		substrings:  substrings{"triple delimiter", "multi-line string", "illegal"},
+		hint:        "Do NOT wrap output in backticks - output raw KQL only",
+		explanation: "The query contains a character KQL does not accept here. Backticks only delimit ``` multi-line strings, so remove any Markdown fences around the query.",
+	},
+	{
+		substrings:  substrings{"datetime", "date"},
+		hint:        "Use datetime() for date values, e.g., datetime(2024-01-01)",
+		explanation: "Date values are written with datetime(), as in datetime(2024-01-01).",
+	},
+	{
+		substrings:  substrings{"timespan", "ago"},
+		hint:        "Use timespan literals like 1h, 7d, 30m or the ago() function",
+		explanation: "Durations are written as timespan literals such as 30m, 1h or 7d, often with ago(), as in ago(1h).",
+	},
+}
+
+// examplePattern links error messages to correct syntax examples.
+type examplePattern struct {
+	substrings substrings
+	examples   []string
+}
+
+var examplePatterns = []examplePattern{
+	{
+		substrings: substrings{"summarize", "count", "sum", "avg"},
+		examples:   []string{"T | summarize count() by Column", "T | summarize Total=sum(Value) by Category"},
+	},
+	{
+		substrings: substrings{"where", "filter"},
+		examples:   []string{"T | where Column > 10", "T | where Name == 'value'"},
+	},
+	{
+		substrings: substrings{"project"},
+		examples:   []string{"T | project Column1, Column2", "T | project NewName = OldName"},
+	},
+	{
+		substrings: substrings{"join"},
+		examples:   []string{"T1 | join kind=inner T2 on CommonColumn"},
+	},
+	{
+		substrings: substrings{"extend"},
+		examples:   []string{"T | extend NewColumn = Expression"},
+	},
+	{
+		substrings: substrings{"expected ')'", "expected '('"},
+		examples:   []string{"Function calls: func(arg1, arg2)"},
+	},
+}
+
+// progressiveExample is added on later attempts in progressive mode.
+const progressiveExample = "// Multi-line query structure:\nTable\n| where Condition\n| summarize count() by Column"
+
+// HintsForMessages returns hints for the given error messages in first-seen
+// order, without duplicates. Rules contribute hints after the built-in ones.
+func HintsForMessages(messages []string, rules ...Rule) []string {
+	var hints orderedSet
+
+	for _, m := range messages {
+		msg := strings.ToLower(m)
+		for _, p := range patterns {
+			if p.substrings.matches(msg) {
+				hints.add(p.hint)
+			}
+		}
+	}
+
+	for _, m := range messages {
+		for _, r := range rules {
+			if r.Hint != "" && r.Matches(m) {
+				hints.add(r.Hint)
+			}
+		}
+	}
+
+	return hints.items
+}
+
+// ExamplesForMessages returns syntax examples for the given error messages
+// in first-seen order, without duplicates. With progressive set, attempt 3
+// and later also get a full multi-line query example. Rules contribute
+// examples after the built-in ones.
+func ExamplesForMessages(messages []string, attempt int, progressive bool, rules ...Rule) []string {
+	var examples orderedSet
+
+	for _, m := range messages {
+		msg := strings.ToLower(m)
+		for _, p := range examplePatterns {
+			if !p.substrings.matches(msg) {
+				continue
+			}
+			for _, ex := range p.examples {
+				examples.add(ex)
+			}
+		}
+
+		if progressive && attempt >= 3 {
+			examples.add(progressiveExample)
+		}
+	}
+
+	for _, m := range messages {
+		for _, r := range rules {
+			if r.Example != "" && r.Matches(m) {
+				examples.add(r.Example)
+			}
+		}
+	}
+
+	return examples.items
+}
+
+// Explain returns a plain-language explanation of an error message, or ""
+// if it matches no known pattern.
+func Explain(message string) string {
+	msg := strings.ToLower(message)
+
+	var explanations []string
+	for _, p := range patterns {
+		if p.substrings.matches(msg) {
+			explanations = append(explanations, p.explanation)
+		}
+	}
+	return strings.Join(explanations, " ")
+}
+
+// orderedSet collects unique strings in first-seen order, so output is
+// reproducible for the same messages.
+type orderedSet struct {
+	seen  map[string]bool
+	items []string
+}
+
+func (s *orderedSet) add(v string) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if s.seen[v] {
+		return
+	}
+	s.seen[v] = true
+	s.items = append(s.items, v)
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlhints
+
+import (
+	"strings"
+	"testing"
+)
+
+func contains(list []string, want string) bool {
+	for _, s := range list {
+		if s == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHintsForMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"parenthesis quoted", "expected ')' after arguments", "Ensure all parentheses are balanced"},
+		{"parenthesis unclosed", "unclosed parenthesis", "Ensure all parentheses are balanced"},
+		{"pipe", "expected '|' before operator", "Each operator should be on a new line starting with |"},
+		{"comma", "expected ',' between arguments", "Multiple arguments should be separated by commas"},
+		{"operator", "unknown operator 'wher'", "Common operators: where, project, summarize, extend, join, take, top, sort"},
+		{"string", "unterminated string literal", "Use single or double quotes for string literals"},
+		{"backtick", "illegal character '`'", "Do NOT wrap output in backticks - output raw KQL only"},
+		{"triple delimiter", "expected triple delimiter for multi-line string", "Do NOT wrap output in backticks - output raw KQL only"},
+		{"datetime", "invalid datetime literal", "Use datetime() for date values, e.g., datetime(2024-01-01)"},
+		{"timespan", "invalid timespan literal", "Use timespan literals like 1h, 7d, 30m or the ago() function"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hints := HintsForMessages([]string{tt.message})
+			if !contains(hints, tt.want) {
+				t.Errorf("HintsForMessages(%q) = %q, want %q", tt.message, hints, tt.want)
+			}
+		})
+	}
+}
+
+func TestHintsForMessages_NoMatch(t *testing.T) {
+	if hints := HintsForMessages([]string{"something entirely different"}); len(hints) != 0 {
+		t.Errorf("expected no hints, got %q", hints)
+	}
+}
+
+func TestHintsForMessages_OrderAndDedup(t *testing.T) {
+	hints := HintsForMessages([]string{
+		"unclosed parenthesis",
+		"invalid datetime literal",
+		"expected ')' after arguments",
+	})
+	want := []string{
+		"Ensure all parentheses are balanced",
+		"Use datetime() for date values, e.g., datetime(2024-01-01)",
+	}
+	if strings.Join(hints, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", hints, want)
+	}
+}
+
+func TestHintsForMessages_RulesAfterBuiltins(t *testing.T) {
+	rules := []Rule{
+		{Match: "/unknown function '\\w+'/", Hint: "Check the function name"},
+		{Match: "never", Hint: "unused"},
+	}
+	hints := HintsForMessages([]string{"unknown function 'cnt' in string"}, rules...)
+	if len(hints) != 2 {
+		t.Fatalf("expected built-in and rule hint, got %q", hints)
+	}
+	if hints[0] != "Use single or double quotes for string literals" || hints[1] != "Check the function name" {
+		t.Errorf("unexpected order: %q", hints)
+	}
+}
+
+func TestExamplesForMessages(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"unexpected token in summarize", "T | summarize count() by Column"},
+		{"bad where clause", "T | where Column > 10"},
+		{"bad project list", "T | project Column1, Column2"},
+		{"join requires on", "T1 | join kind=inner T2 on CommonColumn"},
+		{"extend needs assignment", "T | extend NewColumn = Expression"},
+		{"expected ')' after arguments", "Function calls: func(arg1, arg2)"},
+	}
+	for _, tt := range tests {
+		examples := ExamplesForMessages([]string{tt.message}, 1, false)
+		if !contains(examples, tt.want) {
+			t.Errorf("ExamplesForMessages(%q) = %q, want %q", tt.message, examples, tt.want)
+		}
+	}
+}
+
+func TestExamplesForMessages_Progressive(t *testing.T) {
+	msgs := []string{"unexpected token"}
+
+	if examples := ExamplesForMessages(msgs, 3, false); len(examples) != 0 {
+		t.Errorf("expected no examples without progressive, got %q", examples)
+	}
+	if examples := ExamplesForMessages(msgs, 2, true); len(examples) != 0 {
+		t.Errorf("expected no examples before attempt 3, got %q", examples)
+	}
+	examples := ExamplesForMessages(msgs, 3, true)
+	if len(examples) != 1 || !strings.HasPrefix(examples[0], "// Multi-line query structure:") {
+		t.Errorf("expected progressive example, got %q", examples)
+	}
+}
+
+func TestExamplesForMessages_Rules(t *testing.T) {
+	rules := []Rule{{Match: "mv-expand", Example: "T | mv-expand Tags to typeof(string)"}}
+	examples := ExamplesForMessages([]string{"expected expression after mv-expand"}, 1, false, rules...)
+	if len(examples) != 1 || examples[0] != "T | mv-expand Tags to typeof(string)" {
+		t.Errorf("expected custom example, got %q", examples)
+	}
+}
+
+func TestRule_InvalidRegexFallsBackToSubstring(t *testing.T) {
+	r := Rule{Match: "/([/", Hint: "h"}
+	if r.Matches("no match here") {
+		t.Error("expected no match")
+	}
+	if !r.Matches("saw /([/ in input") {
+		t.Error("expected substring match for invalid regex")
+	}
+}
+
+func TestRule_EmptyMatch(t *testing.T) {
+	if (Rule{Hint: "h"}).Matches("anything") {
+		t.Error("expected empty pattern to match nothing")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"expected ')' after arguments", "parenthesis is missing"},
+		{"unmatched parenthesis", "parenthesis is missing"},
+		{"illegal character '`'", "Backticks"},
+		{"expected ',' between arguments", "separated by commas"},
+		{"unknown operator 'wher'", "query operator was expected"},
+		{"invalid datetime literal", "datetime(2024-01-01)"},
+		{"unterminated string literal", "string literal is malformed"},
+	}
+	for _, tt := range tests {
+		if got := Explain(tt.message); !strings.Contains(got, tt.want) {
+			t.Errorf("Explain(%q) = %q, want it to mention %q", tt.message, got, tt.want)
+		}
+	}
+
+	if got := Explain("something entirely different"); got != "" {
+		t.Errorf("expected no explanation, got %q", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kqlref provides an offline reference for common KQL operators
+// and functions. Entries live in reference.json, embedded at build time.
+package kqlref
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Entry kinds.
+const (
+	KindOperator = "operator"
+	KindFunction = "function"
+)
+
+// Entry is the reference for one operator or function.
+type Entry struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Syntax      string `json:"syntax"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+//go:embed reference.json
+var referenceJSON []byte
+
+var entries = mustLoad(referenceJSON)
+
+func mustLoad(data []byte) map[string]Entry {
+	var list []Entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		panic(fmt.Sprintf("kqlref: invalid embedded reference: %v", err))
+	}
+	m := make(map[string]Entry, len(list))
+	for _, e := range list {
+		m[e.Name] = e
+	}
+	return m
+}
+
+// NotFoundError is returned by Lookup for names without an entry.
+type NotFoundError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	msg := fmt.Sprintf("no reference entry for %q", e.Name)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, ", "))
+	}
+	return msg + "; run 'kql ref --list' to see available entries"
+}
+
+// Lookup returns the entry for an operator or function name. Names are
+// matched case-insensitively and a leading "|" or trailing "()" is ignored,
+// so "| where" and "ago()" both work.
+func Lookup(name string) (Entry, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	key = strings.TrimSpace(strings.TrimPrefix(key, "|"))
+	key = strings.TrimSuffix(key, "()")
+
+	if e, ok := entries[key]; ok {
+		return e, nil
+	}
+	return Entry{}, &NotFoundError{Name: name, Suggestions: suggest(key)}
+}
+
+// All returns every entry, sorted by kind and then name.
+func All() []Entry {
+	list := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind > list[j].Kind // operators before functions
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// suggest returns up to three entry names close to key.
+func suggest(key string) []string {
+	if key == "" {
+		return nil
+	}
+
+	type scored struct {
+		name string
+		dist int
+	}
+	var candidates []scored
+	for name := range entries {
+		d := editDistance(key, name)
+		if d <= 2 || strings.HasPrefix(name, key) || strings.HasPrefix(key, name) {
+			candidates = append(candidates, scored{name, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kqlref
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudygreybeard/kqlparser"
+)
+
+func TestLookup_KnownOperator(t *testing.T) {
+	e, err := Lookup("summarize")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.Name != "summarize" || e.Kind != KindOperator {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if !strings.Contains(e.Syntax, "by") || e.Description == "" || e.Example == "" {
+		t.Errorf("expected syntax, description and example, got %+v", e)
+	}
+}
+
+func TestLookup_NameForms(t *testing.T) {
+	for _, name := range []string{"WHERE", " | where", "ago()", "mv-expand"} {
+		if _, err := Lookup(name); err != nil {
+			t.Errorf("Lookup(%q) unexpected error: %v", name, err)
+		}
+	}
+}
+
+func TestLookup_NotFound(t *testing.T) {
+	_, err := Lookup("sumarize")
+	if err == nil {
+		t.Fatal("expected error for unknown name")
+	}
+
+	var nf *NotFoundError
+	if !errors.As(err, &nf) {
+		t.Fatalf("expected *NotFoundError, got %T", err)
+	}
+	if len(nf.Suggestions) == 0 || nf.Suggestions[0] != "summarize" {
+		t.Errorf("expected 'summarize' suggestion, got %q", nf.Suggestions)
+	}
+	if !strings.Contains(err.Error(), "did you mean summarize") || !strings.Contains(err.Error(), "kql ref --list") {
+		t.Errorf("unhelpful message: %s", err)
+	}
+}
+
+func TestLookup_NotFoundNoSuggestions(t *testing.T) {
+	_, err := Lookup("xyzzyplugh")
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected plain not-found error, got %v", err)
+	}
+}
+
+func TestAll_CoversEntries(t *testing.T) {
+	all := All()
+	if len(all) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(all))
+	}
+	for _, e := range all {
+		if e.Name == "" || e.Syntax == "" || e.Description == "" {
+			t.Errorf("incomplete entry: %+v", e)
+		}
+		if e.Kind != KindOperator && e.Kind != KindFunction {
+			t.Errorf("unknown kind %q for %s", e.Kind, e.Name)
+		}
+	}
+	if all[0].Kind != KindOperator || all[len(all)-1].Kind != KindFunction {
+		t.Error("expected operators to be listed before functions")
+	}
+}
+
+func TestExamplesParse(t *testing.T) {
+	for _, e := range All() {
+		if result := kqlparser.Parse(e.Name, e.Example); result.HasErrors() {
+			t.Errorf("example for %s does not parse: %v", e.Name, result.Errors)
+		}
+	}
+}
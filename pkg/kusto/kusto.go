@@ -0,0 +1,205 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kusto is a minimal client for the Kusto REST API, covering what
+// kql needs from a live cluster: running management commands such as
+// reading a table's schema.
+//
+// Requests are authenticated with an Azure AD access token, taken from
+// KQL_KUSTO_TOKEN or, failing that, from the Azure CLI.
+package kusto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// TokenEnv is the environment variable checked for an access token before
+// falling back to the Azure CLI.
+const TokenEnv = "KQL_KUSTO_TOKEN"
+
+// Client sends management commands to a single cluster.
+type Client struct {
+	// Endpoint is the cluster URL, e.g. https://help.kusto.windows.net
+	Endpoint string
+
+	// HTTPClient sends the requests (default: http.DefaultClient)
+	HTTPClient *http.Client
+
+	// TokenSource returns an access token for the resource, which is the
+	// cluster URL (default: DefaultToken)
+	TokenSource func(resource string) (string, error)
+}
+
+// NewClient returns a client for the cluster, given as a name
+// ("help", "mycluster.westeurope") or a URL.
+func NewClient(cluster string) *Client {
+	return &Client{Endpoint: ClusterURL(cluster)}
+}
+
+// ClusterURL expands a cluster name into its URL. Names without a domain
+// get the public cloud domain; URLs are returned unchanged.
+func ClusterURL(cluster string) string {
+	cluster = strings.TrimSuffix(cluster, "/")
+	if strings.Contains(cluster, "://") {
+		return cluster
+	}
+	if strings.Contains(cluster, ".kusto.") {
+		return "https://" + cluster
+	}
+	return "https://" + cluster + ".kusto.windows.net"
+}
+
+// DefaultToken returns the token in KQL_KUSTO_TOKEN, or one from
+// `az account get-access-token` for the resource.
+func DefaultToken(resource string) (string, error) {
+	if token := os.Getenv(TokenEnv); token != "" {
+		return token, nil
+	}
+	cmd := exec.Command("az", "account", "get-access-token", "--resource", resource, "--query", "accessToken", "--output", "tsv")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("getting access token (run 'az login' or set %s): %w", TokenEnv, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Table is one table of a v1 REST response.
+type Table struct {
+	TableName string   `json:"TableName"`
+	Columns   []Column `json:"Columns"`
+	Rows      [][]any  `json:"Rows"`
+}
+
+// Column describes a column of a Table.
+type Column struct {
+	ColumnName string `json:"ColumnName"`
+	DataType   string `json:"DataType"`
+}
+
+// Value returns the named column of row i as a string, or "" if the
+// column or row doesn't exist or the value isn't a string.
+func (t *Table) Value(i int, column string) string {
+	if i < 0 || i >= len(t.Rows) {
+		return ""
+	}
+	for c, col := range t.Columns {
+		if col.ColumnName == column && c < len(t.Rows[i]) {
+			s, _ := t.Rows[i][c].(string)
+			return s
+		}
+	}
+	return ""
+}
+
+// Mgmt runs a management command against the database and returns the
+// first table of the result.
+func (c *Client) Mgmt(ctx context.Context, database, command string) (*Table, error) {
+	tokenSource := c.TokenSource
+	if tokenSource == nil {
+		tokenSource = DefaultToken
+	}
+	token, err := tokenSource(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{"db": database, "csl": command})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+"/v1/rest/mgmt", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kusto returned status %d: %s", resp.StatusCode, errorMessage(data))
+	}
+
+	var result struct {
+		Tables []Table `json:"Tables"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if len(result.Tables) == 0 {
+		return nil, fmt.Errorf("kusto returned no tables")
+	}
+	return &result.Tables[0], nil
+}
+
+// TableSchema returns the columns of a table as comma-separated
+// "Name:type" pairs, e.g. "State:string,StartTime:datetime".
+func (c *Client) TableSchema(ctx context.Context, database, table string) (string, error) {
+	result, err := c.Mgmt(ctx, database, ".show table "+QuoteName(table)+" schema as csl")
+	if err != nil {
+		return "", fmt.Errorf("reading schema of %s: %w", table, err)
+	}
+	schema := result.Value(0, "Schema")
+	if schema == "" {
+		return "", fmt.Errorf("reading schema of %s: no schema returned", table)
+	}
+	return schema, nil
+}
+
+// QuoteName returns name as is if it is a plain identifier, and as a
+// bracketed string literal otherwise.
+func QuoteName(name string) string {
+	plain := name != ""
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+	return "['" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name) + "']"
+}
+
+// errorMessage extracts the message from a Kusto error response, falling
+// back to the raw body.
+func errorMessage(data []byte) string {
+	var e struct {
+		Error struct {
+			Message   string `json:"message"`
+			AtMessage string `json:"@message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil {
+		if e.Error.AtMessage != "" {
+			return e.Error.AtMessage
+		}
+		if e.Error.Message != "" {
+			return e.Error.Message
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package kusto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClusterURL(t *testing.T) {
+	tests := map[string]string{
+		"help":                                     "https://help.kusto.windows.net",
+		"mycluster.westeurope":                     "https://mycluster.westeurope.kusto.windows.net",
+		"help.kusto.windows.net":                   "https://help.kusto.windows.net",
+		"https://help.kusto.windows.net/":          "https://help.kusto.windows.net",
+		"http://localhost:8080":                    "http://localhost:8080",
+		"mycluster.westus.kusto.usgovcloudapi.net": "https://mycluster.westus.kusto.usgovcloudapi.net",
+	}
+	for in, want := range tests {
+		if got := ClusterURL(in); got != want {
+			t.Errorf("ClusterURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestQuoteName(t *testing.T) {
+	tests := map[string]string{
+		"StormEvents": "StormEvents",
+		"_tmp1":       "_tmp1",
+		"My Table":    "['My Table']",
+		"1st":         "['1st']",
+		"it's":        `['it\'s']`,
+	}
+	for in, want := range tests {
+		if got := QuoteName(in); got != want {
+			t.Errorf("QuoteName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTableSchema(t *testing.T) {
+	var got struct{ DB, CSL, Auth string }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rest/mgmt" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got.DB, got.CSL, got.Auth = body["db"], body["csl"], r.Header.Get("Authorization")
+
+		w.Write([]byte(`{"Tables":[{"TableName":"Table_0",
+			"Columns":[{"ColumnName":"TableName","DataType":"String"},{"ColumnName":"Schema","DataType":"String"}],
+			"Rows":[["StormEvents","State:string,StartTime:datetime,DamageProperty:int"]]}]}`))
+	}))
+	defer server.Close()
+
+	var resource string
+	c := &Client{Endpoint: server.URL, TokenSource: func(r string) (string, error) {
+		resource = r
+		return "tok", nil
+	}}
+	schema, err := c.TableSchema(context.Background(), "Samples", "StormEvents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema != "State:string,StartTime:datetime,DamageProperty:int" {
+		t.Errorf("schema = %q", schema)
+	}
+	if got.DB != "Samples" || got.CSL != ".show table StormEvents schema as csl" || got.Auth != "Bearer tok" {
+		t.Errorf("request = %+v", got)
+	}
+	if resource != server.URL {
+		t.Errorf("token requested for %q", resource)
+	}
+}
+
+func TestMgmt_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"BadRequest","message":"Request is invalid","@message":"'Nope' could not be found"}}`))
+	}))
+	defer server.Close()
+
+	c := &Client{Endpoint: server.URL, TokenSource: func(string) (string, error) { return "tok", nil }}
+	_, err := c.TableSchema(context.Background(), "Samples", "Nope")
+	if err == nil || !strings.Contains(err.Error(), "status 400: 'Nope' could not be found") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestMgmt_TokenError(t *testing.T) {
+	c := &Client{Endpoint: "http://unused", TokenSource: func(string) (string, error) { return "", errors.New("not logged in") }}
+	if _, err := c.Mgmt(context.Background(), "db", ".show tables"); err == nil || err.Error() != "not logged in" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestDefaultToken_Env(t *testing.T) {
+	t.Setenv(TokenEnv, "env-token")
+	if token, err := DefaultToken("https://help.kusto.windows.net"); err != nil || token != "env-token" {
+		t.Errorf("token = %q, err = %v", token, err)
+	}
+}
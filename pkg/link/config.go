@@ -0,0 +1,83 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CloudBaseURLs maps Azure cloud names to their Data Explorer web UI.
+var CloudBaseURLs = map[string]string{
+	"public": DefaultBaseURL,
+	"usgov":  "https://dataexplorer.azure.us",
+	"china":  "https://dataexplorer.azure.cn",
+}
+
+// Config holds deep link settings resolved from flags and the config file.
+type Config struct {
+	// Cluster is the Kusto cluster name
+	Cluster string
+
+	// Database is the database name
+	Database string
+
+	// Cloud selects the base URL by Azure cloud name (see CloudBaseURLs)
+	Cloud string
+
+	// BaseURL overrides the base URL chosen by Cloud
+	BaseURL string
+
+	// MaxLength is the longest acceptable link (0 = no limit)
+	MaxLength int
+
+	// ShortenerURL is the endpoint of a URL shortener
+	ShortenerURL string
+}
+
+// MergeConfig fills the settings not set in cfg, such as those not given
+// on the command line, from defaults, such as the config file's.
+func MergeConfig(cfg, defaults Config) Config {
+	if cfg.Cluster == "" {
+		cfg.Cluster = defaults.Cluster
+	}
+	if cfg.Database == "" {
+		cfg.Database = defaults.Database
+	}
+	if cfg.Cloud == "" {
+		cfg.Cloud = defaults.Cloud
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaults.BaseURL
+	}
+	if cfg.MaxLength == 0 {
+		cfg.MaxLength = defaults.MaxLength
+	}
+	if cfg.ShortenerURL == "" {
+		cfg.ShortenerURL = defaults.ShortenerURL
+	}
+	return cfg
+}
+
+// ResolveBaseURL returns BaseURL if set, otherwise the base URL for Cloud,
+// otherwise DefaultBaseURL.
+func (c Config) ResolveBaseURL() (string, error) {
+	if c.BaseURL != "" {
+		return c.BaseURL, nil
+	}
+	if c.Cloud == "" {
+		return DefaultBaseURL, nil
+	}
+	if u, ok := CloudBaseURLs[strings.ToLower(c.Cloud)]; ok {
+		return u, nil
+	}
+
+	clouds := make([]string, 0, len(CloudBaseURLs))
+	for name := range CloudBaseURLs {
+		clouds = append(clouds, name)
+	}
+	sort.Strings(clouds)
+	return "", fmt.Errorf("unknown cloud %q (supported: %s)", c.Cloud, strings.Join(clouds, ", "))
+}
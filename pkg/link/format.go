@@ -0,0 +1,160 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LinkFormat is the path layout of a deep link.
+type LinkFormat int
+
+const (
+	// FormatDataExplorer is the Azure Data Explorer layout:
+	// /clusters/<cluster>/databases/<database>
+	FormatDataExplorer LinkFormat = iota
+
+	// FormatTrident is the Fabric (Trident) layout, which is scoped to a
+	// tenant: /<tenant>/clusters/<cluster>/databases/<database>
+	FormatTrident
+)
+
+// String returns the format name.
+func (f LinkFormat) String() string {
+	switch f {
+	case FormatTrident:
+		return "trident"
+	default:
+		return "dataexplorer"
+	}
+}
+
+// path returns the escaped URL path for the target.
+func (f LinkFormat) path(cluster, database, tenant string) (string, error) {
+	p := "/clusters/" + url.PathEscape(cluster) + "/databases/" + url.PathEscape(database)
+
+	switch f {
+	case FormatDataExplorer:
+		return p, nil
+	case FormatTrident:
+		if tenant == "" {
+			return "", fmt.Errorf("tenant cannot be empty for the %s format", f)
+		}
+		return "/" + url.PathEscape(tenant) + p, nil
+	default:
+		return "", fmt.Errorf("unknown link format %d", int(f))
+	}
+}
+
+// Target is where a deep link points.
+type Target struct {
+	Format   LinkFormat
+	Tenant   string
+	Cluster  string
+	Database string
+}
+
+// ParseTarget returns the target of a deep link, detecting its format from
+// the path: a single segment before /clusters/ is a Trident tenant. Base
+// URLs with a path of their own are therefore read as Trident links.
+func ParseTarget(link string) (Target, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return Target{}, fmt.Errorf("parse URL: %w", err)
+	}
+	return parseTarget(u)
+}
+
+// parseTarget finds the target path segments. The escaped path is split
+// so that names containing escaped slashes stay whole.
+func parseTarget(u *url.URL) (Target, error) {
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+
+	i := 0
+	for i < len(segments) && segments[i] != "clusters" {
+		i++
+	}
+	if i == len(segments) || i+1 >= len(segments) || segments[i+1] == "" {
+		return Target{}, fmt.Errorf("%w: no cluster in %q (expected /clusters/<cluster>/databases/<database>)", ErrMalformedPath, u.Path)
+	}
+	if i+3 >= len(segments) || segments[i+2] != "databases" || segments[i+3] == "" {
+		return Target{}, fmt.Errorf("%w: no database in %q (expected /clusters/<cluster>/databases/<database>)", ErrMalformedPath, u.Path)
+	}
+
+	var t Target
+	var err error
+	switch i {
+	case 0:
+		t.Format = FormatDataExplorer
+	case 1:
+		t.Format = FormatTrident
+		if t.Tenant, err = url.PathUnescape(segments[0]); err != nil {
+			return Target{}, fmt.Errorf("unescape tenant: %w", err)
+		}
+	default:
+		return Target{}, fmt.Errorf("%w: unrecognized layout %q (expected /clusters/... or /<tenant>/clusters/...)", ErrMalformedPath, u.Path)
+	}
+
+	if t.Cluster, err = url.PathUnescape(segments[i+1]); err != nil {
+		return Target{}, fmt.Errorf("unescape cluster: %w", err)
+	}
+	if t.Database, err = url.PathUnescape(segments[i+3]); err != nil {
+		return Target{}, fmt.Errorf("unescape database: %w", err)
+	}
+	return t, nil
+}
+
+// publicClusterSuffix is the host suffix of public cloud clusters, which
+// the web UI also accepts by their short name.
+const publicClusterSuffix = ".kusto.windows.net"
+
+// ClusterFromURI returns the cluster path segment for a cluster URI or
+// connection string, e.g. "help" for "https://help.kusto.windows.net" or
+// "Data Source=https://help.kusto.windows.net;Fed=True". Public cloud
+// hosts are shortened to the name before ".kusto.windows.net"; other hosts,
+// such as Fabric's "*.kusto.fabric.microsoft.com" or sovereign clouds,
+// are kept whole. A bare name is returned as is.
+func ClusterFromURI(uri string) (string, error) {
+	s := strings.TrimSpace(uri)
+	if s == "" {
+		return "", fmt.Errorf("cluster URI cannot be empty")
+	}
+
+	// Connection strings: the first ;-separated part is either the URI or
+	// a key=value pair, with the URI under "Data Source" (or an alias)
+	parts := strings.Split(s, ";")
+	s = strings.TrimSpace(parts[0])
+	if key, _, ok := strings.Cut(s, "="); ok && !strings.Contains(key, "/") {
+		s = ""
+		for _, part := range parts {
+			key, value, _ := strings.Cut(part, "=")
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "data source", "server", "addr", "address", "network address":
+				s = strings.TrimSpace(value)
+			}
+		}
+		if s == "" {
+			return "", fmt.Errorf("no Data Source in connection string %q", uri)
+		}
+	}
+
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("parse cluster URI: %w", err)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("no host in cluster URI %q", uri)
+	}
+
+	if name, ok := strings.CutSuffix(host, publicClusterSuffix); ok && name != "" {
+		return name, nil
+	}
+	return host, nil
+}
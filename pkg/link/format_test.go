@@ -0,0 +1,104 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildWithParams_Trident(t *testing.T) {
+	link, err := BuildWithParams("print 1", "help", "Samples", "https://trident.example.com",
+		LinkParams{Format: FormatTrident, Tenant: "contoso tenant"})
+	if err != nil {
+		t.Fatalf("BuildWithParams() failed: %v", err)
+	}
+	if !strings.HasPrefix(link, "https://trident.example.com/contoso%20tenant/clusters/help/databases/Samples?query=") {
+		t.Errorf("unexpected Trident link: %s", link)
+	}
+
+	target, err := ParseTarget(link)
+	if err != nil {
+		t.Fatalf("ParseTarget() failed: %v", err)
+	}
+	want := Target{Format: FormatTrident, Tenant: "contoso tenant", Cluster: "help", Database: "Samples"}
+	if target != want {
+		t.Errorf("ParseTarget() = %+v, want %+v", target, want)
+	}
+
+	query, err := Extract(link)
+	if err != nil || query != "print 1" {
+		t.Errorf("Extract() = %q, %v", query, err)
+	}
+
+	if _, err := BuildWithParams("print 1", "help", "Samples", "", LinkParams{Format: FormatTrident}); err == nil {
+		t.Error("expected error for Trident link without a tenant")
+	}
+}
+
+func TestParseTarget_DataExplorer(t *testing.T) {
+	link, err := Build("print 1", "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	target, err := ParseTarget(link)
+	if err != nil {
+		t.Fatalf("ParseTarget() failed: %v", err)
+	}
+	want := Target{Format: FormatDataExplorer, Cluster: "help", Database: "Samples"}
+	if target != want {
+		t.Errorf("ParseTarget() = %+v, want %+v", target, want)
+	}
+}
+
+func TestParseTarget_Unrecognized(t *testing.T) {
+	_, err := ParseTarget("https://example.com/a/b/clusters/help/databases/Samples?query=x")
+	if err == nil || !strings.Contains(err.Error(), "unrecognized layout") {
+		t.Errorf("expected unrecognized layout error, got %v", err)
+	}
+}
+
+func TestLinkFormat_String(t *testing.T) {
+	if FormatDataExplorer.String() != "dataexplorer" || FormatTrident.String() != "trident" {
+		t.Errorf("unexpected names: %s, %s", FormatDataExplorer, FormatTrident)
+	}
+}
+
+func TestClusterFromURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"https://help.kusto.windows.net", "help"},
+		{"https://help.kusto.windows.net/", "help"},
+		{"https://help.kusto.windows.net:443/Samples", "help"},
+		{"https://mycluster.westeurope.kusto.windows.net", "mycluster.westeurope"},
+		{"HTTPS://Help.Kusto.Windows.Net", "help"},
+		{"help.kusto.windows.net", "help"},
+		{"https://trd-abc123.z7.kusto.fabric.microsoft.com", "trd-abc123.z7.kusto.fabric.microsoft.com"},
+		{"https://mycluster.chinaeast2.kusto.chinacloudapi.cn", "mycluster.chinaeast2.kusto.chinacloudapi.cn"},
+		{"help", "help"},
+		{"mycluster.westeurope", "mycluster.westeurope"},
+		{"  help  ", "help"},
+		{"https://help.kusto.windows.net;Fed=True", "help"},
+		{"Data Source=https://help.kusto.windows.net;Initial Catalog=Samples;Fed=True", "help"},
+		{"Initial Catalog=Samples; server=mycluster.westeurope.kusto.windows.net", "mycluster.westeurope"},
+	}
+	for _, tt := range tests {
+		got, err := ClusterFromURI(tt.uri)
+		if err != nil {
+			t.Errorf("ClusterFromURI(%q) failed: %v", tt.uri, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ClusterFromURI(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+
+	for _, uri := range []string{"", "   ", "https://", "Initial Catalog=Samples;Fed=True"} {
+		if got, err := ClusterFromURI(uri); err == nil {
+			t.Errorf("ClusterFromURI(%q) = %q, expected an error", uri, got)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -31,6 +32,14 @@ import (
 // DefaultBaseURL is the Azure Data Explorer web interface URL.
 const DefaultBaseURL = "https://dataexplorer.azure.com"
 
+// DefaultMaxURLLength is the longest deep link that loads reliably in all
+// major browsers.
+const DefaultMaxURLLength = 2000
+
+// ErrURLTooLong is returned (wrapped) when a deep link exceeds
+// LinkParams.MaxURLLength.
+var ErrURLTooLong = errors.New("deep link too long")
+
 // Build creates a Kusto deep link URL from the given KQL query.
 //
 // The query is compressed with gzip and encoded with base64 to create
@@ -44,27 +53,123 @@ const DefaultBaseURL = "https://dataexplorer.azure.com"
 //
 // Returns the complete deep link URL.
 func Build(query, cluster, database, baseURL string) (string, error) {
+	return BuildWithParams(query, cluster, database, baseURL, LinkParams{})
+}
+
+// LinkParams holds optional deep link parameters for the web UI. Empty
+// fields are left out of the URL.
+type LinkParams struct {
+	// TabName labels the query tab (name=)
+	TabName string
+
+	// Timespan presets the time range, e.g. "1d" or "P7D" (timespan=)
+	Timespan string
+
+	// ReadOnly opens the query without allowing edits (readonly=true)
+	ReadOnly bool
+
+	// MaxURLLength fails the build if the URL is longer (0 = no limit;
+	// see DefaultMaxURLLength)
+	MaxURLLength int
+
+	// Format selects the path layout (default FormatDataExplorer)
+	Format LinkFormat
+
+	// Tenant is the leading path segment for FormatTrident
+	Tenant string
+
+	// CompressionLevel is the gzip level, e.g. gzip.BestCompression. 0
+	// uses gzip.DefaultCompression, so gzip.NoCompression is not available.
+	CompressionLevel int
+}
+
+// encode returns the parameters as "&key=value" pairs in a fixed order.
+func (p LinkParams) encode() string {
+	var b strings.Builder
+	if p.TabName != "" {
+		b.WriteString("&name=" + url.QueryEscape(p.TabName))
+	}
+	if p.Timespan != "" {
+		b.WriteString("&timespan=" + url.QueryEscape(p.Timespan))
+	}
+	if p.ReadOnly {
+		b.WriteString("&readonly=true")
+	}
+	return b.String()
+}
+
+// BuildWithParams is like Build but appends the optional params to the URL.
+// If the URL exceeds params.MaxURLLength, the error wraps ErrURLTooLong.
+func BuildWithParams(query, cluster, database, baseURL string, params LinkParams) (string, error) {
+	result, _, err := build(query, cluster, database, baseURL, params)
+	return result, err
+}
+
+// Stats describes the size of each stage of a deep link build.
+type Stats struct {
+	// QueryBytes is the size of the raw query text
+	QueryBytes int `json:"query_bytes"`
+
+	// GzipBytes is the size of the gzip-compressed query
+	GzipBytes int `json:"gzip_bytes"`
+
+	// Base64Bytes is the size of the base64-encoded compressed query
+	Base64Bytes int `json:"base64_bytes"`
+
+	// URLLength is the length of the final deep link URL
+	URLLength int `json:"url_length"`
+}
+
+// CompressionRatio returns the raw query size divided by the gzipped size
+// (e.g. 3.0 means the query compressed to a third of its size).
+func (s Stats) CompressionRatio() float64 {
+	if s.GzipBytes == 0 {
+		return 0
+	}
+	return float64(s.QueryBytes) / float64(s.GzipBytes)
+}
+
+// BuildWithStats is like Build but also reports the size of each stage.
+func BuildWithStats(query, cluster, database, baseURL string) (string, Stats, error) {
+	return build(query, cluster, database, baseURL, LinkParams{})
+}
+
+func build(query, cluster, database, baseURL string, params LinkParams) (string, Stats, error) {
+	var stats Stats
+
 	if query == "" {
-		return "", fmt.Errorf("query cannot be empty")
+		return "", stats, fmt.Errorf("query cannot be empty")
 	}
 	if cluster == "" {
-		return "", fmt.Errorf("cluster cannot be empty")
+		return "", stats, fmt.Errorf("cluster cannot be empty")
 	}
 	if database == "" {
-		return "", fmt.Errorf("database cannot be empty")
+		return "", stats, fmt.Errorf("database cannot be empty")
 	}
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
+	path, err := params.Format.path(cluster, database, params.Tenant)
+	if err != nil {
+		return "", stats, err
+	}
+
+	level := params.CompressionLevel
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
 
 	// Compress with gzip
 	var buf bytes.Buffer
-	gz := gzip.NewWriter(&buf)
+	gz, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return "", stats, fmt.Errorf("compression level: %w", err)
+	}
 	if _, err := gz.Write([]byte(query)); err != nil {
-		return "", fmt.Errorf("compress query: %w", err)
+		return "", stats, fmt.Errorf("compress query: %w", err)
 	}
 	if err := gz.Close(); err != nil {
-		return "", fmt.Errorf("finalize compression: %w", err)
+		return "", stats, fmt.Errorf("finalize compression: %w", err)
 	}
 
 	// Encode with base64, then URL-encode
@@ -72,12 +177,26 @@ func Build(query, cluster, database, baseURL string) (string, error) {
 	encodedQuery := url.QueryEscape(encoded)
 
 	// Build the URL
-	return fmt.Sprintf("%s/clusters/%s/databases/%s?query=%s",
+	result := fmt.Sprintf("%s%s?query=%s%s",
 		strings.TrimSuffix(baseURL, "/"),
-		url.PathEscape(cluster),
-		url.PathEscape(database),
+		path,
 		encodedQuery,
-	), nil
+		params.encode(),
+	)
+
+	stats = Stats{
+		QueryBytes:  len(query),
+		GzipBytes:   buf.Len(),
+		Base64Bytes: len(encoded),
+		URLLength:   len(result),
+	}
+
+	if params.MaxURLLength > 0 && stats.URLLength > params.MaxURLLength {
+		return "", stats, fmt.Errorf("%w: %d chars exceeds the limit of %d by %d",
+			ErrURLTooLong, stats.URLLength, params.MaxURLLength, stats.URLLength-params.MaxURLLength)
+	}
+
+	return result, stats, nil
 }
 
 // Extract retrieves the original KQL query from a Kusto deep link URL.
@@ -89,11 +208,36 @@ func Extract(link string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("parse URL: %w", err)
 	}
+	return decodeQuery(parsedURL)
+}
+
+// ExtractAll is like Extract but also returns the cluster and database
+// from the link path, in either LinkFormat.
+func ExtractAll(link string) (query, cluster, database string, err error) {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return "", "", "", fmt.Errorf("parse URL: %w", err)
+	}
+
+	target, err := parseTarget(parsedURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	cluster, database = target.Cluster, target.Database
+
+	query, err = decodeQuery(parsedURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	return query, cluster, database, nil
+}
 
+// decodeQuery decompresses the query parameter of a deep link.
+func decodeQuery(u *url.URL) (string, error) {
 	// Query().Get() already URL-decodes the value
-	encodedQuery := parsedURL.Query().Get("query")
+	encodedQuery := u.Query().Get("query")
 	if encodedQuery == "" {
-		return "", fmt.Errorf("no 'query' parameter found in URL")
+		return "", ErrNoQueryParam
 	}
 
 	// Base64 decode
@@ -15,6 +15,9 @@
 package link
 
 import (
+	"compress/gzip"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -207,6 +210,109 @@ func TestExtractErrors(t *testing.T) {
 	}
 }
 
+func TestBuildWithParams_MaxURLLength(t *testing.T) {
+	query := strings.Repeat("StormEvents | where State == 'TEXAS' | ", 400)
+	full, err := Build(query, "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	_, err = BuildWithParams(query, "help", "Samples", "", LinkParams{MaxURLLength: 100})
+	if !errors.Is(err, ErrURLTooLong) {
+		t.Fatalf("expected ErrURLTooLong, got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("%d chars", len(full))) {
+		t.Errorf("expected error to report the length %d, got %q", len(full), err)
+	}
+
+	// At or under the limit, and with no limit, the link is built
+	for _, limit := range []int{len(full), 0} {
+		got, err := BuildWithParams(query, "help", "Samples", "", LinkParams{MaxURLLength: limit})
+		if err != nil || got != full {
+			t.Errorf("MaxURLLength %d: unexpected result %v", limit, err)
+		}
+	}
+}
+
+func TestBuildWithParams_CompressionLevel(t *testing.T) {
+	query := strings.Repeat("StormEvents | where State == 'TEXAS' | summarize count() by EventType\n", 50)
+
+	plain, err := Build(query, "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	explicit, err := BuildWithParams(query, "help", "Samples", "", LinkParams{CompressionLevel: gzip.DefaultCompression})
+	if err != nil || explicit != plain {
+		t.Errorf("expected level 0 to match the default level, got %v", err)
+	}
+
+	levels := []int{gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression, gzip.HuffmanOnly, 5}
+	for _, level := range levels {
+		link, err := BuildWithParams(query, "help", "Samples", "", LinkParams{CompressionLevel: level})
+		if err != nil {
+			t.Fatalf("level %d: BuildWithParams() failed: %v", level, err)
+		}
+		got, err := Extract(link)
+		if err != nil || got != query {
+			t.Errorf("level %d: round trip failed: %v", level, err)
+		}
+	}
+
+	if _, err := BuildWithParams(query, "help", "Samples", "", LinkParams{CompressionLevel: 42}); err == nil {
+		t.Error("expected error for an invalid compression level")
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	link, err := Build("StormEvents\n| take 10", "cluster/with/slashes", "database with spaces", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	query, cluster, database, err := ExtractAll(link)
+	if err != nil {
+		t.Fatalf("ExtractAll() failed: %v", err)
+	}
+	if query != "StormEvents\n| take 10" || cluster != "cluster/with/slashes" || database != "database with spaces" {
+		t.Errorf("ExtractAll() = %q, %q, %q", query, cluster, database)
+	}
+
+	// A trailing slash after the database is tolerated
+	_, encoded, _ := strings.Cut(link, "?")
+	_, cluster, database, err = ExtractAll("https://example.com/prefix/clusters/help/databases/Samples/?" + encoded)
+	if err != nil || cluster != "help" || database != "Samples" {
+		t.Errorf("ExtractAll() with trailing slash = %q, %q, %v", cluster, database, err)
+	}
+}
+
+func TestExtractAllErrors(t *testing.T) {
+	link, err := Build("print 1", "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	_, encoded, _ := strings.Cut(link, "?")
+
+	tests := []struct {
+		name    string
+		link    string
+		wantErr string
+	}{
+		{"no cluster", "https://dataexplorer.azure.com/?" + encoded, "no cluster"},
+		{"empty cluster", "https://dataexplorer.azure.com/clusters/?" + encoded, "no cluster"},
+		{"no database", "https://dataexplorer.azure.com/clusters/help?" + encoded, "no database"},
+		{"empty database", "https://dataexplorer.azure.com/clusters/help/databases/?" + encoded, "no database"},
+		{"no query", "https://dataexplorer.azure.com/clusters/help/databases/Samples", "no 'query'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := ExtractAll(tt.link)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExtractAll() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestBuildWithTrailingSlashBaseURL(t *testing.T) {
 	// Ensure trailing slash in base URL is handled
 	link, err := Build("print 1", "test", "testdb", "https://example.com/")
@@ -264,3 +370,131 @@ func TestBuildSpecialCharactersInClusterAndDatabase(t *testing.T) {
 		t.Errorf("Build() did not properly encode database: %s", link)
 	}
 }
+
+func TestBuildWithParams(t *testing.T) {
+	plain, err := Build("print 1", "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	empty, err := BuildWithParams("print 1", "help", "Samples", "", LinkParams{})
+	if err != nil {
+		t.Fatalf("BuildWithParams() failed: %v", err)
+	}
+	if empty != plain {
+		t.Errorf("expected empty params to match Build()\ngot:  %s\nwant: %s", empty, plain)
+	}
+
+	link, err := BuildWithParams("print 1", "help", "Samples", "", LinkParams{
+		TabName:  "Storms & floods #1",
+		Timespan: "P7D",
+		ReadOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("BuildWithParams() failed: %v", err)
+	}
+	want := plain + "&name=Storms+%26+floods+%231&timespan=P7D&readonly=true"
+	if link != want {
+		t.Errorf("BuildWithParams()\ngot:  %s\nwant: %s", link, want)
+	}
+
+	// The query still round-trips
+	query, err := Extract(link)
+	if err != nil || query != "print 1" {
+		t.Errorf("Extract() = %q, %v", query, err)
+	}
+}
+
+func TestBuildWithStats(t *testing.T) {
+	query := strings.Repeat("StormEvents | where State == 'TEXAS' | take 10\n", 20)
+
+	result, stats, err := BuildWithStats(query, "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.QueryBytes != len(query) {
+		t.Errorf("expected query bytes %d, got %d", len(query), stats.QueryBytes)
+	}
+	if stats.GzipBytes == 0 || stats.GzipBytes >= stats.QueryBytes {
+		t.Errorf("expected repetitive query to compress, got %d -> %d bytes", stats.QueryBytes, stats.GzipBytes)
+	}
+	if want := (stats.GzipBytes + 2) / 3 * 4; stats.Base64Bytes != want {
+		t.Errorf("expected base64 bytes %d, got %d", want, stats.Base64Bytes)
+	}
+	if stats.URLLength != len(result) {
+		t.Errorf("expected URL length %d, got %d", len(result), stats.URLLength)
+	}
+
+	wantRatio := float64(stats.QueryBytes) / float64(stats.GzipBytes)
+	if stats.CompressionRatio() != wantRatio {
+		t.Errorf("expected ratio %f, got %f", wantRatio, stats.CompressionRatio())
+	}
+
+	// Build and BuildWithStats produce the same link
+	plain, err := Build(query, "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plain != result {
+		t.Error("expected Build and BuildWithStats to produce the same URL")
+	}
+}
+
+func TestStatsCompressionRatio_Zero(t *testing.T) {
+	if r := (Stats{}).CompressionRatio(); r != 0 {
+		t.Errorf("expected 0 ratio for empty stats, got %f", r)
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	defaults := Config{
+		Cluster:      "help",
+		Database:     "Samples",
+		Cloud:        "china",
+		MaxLength:    2000,
+		ShortenerURL: "https://short.example.com/api",
+	}
+
+	got := MergeConfig(Config{Database: "mydb", MaxLength: 4000}, defaults)
+	want := Config{
+		Cluster:      "help",
+		Database:     "mydb",
+		Cloud:        "china",
+		MaxLength:    4000,
+		ShortenerURL: "https://short.example.com/api",
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	cfg := Config{Cluster: "c", Database: "d"}
+	if got := MergeConfig(cfg, Config{}); got != cfg {
+		t.Errorf("expected cfg unchanged without defaults, got %+v", got)
+	}
+}
+
+func TestConfigResolveBaseURL(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, DefaultBaseURL},
+		{Config{Cloud: "public"}, DefaultBaseURL},
+		{Config{Cloud: "USGov"}, "https://dataexplorer.azure.us"},
+		{Config{Cloud: "china", BaseURL: "https://adx.example.com"}, "https://adx.example.com"},
+	}
+	for _, tt := range tests {
+		got, err := tt.cfg.ResolveBaseURL()
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", tt.cfg, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+
+	if _, err := (Config{Cloud: "mars"}).ResolveBaseURL(); err == nil {
+		t.Error("expected error for unknown cloud")
+	}
+}
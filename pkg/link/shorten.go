@@ -0,0 +1,72 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Shortener shortens deep links with a generic shortener service: the long
+// URL is POSTed to Endpoint as {"url": "..."} and the response is expected
+// to be {"short": "..."}.
+type Shortener struct {
+	Endpoint string
+	client   *http.Client
+}
+
+// NewShortener creates a Shortener for the given endpoint.
+func NewShortener(endpoint string) *Shortener {
+	return &Shortener{
+		Endpoint: endpoint,
+		client:   &http.Client{},
+	}
+}
+
+type shortenRequest struct {
+	URL string `json:"url"`
+}
+
+type shortenResponse struct {
+	Short string `json:"short"`
+}
+
+// Shorten returns the short form of longURL.
+func (s *Shortener) Shorten(ctx context.Context, longURL string) (string, error) {
+	body, err := json.Marshal(shortenRequest{URL: longURL})
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sending request to shortener: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("shortener returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result shortenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+	if result.Short == "" {
+		return "", fmt.Errorf("shortener response has no 'short' URL")
+	}
+
+	return result.Short, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShortener_Shorten(t *testing.T) {
+	var got shortenRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"short":"https://s.example.com/abc"}`))
+	}))
+	defer server.Close()
+
+	long := "https://dataexplorer.azure.com/clusters/help/databases/Samples?query=abc%3D"
+	short, err := NewShortener(server.URL).Shorten(context.Background(), long)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if short != "https://s.example.com/abc" {
+		t.Errorf("unexpected short URL: %q", short)
+	}
+	if got.URL != long {
+		t.Errorf("expected the long URL to be posted, got %q", got.URL)
+	}
+}
+
+func TestShortener_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"non-2xx", http.StatusForbidden, "quota exceeded", "status 403: quota exceeded"},
+		{"invalid JSON", http.StatusOK, "not json", "decoding response"},
+		{"missing short", http.StatusOK, `{"id":"abc"}`, "no 'short' URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewShortener(server.URL).Shorten(context.Background(), "https://example.com")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
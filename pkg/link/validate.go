@@ -0,0 +1,45 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Errors returned (wrapped) by Validate. ExtractAll and ParseTarget also
+// use ErrMalformedPath and ErrNoQueryParam.
+var (
+	// ErrInvalidURL means the link is not a parseable absolute URL.
+	ErrInvalidURL = errors.New("invalid link URL")
+
+	// ErrMalformedPath means the path has no cluster and database.
+	ErrMalformedPath = errors.New("malformed link path")
+
+	// ErrNoQueryParam means the link has no query parameter.
+	ErrNoQueryParam = errors.New("no 'query' parameter found in URL")
+)
+
+// Validate checks the structure of a deep link: an absolute URL with a
+// cluster and database path and a non-empty query parameter. Unlike
+// Extract, it does not decode the query, so it is cheap enough to run over
+// many links. Errors wrap ErrInvalidURL, ErrMalformedPath, or
+// ErrNoQueryParam.
+func Validate(link string) error {
+	u, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an absolute URL", ErrInvalidURL, link)
+	}
+	if _, err := parseTarget(u); err != nil {
+		return err
+	}
+	if u.Query().Get("query") == "" {
+		return ErrNoQueryParam
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid, err := Build("StormEvents | take 10", "help", "Samples", "")
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		link    string
+		wantErr error
+	}{
+		{"valid", valid, nil},
+		{"not a URL", "not a url at all %%%", ErrInvalidURL},
+		{"relative", "/clusters/help/databases/Samples?query=abc", ErrInvalidURL},
+		{"no path", "https://dataexplorer.azure.com/?query=abc", ErrMalformedPath},
+		{"no database", "https://dataexplorer.azure.com/clusters/help?query=abc", ErrMalformedPath},
+		{"no query", "https://dataexplorer.azure.com/clusters/help/databases/Samples", ErrNoQueryParam},
+		{"empty query", "https://dataexplorer.azure.com/clusters/help/databases/Samples?query=", ErrNoQueryParam},
+		// Validate does not decode, so a corrupt query still passes
+		{"undecodable query", "https://dataexplorer.azure.com/clusters/help/databases/Samples?query=!!!", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.link)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractAll_TypedErrors(t *testing.T) {
+	_, _, _, err := ExtractAll("https://dataexplorer.azure.com/clusters/help/databases/Samples")
+	if !errors.Is(err, ErrNoQueryParam) {
+		t.Errorf("expected ErrNoQueryParam, got %v", err)
+	}
+	_, _, _, err = ExtractAll("https://dataexplorer.azure.com/clusters/help?query=abc")
+	if !errors.Is(err, ErrMalformedPath) {
+		t.Errorf("expected ErrMalformedPath, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCapacity(t *testing.T) {
+	// From the byte mode capacity table of ISO/IEC 18004
+	tests := []struct {
+		version int
+		want    [4]int
+	}{
+		{1, [4]int{17, 14, 11, 7}},
+		{10, [4]int{271, 213, 151, 119}},
+		{40, [4]int{2953, 2331, 1663, 1273}},
+	}
+	for _, tt := range tests {
+		for level := L; level <= H; level++ {
+			if got := Capacity(tt.version, level); got != tt.want[level] {
+				t.Errorf("Capacity(%d, %s) = %d, want %d", tt.version, level, got, tt.want[level])
+			}
+		}
+	}
+}
+
+func TestFormatInfo(t *testing.T) {
+	// Mask 0 of each level, from the format information table
+	want := map[Level]int{L: 0x77C4, M: 0x5412, Q: 0x355F, H: 0x1689}
+	for level, bits := range want {
+		if got := formatInfo(level, 0); got != bits {
+			t.Errorf("formatInfo(%s, 0) = %015b, want %015b", level, got, bits)
+		}
+	}
+}
+
+func TestVersionInfo(t *testing.T) {
+	for version, want := range map[int]int{7: 0x07C94, 21: 0x15683, 40: 0x28C69} {
+		if got := versionInfo(version); got != want {
+			t.Errorf("versionInfo(%d) = %018b, want %018b", version, got, want)
+		}
+	}
+}
+
+func TestRSRemainder(t *testing.T) {
+	// The data codewords of "HELLO WORLD" at 1-M and their error
+	// correction codewords
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder() = %v, want %v", got, want)
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		if got := alignmentPositions(version); !reflect.DeepEqual(got, want) {
+			t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestEncode_Version(t *testing.T) {
+	tests := []struct {
+		n       int
+		level   Level
+		version int
+	}{
+		{17, L, 1},
+		{18, L, 2},
+		{14, M, 1},
+		{15, M, 2},
+		{272, L, 11},
+		{2953, L, 40},
+	}
+	for _, tt := range tests {
+		c, err := Encode(bytes.Repeat([]byte("a"), tt.n), tt.level)
+		if err != nil {
+			t.Fatalf("%d bytes at %s: unexpected error: %v", tt.n, tt.level, err)
+		}
+		if c.Version != tt.version || c.Size() != 17+4*tt.version {
+			t.Errorf("%d bytes at %s: got version %d (size %d), want %d", tt.n, tt.level, c.Version, c.Size(), tt.version)
+		}
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	_, err := Encode(make([]byte, 2954), L)
+	if !errors.Is(err, ErrTooLong) || !strings.Contains(err.Error(), "2953") {
+		t.Errorf("expected ErrTooLong naming the limit, got %v", err)
+	}
+}
+
+// TestEncode_ReadBack reads the format information and codewords back out
+// of encoded codes and checks them against what was encoded.
+func TestEncode_ReadBack(t *testing.T) {
+	inputs := []string{
+		"",
+		"https://example.com",
+		"https://dataexplorer.azure.com/clusters/help/databases/Samples?query=H4sIAAAAAAAAAwsuyS%2FKdS1LzSsp5lIAAC6GmtEQAAAA",
+		strings.Repeat("0123456789abcdef", 40), // a version with version information
+	}
+	for _, in := range inputs {
+		for level := L; level <= H; level++ {
+			c, err := Encode([]byte(in), level)
+			if err != nil {
+				t.Fatalf("Encode(%q, %s): %v", in, level, err)
+			}
+
+			// The first copy of the format information, in bit order
+			var format int
+			read := func(x, y, i int) {
+				if c.Dark(x, y) {
+					format |= 1 << i
+				}
+			}
+			for i := 0; i <= 5; i++ {
+				read(8, i, i)
+			}
+			read(8, 7, 6)
+			read(8, 8, 7)
+			read(7, 8, 8)
+			for i := 9; i < 15; i++ {
+				read(14-i, 8, i)
+			}
+			if want := formatInfo(level, c.Mask); format != want {
+				t.Errorf("%s: format bits %015b, want %015b", level, format, want)
+			}
+			if !c.Dark(8, c.Size()-8) {
+				t.Errorf("%s: expected the dark module", level)
+			}
+
+			// Unmask and read the codewords in placement order
+			want := addECCAndInterleave(encodeData([]byte(in), c.Version, level), c.Version, level)
+			c.applyMask(c.Mask)
+			got := make([]byte, len(want))
+			i := 0
+			for right := c.size - 1; right >= 1; right -= 2 {
+				if right == 6 {
+					right = 5
+				}
+				for vert := 0; vert < c.size; vert++ {
+					y := vert
+					if (right+1)&2 == 0 {
+						y = c.size - 1 - vert
+					}
+					for x := right; x >= right-1; x-- {
+						if c.function[y][x] || i >= len(got)*8 {
+							continue
+						}
+						if c.modules[y][x] {
+							got[i>>3] |= 1 << (7 - i&7)
+						}
+						i++
+					}
+				}
+			}
+			c.applyMask(c.Mask)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: codewords read back differ from those encoded", level)
+			}
+		}
+	}
+}
+
+func TestCode_String(t *testing.T) {
+	c, err := Encode([]byte("https://example.com"), M)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.String(), "\n"), "\n")
+	width := c.Size() + 2*QuietZone
+	if len(lines) != (width+1)/2 {
+		t.Errorf("expected %d lines, got %d", (width+1)/2, len(lines))
+	}
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) != width {
+			t.Fatalf("expected lines %d wide, got %q", width, line)
+		}
+	}
+	// The quiet zone is light: the first line is all full blocks
+	if lines[0] != strings.Repeat("█", width) {
+		t.Errorf("expected a light quiet zone, got %q", lines[0])
+	}
+}
+
+func TestCode_WritePNG(t *testing.T) {
+	c, err := Encode([]byte("https://example.com"), M)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := c.WritePNG(&buf, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+
+	n := (c.Size() + 2*QuietZone) * 4
+	if b := img.Bounds(); b.Dx() != n || b.Dy() != n {
+		t.Errorf("expected %dx%d, got %v", n, n, b)
+	}
+	dark := func(x, y int) bool {
+		r, _, _, _ := img.At(x, y).RGBA()
+		return r == 0
+	}
+	// Quiet zone, then the corner of the top left finder pattern
+	if dark(0, 0) || !dark(QuietZone*4, QuietZone*4) {
+		t.Error("expected a light quiet zone around a dark finder pattern")
+	}
+}
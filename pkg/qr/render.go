@@ -0,0 +1,62 @@
+// Copyright 2026 cloudygreybeard
+// SPDX-License-Identifier: Apache-2.0
+
+package qr
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// QuietZone is the width in modules of the light border a reader needs
+// around the code.
+const QuietZone = 4
+
+// String renders the code as text for a terminal, two rows of modules
+// per line using half blocks. Light modules are drawn and dark ones left
+// blank, so the code reads correctly as light text on a dark background.
+func (c *Code) String() string {
+	var sb strings.Builder
+	lo, hi := -QuietZone, c.size+QuietZone
+	for y := lo; y < hi; y += 2 {
+		for x := lo; x < hi; x++ {
+			top := !c.Dark(x, y)
+			bottom := y+1 < hi && !c.Dark(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Image returns the code as a black and white image, scale pixels per
+// module, with the quiet zone.
+func (c *Code) Image(scale int) image.Image {
+	n := (c.size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, n, n), color.Palette{color.White, color.Black})
+	for py := 0; py < n; py++ {
+		for px := 0; px < n; px++ {
+			if c.Dark(px/scale-QuietZone, py/scale-QuietZone) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the code as a PNG image, scale pixels per module.
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}